@@ -4,6 +4,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"mime"
@@ -15,6 +16,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/go-viper/mapstructure/v2"
@@ -32,10 +34,19 @@ type ConfigRelatedOptions struct {
 	BeforeDump func(mapstructure.Metadata)
 }
 
+// configFetchTimeout is the timeout to fetch a configuration file from an URL.
+const configFetchTimeout = 30 * time.Second
+
 // Parse parses the configuration file (if present) and the environment
 // variables into the provided configuration. It returns the paths to watch if
 // we want to detect configuration changes.
 func (c ConfigRelatedOptions) Parse(out io.Writer, component string, config any) ([]string, error) {
+	return c.ParseContext(context.Background(), out, component, config)
+}
+
+// ParseContext is like Parse, but the provided context is used to fetch the
+// configuration from an URL.
+func (c ConfigRelatedOptions) ParseContext(ctx context.Context, out io.Writer, component string, config any) ([]string, error) {
 	var rawConfig helpers.M
 	var paths []string
 	if cfgFile := c.Path; cfgFile != "" {
@@ -50,7 +61,12 @@ func (c ConfigRelatedOptions) Parse(out io.Writer, component string, config any)
 			if u.Fragment != "" {
 				u.Path = fmt.Sprintf("%s/%s", u.Path, u.Fragment)
 			}
-			resp, err := http.Get(u.String())
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+			if err != nil {
+				return nil, fmt.Errorf("unable to build configuration request: %w", err)
+			}
+			client := &http.Client{Timeout: configFetchTimeout}
+			resp, err := client.Do(req)
 			if err != nil {
 				return nil, fmt.Errorf("unable to fetch configuration file: %w", err)
 			}
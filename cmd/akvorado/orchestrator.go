@@ -115,6 +115,12 @@ components and centralizes configuration of the various other components.`,
 						config.Outlet[idx].KafkaOutput.Configuration.Topic = topic
 					}
 				}
+				if !slices.Contains(metadata.Keys, fmt.Sprintf("Outlet[%d].Flow.SharedState.Brokers[0]", idx)) {
+					// Same cluster as the input, but not the same topic.
+					topic := config.Outlet[idx].Flow.SharedState.Topic
+					config.Outlet[idx].Flow.SharedState.Configuration = config.Kafka.Configuration
+					config.Outlet[idx].Flow.SharedState.Configuration.Topic = topic
+				}
 				config.Outlet[idx].Schema = config.Schema
 			}
 			for idx := range config.Console {
@@ -4,7 +4,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"reflect"
 
 	"github.com/go-viper/mapstructure/v2"
@@ -105,6 +107,24 @@ func init() {
 		"Check configuration, but does not start")
 }
 
+// fetchOutletCoreConfiguration returns a function to fetch again the core
+// configuration, or nil if the configuration does not come from a file or an
+// URL.
+func fetchOutletCoreConfiguration() func(context.Context) (core.Configuration, error) {
+	if OutletOptions.Path == "" {
+		return nil
+	}
+	options := OutletOptions.ConfigRelatedOptions
+	options.Dump = false
+	return func(ctx context.Context) (core.Configuration, error) {
+		config := OutletConfiguration{}
+		if _, err := options.ParseContext(ctx, io.Discard, "outlet", &config); err != nil {
+			return core.Configuration{}, err
+		}
+		return config.Core, nil
+	}
+}
+
 func outletStart(r *reporter.Reporter, config OutletConfiguration, checkOnly bool) error {
 	// Initialize the various components
 	daemonComponent, err := daemon.New(r)
@@ -122,6 +142,7 @@ func outletStart(r *reporter.Reporter, config OutletConfiguration, checkOnly boo
 		return fmt.Errorf("unable to initialize schema component: %w", err)
 	}
 	flowComponent, err := flow.New(r, config.Flow, flow.Dependencies{
+		Daemon: daemonComponent,
 		Schema: schemaComponent,
 	})
 	if err != nil {
@@ -190,6 +211,8 @@ func outletStart(r *reporter.Reporter, config OutletConfiguration, checkOnly boo
 		ClickHouse:  clickhouseComponent,
 		HTTP:        httpComponent,
 		Schema:      schemaComponent,
+
+		ConfigurationFetcher: fetchOutletCoreConfiguration(),
 	})
 	if err != nil {
 		return fmt.Errorf("unable to initialize core component: %w", err)
@@ -4,6 +4,8 @@
 package clickhousedb
 
 import (
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/ClickHouse/ch-go"
@@ -21,6 +23,9 @@ type Configuration struct {
 	Cluster string
 	// Database defines the database to use
 	Database string `validate:"required"`
+	// Tenants maps a tenant to the database storing its flows. Flows from
+	// other tenants are stored in the main database.
+	Tenants map[string]string `validate:"dive,keys,required,endkeys,required"`
 	// Username defines the username to use for authentication
 	Username string `validate:"required"`
 	// Password defines the password to use for authentication
@@ -38,6 +43,7 @@ func DefaultConfiguration() Configuration {
 	return Configuration{
 		Servers:      []string{"127.0.0.1:9000"},
 		Database:     "default",
+		Tenants:      map[string]string{},
 		Username:     "default",
 		MaxOpenConns: 10,
 		DialTimeout:  5 * time.Second,
@@ -54,6 +60,36 @@ func (c *Component) DatabaseName() string {
 	return c.config.Database
 }
 
+// Tenant returns the component to use for the provided tenant. It returns
+// false if the tenant is not mapped to a database.
+func (c *Component) Tenant(tenant string) (*Component, bool) {
+	tc, ok := c.tenants[tenant]
+	return tc, ok
+}
+
+// Tenants returns the components for the databases dedicated to tenants,
+// sorted by database name. The main database is not included.
+func (c *Component) Tenants() []*Component {
+	databases := c.databases()
+	result := make([]*Component, 0, len(databases))
+	for _, database := range slices.Sorted(maps.Keys(databases)) {
+		result = append(result, databases[database])
+	}
+	return result
+}
+
+// databases returns the components for the databases dedicated to tenants,
+// indexed by database name.
+func (c *Component) databases() map[string]*Component {
+	result := map[string]*Component{}
+	for _, tc := range c.tenants {
+		if tc != c {
+			result[tc.config.Database] = tc
+		}
+	}
+	return result
+}
+
 // ChGoOptions returns options suitable to use with ch-go and the list of
 // available servers.
 func (c *Component) ChGoOptions() (ch.Options, []string) {
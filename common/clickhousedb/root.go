@@ -6,6 +6,7 @@ package clickhousedb
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
@@ -25,6 +26,8 @@ type Component struct {
 
 	healthy chan reporter.ChannelHealthcheckFunc
 	clickhouse.Conn
+
+	tenants map[string]*Component // component for each tenant with a dedicated database
 }
 
 // Dependencies define the dependencies of the ClickHouse wrapper
@@ -34,11 +37,55 @@ type Dependencies struct {
 
 // New creates a new ClickHouse wrapper
 func New(r *reporter.Reporter, config Configuration, dependencies Dependencies) (*Component, error) {
+	conn, err := open(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Component{
+		r:      r,
+		d:      &dependencies,
+		config: config,
+
+		healthy: make(chan reporter.ChannelHealthcheckFunc),
+		Conn:    conn,
+		tenants: map[string]*Component{},
+	}
+	for tenant, database := range config.Tenants {
+		if database == config.Database {
+			c.tenants[tenant] = &c
+			continue
+		}
+		if other, ok := c.databases()[database]; ok {
+			c.tenants[tenant] = other
+			continue
+		}
+		tenantConfig := config
+		tenantConfig.Database = database
+		tenantConfig.Tenants = nil
+		conn, err := open(tenantConfig)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open database %q for tenant %q: %w", database, tenant, err)
+		}
+		c.tenants[tenant] = &Component{
+			r:      r,
+			d:      &dependencies,
+			config: tenantConfig,
+			Conn:   conn,
+		}
+	}
+	c.d.Daemon.Track(&c.t, "common/clickhousedb")
+	return &c, nil
+}
+
+// open opens a connection to the database described by the provided
+// configuration.
+func open(config Configuration) (clickhouse.Conn, error) {
 	tlsConfig, err := config.TLS.MakeTLSConfig()
 	if err != nil {
 		return nil, err
 	}
-	conn, err := clickhouse.Open(&clickhouse.Options{
+	return clickhouse.Open(&clickhouse.Options{
 		Addr:             config.Servers,
 		ConnOpenStrategy: clickhouse.ConnOpenRoundRobin,
 		Auth: clickhouse.Auth{
@@ -62,20 +109,6 @@ func New(r *reporter.Reporter, config Configuration, dependencies Dependencies)
 			},
 		},
 	})
-	if err != nil {
-		return nil, err
-	}
-
-	c := Component{
-		r:      r,
-		d:      &dependencies,
-		config: config,
-
-		healthy: make(chan reporter.ChannelHealthcheckFunc),
-		Conn:    conn,
-	}
-	c.d.Daemon.Track(&c.t, "common/clickhousedb")
-	return &c, nil
 }
 
 // Start initializes the connection to ClickHouse
@@ -109,6 +142,9 @@ func (c *Component) Start() error {
 func (c *Component) Stop() error {
 	c.r.Info().Msg("stopping ClickHouse component")
 	defer func() {
+		for _, tenant := range c.Tenants() {
+			tenant.Close()
+		}
 		c.Close()
 		c.r.Info().Msg("ClickHouse component stopped")
 	}()
@@ -10,6 +10,7 @@ import (
 	"go.uber.org/mock/gomock"
 
 	"akvorado/common/clickhousedb/mocks"
+	"akvorado/common/daemon"
 	"akvorado/common/helpers"
 	"akvorado/common/reporter"
 )
@@ -154,3 +155,43 @@ func TestRealClickHouse(t *testing.T) {
 		}
 	})
 }
+
+func TestTenants(t *testing.T) {
+	r := reporter.NewMock(t)
+	config := DefaultConfiguration()
+	config.Tenants = map[string]string{
+		"alpha":   "flows_alpha",
+		"beta":    "flows_beta",
+		"beta2":   "flows_beta",
+		"default": "default",
+	}
+	c, err := New(r, config, Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	got := map[string]string{}
+	for _, tenant := range []string{"alpha", "beta", "beta2", "default", "gamma"} {
+		if tc, ok := c.Tenant(tenant); ok {
+			got[tenant] = tc.DatabaseName()
+		}
+	}
+	if diff := helpers.Diff(got, config.Tenants); diff != "" {
+		t.Errorf("Tenant() (-got, +want):\n%s", diff)
+	}
+	if beta, _ := c.Tenant("beta"); beta != c.tenants["beta2"] {
+		t.Error("Tenant() returned different components for the same database")
+	}
+	if tc, _ := c.Tenant("default"); tc != c {
+		t.Error("Tenant() did not return the main component for the main database")
+	}
+
+	databases := []string{}
+	for _, tc := range c.Tenants() {
+		databases = append(databases, tc.DatabaseName())
+	}
+	if diff := helpers.Diff(databases, []string{"flows_alpha", "flows_beta"}); diff != "" {
+		t.Errorf("Tenants() (-got, +want):\n%s", diff)
+	}
+}
@@ -112,6 +112,15 @@ func (c *Cache[K, V]) DeleteLastAccessedBefore(before time.Time) int {
 	return count
 }
 
+// DeleteAll expires all items.
+func (c *Cache[K, V]) DeleteAll() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := len(c.items)
+	clear(c.items)
+	return count
+}
+
 // Size returns the size of the cache
 func (c *Cache[K, V]) Size() int {
 	c.mu.RLock()
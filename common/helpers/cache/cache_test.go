@@ -97,6 +97,19 @@ func TestDeleteLastAccessedBefore(t *testing.T) {
 	expectCacheGet(t, c, "127.0.0.3", "", false)
 }
 
+func TestDeleteAll(t *testing.T) {
+	c := cache.New[netip.Addr, string]()
+	t1 := time.Date(2022, time.December, 31, 10, 23, 0, 0, time.UTC)
+	c.Put(t1, netip.MustParseAddr("::ffff:127.0.0.1"), "entry1")
+	c.Put(t1, netip.MustParseAddr("::ffff:127.0.0.2"), "entry2")
+
+	if count := c.DeleteAll(); count != 2 {
+		t.Errorf("DeleteAll(): got %d, expected %d", count, 2)
+	}
+	expectCacheGet(t, c, "127.0.0.1", "", false)
+	expectCacheGet(t, c, "127.0.0.2", "", false)
+}
+
 func TestItemsLastUpdatedBefore(t *testing.T) {
 	c := cache.New[netip.Addr, string]()
 	t1 := time.Date(2022, time.December, 31, 10, 23, 0, 0, time.UTC)
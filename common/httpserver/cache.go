@@ -5,6 +5,7 @@ package httpserver
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"errors"
 	"fmt"
@@ -25,6 +26,17 @@ type cachedResponse struct {
 	Body    []byte
 }
 
+// cacheScopeContextKey is the key under which the cache scope is stored in the
+// request context.
+type cacheScopeContextKey struct{}
+
+// WithCacheScope returns a context whose cached responses are kept apart from
+// the ones of other scopes. This is needed when the same request gets a
+// different answer depending on the user.
+func WithCacheScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, cacheScopeContextKey{}, scope)
+}
+
 // CacheByRequestPath is a middleware that caches the response keyed
 // on the request path.
 func (c *Component) CacheByRequestPath(expire time.Duration) Middleware {
@@ -59,6 +71,9 @@ func (c *Component) cacheMiddleware(expire time.Duration, keyFn func(*http.Reque
 				next.ServeHTTP(w, req)
 				return
 			}
+			if scope, ok := req.Context().Value(cacheScopeContextKey{}).(string); ok {
+				key = fmt.Sprintf("%s\x00%s", scope, key)
+			}
 
 			var cached cachedResponse
 			if err := c.cacheStore.Get(key, &cached); err == nil {
@@ -60,6 +60,57 @@ func TestCacheByRequestPath(t *testing.T) {
 	}
 }
 
+func TestCacheWithScope(t *testing.T) {
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+
+	count := 0
+	scope := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if tenant := req.Header.Get("X-Tenant"); tenant != "" {
+				req = req.WithContext(httpserver.WithCacheScope(req.Context(), tenant))
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+	h.APIRouter.Group("", scope).GET("/api/v0/test",
+		func(w http.ResponseWriter, _ *http.Request) {
+			count++
+			httpserver.WriteJSON(w, http.StatusOK, helpers.M{
+				"message": "ping",
+				"count":   count,
+			})
+		},
+		h.CacheByRequestPath(time.Minute))
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "no scope",
+			URL:         "/api/v0/test",
+			JSONOutput:  helpers.M{"message": "ping", "count": 1},
+		}, {
+			Description: "scope alpha",
+			URL:         "/api/v0/test",
+			Header:      http.Header{"X-Tenant": []string{"alpha"}},
+			JSONOutput:  helpers.M{"message": "ping", "count": 2},
+		}, {
+			Description: "scope beta",
+			URL:         "/api/v0/test",
+			Header:      http.Header{"X-Tenant": []string{"beta"}},
+			JSONOutput:  helpers.M{"message": "ping", "count": 3},
+		}, {
+			Description: "scope alpha, cached",
+			URL:         "/api/v0/test",
+			Header:      http.Header{"X-Tenant": []string{"alpha"}},
+			JSONOutput:  helpers.M{"message": "ping", "count": 2},
+		}, {
+			Description: "no scope, cached",
+			URL:         "/api/v0/test",
+			JSONOutput:  helpers.M{"message": "ping", "count": 1},
+		},
+	})
+}
+
 func TestCacheByRequestBody(t *testing.T) {
 	r := reporter.NewMock(t)
 	h := httpserver.NewMock(t, r)
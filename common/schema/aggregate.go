@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schema
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// messageAggregation stores the state needed to merge flows sharing the same
+// columns inside a batch.
+type messageAggregation struct {
+	enabled bool
+	window  uint32         // Window in seconds to round TimeReceived to
+	ignored []ColumnKey    // Columns reset before merging
+	rows    map[string]int // Row index for each key in the current batch
+	key     []byte         // Working buffer for the key of the last row
+}
+
+// EnableAggregation turns on aggregation of flows inside the current batch.
+// Once finalized, a flow is merged with a previous flow of the batch sharing
+// the same values for all columns, except Bytes and Packets which are summed.
+// TimeReceived is rounded down to the provided window and the provided columns
+// are reset to their default value before comparison. It must be called before
+// the message is used.
+func (bf *FlowMessage) EnableAggregation(window time.Duration, ignored []ColumnKey) {
+	bf.batch.aggregation = messageAggregation{
+		enabled: true,
+		window:  uint32(max(window/time.Second, 1)),
+		rows:    make(map[string]int),
+	}
+	for _, key := range ignored {
+		if int(key) < len(bf.batch.columns) && bf.batch.columns[key] != nil {
+			bf.batch.aggregation.ignored = append(bf.batch.aggregation.ignored, key)
+		}
+	}
+}
+
+// aggregate merges the last finalized row with a previous row sharing the
+// same key. It returns true if the row was merged.
+func (bf *FlowMessage) aggregate() bool {
+	agg := &bf.batch.aggregation
+	if !agg.enabled {
+		return false
+	}
+	last := bf.batch.rowCount - 1
+	if col, ok := bf.batch.columns[ColumnTimeReceived].(*proto.ColDateTime); ok {
+		col.Data[last] -= col.Data[last] % proto.DateTime(agg.window)
+	}
+	for _, key := range agg.ignored {
+		resetLastRow(bf.batch.columns[key])
+	}
+
+	agg.key = agg.key[:0]
+	for idx, col := range bf.batch.columns {
+		if col == nil || idx == int(ColumnBytes) || idx == int(ColumnPackets) {
+			continue
+		}
+		agg.key = appendRowKey(agg.key, col, last)
+	}
+	row, ok := agg.rows[string(agg.key)]
+	if !ok {
+		agg.rows[string(agg.key)] = last
+		return false
+	}
+	for _, key := range []ColumnKey{ColumnBytes, ColumnPackets} {
+		if col, ok := bf.batch.columns[key].(*proto.ColUInt64); ok {
+			(*col)[row] += (*col)[last]
+		}
+	}
+	for _, col := range bf.batch.columns {
+		if col != nil {
+			truncateLastRow(col)
+		}
+	}
+	bf.batch.rowCount--
+	return true
+}
+
+// appendRowKey appends the value of the provided row of a column to a key.
+func appendRowKey(key []byte, col proto.Column, row int) []byte {
+	switch col := col.(type) {
+	case *proto.ColUInt64:
+		return binary.LittleEndian.AppendUint64(key, (*col)[row])
+	case *proto.ColUInt32:
+		return binary.LittleEndian.AppendUint32(key, (*col)[row])
+	case *proto.ColUInt16:
+		return binary.LittleEndian.AppendUint16(key, (*col)[row])
+	case *proto.ColUInt8:
+		return append(key, (*col)[row])
+	case *proto.ColIPv6:
+		value := (*col)[row]
+		return append(key, value[:]...)
+	case *proto.ColDateTime:
+		return binary.LittleEndian.AppendUint32(key, uint32(col.Data[row]))
+	case *proto.ColEnum8:
+		return append(key, byte((*col)[row]))
+	case *proto.ColLowCardinality[string]:
+		value := col.Values[row]
+		key = binary.AppendUvarint(key, uint64(len(value)))
+		return append(key, value...)
+	case *proto.ColFixedStr:
+		return append(key, col.Row(row)...)
+	case *proto.ColLowCardinality[proto.IPv6]:
+		value := col.Values[row]
+		return append(key, value[:]...)
+	case *proto.ColArr[uint32]:
+		values := col.Row(row)
+		key = binary.AppendUvarint(key, uint64(len(values)))
+		for _, value := range values {
+			key = binary.LittleEndian.AppendUint32(key, value)
+		}
+		return key
+	case *proto.ColArr[proto.UInt128]:
+		values := col.Row(row)
+		key = binary.AppendUvarint(key, uint64(len(values)))
+		for _, value := range values {
+			key = binary.LittleEndian.AppendUint64(key, value.Low)
+			key = binary.LittleEndian.AppendUint64(key, value.High)
+		}
+		return key
+	default:
+		panic(fmt.Sprintf("unhandled ClickHouse type %q", col.Type()))
+	}
+}
+
+// resetLastRow replaces the last value of a column by its default value.
+func resetLastRow(col proto.Column) {
+	switch col := col.(type) {
+	case *proto.ColUInt64:
+		(*col)[len(*col)-1] = 0
+	case *proto.ColUInt32:
+		(*col)[len(*col)-1] = 0
+	case *proto.ColUInt16:
+		(*col)[len(*col)-1] = 0
+	case *proto.ColUInt8:
+		(*col)[len(*col)-1] = 0
+	case *proto.ColIPv6:
+		(*col)[len(*col)-1] = proto.IPv6{}
+	case *proto.ColDateTime:
+		col.Data[len(col.Data)-1] = 0
+	case *proto.ColEnum8:
+		(*col)[len(*col)-1] = 0
+	case *proto.ColLowCardinality[string]:
+		col.Values[len(col.Values)-1] = ""
+	case *proto.ColFixedStr:
+		clear(col.Buf[len(col.Buf)-col.Size:])
+	case *proto.ColLowCardinality[proto.IPv6]:
+		col.Values[len(col.Values)-1] = proto.IPv6{}
+	case *proto.ColArr[uint32], *proto.ColArr[proto.UInt128]:
+		truncateLastRow(col)
+		appendDefaultValue(col)
+	default:
+		panic(fmt.Sprintf("unhandled ClickHouse type %q", col.Type()))
+	}
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schema
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func TestAggregate(t *testing.T) {
+	c := NewMock(t)
+	bf := c.NewFlowMessage()
+	bf.EnableAggregation(10*time.Second, []ColumnKey{ColumnSrcAddr, ColumnSrcPort, ColumnDstASPath})
+
+	flows := []struct {
+		TimeReceived uint32
+		SrcAddr      string
+		SrcPort      uint64
+		DstPort      uint64
+		DstASPath    []uint32
+		Bytes        uint64
+		Packets      uint64
+		Merged       bool
+	}{
+		{1000, "192.0.2.1", 33000, 443, []uint32{65001, 65002}, 1000, 1, false},
+		// Ignored columns are different
+		{1005, "192.0.2.2", 33001, 443, []uint32{65001}, 2000, 2, true},
+		// Different column
+		{1005, "192.0.2.1", 33000, 80, nil, 3000, 3, false},
+		// Same as the first one but in another window
+		{1010, "192.0.2.1", 33000, 443, nil, 4000, 4, false},
+		{1009, "192.0.2.3", 33002, 80, nil, 5000, 5, true},
+	}
+	for _, flow := range flows {
+		previous := bf.FlowCount()
+		bf.TimeReceived = flow.TimeReceived
+		bf.SrcAddr = netip.MustParseAddr("::ffff:" + flow.SrcAddr)
+		bf.AppendUint(ColumnSrcPort, flow.SrcPort)
+		bf.AppendUint(ColumnDstPort, flow.DstPort)
+		bf.AppendArrayUInt32(ColumnDstASPath, flow.DstASPath)
+		bf.AppendUint(ColumnBytes, flow.Bytes)
+		bf.AppendUint(ColumnPackets, flow.Packets)
+		bf.Finalize()
+		if merged := bf.FlowCount() == previous; merged != flow.Merged {
+			t.Errorf("Finalize() merged == %v, expected %v", merged, flow.Merged)
+		}
+	}
+
+	if bf.FlowCount() != 3 {
+		t.Fatalf("FlowCount() == %d, expected 3", bf.FlowCount())
+	}
+	got := map[string]any{
+		"TimeReceived": bf.batch.columns[ColumnTimeReceived].(*proto.ColDateTime).Data,
+		"SrcAddr":      bf.batch.columns[ColumnSrcAddr].(*proto.ColIPv6),
+		"SrcPort":      bf.batch.columns[ColumnSrcPort].(*proto.ColUInt16),
+		"DstPort":      bf.batch.columns[ColumnDstPort].(*proto.ColUInt16),
+		"DstASPath":    bf.batch.columns[ColumnDstASPath].(*proto.ColArr[uint32]).Offsets,
+		"Bytes":        bf.batch.columns[ColumnBytes].(*proto.ColUInt64),
+		"Packets":      bf.batch.columns[ColumnPackets].(*proto.ColUInt64),
+	}
+	expected := map[string]any{
+		"TimeReceived": []proto.DateTime{1000, 1000, 1010},
+		"SrcAddr":      &proto.ColIPv6{{}, {}, {}},
+		"SrcPort":      &proto.ColUInt16{0, 0, 0},
+		"DstPort":      &proto.ColUInt16{443, 80, 443},
+		"DstASPath":    proto.ColUInt64{0, 0, 0},
+		"Bytes":        &proto.ColUInt64{3000, 8000, 4000},
+		"Packets":      &proto.ColUInt64{3, 8, 4},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Aggregated columns (-got, +want):\n%s", diff)
+	}
+	for idx, col := range bf.batch.columns {
+		if col != nil && col.Rows() != 3 {
+			t.Errorf("column %s has %d rows, expected 3", ColumnKey(idx), col.Rows())
+		}
+	}
+
+	// After clearing the batch, flows are not merged with the previous ones
+	bf.Clear()
+	bf.TimeReceived = 1000
+	bf.AppendUint(ColumnDstPort, 443)
+	bf.AppendUint(ColumnBytes, 1000)
+	bf.Finalize()
+	if bf.FlowCount() != 1 {
+		t.Fatalf("FlowCount() after Clear() == %d, expected 1", bf.FlowCount())
+	}
+}
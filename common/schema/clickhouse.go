@@ -269,6 +269,31 @@ func (bf *FlowMessage) AppendArrayUInt128(columnKey ColumnKey, value []UInt128)
 	bf.appendDebug(columnKey, value)
 }
 
+// GetUint returns the value of an UInt64/32/16/8 or Enum8 column for the flow
+// being built. It returns 0 if the column was not set.
+func (bf *FlowMessage) GetUint(columnKey ColumnKey) uint64 {
+	columnKey = reverse(bf, columnKey)
+	col := bf.batch.columns[columnKey]
+	if col == nil || !bf.batch.columnSet.Test(uint(columnKey)) {
+		return 0
+	}
+	row := bf.batch.rowCount
+	switch col := col.(type) {
+	case *proto.ColUInt64:
+		return col.Row(row)
+	case *proto.ColUInt32:
+		return uint64(col.Row(row))
+	case *proto.ColUInt16:
+		return uint64(col.Row(row))
+	case *proto.ColUInt8:
+		return uint64(col.Row(row))
+	case *proto.ColEnum8:
+		return uint64(col.Row(row))
+	default:
+		panic(fmt.Sprintf("unhandled uint type %q", col.Type()))
+	}
+}
+
 //akvorado:inline
 func (bf *FlowMessage) appendDebug(columnKey ColumnKey, value any) {
 	if !debug {
@@ -296,7 +321,7 @@ func (bf *FlowMessage) check() {
 	}
 }
 
-// appendDefaultValue appends a default/zero value to the given column.
+// appendDefaultValues appends a default/zero value to the columns not set.
 func (bf *FlowMessage) appendDefaultValues() {
 	for idx, col := range bf.batch.columns {
 		// Skip unpopulated columns
@@ -307,38 +332,43 @@ func (bf *FlowMessage) appendDefaultValues() {
 		if bf.batch.columnSet.Test(uint(idx)) {
 			continue
 		}
-		// Put the default value depending on the real type
-		switch col := col.(type) {
-		case *proto.ColUInt64:
-			col.Append(0)
-		case *proto.ColUInt32:
-			col.Append(0)
-		case *proto.ColUInt16:
-			col.Append(0)
-		case *proto.ColUInt8:
-			col.Append(0)
-		case *proto.ColIPv6:
-			col.Append([16]byte{})
-		case *proto.ColDateTime:
-			col.Append(time.Unix(0, 0))
-		case *proto.ColEnum8:
-			col.Append(0)
-		case *proto.ColLowCardinality[string]:
-			col.Append("")
-		case *proto.ColFixedStr:
-			// Pad with zeros, like AppendString().
-			for range col.Size {
-				col.Buf = append(col.Buf, 0)
-			}
-		case *proto.ColLowCardinality[proto.IPv6]:
-			col.Append(proto.IPv6{})
-		case *proto.ColArr[uint32]:
-			col.Append([]uint32{})
-		case *proto.ColArr[proto.UInt128]:
-			col.Append([]proto.UInt128{})
-		default:
-			panic(fmt.Sprintf("unhandled ClickHouse type %q", col.Type()))
+		appendDefaultValue(col)
+	}
+}
+
+// appendDefaultValue appends a default/zero value to the given column.
+func appendDefaultValue(col proto.Column) {
+	// Put the default value depending on the real type
+	switch col := col.(type) {
+	case *proto.ColUInt64:
+		col.Append(0)
+	case *proto.ColUInt32:
+		col.Append(0)
+	case *proto.ColUInt16:
+		col.Append(0)
+	case *proto.ColUInt8:
+		col.Append(0)
+	case *proto.ColIPv6:
+		col.Append([16]byte{})
+	case *proto.ColDateTime:
+		col.Append(time.Unix(0, 0))
+	case *proto.ColEnum8:
+		col.Append(0)
+	case *proto.ColLowCardinality[string]:
+		col.Append("")
+	case *proto.ColFixedStr:
+		// Pad with zeros, like AppendString().
+		for range col.Size {
+			col.Buf = append(col.Buf, 0)
 		}
+	case *proto.ColLowCardinality[proto.IPv6]:
+		col.Append(proto.IPv6{})
+	case *proto.ColArr[uint32]:
+		col.Append([]uint32{})
+	case *proto.ColArr[proto.UInt128]:
+		col.Append([]proto.UInt128{})
+	default:
+		panic(fmt.Sprintf("unhandled ClickHouse type %q", col.Type()))
 	}
 }
 
@@ -351,56 +381,61 @@ func (bf *FlowMessage) Undo() {
 		if !bf.batch.columnSet.Test(uint(idx)) {
 			continue
 		}
-		switch col := col.(type) {
-		case *proto.ColUInt64:
-			*col = (*col)[:len(*col)-1]
-		case *proto.ColUInt32:
-			*col = (*col)[:len(*col)-1]
-		case *proto.ColUInt16:
-			*col = (*col)[:len(*col)-1]
-		case *proto.ColUInt8:
-			*col = (*col)[:len(*col)-1]
-		case *proto.ColIPv6:
-			*col = (*col)[:len(*col)-1]
-		case *proto.ColDateTime:
-			col.Data = col.Data[:len(col.Data)-1]
-		case *proto.ColEnum8:
-			*col = (*col)[:len(*col)-1]
-		case *proto.ColLowCardinality[string]:
-			col.Values = col.Values[:len(col.Values)-1]
-		case *proto.ColFixedStr:
-			col.Buf = col.Buf[:len(col.Buf)-col.Size]
-		case *proto.ColLowCardinality[proto.IPv6]:
-			col.Values = col.Values[:len(col.Values)-1]
-		case *proto.ColArr[uint32]:
-			l := len(col.Offsets)
-			if l > 0 {
-				start := uint64(0)
-				if l > 1 {
-					start = col.Offsets[l-2]
-				}
-				data := col.Data.(*proto.ColUInt32)
-				*data = (*data)[:start]
-				col.Data = data
-				col.Offsets = col.Offsets[:l-1]
+		truncateLastRow(col)
+	}
+	bf.reset()
+}
+
+// truncateLastRow removes the last value of the given column.
+func truncateLastRow(col proto.Column) {
+	switch col := col.(type) {
+	case *proto.ColUInt64:
+		*col = (*col)[:len(*col)-1]
+	case *proto.ColUInt32:
+		*col = (*col)[:len(*col)-1]
+	case *proto.ColUInt16:
+		*col = (*col)[:len(*col)-1]
+	case *proto.ColUInt8:
+		*col = (*col)[:len(*col)-1]
+	case *proto.ColIPv6:
+		*col = (*col)[:len(*col)-1]
+	case *proto.ColDateTime:
+		col.Data = col.Data[:len(col.Data)-1]
+	case *proto.ColEnum8:
+		*col = (*col)[:len(*col)-1]
+	case *proto.ColLowCardinality[string]:
+		col.Values = col.Values[:len(col.Values)-1]
+	case *proto.ColFixedStr:
+		col.Buf = col.Buf[:len(col.Buf)-col.Size]
+	case *proto.ColLowCardinality[proto.IPv6]:
+		col.Values = col.Values[:len(col.Values)-1]
+	case *proto.ColArr[uint32]:
+		l := len(col.Offsets)
+		if l > 0 {
+			start := uint64(0)
+			if l > 1 {
+				start = col.Offsets[l-2]
 			}
-		case *proto.ColArr[proto.UInt128]:
-			l := len(col.Offsets)
-			if l > 0 {
-				start := uint64(0)
-				if l > 1 {
-					start = col.Offsets[l-2]
-				}
-				data := col.Data.(*proto.ColUInt128)
-				*data = (*data)[:start]
-				col.Data = data
-				col.Offsets = col.Offsets[:l-1]
+			data := col.Data.(*proto.ColUInt32)
+			*data = (*data)[:start]
+			col.Data = data
+			col.Offsets = col.Offsets[:l-1]
+		}
+	case *proto.ColArr[proto.UInt128]:
+		l := len(col.Offsets)
+		if l > 0 {
+			start := uint64(0)
+			if l > 1 {
+				start = col.Offsets[l-2]
 			}
-		default:
-			panic(fmt.Sprintf("unhandled ClickHouse type %q", col.Type()))
+			data := col.Data.(*proto.ColUInt128)
+			*data = (*data)[:start]
+			col.Data = data
+			col.Offsets = col.Offsets[:l-1]
 		}
+	default:
+		panic(fmt.Sprintf("unhandled ClickHouse type %q", col.Type()))
 	}
-	bf.reset()
 }
 
 // Finalize finalizes the current FlowMessage. It can then be reused for the
@@ -424,6 +459,7 @@ func (bf *FlowMessage) Finalize() {
 	bf.protobufFinalize()
 	bf.batch.rowCount++
 	bf.appendDefaultValues()
+	bf.aggregate()
 	bf.reset()
 	bf.check()
 }
@@ -73,6 +73,35 @@ func TestAppendBasics(t *testing.T) {
 	}
 }
 
+func TestGetUint(t *testing.T) {
+	c := NewMock(t)
+	bf := c.NewFlowMessage()
+
+	// First flow, to check we read the current row
+	bf.AppendUint(ColumnSrcPort, 80)
+	bf.Finalize()
+
+	bf.AppendUint(ColumnSrcPort, 443)
+	bf.AppendUint(ColumnProto, 6)
+	bf.AppendUint(ColumnBytes, 1500)
+	got := []uint64{
+		bf.GetUint(ColumnSrcPort),
+		bf.GetUint(ColumnDstPort),
+		bf.GetUint(ColumnProto),
+		bf.GetUint(ColumnBytes),
+	}
+	if diff := helpers.Diff(got, []uint64{443, 0, 6, 1500}); diff != "" {
+		t.Errorf("GetUint() (-got, +want):\n%s", diff)
+	}
+
+	// With reversed direction
+	bf.Reverse()
+	got = []uint64{bf.GetUint(ColumnSrcPort), bf.GetUint(ColumnDstPort)}
+	if diff := helpers.Diff(got, []uint64{0, 443}); diff != "" {
+		t.Errorf("GetUint() reversed (-got, +want):\n%s", diff)
+	}
+}
+
 func TestAppendWithDisabledColumns(t *testing.T) {
 	c := NewMock(t)
 	bf := c.NewFlowMessage()
@@ -116,6 +116,25 @@ func (d *Direction) UnmarshalText(input []byte) error {
 	return errUnknownDirection
 }
 
+// FirewallEvent is the event reported by a firewall for a flow (IPFIX
+// information element 233, NSEL NF_F_FW_EVENT).
+type FirewallEvent uint
+
+const (
+	// FirewallEventUndefined means the flow is not a firewall event
+	FirewallEventUndefined FirewallEvent = iota
+	// FirewallEventCreated means the flow has been created
+	FirewallEventCreated
+	// FirewallEventDeleted means the flow has been deleted
+	FirewallEventDeleted
+	// FirewallEventDenied means the flow has been denied
+	FirewallEventDenied
+	// FirewallEventAlert means the flow triggered an alert
+	FirewallEventAlert
+	// FirewallEventUpdated means the flow has been updated
+	FirewallEventUpdated
+)
+
 const (
 	// DictionaryASNs is the name of the asns clickhouse dictionary.
 	DictionaryASNs string = "asns"
@@ -222,6 +241,17 @@ const (
 	ColumnMPLS4thLabel
 	ColumnIngressVRFID
 	ColumnEgressVRFID
+	ColumnFirewallEvent
+	ColumnFirewallExtendedEvent
+	ColumnIngressACLID
+	ColumnEgressACLID
+	ColumnDuplicate
+	ColumnSrcApplication
+	ColumnDstApplication
+	ColumnInIfNeighbor
+	ColumnOutIfNeighbor
+	ColumnInIfOperStatus
+	ColumnOutIfOperStatus
 
 	// ColumnLast points to after the last static column, custom dictionaries
 	// (dynamic columns) come after ColumnLast
@@ -232,6 +262,8 @@ const (
 	ColumnGroupL2 ColumnGroup = iota + 1
 	ColumnGroupNAT
 	ColumnGroupL3L4
+	ColumnGroupFirewall
+	ColumnGroupApplication
 
 	ColumnGroupLast
 )
@@ -534,6 +566,40 @@ END`,
 			},
 			{Key: ColumnIngressVRFID, Disabled: true, ParserType: "uint", ClickHouseType: "UInt32"},
 			{Key: ColumnEgressVRFID, Disabled: true, ParserType: "uint", ClickHouseType: "UInt32"},
+			{
+				Key:        ColumnFirewallEvent,
+				Disabled:   true,
+				Group:      ColumnGroupFirewall,
+				ParserType: "string",
+				ClickHouseType: fmt.Sprintf("Enum8('undefined' = %d, 'created' = %d, 'deleted' = %d, 'denied' = %d, 'alert' = %d, 'updated' = %d)",
+					FirewallEventUndefined, FirewallEventCreated, FirewallEventDeleted,
+					FirewallEventDenied, FirewallEventAlert, FirewallEventUpdated),
+			},
+			{Key: ColumnFirewallExtendedEvent, Disabled: true, Group: ColumnGroupFirewall, ParserType: "uint", ClickHouseType: "UInt16"},
+			{Key: ColumnIngressACLID, Disabled: true, Group: ColumnGroupFirewall, ParserType: "string", ClickHouseType: "LowCardinality(String)"},
+			{Key: ColumnEgressACLID, Disabled: true, Group: ColumnGroupFirewall, ParserType: "string", ClickHouseType: "LowCardinality(String)"},
+			{Key: ColumnDuplicate, Disabled: true, ParserType: "uint", ClickHouseType: "UInt8"},
+			{
+				Key:            ColumnSrcApplication,
+				Disabled:       true,
+				Group:          ColumnGroupApplication,
+				ParserType:     "string",
+				ClickHouseType: "LowCardinality(String)",
+			},
+			{
+				Key:                     ColumnInIfNeighbor,
+				Disabled:                true,
+				ParserType:              "string",
+				ClickHouseType:          "LowCardinality(String)",
+				ClickHouseNotSortingKey: true,
+			},
+			{
+				Key:                     ColumnInIfOperStatus,
+				Disabled:                true,
+				ParserType:              "string",
+				ClickHouseType:          "LowCardinality(String)",
+				ClickHouseNotSortingKey: true,
+			},
 		},
 	}.finalize()
 }
@@ -58,6 +58,9 @@ type messageBatch struct {
 	protobufEnabled bool   // Whether to encode flows to Protobuf
 	protobuf        []byte // Working buffer for the flow currently being built
 	protobufMessage []byte // Last finalized flow, valid until the next Finalize
+
+	// Optional aggregation of flows sharing the same columns inside the batch.
+	aggregation messageAggregation
 }
 
 // reset resets a flow message. All public fields are set to 0,
@@ -81,6 +84,7 @@ func (bf *FlowMessage) Clear() {
 	bf.reset()
 	bf.batch.input.Reset()
 	bf.batch.rowCount = 0
+	clear(bf.batch.aggregation.rows)
 }
 
 // EnableProtobuf turns on Protobuf encoding of enriched flows for this message.
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schema
+
+import (
+	"fmt"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// ClickHouseProtoInputsBy splits the current batch using the value of the
+// provided LowCardinality(String) column. It returns a proto.Input for each
+// distinct value. When all the flows share the same value or when the column
+// is not present, the input returned by ClickHouseProtoInput() is used as is.
+func (bf *FlowMessage) ClickHouseProtoInputsBy(columnKey ColumnKey) map[string]proto.Input {
+	col, ok := bf.batch.columns[columnKey].(*proto.ColLowCardinality[string])
+	if !ok || bf.batch.rowCount == 0 {
+		return map[string]proto.Input{"": bf.batch.input}
+	}
+	rows := map[string][]int{}
+	for row, value := range col.Values {
+		rows[value] = append(rows[value], row)
+	}
+	if len(rows) == 1 {
+		return map[string]proto.Input{col.Values[0]: bf.batch.input}
+	}
+
+	inputs := make(map[string]proto.Input, len(rows))
+	for value, indexes := range rows {
+		input := make(proto.Input, 0, len(bf.batch.input))
+		for _, column := range bf.schema.columns {
+			src := bf.batch.columns[column.Key]
+			if src == nil {
+				continue
+			}
+			dst := column.newProtoColumn()
+			for _, row := range indexes {
+				appendRow(dst, src, row)
+			}
+			input = append(input, proto.InputColumn{
+				Name: column.Name,
+				Data: column.wrapProtoColumn(dst),
+			})
+		}
+		inputs[value] = input
+	}
+	return inputs
+}
+
+// appendRow appends the value of the provided row of a column to another
+// column of the same type.
+func appendRow(dst, src proto.Column, row int) {
+	switch src := src.(type) {
+	case *proto.ColUInt64:
+		dst.(*proto.ColUInt64).Append((*src)[row])
+	case *proto.ColUInt32:
+		dst.(*proto.ColUInt32).Append((*src)[row])
+	case *proto.ColUInt16:
+		dst.(*proto.ColUInt16).Append((*src)[row])
+	case *proto.ColUInt8:
+		dst.(*proto.ColUInt8).Append((*src)[row])
+	case *proto.ColIPv6:
+		dst.(*proto.ColIPv6).Append((*src)[row])
+	case *proto.ColDateTime:
+		dst.(*proto.ColDateTime).AppendRaw(src.Data[row])
+	case *proto.ColEnum8:
+		dst.(*proto.ColEnum8).Append((*src)[row])
+	case *proto.ColLowCardinality[string]:
+		dst.(*proto.ColLowCardinality[string]).Append(src.Values[row])
+	case *proto.ColFixedStr:
+		dst := dst.(*proto.ColFixedStr)
+		dst.Buf = append(dst.Buf, src.Row(row)...)
+	case *proto.ColLowCardinality[proto.IPv6]:
+		dst.(*proto.ColLowCardinality[proto.IPv6]).Append(src.Values[row])
+	case *proto.ColArr[uint32]:
+		dst.(*proto.ColArr[uint32]).Append(src.Row(row))
+	case *proto.ColArr[proto.UInt128]:
+		dst.(*proto.ColArr[proto.UInt128]).Append(src.Row(row))
+	default:
+		panic(fmt.Sprintf("unhandled ClickHouse type %q", src.Type()))
+	}
+}
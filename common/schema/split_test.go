@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schema
+
+import (
+	"testing"
+
+	"akvorado/common/helpers"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func TestClickHouseProtoInputsBy(t *testing.T) {
+	c := NewMock(t)
+	bf := c.NewFlowMessage()
+	appendFlow := func(tenant string, dstPort uint64, dstASPath []uint32) {
+		bf.TimeReceived = 1000
+		bf.AppendString(ColumnExporterTenant, tenant)
+		bf.AppendUint(ColumnDstPort, dstPort)
+		bf.AppendArrayUInt32(ColumnDstASPath, dstASPath)
+		bf.Finalize()
+	}
+
+	// A single tenant reuses the main input
+	appendFlow("alpha", 443, nil)
+	appendFlow("alpha", 80, nil)
+	inputs := bf.ClickHouseProtoInputsBy(ColumnExporterTenant)
+	if len(inputs) != 1 || len(inputs["alpha"]) != len(bf.ClickHouseProtoInput()) {
+		t.Fatalf("ClickHouseProtoInputsBy() == %v, expected a single input", inputs)
+	}
+
+	// Several tenants
+	appendFlow("", 22, []uint32{65001, 65002})
+	appendFlow("beta", 53, []uint32{65003})
+	got := map[string]any{}
+	for tenant, input := range bf.ClickHouseProtoInputsBy(ColumnExporterTenant) {
+		if len(input) != len(bf.ClickHouseProtoInput()) {
+			t.Errorf("ClickHouseProtoInputsBy(%q) has %d columns, expected %d",
+				tenant, len(input), len(bf.ClickHouseProtoInput()))
+		}
+		columns := map[string]any{}
+		for _, column := range input {
+			if column.Data.Rows() != input[0].Data.Rows() {
+				t.Errorf("ClickHouseProtoInputsBy(%q): column %s has %d rows, expected %d",
+					tenant, column.Name, column.Data.Rows(), input[0].Data.Rows())
+			}
+			switch column.Name {
+			case "DstPort":
+				columns[column.Name] = column.Data.(*proto.ColUInt16)
+			case "DstASPath":
+				paths := [][]uint32{}
+				col := column.Data.(*proto.ColArr[uint32])
+				for row := range col.Rows() {
+					paths = append(paths, col.Row(row))
+				}
+				columns[column.Name] = paths
+			case "TimeReceived":
+				columns[column.Name] = column.Data.(*proto.ColDateTime).Data
+			}
+		}
+		got[tenant] = columns
+	}
+	expected := map[string]any{
+		"alpha": map[string]any{
+			"TimeReceived": []proto.DateTime{1000, 1000},
+			"DstPort":      &proto.ColUInt16{443, 80},
+			"DstASPath":    [][]uint32{nil, nil},
+		},
+		"": map[string]any{
+			"TimeReceived": []proto.DateTime{1000},
+			"DstPort":      &proto.ColUInt16{22},
+			"DstASPath":    [][]uint32{{65001, 65002}},
+		},
+		"beta": map[string]any{
+			"TimeReceived": []proto.DateTime{1000},
+			"DstPort":      &proto.ColUInt16{53},
+			"DstASPath":    [][]uint32{{65003}},
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("ClickHouseProtoInputsBy() (-got, +want):\n%s", diff)
+	}
+}
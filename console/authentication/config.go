@@ -28,6 +28,7 @@ type ConfigurationHeaders struct {
 	Email     string
 	LogoutURL string
 	AvatarURL string
+	Tenant    string
 }
 
 // DefaultConfiguration represents the default configuration for the console component.
@@ -39,6 +40,7 @@ func DefaultConfiguration() Configuration {
 			Email:     "Remote-Email",
 			LogoutURL: "X-Logout-URL",
 			AvatarURL: "X-Avatar-URL",
+			Tenant:    "Remote-Tenant",
 		},
 		DefaultUser: UserInformation{
 			Login: "__default",
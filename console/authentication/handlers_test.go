@@ -54,6 +54,7 @@ func TestUserHandler(t *testing.T) {
 					headers.Add("Remote-Email", "alfred@batman.com")
 					headers.Add("X-Logout-URL", "/logout")
 					headers.Add("X-Avatar-URL", "https://avatars.githubusercontent.com/akvorado")
+					headers.Add("Remote-Tenant", "wayne")
 					return headers
 				}(),
 				StatusCode: 200,
@@ -63,6 +64,7 @@ func TestUserHandler(t *testing.T) {
 					"email":      "alfred@batman.com",
 					"logout-url": "/logout",
 					"avatar-url": "https://avatars.githubusercontent.com/akvorado",
+					"tenant":     "wayne",
 				},
 			}, {
 				Description: "user info, invalid user logged in",
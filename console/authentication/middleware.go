@@ -20,6 +20,7 @@ type UserInformation struct {
 	Email     string `json:"email,omitempty" validate:"omitempty,email"`
 	LogoutURL string `json:"logout-url,omitempty" validate:"omitempty,uri"`
 	AvatarURL string `json:"avatar-url,omitempty" validate:"omitempty,uri"`
+	Tenant    string `json:"tenant,omitempty"`
 }
 
 // userContextKey is the key under which the current user is stored in the
@@ -70,6 +71,10 @@ func (c *Component) UserAuthentication() httpserver.Middleware {
 			}
 
 			ctx := context.WithValue(req.Context(), userContextKey{}, info)
+			if info.Tenant != "" {
+				// Users from different tenants should not share cached answers
+				ctx = httpserver.WithCacheScope(ctx, info.Tenant)
+			}
 			next.ServeHTTP(w, req.WithContext(ctx))
 		})
 	}
@@ -90,5 +95,6 @@ func (c *Component) userFromHeaders(req *http.Request) UserInformation {
 		Email:     get(c.config.Headers.Email),
 		LogoutURL: get(c.config.Headers.LogoutURL),
 		AvatarURL: get(c.config.Headers.AvatarURL),
+		Tenant:    get(c.config.Headers.Tenant),
 	}
 }
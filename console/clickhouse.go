@@ -4,20 +4,22 @@
 package console
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"time"
 
+	"akvorado/common/clickhousedb"
 	sb "akvorado/common/sqlbuilder"
 	"akvorado/console/query"
 )
 
 // dictionary names a ClickHouse dictionary, qualified with the database it
 // lives in, as the remote nodes of a cluster do not resolve a bare name.
-func (c *Component) dictionary(name string) sb.TableName {
-	return sb.Table(name).In(c.d.ClickHouseDB.DatabaseName())
+func (c *Component) dictionary(ctx context.Context, name string) sb.TableName {
+	return sb.Table(name).In(c.clickhouseDB(ctx).DatabaseName())
 }
 
 // flowsTable describe a consolidated or unconsolidated flows table.
@@ -28,14 +30,38 @@ type flowsTable struct {
 }
 
 // refreshFlowsTables refreshes the information we have about flows
-// tables (live one and consolidated ones). This information includes
-// the consolidation interval and the oldest available data.
+// tables (live one and consolidated ones), in the main database and in the
+// databases dedicated to tenants. This information includes the
+// consolidation interval and the oldest available data.
 func (c *Component) refreshFlowsTables() error {
+	newFlowsTables, err := c.queryFlowsTables(c.d.ClickHouseDB)
+	if err != nil {
+		return err
+	}
+	newTenantFlowsTables := map[string][]flowsTable{}
+	for _, tenant := range c.d.ClickHouseDB.Tenants() {
+		tables, err := c.queryFlowsTables(tenant)
+		if err != nil {
+			return fmt.Errorf("database %s: %w", tenant.DatabaseName(), err)
+		}
+		newTenantFlowsTables[tenant.DatabaseName()] = tables
+	}
+
+	c.flowsTablesLock.Lock()
+	c.flowsTables = newFlowsTables
+	c.tenantFlowsTables = newTenantFlowsTables
+	c.flowsTablesLock.Unlock()
+	return nil
+}
+
+// queryFlowsTables queries the information about flows tables in the database
+// of the provided component.
+func (c *Component) queryFlowsTables(ch *clickhousedb.Component) ([]flowsTable, error) {
 	ctx := c.t.Context(nil)
 	var tables []struct {
 		Name string `ch:"name"`
 	}
-	err := c.d.ClickHouseDB.Select(ctx, &tables, `
+	err := ch.Select(ctx, &tables, `
 SELECT name
 FROM system.tables
 WHERE database=currentDatabase()
@@ -45,7 +71,7 @@ AND table != 'flows_raw_errors'
 AND (engine LIKE '%MergeTree' OR engine = 'Distributed')
 `)
 	if err != nil {
-		return fmt.Errorf("cannot query flows table metadata: %w", err)
+		return nil, fmt.Errorf("cannot query flows table metadata: %w", err)
 	}
 
 	newFlowsTables := []flowsTable{}
@@ -64,10 +90,10 @@ AND (engine LIKE '%MergeTree' OR engine = 'Distributed')
 		var oldest []struct {
 			T time.Time `ch:"t"`
 		}
-		err := c.d.ClickHouseDB.Conn.Select(ctx, &oldest,
+		err := ch.Conn.Select(ctx, &oldest,
 			fmt.Sprintf(`SELECT MIN(TimeReceived) AS t FROM %s`, table.Name))
 		if err != nil {
-			return fmt.Errorf("cannot query table %s for oldest timestamp: %w", table.Name, err)
+			return nil, fmt.Errorf("cannot query table %s for oldest timestamp: %w", table.Name, err)
 		}
 
 		newFlowsTables = append(newFlowsTables, flowsTable{
@@ -77,13 +103,9 @@ AND (engine LIKE '%MergeTree' OR engine = 'Distributed')
 		})
 	}
 	if len(newFlowsTables) == 0 {
-		return errors.New("no flows table present (yet?)")
+		return nil, errors.New("no flows table present (yet?)")
 	}
-
-	c.flowsTablesLock.Lock()
-	c.flowsTables = newFlowsTables
-	c.flowsTablesLock.Unlock()
-	return nil
+	return newFlowsTables, nil
 }
 
 // inputContext describes a time range, as requested by an input handler. It is
@@ -92,6 +114,7 @@ type inputContext struct {
 	Start             time.Time
 	End               time.Time
 	MainTableRequired bool
+	Database          string // Database to query, empty for the main one
 	Points            uint
 }
 
@@ -275,22 +298,26 @@ func (c *Component) computeTableAndInterval(input inputContext) (string, time.Du
 	if input.MainTableRequired {
 		return "flows", time.Second, targetInterval
 	}
-	table, computedInterval := c.getBestTable(input.Start, targetInterval)
+	table, computedInterval := c.getBestTable(input.Database, input.Start, targetInterval)
 	return table, computedInterval, targetInterval
 }
 
-// Get the best table starting at the specified time.
-func (c *Component) getBestTable(start time.Time, targetInterval time.Duration) (string, time.Duration) {
+// Get the best table of the provided database starting at the specified time.
+func (c *Component) getBestTable(database string, start time.Time, targetInterval time.Duration) (string, time.Duration) {
 	c.flowsTablesLock.RLock()
 	defer c.flowsTablesLock.RUnlock()
+	flowsTables, ok := c.tenantFlowsTables[database]
+	if !ok {
+		flowsTables = c.flowsTables
+	}
 
 	table := "flows"
 	computedInterval := time.Second
-	if len(c.flowsTables) > 0 {
+	if len(flowsTables) > 0 {
 		// We can use the consolidated data. The first criteria is to find the
 		// tables matching the time criteria.
 		candidates := []int{}
-		for idx, table := range c.flowsTables {
+		for idx, table := range flowsTables {
 			if start.After(table.Oldest.Add(table.Resolution)) {
 				candidates = append(candidates, idx)
 			}
@@ -298,35 +325,35 @@ func (c *Component) getBestTable(start time.Time, targetInterval time.Duration)
 		if len(candidates) == 0 {
 			// No candidate, fallback to the one with oldest data
 			best := 0
-			for idx, table := range c.flowsTables {
-				if c.flowsTables[best].Oldest.After(table.Oldest.Add(table.Resolution)) {
+			for idx, table := range flowsTables {
+				if flowsTables[best].Oldest.After(table.Oldest.Add(table.Resolution)) {
 					best = idx
 				}
 			}
 			candidates = []int{best}
 			// Add other candidates that are not far off in term of oldest data
-			for idx, table := range c.flowsTables {
+			for idx, table := range flowsTables {
 				if idx == best {
 					continue
 				}
-				if c.flowsTables[best].Oldest.After(table.Oldest) {
+				if flowsTables[best].Oldest.After(table.Oldest) {
 					candidates = append(candidates, idx)
 				}
 			}
 		}
 		sort.Slice(candidates, func(i, j int) bool {
-			return c.flowsTables[candidates[i]].Resolution < c.flowsTables[candidates[j]].Resolution
+			return flowsTables[candidates[i]].Resolution < flowsTables[candidates[j]].Resolution
 		})
 		// If possible, use the first resolution before the target interval
 		for len(candidates) > 1 {
-			if c.flowsTables[candidates[1]].Resolution <= targetInterval {
+			if flowsTables[candidates[1]].Resolution <= targetInterval {
 				candidates = candidates[1:]
 			} else {
 				break
 			}
 		}
-		table = c.flowsTables[candidates[0]].Name
-		computedInterval = c.flowsTables[candidates[0]].Resolution
+		table = flowsTables[candidates[0]].Name
+		computedInterval = flowsTables[candidates[0]].Resolution
 	}
 	if computedInterval < time.Second {
 		computedInterval = time.Second
@@ -66,7 +66,7 @@ const (
 // VisualizeOptionsConfiguration defines options for the "visualize" tab.
 type VisualizeOptionsConfiguration struct {
 	// GraphType tells the type of the graph we request
-	GraphType string `json:"graphType" validate:"oneof=stacked stacked100 lines grid sankey"`
+	GraphType string `json:"graphType" validate:"oneof=stacked stacked100 lines grid sankey heatmap matrix"`
 	// Start is the start time (as a string)
 	Start string `json:"start" validate:"required"`
 	// End is the end time (as string)
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"net/http"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	sb "akvorado/common/sqlbuilder"
+)
+
+// graphInterfaceCountersHandlerInput describes the input for the
+// /graph/interface-counters endpoint.
+type graphInterfaceCountersHandlerInput struct {
+	Start         time.Time `json:"start" validate:"required"`
+	End           time.Time `json:"end" validate:"required,gtfield=Start"`
+	Points        uint      `json:"points" validate:"required,min=5,max=2000"`
+	ExporterName  string    `json:"exporter-name" validate:"required"`
+	InterfaceName string    `json:"interface-name" validate:"required"`
+}
+
+// graphInterfaceCountersHandlerOutput describes one point of the output of the
+// /graph/interface-counters endpoint. Counters are per second. The sampled
+// rates are computed from flows, like the l2bps unit.
+type graphInterfaceCountersHandlerOutput struct {
+	Time          time.Time `json:"t" ch:"Time"`
+	InBps         float64   `json:"in-bps" ch:"InBps"`
+	OutBps        float64   `json:"out-bps" ch:"OutBps"`
+	InErrors      float64   `json:"in-errors" ch:"InErrors"`
+	OutErrors     float64   `json:"out-errors" ch:"OutErrors"`
+	InDiscards    float64   `json:"in-discards" ch:"InDiscards"`
+	OutDiscards   float64   `json:"out-discards" ch:"OutDiscards"`
+	SampledInBps  float64   `json:"sampled-in-bps" ch:"-"`
+	SampledOutBps float64   `json:"sampled-out-bps" ch:"-"`
+}
+
+// counterRate turns a cumulative counter into a rate per second for each
+// interval. deltaSumTimestamp() handles counter resets.
+func counterRate(column string, factor uint64) sb.Expr {
+	delta := sb.Function("deltaSumTimestamp", sb.Column(column), sb.Column("TimeReceived"))
+	if factor != 1 {
+		delta = sb.Op(delta, "*", sb.Uint(factor))
+	}
+	return sb.Function("ifNotFinite",
+		sb.Op(
+			delta,
+			"/",
+			sb.Parens(sb.Op(
+				sb.Function("max", sb.Column("TimeReceived")),
+				"-",
+				sb.Function("min", sb.Column("TimeReceived"))))),
+		sb.Uint(0))
+}
+
+// sampledRate computes the L2 bitrate of the interface from the sampled flows.
+func sampledRate(column, ifName string, interval uint64) sb.Expr {
+	return sb.Op(
+		sb.Function("SUM", sb.Function("if",
+			sb.Op(sb.Column(column), "=", sb.String(ifName)),
+			sb.MustParseExpr("(Bytes+38*Packets)*SamplingRate*8"),
+			sb.Uint(0))),
+		"/", sb.Uint(interval))
+}
+
+// graphInterfaceCountersHandlerFunc returns the rates computed from the
+// interface counters sent by an exporter, alongside the rates computed from
+// the sampled flows for the same interface. This helps to spot sampling or
+// accounting issues.
+func (c *Component) graphInterfaceCountersHandlerFunc(w http.ResponseWriter, req *http.Request) {
+	ctx := c.t.Context(req.Context())
+	var input graphInterfaceCountersHandlerInput
+	if err := httpserver.BindJSON(req, &input); err != nil {
+		httpserver.WriteJSON(w, http.StatusBadRequest, helpers.M{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+
+	r := c.resolve(inputContext{
+		Start:             input.Start,
+		End:               input.End,
+		MainTableRequired: false,
+		Database:          c.clickhouseDB(ctx).DatabaseName(),
+		Points:            input.Points,
+	}).forRange(input.Start, input.End)
+	fill := func(q *sb.Query) *sb.Query {
+		return q.GroupBy(sb.Column("Time")).
+			OrderBy(sb.Order(sb.Column("Time")).Fill(
+				r.timefilterStart(),
+				sb.Op(r.timefilterEnd(), "+", seconds(1)),
+				sb.Uint(r.Interval)))
+	}
+
+	// Rates from interface counters
+	countersQuery := fill(sb.Select(
+		sb.Alias(r.toStartOfInterval(), "Time"),
+		sb.Alias(counterRate("InOctets", 8), "InBps"),
+		sb.Alias(counterRate("OutOctets", 8), "OutBps"),
+		sb.Alias(counterRate("InErrors", 1), "InErrors"),
+		sb.Alias(counterRate("OutErrors", 1), "OutErrors"),
+		sb.Alias(counterRate("InDiscards", 1), "InDiscards"),
+		sb.Alias(counterRate("OutDiscards", 1), "OutDiscards")).
+		From(sb.Table("interface_counters")).
+		Where(sb.And(
+			r.timefilter(),
+			sb.Op(sb.Column("ExporterName"), "=", sb.String(input.ExporterName)),
+			sb.Op(sb.Column("IfName"), "=", sb.String(input.InterfaceName))))).
+		String()
+
+	// Rates from sampled flows
+	flowsQuery := fill(sb.Select(
+		sb.Alias(r.toStartOfInterval(), "Time"),
+		sb.Alias(sampledRate("InIfName", input.InterfaceName, r.Interval), "SampledInBps"),
+		sb.Alias(sampledRate("OutIfName", input.InterfaceName, r.Interval), "SampledOutBps")).
+		From(sb.Table(r.Table)).
+		Where(sb.And(
+			r.timefilter(),
+			sb.Op(sb.Column("ExporterName"), "=", sb.String(input.ExporterName)),
+			sb.Or(
+				sb.Op(sb.Column("InIfName"), "=", sb.String(input.InterfaceName)),
+				sb.Op(sb.Column("OutIfName"), "=", sb.String(input.InterfaceName)))))).
+		String()
+	w.Header().Set("X-SQL-Query", countersQuery+";\n"+flowsQuery)
+
+	counters := []graphInterfaceCountersHandlerOutput{}
+	c.metrics.clickhouseQueries.WithLabelValues("interface_counters").Inc()
+	if err := c.clickhouseDB(ctx).Select(ctx, &counters, countersQuery); err != nil {
+		c.r.Err(err).Str("query", countersQuery).Msg("unable to query database")
+		httpserver.WriteJSON(w, http.StatusInternalServerError, helpers.M{"message": "Unable to query database."})
+		return
+	}
+	flows := []struct {
+		Time          time.Time `ch:"Time"`
+		SampledInBps  float64   `ch:"SampledInBps"`
+		SampledOutBps float64   `ch:"SampledOutBps"`
+	}{}
+	c.metrics.clickhouseQueries.WithLabelValues(r.Table).Inc()
+	if err := c.clickhouseDB(ctx).Select(ctx, &flows, flowsQuery); err != nil {
+		c.r.Err(err).Str("query", flowsQuery).Msg("unable to query database")
+		httpserver.WriteJSON(w, http.StatusInternalServerError, helpers.M{"message": "Unable to query database."})
+		return
+	}
+
+	// Both queries use the same buckets, merge them.
+	sampled := make(map[time.Time]int, len(flows))
+	for idx, row := range flows {
+		sampled[row.Time.UTC()] = idx
+	}
+	for idx := range counters {
+		if fidx, ok := sampled[counters[idx].Time.UTC()]; ok {
+			counters[idx].SampledInBps = flows[fidx].SampledInBps
+			counters[idx].SampledOutBps = flows[fidx].SampledOutBps
+		}
+	}
+
+	httpserver.WriteJSON(w, http.StatusOK, helpers.M{"data": counters})
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/helpers"
+	sb "akvorado/common/sqlbuilder"
+)
+
+func TestGraphInterfaceCounters(t *testing.T) {
+	_, h, mockConn, _ := NewMock(t, DefaultConfiguration())
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	countersQuery := `
+SELECT
+ toStartOfInterval(TimeReceived + INTERVAL 3600 second, INTERVAL 3600 second) - INTERVAL 3600 second AS Time,
+ ifNotFinite(deltaSumTimestamp(InOctets, TimeReceived)*8/(max(TimeReceived)-min(TimeReceived)), 0) AS InBps,
+ ifNotFinite(deltaSumTimestamp(OutOctets, TimeReceived)*8/(max(TimeReceived)-min(TimeReceived)), 0) AS OutBps,
+ ifNotFinite(deltaSumTimestamp(InErrors, TimeReceived)/(max(TimeReceived)-min(TimeReceived)), 0) AS InErrors,
+ ifNotFinite(deltaSumTimestamp(OutErrors, TimeReceived)/(max(TimeReceived)-min(TimeReceived)), 0) AS OutErrors,
+ ifNotFinite(deltaSumTimestamp(InDiscards, TimeReceived)/(max(TimeReceived)-min(TimeReceived)), 0) AS InDiscards,
+ ifNotFinite(deltaSumTimestamp(OutDiscards, TimeReceived)/(max(TimeReceived)-min(TimeReceived)), 0) AS OutDiscards
+FROM interface_counters
+WHERE TimeReceived BETWEEN toDateTime('2009-11-10 23:00:00', 'UTC') AND toDateTime('2009-11-11 04:00:00', 'UTC')
+AND ExporterName = 'router1'
+AND IfName = 'Gi0/0/1'
+GROUP BY Time
+ORDER BY Time WITH FILL
+ FROM toDateTime('2009-11-10 23:00:00', 'UTC')
+ TO toDateTime('2009-11-11 04:00:00', 'UTC') + INTERVAL 1 second
+ STEP 3600`
+	flowsQuery := `
+SELECT
+ toStartOfInterval(TimeReceived + INTERVAL 3600 second, INTERVAL 3600 second) - INTERVAL 3600 second AS Time,
+ SUM(if(InIfName = 'Gi0/0/1', (Bytes+38*Packets)*SamplingRate*8, 0))/3600 AS SampledInBps,
+ SUM(if(OutIfName = 'Gi0/0/1', (Bytes+38*Packets)*SamplingRate*8, 0))/3600 AS SampledOutBps
+FROM flows
+WHERE TimeReceived BETWEEN toDateTime('2009-11-10 23:00:00', 'UTC') AND toDateTime('2009-11-11 04:00:00', 'UTC')
+AND ExporterName = 'router1'
+AND (InIfName = 'Gi0/0/1' OR OutIfName = 'Gi0/0/1')
+GROUP BY Time
+ORDER BY Time WITH FILL
+ FROM toDateTime('2009-11-10 23:00:00', 'UTC')
+ TO toDateTime('2009-11-11 04:00:00', 'UTC') + INTERVAL 1 second
+ STEP 3600`
+	countersResults := []graphInterfaceCountersHandlerOutput{
+		{Time: base, InBps: 1000, OutBps: 2000, InErrors: 1, OutErrors: 2, InDiscards: 3, OutDiscards: 4},
+		{Time: base.Add(time.Hour), InBps: 1100, OutBps: 2100},
+		{Time: base.Add(2 * time.Hour)},
+	}
+	flowsResults := []struct {
+		Time          time.Time `ch:"Time"`
+		SampledInBps  float64   `ch:"SampledInBps"`
+		SampledOutBps float64   `ch:"SampledOutBps"`
+	}{
+		{base, 900, 1900},
+		{base.Add(time.Hour), 1000, 2200},
+		{base.Add(2 * time.Hour), 0, 0},
+	}
+	gomock.InOrder(
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), sb.SQLMatcher(t, countersQuery)).
+			SetArg(1, countersResults).
+			Return(nil),
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), sb.SQLMatcher(t, flowsQuery)).
+			SetArg(1, flowsResults).
+			Return(nil),
+	)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/console/graph/interface-counters",
+			JSONInput: helpers.M{
+				"start":          base,
+				"end":            base.Add(5 * time.Hour),
+				"points":         5,
+				"exporter-name":  "router1",
+				"interface-name": "Gi0/0/1",
+			},
+			JSONOutput: helpers.M{
+				"data": []helpers.M{
+					{
+						"t":      "2009-11-10T23:00:00Z",
+						"in-bps": 1000, "out-bps": 2000,
+						"in-errors": 1, "out-errors": 2,
+						"in-discards": 3, "out-discards": 4,
+						"sampled-in-bps": 900, "sampled-out-bps": 1900,
+					}, {
+						"t":      "2009-11-11T00:00:00Z",
+						"in-bps": 1100, "out-bps": 2100,
+						"in-errors": 0, "out-errors": 0,
+						"in-discards": 0, "out-discards": 0,
+						"sampled-in-bps": 1000, "sampled-out-bps": 2200,
+					}, {
+						"t":      "2009-11-11T01:00:00Z",
+						"in-bps": 0, "out-bps": 0,
+						"in-errors": 0, "out-errors": 0,
+						"in-discards": 0, "out-discards": 0,
+						"sampled-in-bps": 0, "sampled-out-bps": 0,
+					},
+				},
+			},
+		}, {
+			Description: "missing interface",
+			URL:         "/api/v0/console/graph/interface-counters",
+			JSONInput: helpers.M{
+				"start":         base,
+				"end":           base.Add(5 * time.Hour),
+				"points":        5,
+				"exporter-name": "router1",
+			},
+			StatusCode: 400,
+			JSONOutput: helpers.M{
+				"message": "Key: 'graphInterfaceCountersHandlerInput.InterfaceName' Error:Field validation for 'InterfaceName' failed on the 'required' tag",
+			},
+		},
+	})
+}
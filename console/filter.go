@@ -73,7 +73,7 @@ func (c *Component) filterValidateHandlerFunc(w http.ResponseWriter, req *http.R
 	got, err := filter.Parse("", []byte(input.Filter),
 		filter.GlobalStore("meta", &filter.Meta{
 			Schema:   c.d.Schema,
-			Database: c.d.ClickHouseDB.DatabaseName(),
+			Database: c.clickhouseDB(req.Context()).DatabaseName(),
 		}))
 	if err == nil {
 		httpserver.WriteJSON(w, http.StatusOK, filterValidateHandlerOutput{
@@ -141,7 +141,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 			filter.Entrypoint("ConditionExpr"),
 			filter.GlobalStore("meta", &filter.Meta{
 				Schema:   c.d.Schema,
-				Database: c.d.ClickHouseDB.DatabaseName(),
+				Database: c.clickhouseDB(ctx).DatabaseName(),
 			}))
 		if err != nil {
 			for _, candidate := range filter.Expected(err) {
@@ -229,7 +229,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 				OrderBy(mostUsedFirst()).
 				Limit(input.Limit).
 				String()
-			if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+			if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
 				c.r.Err(err).Msg("unable to query database")
 				break
 			}
@@ -269,7 +269,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 				Where(sb.Function("startsWith", sb.Column("label"), sb.String(input.Prefix))).
 				Limit(input.Limit).
 				String()
-			if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+			if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
 				c.r.Err(err).Msg("unable to query database")
 				break
 			}
@@ -290,7 +290,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 			if columnName == "DstASPath" {
 				columnName = "DstAS"
 			}
-			asns := c.dictionary(schema.DictionaryASNs)
+			asns := c.dictionary(ctx, schema.DictionaryASNs)
 			column := sb.Column(columnName)
 			// The AS numbers seen in the recent flows, the most used first.
 			fromFlows := sb.Select(
@@ -327,7 +327,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 					sb.Order(sb.Function("MIN", sb.Function("rowNumberInBlock")))).
 				Limit(input.Limit).
 				String()
-			if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+			if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
 				c.r.Err(err).Msg("unable to query database")
 				break
 			}
@@ -345,8 +345,8 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 				Detail string `ch:"detail"`
 			}{}
 			column := sb.Column(c.fixQueryColumnName(input.Column))
-			tcp := c.dictionary(schema.DictionaryTCP)
-			udp := c.dictionary(schema.DictionaryUDP)
+			tcp := c.dictionary(ctx, schema.DictionaryTCP)
+			udp := c.dictionary(ctx, schema.DictionaryUDP)
 			portName := func(dictionary sb.TableName) sb.Expr {
 				return sb.Function("dictGet",
 					sb.String(dictionary.String()), sb.String("name"), column)
@@ -393,7 +393,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 					sb.Order(sb.Function("MIN", sb.Function("rowNumberInBlock")))).
 				Limit(input.Limit).
 				String()
-			if err := c.d.ClickHouseDB.Select(ctx, &results, sqlQuery); err != nil {
+			if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
 				c.r.Err(err).Msg("unable to query database")
 				break
 			}
@@ -423,7 +423,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 					mostUsedFirst()).
 				Limit(input.Limit).
 				String()
-			if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+			if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
 				c.r.Err(err).Msg("unable to query database")
 				break
 			}
@@ -461,7 +461,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 			fromDictionary := sb.Select(
 				sb.Alias(sb.Column("name"), "label"),
 				sb.Alias(sb.Uint(2), "rank")).
-				From(c.dictionary(schema.DictionaryICMP)).
+				From(c.dictionary(ctx, schema.DictionaryICMP)).
 				Where(sb.And(
 					matchPrefix(sb.Column("label"), input.Prefix),
 					sb.Op(sb.Column("proto"), "=", sb.Uint(proto)))).
@@ -478,7 +478,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 					sb.Order(sb.Function("MIN", sb.Function("rowNumberInBlock")))).
 				Limit(input.Limit).
 				String()
-			err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery)
+			err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery)
 			if err != nil {
 				c.r.Err(err).Msg("unable to query database")
 				break
@@ -522,7 +522,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 			results := []struct {
 				Label string `ch:"label"`
 			}{}
-			if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+			if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
 				c.r.Err(err).Msg("unable to query database")
 				break
 			}
@@ -557,7 +557,7 @@ func (c *Component) filterCompleteHandlerFunc(w http.ResponseWriter, req *http.R
 					OrderBy(sb.Order(name)).
 					Limit(input.Limit).
 					String()
-				if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+				if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
 					c.r.Err(err).Msg("unable to query database")
 					break
 				}
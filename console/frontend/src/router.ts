@@ -4,6 +4,7 @@
 import { createRouter, createWebHistory } from "vue-router";
 import HomePage from "@/views/HomePage.vue";
 import VisualizePage from "@/views/VisualizePage.vue";
+import InterfaceCountersPage from "@/views/InterfaceCountersPage.vue";
 import DocumentationPage from "@/views/DocumentationPage.vue";
 import ErrorPage from "@/views/ErrorPage.vue";
 
@@ -40,6 +41,12 @@ const router = createRouter({
       meta: { title: "Visualize" },
       props: (route) => ({ routeState: route.params.state }),
     },
+    {
+      path: "/interface-counters",
+      name: "InterfaceCounters",
+      component: InterfaceCountersPage,
+      meta: { title: "Interface counters" },
+    },
     {
       path: "/docs",
       redirect: "/docs/intro",
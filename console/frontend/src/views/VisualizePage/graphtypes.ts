@@ -8,5 +8,6 @@ export const graphTypes = {
   grid: "Grid",
   sankey: "Sankey",
   heatmap: "Heatmap",
+  matrix: "Matrix",
 } as const;
 export type GraphType = keyof typeof graphTypes;
@@ -30,6 +30,13 @@ export type GraphSankeyHandlerOutput = {
     axis: number;
   }[];
 };
+export type GraphMatrixHandlerInput = GraphSankeyHandlerInput;
+export type GraphMatrixHandlerOutput = {
+  sources: string[];
+  destinations: string[];
+  xps: Record<number, number[][]>;
+  "axis-names": Record<number, string>;
+};
 export type GraphLineHandlerOutput = {
   t: string[];
   rows: string[][];
@@ -49,8 +56,14 @@ export type GraphSankeyHandlerResult = GraphSankeyHandlerOutput & {
     GraphSankeyHandlerInput,
     "start" | "end" | "dimensions" | "units" | "bidirectional"
   >;
+export type GraphMatrixHandlerResult = GraphMatrixHandlerOutput & {
+  graphType: Extract<GraphType, "matrix">;
+} & Pick<
+    GraphMatrixHandlerInput,
+    "start" | "end" | "dimensions" | "units" | "bidirectional"
+  >;
 export type GraphLineHandlerResult = GraphLineHandlerOutput & {
-  graphType: Exclude<GraphType, "sankey">;
+  graphType: Exclude<GraphType, "sankey" | "matrix">;
 } & Pick<
     GraphLineHandlerInput,
     "start" | "end" | "dimensions" | "units" | "bidirectional"
@@ -11,8 +11,8 @@ import (
 	"akvorado/console/query"
 )
 
-// graphCommonHandlerInput is for bits common to graphLineHandlerInput and
-// graphSankeyHandlerInput.
+// graphCommonHandlerInput is for bits common to graphLineHandlerInput,
+// graphSankeyHandlerInput and graphMatrixHandlerInput.
 type graphCommonHandlerInput struct {
 	schema         *schema.Component
 	database       string
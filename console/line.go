@@ -181,6 +181,7 @@ func (input graphLineHandlerInput) resolveContext() inputContext {
 		Start:             input.Start,
 		End:               input.End,
 		MainTableRequired: requireMainTable(input.schema, input.Dimensions, input.Filter),
+		Database:          input.database,
 		Points:            input.Points,
 	}
 }
@@ -219,7 +220,7 @@ func (c *Component) graphLineHandlerFunc(w http.ResponseWriter, req *http.Reques
 	ctx := c.t.Context(req.Context())
 	input := graphLineHandlerInput{
 		schema:   c.d.Schema,
-		database: c.d.ClickHouseDB.DatabaseName(),
+		database: c.clickhouseDB(ctx).DatabaseName(),
 	}
 	if err := httpserver.BindJSON(req, &input); err != nil {
 		httpserver.WriteJSON(w, http.StatusBadRequest, helpers.M{"message": helpers.Capitalize(err.Error())})
@@ -251,7 +252,7 @@ func (c *Component) graphLineHandlerFunc(w http.ResponseWriter, req *http.Reques
 		Dimensions []string  `ch:"dimensions"`
 	}{}
 	c.metrics.clickhouseQueries.WithLabelValues(r.Table).Inc()
-	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+	if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
 		c.r.Err(err).Str("query", sqlQuery).Msg("unable to query database")
 		httpserver.WriteJSON(w, http.StatusInternalServerError, helpers.M{"message": "Unable to query database."})
 		return
@@ -479,9 +480,10 @@ func (c *Component) getTableAndIntervalHandlerFunc(w http.ResponseWriter, req *h
 		return
 	}
 	table, interval, _ := c.computeTableAndInterval(inputContext{
-		Points: input.Points,
-		Start:  input.Start,
-		End:    input.End,
+		Points:   input.Points,
+		Start:    input.Start,
+		End:      input.End,
+		Database: c.clickhouseDB(req.Context()).DatabaseName(),
 	})
 
 	httpserver.WriteJSON(w, http.StatusOK, tableIntervalOutput{Table: table, Interval: uint64(interval.Seconds())})
@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	sb "akvorado/common/sqlbuilder"
+	"akvorado/console/query"
+)
+
+// graphMatrixHandlerInput describes the input for the /graph/matrix endpoint.
+// The first dimension is the source of the traffic and the second one is the
+// destination.
+type graphMatrixHandlerInput struct {
+	graphCommonHandlerInput
+	Bidirectional bool `json:"bidirectional"`
+}
+
+// graphMatrixHandlerOutput describes the output for the /graph/matrix endpoint.
+type graphMatrixHandlerOutput struct {
+	Sources      []string        `json:"sources"`
+	Destinations []string        `json:"destinations"`
+	Xps          map[int][][]int `json:"xps"` // axis → source → destination → xps
+	AxisNames    map[int]string  `json:"axis-names"`
+}
+
+// matrixCTEs are the names of the CTEs holding the values kept for the source
+// and for the destination.
+var matrixCTEs = [2]string{"sources", "destinations"}
+
+// reverseDirection reverts the direction of a provided input. It does not
+// modify the original.
+func (input graphMatrixHandlerInput) reverseDirection() graphMatrixHandlerInput {
+	input.Filter.Swap()
+	input.Dimensions = slices.Clone(input.Dimensions)
+	query.Columns(input.Dimensions).Reverse(input.schema)
+	return input
+}
+
+type matrixToSQL1Options struct {
+	skipWithClause   bool
+	reverseDirection bool
+	// ctesColumns names, for the source and the destination, the column of
+	// the forward CTE to probe. For the reverse query, these are the forward
+	// dimensions, so both axes share the same labels.
+	ctesColumns []query.Column
+}
+
+func (input graphMatrixHandlerInput) toSQL1(axis int, res resolution, options matrixToSQL1Options) *sb.Query {
+	r := res.forRange(input.Start, input.End)
+	where := r.where(input.Filter)
+	ctesColumns := options.ctesColumns
+	if ctesColumns == nil {
+		ctesColumns = input.Dimensions
+	}
+
+	// Units
+	units := input.Units
+	if options.reverseDirection {
+		units = reverseUnits(units)
+	}
+	unitsSQL := unitsExpr(units)
+
+	// Select
+	arrayFields := []sb.Expr{}
+	for i, column := range input.Dimensions {
+		arrayFields = append(arrayFields, sb.Function("if",
+			sb.Op(sb.Column(column.String()), "IN",
+				sb.Select(sb.Column(ctesColumns[i].String())).
+					From(sb.Table(matrixCTEs[i])).Subquery()),
+			column.ToSQLSelect(input.schema, input.database),
+			sb.String("Other")))
+	}
+
+	query := sb.Select(
+		sb.Alias(sb.Int(int64(axis)), "axis"),
+		sb.Alias(sb.Op(unitsSQL, "/", sb.Column("range")), "xps"),
+		sb.Alias(sb.Array(arrayFields...), "dimensions"),
+	).
+		From(sb.Table("source")).
+		Where(where).
+		GroupBy(sb.Column("dimensions"))
+	if !options.skipWithClause {
+		query.With("source", input.sourceSelect(r.Table))
+		// Like for the sankey graph, the traffic is averaged over the whole
+		// period covered by the data.
+		query.WithScalar(
+			sb.Select(sb.Op(
+				sb.Function("MAX", sb.Column("TimeReceived")), "-",
+				sb.Function("MIN", sb.Column("TimeReceived")))).
+				From(sb.Table("source")).
+				Where(where),
+			"range")
+		// The top values are selected independently for the source and the
+		// destination, so the matrix is at most (limit+1)×(limit+1).
+		for i, column := range input.Dimensions {
+			common := input.graphCommonHandlerInput
+			common.Dimensions = input.Dimensions[i : i+1]
+			query.With(matrixCTEs[i], selectRowsByLimitType(common, r,
+				[]sb.Expr{sb.Column(column.String())}, where, unitsSQL))
+		}
+	}
+	return query
+}
+
+// resolveContext returns what is needed to select the table for this query.
+func (input graphMatrixHandlerInput) resolveContext() inputContext {
+	return inputContext{
+		Start:             input.Start,
+		End:               input.End,
+		MainTableRequired: requireMainTable(input.schema, input.Dimensions, input.Filter),
+		Database:          input.database,
+		Points:            20,
+	}
+}
+
+// toSQL converts a matrix query to a list of SQL requests, one per axis.
+func (input graphMatrixHandlerInput) toSQL(res resolution) []*sb.Query {
+	queries := []*sb.Query{input.toSQL1(1, res, matrixToSQL1Options{})}
+	if input.Bidirectional {
+		queries = append(queries, input.reverseDirection().toSQL1(2, res, matrixToSQL1Options{
+			skipWithClause:   true,
+			reverseDirection: true,
+			ctesColumns:      input.Dimensions,
+		}))
+	}
+	return queries
+}
+
+func (c *Component) graphMatrixHandlerFunc(w http.ResponseWriter, req *http.Request) {
+	ctx := c.t.Context(req.Context())
+	input := graphMatrixHandlerInput{
+		schema:   c.d.Schema,
+		database: c.clickhouseDB(ctx).DatabaseName(),
+	}
+	if err := httpserver.BindJSON(req, &input); err != nil {
+		httpserver.WriteJSON(w, http.StatusBadRequest, helpers.M{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if err := query.Columns(input.Dimensions).Validate(input.schema); err != nil {
+		httpserver.WriteJSON(w, http.StatusBadRequest, helpers.M{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if err := input.Filter.Validate(input.schema, input.database); err != nil {
+		httpserver.WriteJSON(w, http.StatusBadRequest, helpers.M{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if input.Limit > c.config.DimensionsLimit {
+		httpserver.WriteJSON(w, http.StatusBadRequest,
+			helpers.M{"message": fmt.Sprintf("Limit is set beyond maximum value (%d)",
+				c.config.DimensionsLimit)})
+		return
+	}
+	if len(input.Dimensions) != 2 {
+		httpserver.WriteJSON(w, http.StatusBadRequest,
+			helpers.M{"message": "Exactly two dimensions are required (source and destination)."})
+		return
+	}
+
+	// Prepare and execute query
+	r := c.resolve(input.resolveContext())
+	sqlQuery := unionAll(input.toSQL(r))
+	w.Header().Set("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
+	results := []struct {
+		Axis       uint8    `ch:"axis"`
+		Xps        float64  `ch:"xps"`
+		Dimensions []string `ch:"dimensions"`
+	}{}
+	c.metrics.clickhouseQueries.WithLabelValues(r.Table).Inc()
+	if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
+		c.r.Err(err).Str("query", sqlQuery).Msg("unable to query database")
+		httpserver.WriteJSON(w, http.StatusInternalServerError, helpers.M{"message": "Unable to query database."})
+		return
+	}
+
+	// Order the labels by decreasing traffic, "Other" being last.
+	var totals [2]map[string]int
+	for i := range totals {
+		totals[i] = map[string]int{}
+	}
+	for _, result := range results {
+		for i := range totals {
+			totals[i][result.Dimensions[i]] += int(result.Xps)
+		}
+	}
+	var labels [2][]string
+	var indexes [2]map[string]int
+	for i := range labels {
+		labels[i] = make([]string, 0, len(totals[i]))
+		for label := range totals[i] {
+			labels[i] = append(labels[i], label)
+		}
+		slices.SortFunc(labels[i], func(a, b string) int {
+			if (a == "Other") != (b == "Other") {
+				if a == "Other" {
+					return 1
+				}
+				return -1
+			}
+			if totals[i][a] != totals[i][b] {
+				return totals[i][b] - totals[i][a]
+			}
+			return strings.Compare(a, b)
+		})
+		indexes[i] = make(map[string]int, len(labels[i]))
+		for idx, label := range labels[i] {
+			indexes[i][label] = idx
+		}
+	}
+
+	// Prepare output
+	output := graphMatrixHandlerOutput{
+		Sources:      labels[0],
+		Destinations: labels[1],
+		Xps:          make(map[int][][]int),
+		AxisNames:    make(map[int]string),
+	}
+	axes := []int{1}
+	if input.Bidirectional {
+		axes = append(axes, 2)
+	}
+	for _, axis := range axes {
+		matrix := make([][]int, len(output.Sources))
+		for idx := range matrix {
+			matrix[idx] = make([]int, len(output.Destinations))
+		}
+		output.Xps[axis] = matrix
+		switch axis {
+		case 1:
+			output.AxisNames[axis] = "Direct"
+		case 2:
+			output.AxisNames[axis] = "Reverse"
+		}
+	}
+	for _, result := range results {
+		matrix, ok := output.Xps[int(result.Axis)]
+		if !ok {
+			continue
+		}
+		source := indexes[0][result.Dimensions[0]]
+		destination := indexes[1][result.Dimensions[1]]
+		matrix[source][destination] += int(result.Xps)
+	}
+
+	httpserver.WriteJSON(w, http.StatusOK, output)
+}
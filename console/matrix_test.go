@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/helpers"
+	"akvorado/common/schema"
+	sb "akvorado/common/sqlbuilder"
+	"akvorado/console/query"
+)
+
+func TestMatrixQuerySQL(t *testing.T) {
+	cases := []struct {
+		Description string
+		Pos         helpers.Pos
+		Input       graphMatrixHandlerInput
+		Expected    []string
+	}{
+		{
+			Description: "no filters, l3 bps",
+			Pos:         helpers.Mark(),
+			Input: graphMatrixHandlerInput{
+				graphCommonHandlerInput: graphCommonHandlerInput{
+					Start: time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+					End:   time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+					Dimensions: []query.Column{
+						query.NewColumn("SrcAS"),
+						query.NewColumn("DstAS"),
+					},
+					Limit:  5,
+					Filter: query.Filter{},
+					Units:  "l3bps",
+				},
+			},
+			Expected: []string{
+				`WITH
+ source AS (SELECT * FROM flows SETTINGS asterisk_include_alias_columns = 1),
+ (SELECT MAX(TimeReceived) - MIN(TimeReceived) FROM source WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC')) AS range,
+ sources AS (SELECT tupleElement(tk, 1) AS SrcAS FROM ( SELECT arrayJoin(topKWeighted(5, 20)(tuple(SrcAS), toUInt64(Bytes*SamplingRate*8))) AS tk FROM source WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') )),
+ destinations AS (SELECT tupleElement(tk, 1) AS DstAS FROM ( SELECT arrayJoin(topKWeighted(5, 20)(tuple(DstAS), toUInt64(Bytes*SamplingRate*8))) AS tk FROM source WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') ))
+SELECT
+ 1 AS axis,
+ SUM(Bytes*SamplingRate*8)/range AS xps,
+ [if(SrcAS IN (SELECT SrcAS FROM sources), concat(toString(SrcAS), ': ', dictGetOrDefault('asns', 'name', SrcAS, '???')), 'Other'),
+  if(DstAS IN (SELECT DstAS FROM destinations), concat(toString(DstAS), ': ', dictGetOrDefault('asns', 'name', DstAS, '???')), 'Other')] AS dimensions
+FROM source
+WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC')
+GROUP BY dimensions`,
+			},
+		}, {
+			Description: "with filter, limitType by max",
+			Pos:         helpers.Mark(),
+			Input: graphMatrixHandlerInput{
+				graphCommonHandlerInput: graphCommonHandlerInput{
+					Start: time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+					End:   time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+					Dimensions: []query.Column{
+						query.NewColumn("ExporterName"),
+						query.NewColumn("OutIfProvider"),
+					},
+					Limit:     5,
+					LimitType: "max",
+					Filter:    query.NewFilter("DstCountry = 'FR'"),
+					Units:     "pps",
+				},
+			},
+			Expected: []string{
+				`WITH
+ source AS (SELECT * FROM flows SETTINGS asterisk_include_alias_columns = 1),
+ (SELECT MAX(TimeReceived) - MIN(TimeReceived) FROM source WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') AND DstCountry = 'FR') AS range,
+ sources AS (SELECT ExporterName FROM ( SELECT toStartOfInterval(TimeReceived + INTERVAL 60 second, INTERVAL 60 second) - INTERVAL 60 second AS time, ExporterName, SUM(Packets*SamplingRate) AS sum_at_time FROM source WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') AND DstCountry = 'FR' GROUP BY time, ExporterName ) GROUP BY ExporterName ORDER BY MAX(sum_at_time) DESC LIMIT 5),
+ destinations AS (SELECT OutIfProvider FROM ( SELECT toStartOfInterval(TimeReceived + INTERVAL 60 second, INTERVAL 60 second) - INTERVAL 60 second AS time, OutIfProvider, SUM(Packets*SamplingRate) AS sum_at_time FROM source WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') AND DstCountry = 'FR' GROUP BY time, OutIfProvider ) GROUP BY OutIfProvider ORDER BY MAX(sum_at_time) DESC LIMIT 5)
+SELECT
+ 1 AS axis,
+ SUM(Packets*SamplingRate)/range AS xps,
+ [if(ExporterName IN (SELECT ExporterName FROM sources), ExporterName, 'Other'),
+  if(OutIfProvider IN (SELECT OutIfProvider FROM destinations), OutIfProvider, 'Other')] AS dimensions
+FROM source
+WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') AND DstCountry = 'FR'
+GROUP BY dimensions`,
+			},
+		}, {
+			Description: "bidirectional",
+			Pos:         helpers.Mark(),
+			Input: graphMatrixHandlerInput{
+				graphCommonHandlerInput: graphCommonHandlerInput{
+					Start: time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+					End:   time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+					Dimensions: []query.Column{
+						query.NewColumn("SrcCountry"),
+						query.NewColumn("DstCountry"),
+					},
+					Limit:  5,
+					Filter: query.NewFilter("InIfBoundary = external"),
+					Units:  "inl2%",
+				},
+				Bidirectional: true,
+			},
+			Expected: []string{
+				`WITH
+ source AS (SELECT * FROM flows SETTINGS asterisk_include_alias_columns = 1),
+ (SELECT MAX(TimeReceived) - MIN(TimeReceived) FROM source WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') AND InIfBoundary = 'external') AS range,
+ sources AS (SELECT SrcCountry FROM source WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') AND InIfBoundary = 'external' GROUP BY SrcCountry ORDER BY ifNotFinite(SUM((Bytes+38*Packets)*SamplingRate*8*100/(InIfSpeed*1000000))/COUNT(DISTINCT ExporterAddress, InIfName),0) DESC LIMIT 5),
+ destinations AS (SELECT DstCountry FROM source WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') AND InIfBoundary = 'external' GROUP BY DstCountry ORDER BY ifNotFinite(SUM((Bytes+38*Packets)*SamplingRate*8*100/(InIfSpeed*1000000))/COUNT(DISTINCT ExporterAddress, InIfName),0) DESC LIMIT 5)
+SELECT
+ 1 AS axis,
+ ifNotFinite(SUM((Bytes+38*Packets)*SamplingRate*8*100/(InIfSpeed*1000000))/COUNT(DISTINCT ExporterAddress, InIfName),0)/range AS xps,
+ [if(SrcCountry IN (SELECT SrcCountry FROM sources), SrcCountry, 'Other'),
+  if(DstCountry IN (SELECT DstCountry FROM destinations), DstCountry, 'Other')] AS dimensions
+FROM source
+WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') AND InIfBoundary = 'external'
+GROUP BY dimensions`,
+				`SELECT
+ 2 AS axis,
+ ifNotFinite(SUM((Bytes+38*Packets)*SamplingRate*8*100/(OutIfSpeed*1000000))/COUNT(DISTINCT ExporterAddress, OutIfName),0)/range AS xps,
+ [if(DstCountry IN (SELECT SrcCountry FROM sources), DstCountry, 'Other'),
+  if(SrcCountry IN (SELECT DstCountry FROM destinations), SrcCountry, 'Other')] AS dimensions
+FROM source
+WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') AND OutIfBoundary = 'external'
+GROUP BY dimensions`,
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc.Input.schema = schema.NewMock(t)
+		if err := query.Columns(tc.Input.Dimensions).Validate(tc.Input.schema); err != nil {
+			t.Fatalf("%sValidate() error:\n%+v", tc.Pos, err)
+		}
+		if err := tc.Input.Filter.Validate(tc.Input.schema, tc.Input.database); err != nil {
+			t.Fatalf("%sValidate() error:\n%+v", tc.Pos, err)
+		}
+		t.Run(tc.Description, func(t *testing.T) {
+			got := toSQLStrings(t, tc.Input.toSQL(testResolution))
+			if diff := helpers.Diff(got, sb.NormalizeAll(t, tc.Expected)); diff != "" {
+				t.Errorf("%stoSQL (-got, +want):\n%s", tc.Pos, diff)
+			}
+		})
+	}
+}
+
+func TestMatrixHandlerDimensions(t *testing.T) {
+	_, h, _, _ := NewMock(t, DefaultConfiguration())
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "one dimension",
+			URL:         "/api/v0/console/graph/matrix",
+			JSONInput: helpers.M{
+				"start":      time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				"end":        time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				"dimensions": []string{"SrcAS"},
+				"limit":      10,
+				"units":      "l3bps",
+			},
+			StatusCode: 400,
+			JSONOutput: helpers.M{
+				"message": "Exactly two dimensions are required (source and destination).",
+			},
+		},
+	})
+}
+
+func TestMatrixHandler(t *testing.T) {
+	_, h, mockConn, _ := NewMock(t, DefaultConfiguration())
+
+	expectedSQL := []struct {
+		Axis       uint8    `ch:"axis"`
+		Xps        float64  `ch:"xps"`
+		Dimensions []string `ch:"dimensions"`
+	}{
+		// Forward direction (axis 1): SrcCountry, DstCountry
+		{1, 9000, []string{"FR", "US"}},
+		{1, 7000, []string{"US", "FR"}},
+		{1, 5000, []string{"FR", "Other"}},
+		{1, 3000, []string{"Other", "US"}},
+		{1, 1000, []string{"US", "US"}},
+		// Reverse direction (axis 2): DstCountry, SrcCountry
+		{2, 8000, []string{"US", "FR"}},
+		{2, 2000, []string{"Other", "FR"}},
+	}
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		SetArg(1, expectedSQL).
+		Return(nil)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/console/graph/matrix",
+			JSONInput: helpers.M{
+				"start":         time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				"end":           time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				"dimensions":    []string{"SrcCountry", "DstCountry"},
+				"limit":         10,
+				"units":         "l3bps",
+				"bidirectional": true,
+			},
+			JSONOutput: helpers.M{
+				// US: 7000+1000+8000, FR: 9000+5000
+				"sources": []string{"US", "FR", "Other"},
+				// US: 9000+3000+1000, FR: 7000+8000+2000
+				"destinations": []string{"FR", "US", "Other"},
+				"xps": map[int][][]int{
+					1: {
+						{7000, 1000, 0},
+						{0, 9000, 5000},
+						{0, 3000, 0},
+					},
+					2: {
+						{8000, 0, 0},
+						{0, 0, 0},
+						{2000, 0, 0},
+					},
+				},
+				"axis-names": map[int]string{1: "Direct", 2: "Reverse"},
+			},
+		},
+	})
+}
@@ -39,6 +39,7 @@ type Component struct {
 
 	homepageGraphFilter sb.Expr
 	flowsTables         []flowsTable
+	tenantFlowsTables   map[string][]flowsTable // indexed by database
 	flowsTablesLock     sync.RWMutex
 
 	metrics struct {
@@ -102,7 +103,7 @@ func (c *Component) Start() error {
 	c.d.HTTP.AddHandler("/assets/", http.StripPrefix("/assets/", http.HandlerFunc(c.staticAssetsHandlerFunc)))
 	c.d.HTTP.AddHandler("/assets/docs/", http.StripPrefix("/assets/docs/", http.HandlerFunc(c.docAssetsHandlerFunc)))
 	// Dynamic assets
-	endpoint := c.d.HTTP.APIRouter.Group("/api/v0/console", c.d.Auth.UserAuthentication())
+	endpoint := c.d.HTTP.APIRouter.Group("/api/v0/console", c.d.Auth.UserAuthentication(), c.tenantRestriction())
 	endpoint.GET("/configuration", c.configHandlerFunc)
 	endpoint.GET("/docs/{name}", c.docsHandlerFunc)
 	endpoint.GET("/widget/flow-last", c.widgetFlowLastHandlerFunc, c.d.HTTP.CacheByRequestPath(5*time.Second))
@@ -112,6 +113,8 @@ func (c *Component) Start() error {
 	endpoint.GET("/widget/graph", c.widgetGraphHandlerFunc, c.d.HTTP.CacheByRequestPath(5*time.Minute))
 	endpoint.POST("/graph/line", c.graphLineHandlerFunc, c.d.HTTP.CacheByRequestBody(c.config.CacheTTL))
 	endpoint.POST("/graph/sankey", c.graphSankeyHandlerFunc, c.d.HTTP.CacheByRequestBody(c.config.CacheTTL))
+	endpoint.POST("/graph/matrix", c.graphMatrixHandlerFunc, c.d.HTTP.CacheByRequestBody(c.config.CacheTTL))
+	endpoint.POST("/graph/interface-counters", c.graphInterfaceCountersHandlerFunc, c.d.HTTP.CacheByRequestBody(c.config.CacheTTL))
 	endpoint.POST("/graph/table-interval", c.getTableAndIntervalHandlerFunc)
 	endpoint.POST("/filter/validate", c.filterValidateHandlerFunc)
 	endpoint.POST("/filter/complete", c.filterCompleteHandlerFunc, c.d.HTTP.CacheByRequestBody(time.Minute))
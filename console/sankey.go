@@ -129,6 +129,7 @@ func (input graphSankeyHandlerInput) resolveContext() inputContext {
 		Start:             input.Start,
 		End:               input.End,
 		MainTableRequired: requireMainTable(input.schema, input.Dimensions, input.Filter),
+		Database:          input.database,
 		Points:            20,
 	}
 }
@@ -150,7 +151,7 @@ func (c *Component) graphSankeyHandlerFunc(w http.ResponseWriter, req *http.Requ
 	ctx := c.t.Context(req.Context())
 	input := graphSankeyHandlerInput{
 		schema:   c.d.Schema,
-		database: c.d.ClickHouseDB.DatabaseName(),
+		database: c.clickhouseDB(ctx).DatabaseName(),
 	}
 	if err := httpserver.BindJSON(req, &input); err != nil {
 		httpserver.WriteJSON(w, http.StatusBadRequest, helpers.M{"message": helpers.Capitalize(err.Error())})
@@ -188,7 +189,7 @@ func (c *Component) graphSankeyHandlerFunc(w http.ResponseWriter, req *http.Requ
 		Dimensions []string `ch:"dimensions"`
 	}{}
 	c.metrics.clickhouseQueries.WithLabelValues(r.Table).Inc()
-	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+	if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
 		c.r.Err(err).Str("query", sqlQuery).Msg("unable to query database")
 		httpserver.WriteJSON(w, http.StatusInternalServerError, helpers.M{"message": "Unable to query database."})
 		return
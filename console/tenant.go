@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"context"
+	"net/http"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/console/authentication"
+)
+
+// tenantContextKey is the key under which the ClickHouse component for the
+// tenant of the current user is stored in the request context.
+type tenantContextKey struct{}
+
+// tenantRestriction is a middleware restricting the current user to the
+// database of their tenant. Users without a tenant use the main database,
+// while users from a tenant without a database are rejected.
+func (c *Component) tenantRestriction() httpserver.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			tenant := authentication.UserFromContext(req.Context()).Tenant
+			if tenant == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+			ch, ok := c.d.ClickHouseDB.Tenant(tenant)
+			if !ok {
+				httpserver.WriteJSON(w, http.StatusForbidden,
+					helpers.M{"message": "No database for this tenant."})
+				return
+			}
+			ctx := context.WithValue(req.Context(), tenantContextKey{}, ch)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// clickhouseDB returns the ClickHouse component to use for the current user.
+func (c *Component) clickhouseDB(ctx context.Context) *clickhousedb.Component {
+	if ch, ok := ctx.Value(tenantContextKey{}).(*clickhousedb.Component); ok {
+		return ch
+	}
+	return c.d.ClickHouseDB
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+)
+
+func TestTenantRestriction(t *testing.T) {
+	c, _, _, _ := NewMock(t, DefaultConfiguration())
+	r := reporter.NewMock(t)
+	config := clickhousedb.DefaultConfiguration()
+	config.Tenants = map[string]string{"alpha": "flows_alpha"}
+	ch, err := clickhousedb.New(r, config, clickhousedb.Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("clickhousedb.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, ch)
+	c.d.ClickHouseDB = ch
+
+	var database string
+	handler := c.d.Auth.UserAuthentication()(c.tenantRestriction()(
+		http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			database = c.clickhouseDB(req.Context()).DatabaseName()
+		})))
+	cases := []struct {
+		Description string
+		Tenant      string
+		StatusCode  int
+		Database    string
+	}{
+		{"no tenant", "", http.StatusOK, "default"},
+		{"known tenant", "alpha", http.StatusOK, "flows_alpha"},
+		{"unknown tenant", "beta", http.StatusForbidden, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			database = ""
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/console/widget/flow-rate", nil)
+			req.Header.Set("Remote-User", "alfred")
+			if tc.Tenant != "" {
+				req.Header.Set("Remote-Tenant", tc.Tenant)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tc.StatusCode {
+				t.Errorf("ServeHTTP() status code == %d, expected %d", w.Code, tc.StatusCode)
+			}
+			if database != tc.Database {
+				t.Errorf("clickhouseDB() database == %q, expected %q", database, tc.Database)
+			}
+		})
+	}
+
+	// Each database gets its own inventory of flows tables
+	c.flowsTables = []flowsTable{
+		{"flows", 0, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"flows_1m0s", time.Minute, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	c.tenantFlowsTables = map[string][]flowsTable{
+		"flows_alpha": {
+			{"flows", 0, time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)},
+			{"flows_1m0s", time.Minute, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	got := map[string]string{}
+	for _, database := range []string{"", "default", "flows_alpha"} {
+		got[database], _, _ = c.computeTableAndInterval(inputContext{
+			Start:    time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC),
+			End:      time.Date(2022, 10, 30, 1, 0, 0, 0, time.UTC),
+			Points:   3600,
+			Database: database,
+		})
+	}
+	expected := map[string]string{
+		"":            "flows",
+		"default":     "flows",
+		"flows_alpha": "flows_1m0s",
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Errorf("computeTableAndInterval() (-got, +want):\n%s", diff)
+	}
+}
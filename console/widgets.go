@@ -58,7 +58,7 @@ func (c *Component) widgetFlowLastHandlerFunc(w http.ResponseWriter, req *http.R
 		String()
 	w.Header().Set("X-SQL-Query", sqlQuery)
 	// Do not increase counter for this one.
-	rows, err := c.d.ClickHouseDB.Conn.Query(ctx, sqlQuery)
+	rows, err := c.clickhouseDB(ctx).Query(ctx, sqlQuery)
 	if err != nil {
 		c.r.Err(err).Msg("unable to query database")
 		httpserver.WriteJSON(w, http.StatusInternalServerError, helpers.M{"message": "Unable to query database."})
@@ -96,7 +96,7 @@ func (c *Component) widgetFlowRateHandlerFunc(w http.ResponseWriter, req *http.R
 	w.Header().Set("X-SQL-Query", query)
 	// Do not increase counter for this one.
 	var result float64
-	row := c.d.ClickHouseDB.Conn.QueryRow(ctx, query)
+	row := c.clickhouseDB(ctx).QueryRow(ctx, query)
 	if err := row.Scan(&result); err != nil {
 		c.r.Err(err).Msg("unable to parse result")
 		httpserver.WriteJSON(w, http.StatusInternalServerError, helpers.M{"message": "Unable to parse result."})
@@ -117,7 +117,7 @@ func (c *Component) widgetExportersHandlerFunc(w http.ResponseWriter, req *http.
 	exporters := []struct {
 		ExporterName string
 	}{}
-	err := c.d.ClickHouseDB.Conn.Select(ctx, &exporters, query)
+	err := c.clickhouseDB(ctx).Select(ctx, &exporters, query)
 	if err != nil {
 		c.r.Err(err).Msg("unable to query database")
 		httpserver.WriteJSON(w, http.StatusInternalServerError, helpers.M{"message": "Unable to query database."})
@@ -145,7 +145,7 @@ func (c *Component) widgetTopHandlerFunc(w http.ResponseWriter, req *http.Reques
 		mainTableRequired bool
 	)
 	dictLookup := func(dictionary string, column string) sb.Expr {
-		return query.DictionaryLookup(c.d.ClickHouseDB.DatabaseName(), dictionary,
+		return query.DictionaryLookup(c.clickhouseDB(ctx).DatabaseName(), dictionary,
 			sb.Column(column), "???")
 	}
 
@@ -216,6 +216,7 @@ func (c *Component) widgetTopHandlerFunc(w http.ResponseWriter, req *http.Reques
 		Start:             start,
 		End:               end,
 		MainTableRequired: mainTableRequired,
+		Database:          c.clickhouseDB(ctx).DatabaseName(),
 		Points:            5,
 	}).forRange(start, end)
 	where := sb.And(r.timefilter(), filter)
@@ -239,7 +240,7 @@ func (c *Component) widgetTopHandlerFunc(w http.ResponseWriter, req *http.Reques
 
 	results := []topResult{}
 	c.metrics.clickhouseQueries.WithLabelValues(r.Table).Inc()
-	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+	if err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery); err != nil {
 		c.r.Err(err).Msg("unable to query database")
 		httpserver.WriteJSON(w, http.StatusInternalServerError, helpers.M{"message": "Unable to query database."})
 		return
@@ -255,6 +256,7 @@ func (c *Component) widgetGraphHandlerFunc(w http.ResponseWriter, req *http.Requ
 		Start:             start,
 		End:               end,
 		MainTableRequired: false,
+		Database:          c.clickhouseDB(ctx).DatabaseName(),
 		Points:            200,
 	}).forRange(start, end)
 	gbps := sb.Function("SUM",
@@ -281,7 +283,7 @@ func (c *Component) widgetGraphHandlerFunc(w http.ResponseWriter, req *http.Requ
 		Gbps float64   `json:"gbps"`
 	}{}
 	c.metrics.clickhouseQueries.WithLabelValues(r.Table).Inc()
-	err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery)
+	err := c.clickhouseDB(ctx).Select(ctx, &results, sqlQuery)
 	if err != nil {
 		c.r.Err(err).Msg("unable to query database")
 		httpserver.WriteJSON(w, http.StatusInternalServerError, helpers.M{"message": "Unable to query database."})
@@ -4,11 +4,20 @@
 package flow
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+
 	"akvorado/common/helpers"
 	"akvorado/common/pb"
 	"akvorado/inlet/flow/input"
 	"akvorado/inlet/flow/input/file"
+	"akvorado/inlet/flow/input/kafka"
+	"akvorado/inlet/flow/input/pcap"
+	"akvorado/inlet/flow/input/tcp"
 	"akvorado/inlet/flow/input/udp"
+
+	"github.com/go-viper/mapstructure/v2"
 )
 
 // Configuration describes the configuration for the flow component
@@ -55,11 +64,72 @@ func (ic InputConfiguration) MarshalYAML() (any, error) {
 }
 
 var inputs = map[string](func() input.Configuration){
-	"udp":  udp.DefaultConfiguration,
-	"file": file.DefaultConfiguration,
+	"udp":   udp.DefaultConfiguration,
+	"tcp":   tcp.DefaultConfiguration,
+	"file":  file.DefaultConfiguration,
+	"pcap":  pcap.DefaultConfiguration,
+	"kafka": kafka.DefaultConfiguration,
+}
+
+// kafkaInputUnmarshallerHook defaults the decoder of the Kafka input to goflow2,
+// the only decoder able to handle its messages, and rejects any other decoder.
+// It should run before the hook consuming the "type" key.
+func kafkaInputUnmarshallerHook() mapstructure.DecodeHookFunc {
+	return func(from, to reflect.Value) (any, error) {
+		if from.Kind() != reflect.Map || from.IsNil() || to.Type() != reflect.TypeFor[InputConfiguration]() {
+			return from.Interface(), nil
+		}
+		var inputType string
+		var decoderKey *reflect.Value
+		fromMap := from.MapKeys()
+		for i, k := range fromMap {
+			k = helpers.ElemOrIdentity(k)
+			if k.Kind() != reflect.String {
+				return from.Interface(), nil
+			}
+			if helpers.MapStructureMatchName(k.String(), "Type") {
+				v := helpers.ElemOrIdentity(from.MapIndex(fromMap[i]))
+				if v.Kind() == reflect.String {
+					inputType = strings.ToLower(v.String())
+				}
+			} else if helpers.MapStructureMatchName(k.String(), "Decoder") {
+				decoderKey = &fromMap[i]
+			}
+		}
+		if inputType == "" {
+			// Keep the current type, if any
+			if _, ok := to.FieldByName("Config").Interface().(*kafka.Configuration); ok {
+				inputType = "kafka"
+			}
+		}
+		if inputType != "kafka" {
+			return from.Interface(), nil
+		}
+
+		decoder := to.FieldByName("Decoder").Interface().(pb.RawFlow_Decoder)
+		if decoderKey != nil {
+			v := helpers.ElemOrIdentity(from.MapIndex(*decoderKey))
+			if v.Kind() != reflect.String {
+				return from.Interface(), nil
+			}
+			if err := decoder.UnmarshalText([]byte(v.String())); err != nil {
+				return nil, err
+			}
+		}
+		switch decoder {
+		case pb.RawFlow_DECODER_UNSPECIFIED:
+			from.SetMapIndex(reflect.ValueOf("decoder"), reflect.ValueOf("goflow2"))
+		case pb.RawFlow_DECODER_GOFLOW2:
+		default:
+			name, _ := decoder.MarshalText()
+			return nil, fmt.Errorf("kafka input only supports the goflow2 decoder, not %q", name)
+		}
+		return from.Interface(), nil
+	}
 }
 
 func init() {
+	helpers.RegisterMapstructureUnmarshallerHook(kafkaInputUnmarshallerHook())
 	helpers.RegisterMapstructureUnmarshallerHook(
 		helpers.ParametrizedConfigurationUnmarshallerHook(InputConfiguration{}, inputs))
 }
@@ -6,11 +6,15 @@ package flow
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"akvorado/common/helpers"
 	"akvorado/common/helpers/yaml"
+	kafkaCommon "akvorado/common/kafka"
 	"akvorado/common/pb"
 	"akvorado/inlet/flow/input/file"
+	"akvorado/inlet/flow/input/kafka"
+	"akvorado/inlet/flow/input/tcp"
 	"akvorado/inlet/flow/input/udp"
 )
 
@@ -156,6 +160,106 @@ func TestDecodeConfiguration(t *testing.T) {
 				}},
 			},
 		},
+		{
+			Description: "IPFIX over TCP",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return helpers.M{
+					"inputs": []helpers.M{
+						{
+							"type":            "tcp",
+							"decoder":         "netflow",
+							"listen":          "192.0.2.1:4739",
+							"max-connections": 100,
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Inputs: []InputConfiguration{{
+					Decoder: pb.RawFlow_DECODER_NETFLOW,
+					Config: &tcp.Configuration{
+						Listen:         "192.0.2.1:4739",
+						MaxConnections: 100,
+						IdleTimeout:    10 * time.Minute,
+					},
+				}},
+			},
+		},
+		{
+			Description: "goflow2 from Kafka",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return helpers.M{
+					"inputs": []helpers.M{
+						{
+							"type":           "kafka",
+							"decoder":        "goflow2",
+							"brokers":        []string{"192.0.2.1:9092"},
+							"consumer-group": "remote-sites",
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Inputs: []InputConfiguration{{
+					Decoder: pb.RawFlow_DECODER_GOFLOW2,
+					Config: &kafka.Configuration{
+						Configuration: kafkaCommon.Configuration{
+							Topic:   "flow-messages",
+							Brokers: []string{"192.0.2.1:9092"},
+						},
+						ConsumerGroup:  "remote-sites",
+						LengthPrefixed: true,
+					},
+				}},
+			},
+		},
+		{
+			Description: "Kafka without decoder",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return helpers.M{
+					"inputs": []helpers.M{
+						{
+							"type":           "kafka",
+							"brokers":        []string{"192.0.2.1:9092"},
+							"consumer-group": "remote-sites",
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Inputs: []InputConfiguration{{
+					Decoder: pb.RawFlow_DECODER_GOFLOW2,
+					Config: &kafka.Configuration{
+						Configuration: kafkaCommon.Configuration{
+							Topic:   "flow-messages",
+							Brokers: []string{"192.0.2.1:9092"},
+						},
+						ConsumerGroup:  "remote-sites",
+						LengthPrefixed: true,
+					},
+				}},
+			},
+		},
+		{
+			Description: "Kafka with another decoder",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return helpers.M{
+					"inputs": []helpers.M{
+						{
+							"type":           "kafka",
+							"decoder":        "netflow",
+							"brokers":        []string{"192.0.2.1:9092"},
+							"consumer-group": "remote-sites",
+						},
+					},
+				}
+			},
+			Error: true,
+		},
 		{
 			Description: "only set one item",
 			Initial: func() any {
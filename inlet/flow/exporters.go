@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flow
+
+import (
+	"cmp"
+	"net/http"
+	"reflect"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/pb"
+	"akvorado/inlet/flow/input"
+)
+
+const (
+	// statsWindow is the duration over which rates are computed.
+	statsWindow = 10 * time.Second
+	// statsExpiry is the duration after which an exporter not sending
+	// anymore is forgotten.
+	statsExpiry = time.Hour
+	// statsMaxExporters is the maximum number of exporters tracked. Past
+	// this limit, new exporters are not tracked until some others expire.
+	statsMaxExporters = 10000
+)
+
+// exporterKey identifies an exporter sending to an input.
+type exporterKey struct {
+	exporter string
+	input    int
+}
+
+// exporterStats tracks the traffic received from an exporter on an input. It
+// is updated without locking.
+type exporterStats struct {
+	packets  atomic.Uint64
+	bytes    atomic.Uint64
+	lastSeen atomic.Int64 // as Unix nanoseconds
+
+	// Counters for the current and previous windows, indexed by the parity
+	// of the window.
+	windows [2]windowStats
+}
+
+// windowStats tracks the traffic received during a window.
+type windowStats struct {
+	window  atomic.Int64
+	packets atomic.Uint64
+	bytes   atomic.Uint64
+}
+
+// exporterStatsResponse is the representation of an exporter in the API.
+type exporterStatsResponse struct {
+	Exporter         string             `json:"exporter"`
+	Input            int                `json:"input"`
+	Decoder          pb.RawFlow_Decoder `json:"decoder"`
+	Packets          uint64             `json:"packets"`
+	Bytes            uint64             `json:"bytes"`
+	PacketsPerSecond float64            `json:"packets-per-second"`
+	BytesPerSecond   float64            `json:"bytes-per-second"`
+	LastSeen         time.Time          `json:"last-seen"`
+	RateLimit        uint64             `json:"rate-limit"`
+	InputKernelDrops *uint64            `json:"input-kernel-drops,omitempty"`
+}
+
+// inputStatsResponse is the representation of an input in the API.
+type inputStatsResponse struct {
+	Input       int                `json:"input"`
+	Type        string             `json:"type"`
+	Decoder     pb.RawFlow_Decoder `json:"decoder"`
+	RateLimit   uint64             `json:"rate-limit"`
+	KernelDrops *uint64            `json:"kernel-drops,omitempty"`
+}
+
+// windowOf returns the window a time belongs to.
+func windowOf(t time.Time) int64 {
+	return t.UnixNano() / int64(statsWindow)
+}
+
+// recordPacket accounts for a packet received from an exporter on an input.
+func (c *Component) recordPacket(key exporterKey, size int) {
+	stats, ok := c.exporters.Load(key)
+	if !ok {
+		if c.exporters.Size() >= statsMaxExporters {
+			return
+		}
+		stats, _ = c.exporters.LoadOrStore(key, &exporterStats{})
+	}
+	now := c.d.Clock.Now()
+	window := windowOf(now)
+	ws := &stats.windows[window&1]
+	if current := ws.window.Load(); current != window && ws.window.CompareAndSwap(current, window) {
+		// This slot was used for an older window. Packets counted by
+		// concurrent writers between the swap and the reset are lost, which is
+		// fine for statistics.
+		ws.packets.Store(0)
+		ws.bytes.Store(0)
+	}
+	ws.packets.Add(1)
+	ws.bytes.Add(uint64(size))
+	stats.packets.Add(1)
+	stats.bytes.Add(uint64(size))
+	stats.lastSeen.Store(now.UnixNano())
+}
+
+// rates returns the packet and byte rates over the last complete window.
+func (es *exporterStats) rates(now time.Time) (float64, float64) {
+	previous := windowOf(now) - 1
+	ws := &es.windows[previous&1]
+	if ws.window.Load() != previous {
+		return 0, 0
+	}
+	seconds := statsWindow.Seconds()
+	return float64(ws.packets.Load()) / seconds, float64(ws.bytes.Load()) / seconds
+}
+
+// expireExporters forgets about exporters not seen recently.
+func (c *Component) expireExporters() {
+	before := c.d.Clock.Now().Add(-statsExpiry).UnixNano()
+	c.exporters.Range(func(key exporterKey, value *exporterStats) bool {
+		if value.lastSeen.Load() < before {
+			c.exporters.Delete(key)
+		}
+		return true
+	})
+}
+
+// kernelDrops returns the number of packets dropped by the kernel for an
+// input, if it is able to tell.
+func (c *Component) kernelDrops(idx int) *uint64 {
+	if dropper, ok := c.inputs[idx].(input.KernelDropper); ok {
+		drops := dropper.KernelDrops()
+		return &drops
+	}
+	return nil
+}
+
+// inputType returns the name of the type of an input configuration.
+func inputType(config input.Configuration) string {
+	configType := reflect.TypeOf(config)
+	if configType.Kind() == reflect.Pointer {
+		configType = configType.Elem()
+	}
+	for name, fn := range inputs {
+		candidate := reflect.TypeOf(fn())
+		if candidate.Kind() == reflect.Pointer {
+			candidate = candidate.Elem()
+		}
+		if candidate == configType {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// ExportersHTTPHandler lists the exporters seen by each input with their
+// traffic and the drops of the inputs.
+func (c *Component) ExportersHTTPHandler(w http.ResponseWriter, _ *http.Request) {
+	now := c.d.Clock.Now()
+	inputsStats := make([]inputStatsResponse, len(c.config.Inputs))
+	drops := make([]*uint64, len(c.config.Inputs))
+	for idx, config := range c.config.Inputs {
+		drops[idx] = c.kernelDrops(idx)
+		inputsStats[idx] = inputStatsResponse{
+			Input:       idx,
+			Type:        inputType(config.Config),
+			Decoder:     config.Decoder,
+			RateLimit:   config.RateLimit,
+			KernelDrops: drops[idx],
+		}
+	}
+	exportersStats := []exporterStatsResponse{}
+	c.exporters.Range(func(key exporterKey, value *exporterStats) bool {
+		pps, bps := value.rates(now)
+		config := c.config.Inputs[key.input]
+		exportersStats = append(exportersStats, exporterStatsResponse{
+			Exporter:         key.exporter,
+			Input:            key.input,
+			Decoder:          config.Decoder,
+			Packets:          value.packets.Load(),
+			Bytes:            value.bytes.Load(),
+			PacketsPerSecond: pps,
+			BytesPerSecond:   bps,
+			LastSeen:         time.Unix(0, value.lastSeen.Load()).UTC(),
+			RateLimit:        config.RateLimit,
+			InputKernelDrops: drops[key.input],
+		})
+		return true
+	})
+	slices.SortFunc(exportersStats, func(a, b exporterStatsResponse) int {
+		return cmp.Or(
+			cmp.Compare(a.Exporter, b.Exporter),
+			cmp.Compare(a.Input, b.Input))
+	})
+	httpserver.WriteJSON(w, http.StatusOK, helpers.M{
+		"inputs":    inputsStats,
+		"exporters": exportersStats,
+	})
+}
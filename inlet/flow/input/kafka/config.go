@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"akvorado/common/kafka"
+	"akvorado/inlet/flow/input"
+)
+
+// Configuration describes Kafka input configuration.
+type Configuration struct {
+	kafka.Configuration `mapstructure:",squash" yaml:"-,inline"`
+	// ConsumerGroup is the name of the consumer group to use
+	ConsumerGroup string `validate:"min=1,ascii"`
+	// LengthPrefixed tells if each message is prefixed by its length, as
+	// done by goflow2 v2 and later. Otherwise, each Kafka record contains
+	// exactly one message.
+	LengthPrefixed bool
+}
+
+// DefaultConfiguration describes the default configuration for Kafka input.
+func DefaultConfiguration() input.Configuration {
+	configuration := kafka.DefaultConfiguration()
+	configuration.Topic = "flow-messages"
+	return &Configuration{
+		Configuration:  configuration,
+		ConsumerGroup:  "akvorado-inlet",
+		LengthPrefixed: true,
+	}
+}
@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package kafka consumes flows produced by other collectors, like goflow2, from
+// a Kafka topic.
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"google.golang.org/protobuf/encoding/protowire"
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/kafka"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+	"akvorado/inlet/flow/input"
+)
+
+// samplerAddressField is the field number of the sampler address in the
+// FlowMessage message from goflow2.
+const samplerAddressField protowire.Number = 11
+
+// Input represents the state of a Kafka input.
+type Input struct {
+	r         *reporter.Reporter
+	t         tomb.Tomb
+	config    Configuration
+	kafkaOpts []kgo.Opt
+	send      input.SendFunc
+
+	metrics struct {
+		bytes    *reporter.CounterVec
+		messages *reporter.CounterVec
+		errors   *reporter.CounterVec
+	}
+}
+
+var (
+	_ input.Input         = &Input{}
+	_ input.Configuration = Configuration{}
+)
+
+// New instantiate a new Kafka input from the provided configuration.
+func (configuration Configuration) New(r *reporter.Reporter, daemon daemon.Component, send input.SendFunc) (input.Input, error) {
+	kafkaOpts, err := kafka.NewConfig(r, configuration.Configuration)
+	if err != nil {
+		return nil, err
+	}
+	input := &Input{
+		r:      r,
+		config: configuration,
+		send:   send,
+		kafkaOpts: append(kafkaOpts,
+			kgo.ConsumerGroup(configuration.ConsumerGroup),
+			kgo.ConsumeTopics(configuration.Topic),
+		),
+	}
+
+	input.metrics.bytes = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "bytes_total",
+			Help: "Bytes received from Kafka.",
+		},
+		[]string{"topic", "exporter"},
+	)
+	input.metrics.messages = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "messages_total",
+			Help: "Flow messages received from Kafka.",
+		},
+		[]string{"topic", "exporter"},
+	)
+	input.metrics.errors = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Errors while receiving flow messages from Kafka.",
+		},
+		[]string{"topic", "error"},
+	)
+
+	daemon.Track(&input.t, "inlet/flow/input/kafka")
+	return input, nil
+}
+
+// Start starts consuming the Kafka topic.
+func (in *Input) Start() error {
+	in.r.Info().Str("topic", in.config.Topic).Msg("starting Kafka input")
+	client, err := kgo.NewClient(in.kafkaOpts...)
+	if err != nil {
+		in.r.Err(err).
+			Str("brokers", strings.Join(in.config.Brokers, ",")).
+			Msg("unable to create Kafka client")
+		return fmt.Errorf("unable to create Kafka client: %w", err)
+	}
+
+	in.t.Go(func() error {
+		defer client.Close()
+		ctx := in.t.Context(nil)
+		errLogger := in.r.Sample(reporter.BurstSampler(time.Minute, 1))
+		flow := pb.RawFlow{}
+		for {
+			fetches := client.PollFetches(ctx)
+			if fetches.IsClientClosed() || ctx.Err() != nil {
+				return nil
+			}
+			fetches.EachError(func(_ string, _ int32, err error) {
+				in.metrics.errors.WithLabelValues(in.config.Topic, "cannot fetch").Inc()
+				errLogger.Err(err).Str("topic", in.config.Topic).Msg("cannot fetch from Kafka")
+			})
+			fetches.EachRecord(func(record *kgo.Record) {
+				if err := in.handleRecord(record, &flow); err != nil {
+					in.metrics.errors.WithLabelValues(in.config.Topic, errorKind(err)).Inc()
+					errLogger.Err(err).Str("topic", in.config.Topic).Msg("cannot handle Kafka record")
+				}
+			})
+		}
+	})
+	return nil
+}
+
+// handleRecord sends the flow messages contained in a Kafka record.
+func (in *Input) handleRecord(record *kgo.Record, flow *pb.RawFlow) error {
+	payload := record.Value
+	for len(payload) > 0 {
+		message := payload
+		payload = nil
+		if in.config.LengthPrefixed {
+			length, n := protowire.ConsumeVarint(message)
+			if n < 0 || uint64(len(message)-n) < length {
+				return errTruncatedMessage
+			}
+			message, payload = message[n:n+int(length)], message[n+int(length):]
+		}
+
+		source, err := samplerAddress(message)
+		if err != nil {
+			return err
+		}
+		srcIP := source.String()
+		in.metrics.bytes.WithLabelValues(in.config.Topic, srcIP).Add(float64(len(message)))
+		in.metrics.messages.WithLabelValues(in.config.Topic, srcIP).Inc()
+
+		flow.Reset()
+		flow.TimeReceived = uint64(record.Timestamp.Unix())
+		flow.Payload = message
+		flow.SourceAddress = source.To16()
+		in.send(srcIP, flow)
+	}
+	return nil
+}
+
+var (
+	errTruncatedMessage      = errors.New("truncated message")
+	errInvalidMessage        = errors.New("invalid message")
+	errInvalidSamplerAddress = errors.New("invalid sampler address")
+	errNoSamplerAddress      = errors.New("no sampler address")
+)
+
+// errorKind returns the label to use in metrics for an error when handling a
+// record. The set of values is fixed to keep the cardinality bounded.
+func errorKind(err error) string {
+	for _, known := range []error{
+		errTruncatedMessage,
+		errInvalidMessage,
+		errInvalidSamplerAddress,
+		errNoSamplerAddress,
+	} {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+	return "cannot handle record"
+}
+
+// samplerAddress extracts the sampler address from a goflow2 message.
+func samplerAddress(message []byte) (net.IP, error) {
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return nil, errInvalidMessage
+		}
+		message = message[n:]
+		if num == samplerAddressField && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(message)
+			if n < 0 || (len(v) != net.IPv4len && len(v) != net.IPv6len) {
+				return nil, errInvalidSamplerAddress
+			}
+			return net.IP(v), nil
+		}
+		n = protowire.ConsumeFieldValue(num, typ, message)
+		if n < 0 {
+			return nil, errInvalidMessage
+		}
+		message = message[n:]
+	}
+	return nil, errNoSamplerAddress
+}
+
+// Stop stops the Kafka input
+func (in *Input) Stop() error {
+	defer in.r.Info().Msg("Kafka input stopped")
+	in.t.Kill(nil)
+	return in.t.Wait()
+}
@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/kafka"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+)
+
+// goflow2Message builds a minimal goflow2 message with a sampler address and
+// a number of bytes.
+func goflow2Message(sampler string, bytes uint64) []byte {
+	var msg []byte
+	msg = protowire.AppendTag(msg, 1, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, 4)
+	if sampler != "" {
+		msg = protowire.AppendTag(msg, samplerAddressField, protowire.BytesType)
+		ip := net.ParseIP(sampler)
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		msg = protowire.AppendBytes(msg, ip)
+	}
+	msg = protowire.AppendTag(msg, 9, protowire.VarintType)
+	return protowire.AppendVarint(msg, bytes)
+}
+
+func TestKafkaInput(t *testing.T) {
+	r := reporter.NewMock(t)
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(1, topic),
+		kfake.WithLogger(kafka.NewLogger(r)),
+	)
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Topic = topic
+	configuration.Brokers = cluster.ListenAddrs()
+
+	expected := 3
+	done := make(chan bool)
+	var mu sync.Mutex
+	got := []*pb.RawFlow{}
+	send := func(exporter string, flow *pb.RawFlow) {
+		if exporter != net.IP(flow.SourceAddress).String() {
+			t.Errorf("send() exporter %q does not match source address %s",
+				exporter, net.IP(flow.SourceAddress))
+		}
+		// Make a copy
+		payload := make([]byte, len(flow.Payload))
+		copy(payload, flow.Payload)
+		newFlow := pb.RawFlow{
+			TimeReceived:  flow.TimeReceived,
+			Payload:       payload,
+			SourceAddress: flow.SourceAddress,
+		}
+		mu.Lock()
+		if len(got) < expected {
+			got = append(got, &newFlow)
+			if len(got) == expected {
+				close(done)
+			}
+		}
+		mu.Unlock()
+	}
+
+	in, err := configuration.New(r, daemon.NewMock(t), send)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+
+	// Produce some records. The first one contains two messages, the second
+	// one has no sampler address, the third one is truncated.
+	producer, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...))
+	if err != nil {
+		t.Fatalf("NewClient() error:\n%+v", err)
+	}
+	defer producer.Close()
+	recordTime := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	delimited := func(messages ...[]byte) []byte {
+		var value []byte
+		for _, message := range messages {
+			value = protowire.AppendBytes(value, message)
+		}
+		return value
+	}
+	values := [][]byte{
+		delimited(goflow2Message("192.0.2.1", 1000), goflow2Message("2001:db8::1", 2000)),
+		delimited(goflow2Message("", 3000)),
+		delimited(goflow2Message("192.0.2.1", 4000))[:10],
+		delimited(goflow2Message("192.0.2.2", 5000)),
+	}
+	for _, value := range values {
+		record := &kgo.Record{
+			Topic:     configuration.Topic,
+			Value:     value,
+			Timestamp: recordTime,
+		}
+		if err := producer.ProduceSync(context.Background(), record).FirstErr(); err != nil {
+			t.Fatalf("ProduceSync() error:\n%+v", err)
+		}
+	}
+
+	select {
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout while waiting to receive flows")
+	case <-done:
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expectedFlows := []*pb.RawFlow{
+		{
+			TimeReceived:  uint64(recordTime.Unix()),
+			Payload:       goflow2Message("192.0.2.1", 1000),
+			SourceAddress: net.ParseIP("192.0.2.1").To16(),
+		}, {
+			TimeReceived:  uint64(recordTime.Unix()),
+			Payload:       goflow2Message("2001:db8::1", 2000),
+			SourceAddress: net.ParseIP("2001:db8::1").To16(),
+		}, {
+			TimeReceived:  uint64(recordTime.Unix()),
+			Payload:       goflow2Message("192.0.2.2", 5000),
+			SourceAddress: net.ParseIP("192.0.2.2").To16(),
+		},
+	}
+	if diff := helpers.Diff(got, expectedFlows); diff != "" {
+		t.Fatalf("Input data (-got, +want):\n%s", diff)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_kafka_", "messages_total", "errors_total")
+	expectedMetrics := map[string]string{
+		fmt.Sprintf(`messages_total{exporter="192.0.2.1",topic="%s"}`, topic):     "1",
+		fmt.Sprintf(`messages_total{exporter="192.0.2.2",topic="%s"}`, topic):     "1",
+		fmt.Sprintf(`messages_total{exporter="2001:db8::1",topic="%s"}`, topic):   "1",
+		fmt.Sprintf(`errors_total{error="no sampler address",topic="%s"}`, topic): "1",
+		fmt.Sprintf(`errors_total{error="truncated message",topic="%s"}`, topic):  "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected string
+	}{
+		{errNoSamplerAddress, "no sampler address"},
+		{fmt.Errorf("record 10: %w", errTruncatedMessage), "truncated message"},
+		{fmt.Errorf("unexpected error for 192.0.2.1"), "cannot handle record"},
+	}
+	for _, tc := range cases {
+		if got := errorKind(tc.err); got != tc.expected {
+			t.Errorf("errorKind(%q) == %q, expected %q", tc.err, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package pcap
+
+import "akvorado/inlet/flow/input"
+
+// Configuration describes pcap input configuration.
+type Configuration struct {
+	// Paths to the pcap or pcapng files to replay
+	Paths []string `validate:"min=1,dive,required"`
+	// Port is the destination UDP port of the packets to replay. 0 means all
+	// UDP packets are replayed.
+	Port uint16
+	// Speed is the replay speed relative to the original timing. 0 means to
+	// replay as fast as possible, 1 means to use the original timing, 2 means
+	// twice faster.
+	Speed float64 `validate:"min=0"`
+	// Loop tells to replay the files again once they have all been replayed.
+	Loop bool
+}
+
+// DefaultConfiguration describes the default configuration for pcap input.
+func DefaultConfiguration() input.Configuration {
+	return &Configuration{}
+}
@@ -0,0 +1,19 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package pcap
+
+import (
+	"testing"
+
+	"akvorado/common/helpers"
+)
+
+func TestDefaultConfiguration(t *testing.T) {
+	if err := helpers.Validate.Struct(Configuration{
+		Paths: []string{"/path/1.pcap", "/path/2.pcapng"},
+		Speed: 1,
+	}); err != nil {
+		t.Fatalf("validate.Struct() error:\n%+v", err)
+	}
+}
@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package pcap replays UDP payloads from pcap and pcapng files.
+package pcap
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+	"akvorado/inlet/flow/input"
+)
+
+// Input represents the state of a pcap input.
+type Input struct {
+	r      *reporter.Reporter
+	t      tomb.Tomb
+	config Configuration
+	send   input.SendFunc
+
+	metrics struct {
+		bytes   *reporter.CounterVec
+		packets *reporter.CounterVec
+		skipped *reporter.CounterVec
+	}
+}
+
+var (
+	_ input.Input         = &Input{}
+	_ input.Configuration = Configuration{}
+)
+
+// pcapngMagic is the block type of the section header block starting a pcapng
+// file.
+var pcapngMagic = []byte{0x0a, 0x0d, 0x0d, 0x0a}
+
+// packetReader is the common interface for pcap and pcapng readers.
+type packetReader interface {
+	ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
+	LinkType() layers.LinkType
+}
+
+// New instantiate a new pcap input from the provided configuration.
+func (configuration Configuration) New(r *reporter.Reporter, daemon daemon.Component, send input.SendFunc) (input.Input, error) {
+	if len(configuration.Paths) == 0 {
+		return nil, errors.New("no paths provided for pcap input")
+	}
+	input := &Input{
+		r:      r,
+		config: configuration,
+		send:   send,
+	}
+
+	input.metrics.bytes = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "bytes_total",
+			Help: "Bytes replayed from capture files.",
+		},
+		[]string{"path", "exporter"},
+	)
+	input.metrics.packets = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "packets_total",
+			Help: "Packets replayed from capture files.",
+		},
+		[]string{"path", "exporter"},
+	)
+	input.metrics.skipped = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "skipped_packets_total",
+			Help: "Packets from capture files that were not replayed.",
+		},
+		[]string{"path", "reason"},
+	)
+
+	daemon.Track(&input.t, "inlet/flow/input/pcap")
+	return input, nil
+}
+
+// Start starts replaying capture files.
+func (in *Input) Start() error {
+	in.r.Info().Msg("pcap input starting")
+	in.t.Go(func() error {
+		for {
+			for _, path := range in.config.Paths {
+				if err := in.replay(path); err != nil {
+					in.r.Err(err).Str("path", path).Msg("unable to replay capture file")
+					return err
+				}
+				select {
+				case <-in.t.Dying():
+					return nil
+				default:
+				}
+			}
+			if !in.config.Loop {
+				break
+			}
+		}
+		in.r.Info().Msg("pcap input has replayed all files")
+		<-in.t.Dying()
+		return nil
+	})
+	return nil
+}
+
+// openCapture opens a pcap or a pcapng file.
+func openCapture(path string) (packetReader, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	buffered := bufio.NewReader(f)
+	magic, err := buffered.Peek(len(pcapngMagic))
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("unable to read header: %w", err)
+	}
+	var reader packetReader
+	if bytes.Equal(magic, pcapngMagic) {
+		reader, err = pcapgo.NewNgReader(buffered, pcapgo.DefaultNgReaderOptions)
+	} else {
+		reader, err = pcapgo.NewReader(buffered)
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return reader, f, nil
+}
+
+// replay replays a single capture file. It returns early without error when
+// the input is stopped.
+func (in *Input) replay(path string) error {
+	reader, closer, err := openCapture(path)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	dying := in.t.Dying()
+	linkType := reader.LinkType()
+	flow := pb.RawFlow{}
+	var firstCapture, start time.Time
+	for {
+		data, ci, err := reader.ReadPacketData()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("unable to read packet: %w", err)
+		}
+
+		packet := gopacket.NewPacket(data, linkType, gopacket.DecodeOptions{
+			Lazy:   true,
+			NoCopy: true,
+		})
+		var source net.IP
+		switch network := packet.NetworkLayer().(type) {
+		case *layers.IPv4:
+			if network.Flags&layers.IPv4MoreFragments != 0 || network.FragOffset != 0 {
+				in.metrics.skipped.WithLabelValues(path, "fragmented").Inc()
+				continue
+			}
+			source = network.SrcIP
+		case *layers.IPv6:
+			source = network.SrcIP
+		default:
+			in.metrics.skipped.WithLabelValues(path, "not IP").Inc()
+			continue
+		}
+		udp, ok := packet.Layer(layers.LayerTypeUDP).(*layers.UDP)
+		if !ok {
+			in.metrics.skipped.WithLabelValues(path, "not UDP").Inc()
+			continue
+		}
+		if in.config.Port != 0 && uint16(udp.DstPort) != in.config.Port {
+			in.metrics.skipped.WithLabelValues(path, "port mismatch").Inc()
+			continue
+		}
+
+		// Wait to respect the original timing
+		if in.config.Speed > 0 {
+			if firstCapture.IsZero() {
+				firstCapture = ci.Timestamp
+				start = time.Now()
+			}
+			elapsed := time.Duration(float64(ci.Timestamp.Sub(firstCapture)) / in.config.Speed)
+			if wait := time.Until(start.Add(elapsed)); wait > 0 {
+				select {
+				case <-dying:
+					return nil
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		srcIP := source.String()
+		in.metrics.bytes.WithLabelValues(path, srcIP).Add(float64(len(udp.Payload)))
+		in.metrics.packets.WithLabelValues(path, srcIP).Inc()
+
+		flow.Reset()
+		flow.TimeReceived = uint64(ci.Timestamp.Unix())
+		flow.Payload = udp.Payload
+		flow.SourceAddress = source.To16()
+		in.send(srcIP, &flow)
+
+		select {
+		case <-dying:
+			return nil
+		default:
+		}
+	}
+}
+
+// Stop stops the pcap input
+func (in *Input) Stop() error {
+	defer in.r.Info().Msg("pcap input stopped")
+	in.t.Kill(nil)
+	return in.t.Wait()
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package pcap
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+)
+
+// replayFlows replays the provided configuration and returns the received
+// flows once expected flows have been received.
+func replayFlows(t *testing.T, r *reporter.Reporter, configuration *Configuration, expected int) []*pb.RawFlow {
+	t.Helper()
+	done := make(chan bool)
+	var mu sync.Mutex
+	got := []*pb.RawFlow{}
+	send := func(exporter string, flow *pb.RawFlow) {
+		if exporter != net.IP(flow.SourceAddress).String() {
+			t.Errorf("send() exporter %q does not match source address %s",
+				exporter, net.IP(flow.SourceAddress))
+		}
+		// Make a copy
+		payload := make([]byte, len(flow.Payload))
+		copy(payload, flow.Payload)
+		newFlow := pb.RawFlow{
+			TimeReceived:  flow.TimeReceived,
+			Payload:       payload,
+			SourceAddress: flow.SourceAddress,
+		}
+		mu.Lock()
+		if len(got) < expected {
+			got = append(got, &newFlow)
+			if len(got) == expected {
+				close(done)
+			}
+		}
+		mu.Unlock()
+	}
+
+	in, err := configuration.New(r, daemon.NewMock(t), send)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout while waiting to receive flows")
+	case <-done:
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return got
+}
+
+func TestPcapInput(t *testing.T) {
+	captureTime := uint64(time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC).Unix())
+	for _, file := range []string{"replay.pcap", "replay.pcapng"} {
+		t.Run(file, func(t *testing.T) {
+			r := reporter.NewMock(t)
+			configuration := DefaultConfiguration().(*Configuration)
+			configuration.Paths = []string{path.Join("testdata", file)}
+			configuration.Port = 2055
+
+			got := replayFlows(t, r, configuration, 3)
+			expected := []*pb.RawFlow{
+				{
+					TimeReceived:  captureTime,
+					Payload:       []byte("hello world!"),
+					SourceAddress: net.ParseIP("192.0.2.1").To16(),
+				}, {
+					TimeReceived:  captureTime,
+					Payload:       []byte("bye bye"),
+					SourceAddress: net.ParseIP("2001:db8::2").To16(),
+				}, {
+					TimeReceived:  captureTime,
+					Payload:       []byte("hello again!"),
+					SourceAddress: net.ParseIP("192.0.2.1").To16(),
+				},
+			}
+			if diff := helpers.Diff(got, expected); diff != "" {
+				t.Fatalf("Input data (-got, +want):\n%s", diff)
+			}
+
+			path := configuration.Paths[0]
+			gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_pcap_")
+			expectedMetrics := map[string]string{
+				fmt.Sprintf(`bytes_total{exporter="192.0.2.1",path="%s"}`, path):             "24",
+				fmt.Sprintf(`bytes_total{exporter="2001:db8::2",path="%s"}`, path):           "7",
+				fmt.Sprintf(`packets_total{exporter="192.0.2.1",path="%s"}`, path):           "2",
+				fmt.Sprintf(`packets_total{exporter="2001:db8::2",path="%s"}`, path):         "1",
+				fmt.Sprintf(`skipped_packets_total{path="%s",reason="not UDP"}`, path):       "1",
+				fmt.Sprintf(`skipped_packets_total{path="%s",reason="port mismatch"}`, path): "1",
+			}
+			if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+				t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPcapInputLoop(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Paths = []string{path.Join("testdata", "replay.pcap")}
+	configuration.Loop = true
+
+	got := replayFlows(t, r, configuration, 10)
+	if string(got[6].Payload) != "other port" {
+		t.Fatalf("Input data: got %q, expected %q", got[6].Payload, "other port")
+	}
+}
+
+func TestPcapInputTiming(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Paths = []string{path.Join("testdata", "replay.pcapng")}
+	configuration.Speed = 2
+
+	start := time.Now()
+	replayFlows(t, r, configuration, 4)
+	// The capture lasts 200 ms, we replay it twice faster.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Fatalf("Replay took %s, expected about 100ms", elapsed)
+	}
+}
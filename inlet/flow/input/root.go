@@ -18,6 +18,13 @@ type Input interface {
 	Stop() error
 }
 
+// KernelDropper is implemented by inputs able to tell how many packets were
+// dropped by the kernel before they could be read.
+type KernelDropper interface {
+	// KernelDrops returns the number of packets dropped by the kernel.
+	KernelDrops() uint64
+}
+
 // SendFunc is a function to send a flow to Kafka
 type SendFunc func(exporter string, flow *pb.RawFlow)
 
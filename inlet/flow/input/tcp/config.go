@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tcp
+
+import (
+	"time"
+
+	"akvorado/inlet/flow/input"
+)
+
+// Configuration describes TCP input configuration.
+type Configuration struct {
+	// Listen tells which port to listen to.
+	Listen string `validate:"required,listen"`
+	// MaxConnections is the maximum number of simultaneous connections. 0
+	// means no limit.
+	MaxConnections uint
+	// IdleTimeout closes a connection when nothing has been received during
+	// this duration. 0 means connections are never closed.
+	IdleTimeout time.Duration `validate:"min=0"`
+}
+
+// DefaultConfiguration is the default configuration for this input
+func DefaultConfiguration() input.Configuration {
+	return &Configuration{
+		Listen:      ":0",
+		IdleTimeout: 10 * time.Minute,
+	}
+}
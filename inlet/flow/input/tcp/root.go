@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package tcp handles IPFIX over TCP listeners (RFC 7011, section 10.4).
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+	"akvorado/inlet/flow/input"
+)
+
+const (
+	// ipfixVersion is the only version we accept over TCP as NetFlow v9
+	// messages do not carry their length.
+	ipfixVersion = 10
+	// ipfixHeaderLength is the length of an IPFIX message header.
+	ipfixHeaderLength = 16
+)
+
+// Input represents the state of a TCP listener.
+type Input struct {
+	r      *reporter.Reporter
+	t      tomb.Tomb
+	config Configuration
+
+	metrics struct {
+		bytes       *reporter.CounterVec
+		packets     *reporter.CounterVec
+		connections *reporter.GaugeVec
+		errors      *reporter.CounterVec
+	}
+
+	connLock sync.Mutex
+	conns    map[net.Conn]struct{}
+
+	address net.Addr       // listening address, for testing purpoese
+	send    input.SendFunc // function to send to kafka
+}
+
+var (
+	_ input.Input         = &Input{}
+	_ input.Configuration = Configuration{}
+)
+
+// New instantiate a new TCP listener from the provided configuration.
+func (configuration Configuration) New(r *reporter.Reporter, daemon daemon.Component, send input.SendFunc) (input.Input, error) {
+	input := &Input{
+		r:      r,
+		config: configuration,
+		send:   send,
+		conns:  make(map[net.Conn]struct{}),
+	}
+
+	input.metrics.bytes = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "bytes_total",
+			Help: "Bytes received by the application.",
+		},
+		[]string{"listener", "exporter"},
+	)
+	input.metrics.packets = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "packets_total",
+			Help: "IPFIX messages received by the application.",
+		},
+		[]string{"listener", "exporter"},
+	)
+	input.metrics.connections = r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "connections",
+			Help: "Number of established connections.",
+		},
+		[]string{"listener"},
+	)
+	input.metrics.errors = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Errors while receiving messages by the application.",
+		},
+		[]string{"listener", "error"},
+	)
+
+	daemon.Track(&input.t, "inlet/flow/input/tcp")
+	return input, nil
+}
+
+// Start starts listening to the provided TCP socket and producing flows.
+func (in *Input) Start() error {
+	in.r.Info().Str("listen", in.config.Listen).Msg("starting TCP input")
+
+	var lc net.ListenConfig
+	listener, err := lc.Listen(in.t.Context(context.Background()), "tcp", in.config.Listen)
+	if err != nil {
+		return fmt.Errorf("unable to listen to %v: %w", in.config.Listen, err)
+	}
+	in.address = listener.Addr()
+	in.r.Info().Str("listen", in.address.String()).Msg("TCP input listening")
+	in.metrics.connections.WithLabelValues(in.config.Listen).Set(0)
+
+	in.t.Go(func() error {
+		errLogger := in.r.Sample(reporter.BurstSampler(time.Minute, 1))
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return nil
+				}
+				errLogger.Err(err).Str("listen", in.config.Listen).Msg("unable to accept connection")
+				in.metrics.errors.WithLabelValues(in.config.Listen, "accept error").Inc()
+				continue
+			}
+			if !in.track(conn) {
+				errLogger.Warn().
+					Str("listen", in.config.Listen).
+					Str("remote", conn.RemoteAddr().String()).
+					Msg("too many connections, closing new one")
+				in.metrics.errors.WithLabelValues(in.config.Listen, "too many connections").Inc()
+				conn.Close()
+				continue
+			}
+			in.t.Go(func() error {
+				defer in.untrack(conn)
+				in.handleConnection(conn)
+				return nil
+			})
+		}
+	})
+
+	// Watch for termination and close on dying
+	in.t.Go(func() error {
+		<-in.t.Dying()
+		listener.Close()
+		in.connLock.Lock()
+		for conn := range in.conns {
+			conn.Close()
+		}
+		in.connLock.Unlock()
+		return nil
+	})
+
+	return nil
+}
+
+// track registers a new connection. It returns false if the connection should
+// be refused.
+func (in *Input) track(conn net.Conn) bool {
+	in.connLock.Lock()
+	defer in.connLock.Unlock()
+	select {
+	case <-in.t.Dying():
+		return false
+	default:
+	}
+	if in.config.MaxConnections > 0 && uint(len(in.conns)) >= in.config.MaxConnections {
+		return false
+	}
+	in.conns[conn] = struct{}{}
+	in.metrics.connections.WithLabelValues(in.config.Listen).Set(float64(len(in.conns)))
+	return true
+}
+
+// untrack closes and unregisters a connection.
+func (in *Input) untrack(conn net.Conn) {
+	conn.Close()
+	in.connLock.Lock()
+	defer in.connLock.Unlock()
+	delete(in.conns, conn)
+	in.metrics.connections.WithLabelValues(in.config.Listen).Set(float64(len(in.conns)))
+}
+
+// handleConnection reads IPFIX messages from a connection until it is closed.
+// Each message is sent as a raw flow, the peer address being used as source
+// address.
+func (in *Input) handleConnection(conn net.Conn) {
+	listen := in.config.Listen
+	source := conn.RemoteAddr().(*net.TCPAddr)
+	srcIP := source.IP.String()
+	l := in.r.With().
+		Str("listen", listen).
+		Str("exporter", srcIP).
+		Logger()
+	l.Debug().Msg("new IPFIX connection")
+	defer l.Debug().Msg("IPFIX connection closed")
+
+	reader := bufio.NewReader(conn)
+	payload := make([]byte, 65535)
+	flow := pb.RawFlow{}
+	for {
+		if in.config.IdleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(in.config.IdleTimeout))
+		}
+		if _, err := io.ReadFull(reader, payload[:4]); err != nil {
+			switch {
+			case errors.Is(err, io.EOF), errors.Is(err, net.ErrClosed):
+			case errors.Is(err, os.ErrDeadlineExceeded):
+				l.Info().Msg("closing idle IPFIX connection")
+			default:
+				l.Err(err).Msg("unable to read IPFIX message header")
+				in.metrics.errors.WithLabelValues(listen, "read error").Inc()
+			}
+			return
+		}
+		version := binary.BigEndian.Uint16(payload[0:2])
+		length := int(binary.BigEndian.Uint16(payload[2:4]))
+		if version != ipfixVersion {
+			l.Warn().Msgf("unexpected IPFIX version %d", version)
+			in.metrics.errors.WithLabelValues(listen, "invalid version").Inc()
+			return
+		}
+		if length < ipfixHeaderLength {
+			l.Warn().Msgf("invalid IPFIX message length %d", length)
+			in.metrics.errors.WithLabelValues(listen, "invalid length").Inc()
+			return
+		}
+		if _, err := io.ReadFull(reader, payload[4:length]); err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				l.Err(err).Msg("unable to read IPFIX message")
+				in.metrics.errors.WithLabelValues(listen, "read error").Inc()
+			}
+			return
+		}
+
+		in.metrics.bytes.WithLabelValues(listen, srcIP).Add(float64(length))
+		in.metrics.packets.WithLabelValues(listen, srcIP).Inc()
+
+		flow.Reset()
+		flow.TimeReceived = uint64(time.Now().Unix())
+		flow.Payload = payload[:length]
+		flow.SourceAddress = source.IP.To16()
+		in.send(srcIP, &flow)
+	}
+}
+
+// Stop stops the TCP listener
+func (in *Input) Stop() error {
+	l := in.r.With().Str("listen", in.config.Listen).Logger()
+	defer l.Info().Msg("TCP listener stopped")
+	in.t.Kill(nil)
+	return in.t.Wait()
+}
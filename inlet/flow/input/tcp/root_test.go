@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tcp
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+)
+
+// ipfixMessage builds a fake IPFIX message with the provided body.
+func ipfixMessage(version uint16, body string) []byte {
+	msg := make([]byte, ipfixHeaderLength+len(body))
+	binary.BigEndian.PutUint16(msg[0:2], version)
+	binary.BigEndian.PutUint16(msg[2:4], uint16(len(msg)))
+	copy(msg[ipfixHeaderLength:], body)
+	return msg
+}
+
+func TestTCPInput(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+
+	received := make(chan *pb.RawFlow, 10)
+	send := func(exporter string, got *pb.RawFlow) {
+		if exporter != "127.0.0.1" {
+			t.Errorf("send() exporter %q, not 127.0.0.1", exporter)
+		}
+		received <- &pb.RawFlow{
+			TimeReceived:  got.TimeReceived,
+			SourceAddress: got.SourceAddress,
+			Payload:       append([]byte{}, got.Payload...),
+		}
+	}
+
+	in, err := configuration.New(r, daemon.NewMock(t), send)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+
+	conn, err := net.Dial("tcp", in.(*Input).address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn.Close()
+
+	// Send two messages, the second one split across two writes.
+	msg1 := ipfixMessage(10, "hello world!")
+	msg2 := ipfixMessage(10, "goodbye world!")
+	if _, err := conn.Write(append(msg1, msg2[:10]...)); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := conn.Write(msg2[10:]); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+
+	for _, expectedPayload := range [][]byte{msg1, msg2} {
+		select {
+		case <-time.After(time.Second):
+			t.Fatal("no flow received")
+		case got := <-received:
+			delta := uint64(time.Now().UTC().Unix()) - got.TimeReceived
+			if delta > 1 {
+				t.Errorf("TimeReceived out of range: %d (now: %d)", got.TimeReceived, time.Now().UTC().Unix())
+			}
+			expected := &pb.RawFlow{
+				TimeReceived:  got.TimeReceived,
+				SourceAddress: net.ParseIP("127.0.0.1").To16(),
+				Payload:       expectedPayload,
+			}
+			if diff := helpers.Diff(got, expected); diff != "" {
+				t.Fatalf("Input data (-got, +want):\n%s", diff)
+			}
+		}
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_tcp_")
+	expectedMetrics := map[string]string{
+		`bytes_total{exporter="127.0.0.1",listener="127.0.0.1:0"}`:   "58",
+		`packets_total{exporter="127.0.0.1",listener="127.0.0.1:0"}`: "2",
+		`connections{listener="127.0.0.1:0"}`:                        "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestTCPInvalidVersion(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+
+	in, err := configuration.New(r, daemon.NewMock(t), func(string, *pb.RawFlow) {
+		t.Error("send() should not be called")
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+
+	conn, err := net.Dial("tcp", in.(*Input).address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write(ipfixMessage(9, "hello world!")); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+
+	// The connection should be closed by the remote end.
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Read() should have failed")
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_tcp_", "errors_total")
+	expectedMetrics := map[string]string{
+		`errors_total{error="invalid version",listener="127.0.0.1:0"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestTCPMaxConnections(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	configuration.MaxConnections = 1
+
+	in, err := configuration.New(r, daemon.NewMock(t), func(string, *pb.RawFlow) {})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, in)
+
+	conn1, err := net.Dial("tcp", in.(*Input).address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn1.Close()
+	time.Sleep(20 * time.Millisecond)
+	conn2, err := net.Dial("tcp", in.(*Input).address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn2.Close()
+
+	conn2.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn2.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Read() should have failed")
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_tcp_")
+	expectedMetrics := map[string]string{
+		`connections{listener="127.0.0.1:0"}`:                               "1",
+		`errors_total{error="too many connections",listener="127.0.0.1:0"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net"
 	"runtime/pprof"
 	"strconv"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -38,12 +39,14 @@ type Input struct {
 		ebpf          reporter.Gauge
 	}
 
-	address net.Addr       // listening address, for testing purpoese
-	send    input.SendFunc // function to send to kafka
+	address net.Addr        // listening address, for testing purpoese
+	send    input.SendFunc  // function to send to kafka
+	drops   []atomic.Uint32 // packets dropped by the kernel, per worker
 }
 
 var (
 	_ input.Input         = &Input{}
+	_ input.KernelDropper = &Input{}
 	_ input.Configuration = Configuration{}
 )
 
@@ -53,6 +56,7 @@ func (configuration Configuration) New(r *reporter.Reporter, daemon daemon.Compo
 		r:      r,
 		config: configuration,
 		send:   send,
+		drops:  make([]atomic.Uint32, configuration.Workers),
 	}
 
 	input.metrics.bytes = r.CounterVec(
@@ -215,6 +219,7 @@ func (in *Input) Start() error {
 				} else {
 					in.metrics.inDrops.WithLabelValues(listen, worker).Set(
 						float64(oobMsg.Drops))
+					in.drops[workerID].Store(oobMsg.Drops)
 				}
 				if oobMsg.Received.IsZero() {
 					oobMsg.Received = time.Now()
@@ -257,6 +262,16 @@ func (in *Input) Start() error {
 	return nil
 }
 
+// KernelDrops returns the number of packets dropped by the kernel on all the
+// sockets of the listener.
+func (in *Input) KernelDrops() uint64 {
+	var total uint64
+	for i := range in.drops {
+		total += uint64(in.drops[i].Load())
+	}
+	return total
+}
+
 // Stop stops the UDP listeners
 func (in *Input) Stop() error {
 	l := in.r.With().Str("listen", in.config.Listen).Logger()
@@ -8,6 +8,8 @@ import (
 	"errors"
 	"sync"
 
+	"github.com/benbjohnson/clock"
+	"github.com/puzpuzpuz/xsync/v4"
 	"gopkg.in/tomb.v2"
 
 	"akvorado/common/daemon"
@@ -28,6 +30,7 @@ type Component struct {
 
 	inputs      []input.Input
 	payloadPool sync.Pool
+	exporters   *xsync.Map[exporterKey, *exporterStats]
 }
 
 // Dependencies are the dependencies of the flow component.
@@ -35,6 +38,7 @@ type Dependencies struct {
 	Daemon daemon.Component
 	HTTP   *httpserver.Component
 	Kafka  *kafka.Component
+	Clock  clock.Clock
 }
 
 // New creates a new flow component.
@@ -42,6 +46,9 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 	if len(configuration.Inputs) == 0 {
 		return nil, errors.New("no input configured")
 	}
+	if dependencies.Clock == nil {
+		dependencies.Clock = clock.New()
+	}
 
 	c := Component{
 		r:      r,
@@ -59,25 +66,29 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 				return &s
 			},
 		},
+		exporters: xsync.NewMap[exporterKey, *exporterStats](),
 	}
 
 	// Initialize inputs
 	for idx, input := range c.config.Inputs {
 		var err error
-		c.inputs[idx], err = input.Config.New(r, c.d.Daemon, c.Send(input))
+		c.inputs[idx], err = input.Config.New(r, c.d.Daemon, c.Send(idx, input))
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	c.d.HTTP.APIRouter.GET("/api/v0/inlet/exporters", c.ExportersHTTPHandler)
 	c.d.Daemon.Track(&c.t, "inlet/flow")
 
 	return &c, nil
 }
 
-// Send sends a raw flow to Kafka.
-func (c *Component) Send(config InputConfiguration) input.SendFunc {
+// Send sends a raw flow received by the input with the provided index to
+// Kafka.
+func (c *Component) Send(idx int, config InputConfiguration) input.SendFunc {
 	return func(exporter string, flow *pb.RawFlow) {
+		c.recordPacket(exporterKey{exporter: exporter, input: idx}, len(flow.Payload))
 		flow.TimestampSource = config.TimestampSource
 		flow.Decoder = config.Decoder
 		flow.UseSourceAddress = config.UseSrcAddrForExporterAddr
@@ -121,6 +132,20 @@ func (c *Component) Start() error {
 			return nil
 		})
 	}
+
+	// Expire exporters not sending anymore
+	c.t.Go(func() error {
+		ticker := c.d.Clock.Ticker(statsExpiry / 10)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.t.Dying():
+				return nil
+			case <-ticker.C:
+				c.expireExporters()
+			}
+		}
+	})
 	return nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"path"
 	"runtime"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -21,6 +22,7 @@ import (
 	"akvorado/inlet/flow/input/file"
 	"akvorado/inlet/kafka"
 
+	"github.com/benbjohnson/clock"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
@@ -105,3 +107,127 @@ func TestFlow(t *testing.T) {
 		t.Fatalf("flows not received")
 	}
 }
+
+func TestExportersHTTP(t *testing.T) {
+	r := reporter.NewMock(t)
+	config := DefaultConfiguration()
+	config.Inputs[1].RateLimit = 1000
+	config.Inputs = append(config.Inputs, InputConfiguration{
+		Decoder: pb.RawFlow_DECODER_GOB,
+		Config: &file.Configuration{
+			Paths: []string{"/dev/null"},
+		},
+	})
+
+	producer, cluster := kafka.NewMock(t, r, kafka.DefaultConfiguration())
+	defer cluster.Close()
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC))
+	h := httpserver.NewMock(t, r)
+	c, err := New(r, config, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   h,
+		Kafka:  producer,
+		Clock:  mockClock,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	// 20 packets of 100 bytes during a first window, 10 during the second one.
+	for range 20 {
+		c.recordPacket(exporterKey{exporter: "192.0.2.1", input: 0}, 100)
+	}
+	mockClock.Add(statsWindow)
+	for range 10 {
+		c.recordPacket(exporterKey{exporter: "192.0.2.1", input: 0}, 100)
+	}
+	c.recordPacket(exporterKey{exporter: "192.0.2.1", input: 1}, 200)
+	c.recordPacket(exporterKey{exporter: "192.0.2.2", input: 2}, 300)
+	mockClock.Add(time.Second)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/inlet/exporters",
+			JSONOutput: helpers.M{
+				"inputs": []helpers.M{
+					{"input": 0, "type": "udp", "decoder": "netflow", "rate-limit": 0, "kernel-drops": 0},
+					{"input": 1, "type": "udp", "decoder": "sflow", "rate-limit": 1000, "kernel-drops": 0},
+					{"input": 2, "type": "file", "decoder": "gob", "rate-limit": 0},
+				},
+				"exporters": []helpers.M{
+					{
+						"exporter":           "192.0.2.1",
+						"input":              0,
+						"decoder":            "netflow",
+						"packets":            30,
+						"bytes":              3000,
+						"packets-per-second": 2,
+						"bytes-per-second":   200,
+						"last-seen":          "2026-10-01T10:00:10Z",
+						"rate-limit":         0,
+						"input-kernel-drops": 0,
+					}, {
+						"exporter":           "192.0.2.1",
+						"input":              1,
+						"decoder":            "sflow",
+						"packets":            1,
+						"bytes":              200,
+						"packets-per-second": 0,
+						"bytes-per-second":   0,
+						"last-seen":          "2026-10-01T10:00:10Z",
+						"rate-limit":         1000,
+						"input-kernel-drops": 0,
+					}, {
+						"exporter":           "192.0.2.2",
+						"input":              2,
+						"decoder":            "gob",
+						"packets":            1,
+						"bytes":              300,
+						"packets-per-second": 0,
+						"bytes-per-second":   0,
+						"last-seen":          "2026-10-01T10:00:10Z",
+						"rate-limit":         0,
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestExportersExpiry(t *testing.T) {
+	r := reporter.NewMock(t)
+	producer, cluster := kafka.NewMock(t, r, kafka.DefaultConfiguration())
+	defer cluster.Close()
+	mockClock := clock.NewMock()
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Kafka:  producer,
+		Clock:  mockClock,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	c.recordPacket(exporterKey{exporter: "192.0.2.1", input: 0}, 100)
+	c.recordPacket(exporterKey{exporter: "192.0.2.2", input: 0}, 100)
+	mockClock.Add(statsExpiry / 2)
+	c.recordPacket(exporterKey{exporter: "192.0.2.2", input: 0}, 100)
+	mockClock.Add(statsExpiry/2 + time.Second)
+	c.expireExporters()
+	if _, ok := c.exporters.Load(exporterKey{exporter: "192.0.2.1", input: 0}); ok {
+		t.Error("expireExporters() did not expire 192.0.2.1")
+	}
+	if _, ok := c.exporters.Load(exporterKey{exporter: "192.0.2.2", input: 0}); !ok {
+		t.Error("expireExporters() expired 192.0.2.2")
+	}
+
+	// Past the limit, new exporters are not tracked
+	for i := range statsMaxExporters + 10 {
+		c.recordPacket(exporterKey{exporter: strconv.Itoa(i), input: 0}, 100)
+	}
+	if size := c.exporters.Size(); size != statsMaxExporters {
+		t.Errorf("exporters.Size() == %d, expected %d", size, statsMaxExporters)
+	}
+}
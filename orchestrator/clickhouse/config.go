@@ -23,6 +23,8 @@ type Configuration struct {
 	// MaxPartitions define the number of partitions to have for a
 	// consolidated flow tables when full.
 	MaxPartitions int `validate:"isdefault|min=1"`
+	// InterfaceCountersTTL is how long to keep interface counters.
+	InterfaceCountersTTL time.Duration `validate:"min=1h"`
 	// ASNs is a mapping from AS numbers to names. It replaces or
 	// extends the builtin list of AS numbers.
 	ASNs map[uint32]string
@@ -69,7 +71,8 @@ func DefaultConfiguration() Configuration {
 			{Interval: 5 * time.Minute, TTL: 3 * 30 * 24 * time.Hour}, // 90 days
 			{Interval: time.Hour, TTL: 12 * 30 * 24 * time.Hour},      // 1 year
 		},
-		MaxPartitions: 50,
+		MaxPartitions:        50,
+		InterfaceCountersTTL: 30 * 24 * time.Hour, // 30 days
 	}
 }
 
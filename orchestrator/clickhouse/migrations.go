@@ -9,12 +9,20 @@ import (
 	"fmt"
 	"net"
 
+	"akvorado/common/clickhousedb"
 	"akvorado/common/schema"
 	sb "akvorado/common/sqlbuilder"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 )
 
+// migrator runs the migrations in a database: the main one or the one
+// dedicated to a tenant.
+type migrator struct {
+	*Component
+	ch *clickhousedb.Component
+}
+
 // migrateDatabase execute database migration
 func (c *Component) migrateDatabase() error {
 	ctx := c.t.Context(nil)
@@ -58,6 +66,34 @@ func (c *Component) migrateDatabase() error {
 		}))
 	}
 
+	// Migrate the main database, then the ones dedicated to tenants
+	migrators := []*migrator{{Component: c, ch: c.d.ClickHouse}}
+	for _, tenant := range c.d.ClickHouse.Tenants() {
+		if err := c.d.ClickHouse.ExecOnCluster(ctx, sb.CreateDatabase(tenant.DatabaseName())); err != nil {
+			return fmt.Errorf("cannot create database %q: %w", tenant.DatabaseName(), err)
+		}
+		migrators = append(migrators, &migrator{Component: c, ch: tenant})
+	}
+	for _, m := range migrators {
+		if err := m.migrate(ctx); err != nil {
+			return fmt.Errorf("cannot migrate database %q: %w", m.ch.DatabaseName(), err)
+		}
+	}
+
+	close(c.migrationsDone)
+	c.metrics.migrationsRunning.Set(0)
+	c.r.Info().Msg("database migration done")
+
+	// Reload dictionaries with a source in the migrated databases
+	for _, m := range migrators {
+		m.reloadDictionaries(ctx)
+	}
+
+	return nil
+}
+
+// migrate executes the migrations in the database.
+func (c *migrator) migrate(ctx context.Context) error {
 	// Create dictionaries
 	err := c.wrapMigrations(
 		ctx,
@@ -153,24 +189,16 @@ func (c *Component) migrateDatabase() error {
 	}
 
 	// Remaining tables
-	err = c.wrapMigrations(ctx,
+	return c.wrapMigrations(ctx,
 		c.createExportersTable,
 		c.createExportersConsumerView,
 		c.createRawFlowsTable,
 		c.createRawFlowsConsumerView,
+		c.createOrUpdateInterfaceCountersTable,
+		func(ctx context.Context) error {
+			return c.createDistributedTable(ctx, "interface_counters")
+		},
 	)
-	if err != nil {
-		return err
-	}
-
-	close(c.migrationsDone)
-	c.metrics.migrationsRunning.Set(0)
-	c.r.Info().Msg("database migration done")
-
-	// Reload dictionaries with a source in the current database
-	c.reloadDictionaries(ctx)
-
-	return nil
 }
 
 // guessHTTPBaseURL tries to guess the appropriate URL to access our
@@ -199,9 +227,9 @@ func (c *Component) guessHTTPBaseURL(ip string) (string, error) {
 	return base, nil
 }
 
-// reloadDictionaries reloads all dictionaries with a source in the current database.
-func (c *Component) reloadDictionaries(ctx context.Context) {
-	rows, err := c.d.ClickHouse.Query(ctx,
+// reloadDictionaries reloads all dictionaries with a source in the database.
+func (c *migrator) reloadDictionaries(ctx context.Context) {
+	rows, err := c.ch.Query(ctx,
 		`SELECT name FROM system.dictionaries WHERE database = currentDatabase() AND source != ''`)
 	if err != nil {
 		c.r.Err(err).Msg("unable to list dictionaries for reload")
@@ -221,10 +249,7 @@ func (c *Component) reloadDictionaries(ctx context.Context) {
 }
 
 // ReloadDictionary will reload the specified dictionnary.
-func (c *Component) ReloadDictionary(ctx context.Context, dictName string) error {
-	if c.d.ClickHouse != nil {
-		return c.d.ClickHouse.ExecOnCluster(ctx,
-			sb.SystemReloadDictionary(sb.Table(dictName).In(c.d.ClickHouse.DatabaseName())))
-	}
-	return nil
+func (c *migrator) ReloadDictionary(ctx context.Context, dictName string) error {
+	return c.ch.ExecOnCluster(ctx,
+		sb.SystemReloadDictionary(sb.Table(dictName).In(c.ch.DatabaseName())))
 }
@@ -93,9 +93,9 @@ func (c *Component) wrapMigrations(ctx context.Context, fns ...func(context.Cont
 	return nil
 }
 
-// table names a table of the database akvorado uses.
-func (c *Component) table(name string) sb.TableName {
-	return sb.Table(name).In(c.d.ClickHouse.DatabaseName())
+// table names a table of the database being migrated.
+func (c *migrator) table(name string) sb.TableName {
+	return sb.Table(name).In(c.ch.DatabaseName())
 }
 
 // statement is a SQL statement we can compare with what ClickHouse kept about
@@ -108,10 +108,10 @@ type statement interface {
 // tableColumn fetches one column of system.tables for the provided table. The
 // column can be any expression. An empty string is returned when the table does
 // not exist.
-func (c *Component) tableColumn(ctx context.Context, table, column string) (string, error) {
-	row := c.d.ClickHouse.QueryRow(ctx,
+func (c *migrator) tableColumn(ctx context.Context, table, column string) (string, error) {
+	row := c.ch.QueryRow(ctx,
 		fmt.Sprintf("SELECT %s FROM system.tables WHERE name = $1 AND database = $2", column),
-		table, c.d.ClickHouse.DatabaseName())
+		table, c.ch.DatabaseName())
 	var existing string
 	if err := row.Scan(&existing); err != nil && err != sql.ErrNoRows {
 		return "", fmt.Errorf("cannot check if table %s already exists: %w", table, err)
@@ -122,7 +122,7 @@ func (c *Component) tableColumn(ctx context.Context, table, column string) (stri
 // tableAlreadyExists compares the table in the database with the statement we
 // would use to create it. `column` is either "create_table_query" or
 // "as_select".
-func (c *Component) tableAlreadyExists(ctx context.Context, table, column string, target statement) (bool, error) {
+func (c *migrator) tableAlreadyExists(ctx context.Context, table, column string, target statement) (bool, error) {
 	existing, err := c.tableColumn(ctx, table, column)
 	if err != nil {
 		return false, err
@@ -130,7 +130,7 @@ func (c *Component) tableAlreadyExists(ctx context.Context, table, column string
 	// ClickHouse adds the database in front of the dictionaries, we do not.
 	for _, function := range []string{"dictGetOrDefault", "dictGet"} {
 		existing = strings.ReplaceAll(existing,
-			fmt.Sprintf("%s('%s.", function, c.d.ClickHouse.DatabaseName()),
+			fmt.Sprintf("%s('%s.", function, c.ch.DatabaseName()),
 			fmt.Sprintf("%s('", function))
 	}
 	existing = sb.StripTableSettings(existing)
@@ -146,7 +146,7 @@ func (c *Component) tableAlreadyExists(ctx context.Context, table, column string
 
 // queryExistingZkPath returns the path in ZooKeeper an existing replicated
 // table uses. A non-existing table gets the default path.
-func (c *Component) queryExistingZkPath(ctx context.Context, table string) string {
+func (c *migrator) queryExistingZkPath(ctx context.Context, table string) string {
 	engine, err := c.tableEngine(ctx, table)
 	if err != nil {
 		// The default path would send the new replica to another ZooKeeper
@@ -156,13 +156,13 @@ func (c *Component) queryExistingZkPath(ctx context.Context, table string) strin
 		return path
 	}
 	return fmt.Sprintf("/clickhouse/tables/shard-{shard}/%s/%s",
-		c.d.ClickHouse.DatabaseName(), table)
+		c.ch.DatabaseName(), table)
 }
 
 // mergeTreeEngine returns a MergeTree engine definition, either plain or using
 // Replicated if we are on a cluster. Zookeeper path from an existing table is kept unchanged.
-func (c *Component) mergeTreeEngine(ctx context.Context, table, variant string, args ...sb.Expr) sb.Engine {
-	if c.d.ClickHouse.ClusterName() != "" {
+func (c *migrator) mergeTreeEngine(ctx context.Context, table, variant string, args ...sb.Expr) sb.Engine {
+	if c.ch.ClusterName() != "" {
 		zkPath := c.queryExistingZkPath(ctx, table)
 
 		return sb.NewEngine(fmt.Sprintf("Replicated%sMergeTree", variant),
@@ -190,7 +190,7 @@ func (c *Component) localTable(table string) string {
 }
 
 // createDictionary creates the provided dictionary.
-func (c *Component) createDictionary(ctx context.Context, name, layout string, attributes []sb.DictionaryAttribute, keys []sb.Expr) error {
+func (c *migrator) createDictionary(ctx context.Context, name, layout string, attributes []sb.DictionaryAttribute, keys []sb.Expr) error {
 	url := fmt.Sprintf("%s/api/v0/orchestrator/clickhouse/%s.csv", c.config.OrchestratorURL, name)
 	source := []sb.SourceParam{
 		sb.Param("URL", sb.String(url)),
@@ -217,14 +217,14 @@ func (c *Component) createDictionary(ctx context.Context, name, layout string, a
 		return errSkipStep
 	}
 	c.r.Info().Msgf("create dictionary %s", name)
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, createQuery.OrReplace()); err != nil {
+	if err := c.ch.ExecOnCluster(ctx, createQuery.OrReplace()); err != nil {
 		return fmt.Errorf("cannot create dictionary %s: %w", name, err)
 	}
 	return nil
 }
 
 // createExportersTable creates the exporters table. This table is always local.
-func (c *Component) createExportersTable(ctx context.Context) error {
+func (c *migrator) createExportersTable(ctx context.Context) error {
 	// Select the columns we need. Codecs and aliases are not carried over.
 	columns := []sb.ColumnDef{}
 	for _, column := range c.d.Schema.Columns() {
@@ -259,7 +259,7 @@ func (c *Component) createExportersTable(ctx context.Context) error {
 	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
 		"allow_suspicious_low_cardinality_types": 1,
 	}))
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, createQuery.OrReplace()); err != nil {
+	if err := c.ch.ExecOnCluster(ctx, createQuery.OrReplace()); err != nil {
 		return fmt.Errorf("cannot create exporters table: %w", err)
 	}
 
@@ -267,7 +267,7 @@ func (c *Component) createExportersTable(ctx context.Context) error {
 }
 
 // createExportersConsumerView creates the exporters view.
-func (c *Component) createExportersConsumerView(ctx context.Context) error {
+func (c *migrator) createExportersConsumerView(ctx context.Context) error {
 	// Select the columns we need. The In/Out pair of an interface column
 	// becomes two rows, picked by the ARRAY JOIN below.
 	items := []sb.Expr{}
@@ -303,10 +303,10 @@ func (c *Component) createExportersConsumerView(ctx context.Context) error {
 
 	// Drop existing table and recreate
 	c.r.Info().Msg("create exporters view")
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, sb.DropTable(sb.Table(name))); err != nil {
+	if err := c.ch.ExecOnCluster(ctx, sb.DropTable(sb.Table(name))); err != nil {
 		return fmt.Errorf("cannot drop existing exporters view: %w", err)
 	}
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, sb.CreateMaterializedView(
+	if err := c.ch.ExecOnCluster(ctx, sb.CreateMaterializedView(
 		sb.Table(name), sb.Table("exporters"), selectQuery)); err != nil {
 		return fmt.Errorf("cannot create exporters view: %w", err)
 	}
@@ -314,8 +314,72 @@ func (c *Component) createExportersConsumerView(ctx context.Context) error {
 	return nil
 }
 
+// createOrUpdateInterfaceCountersTable creates the table storing interface
+// counters or updates its TTL.
+func (c *migrator) createOrUpdateInterfaceCountersTable(ctx context.Context) error {
+	tableName := c.localTable("interface_counters")
+	ttlExpr := sb.Op(sb.Column("TimeReceived"), "+",
+		sb.Function("toIntervalSecond", sb.Uint(uint64(c.config.InterfaceCountersTTL.Seconds()))))
+
+	// Create table if it does not exist
+	if existing, err := c.tableColumn(ctx, tableName, "name"); err != nil {
+		return err
+	} else if existing == "" {
+		columns, err := sb.ParseColumnDefs(`
+TimeReceived DateTime CODEC(DoubleDelta, LZ4),
+ExporterAddress LowCardinality(IPv6),
+ExporterName LowCardinality(String),
+IfIndex UInt32,
+IfName LowCardinality(String),
+IfDescription LowCardinality(String),
+IfSpeed UInt64,
+InOctets UInt64,
+OutOctets UInt64,
+InPackets UInt64,
+OutPackets UInt64,
+InErrors UInt64,
+OutErrors UInt64,
+InDiscards UInt64,
+OutDiscards UInt64`)
+		if err != nil {
+			return fmt.Errorf("cannot build create table statement for %s: %w", tableName, err)
+		}
+		createQuery := sb.CreateTable(sb.Table(tableName)).
+			Columns(columns...).
+			Engine(c.mergeTreeEngine(ctx, tableName, "")).
+			PartitionBy(sb.Function("toYYYYMMDD", sb.Column("TimeReceived"))).
+			OrderBy(sb.Columns("ExporterAddress", "IfIndex", "TimeReceived")...).
+			TTL(ttlExpr)
+		c.r.Info().Msg("create interface counters table")
+		if err := c.ch.ExecOnCluster(ctx, createQuery); err != nil {
+			return fmt.Errorf("cannot create %s: %w", tableName, err)
+		}
+		return nil
+	}
+
+	// Check if we need to update the TTL
+	engine, err := c.tableEngine(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	if engine.TTL().Matches(ttlExpr) {
+		c.r.Info().Msg("interface counters table already exists, skip migration")
+		return errSkipStep
+	}
+	c.r.Info().Msg("updating TTL of interface counters table")
+	err = c.ch.ExecOnCluster(
+		clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+			"materialize_ttl_after_modify": 0,
+		})),
+		sb.AlterTable(sb.Table(tableName)).ModifyTTL(ttlExpr))
+	if err != nil {
+		return fmt.Errorf("cannot modify TTL for table %s: %w", tableName, err)
+	}
+	return nil
+}
+
 // createRawFlowsTable creates the raw flow table
-func (c *Component) createRawFlowsTable(ctx context.Context) error {
+func (c *migrator) createRawFlowsTable(ctx context.Context) error {
 	hash := c.d.Schema.ClickHouseHash()
 	tableName := fmt.Sprintf("flows_%s_raw", hash)
 
@@ -344,21 +408,21 @@ func (c *Component) createRawFlowsTable(ctx context.Context) error {
 		fmt.Sprintf("%s_consumer", tableName),
 		tableName,
 	} {
-		if err := c.d.ClickHouse.ExecOnCluster(ctx, sb.DropTable(sb.Table(table))); err != nil {
+		if err := c.ch.ExecOnCluster(ctx, sb.DropTable(sb.Table(table))); err != nil {
 			return fmt.Errorf("cannot drop %s: %w", table, err)
 		}
 	}
 	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
 		"allow_suspicious_low_cardinality_types": 1,
 	}))
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, createQuery); err != nil {
+	if err := c.ch.ExecOnCluster(ctx, createQuery); err != nil {
 		return fmt.Errorf("cannot create raw flows table: %w", err)
 	}
 
 	return nil
 }
 
-func (c *Component) createRawFlowsConsumerView(ctx context.Context) error {
+func (c *migrator) createRawFlowsConsumerView(ctx context.Context) error {
 	tableName := fmt.Sprintf("flows_%s_raw", c.d.Schema.ClickHouseHash())
 	viewName := fmt.Sprintf("%s_consumer", tableName)
 
@@ -386,10 +450,10 @@ func (c *Component) createRawFlowsConsumerView(ctx context.Context) error {
 
 	// Drop and create
 	c.r.Info().Msg("create raw flows consumer view")
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, sb.DropTable(sb.Table(viewName))); err != nil {
+	if err := c.ch.ExecOnCluster(ctx, sb.DropTable(sb.Table(viewName))); err != nil {
 		return fmt.Errorf("cannot drop table %s: %w", viewName, err)
 	}
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, sb.CreateMaterializedView(
+	if err := c.ch.ExecOnCluster(ctx, sb.CreateMaterializedView(
 		sb.Table(viewName), sb.Table(c.distributedTable("flows")),
 		selectQuery)); err != nil {
 		return fmt.Errorf("cannot create raw flows consumer view: %w", err)
@@ -398,7 +462,7 @@ func (c *Component) createRawFlowsConsumerView(ctx context.Context) error {
 	return nil
 }
 
-func (c *Component) createOrUpdateFlowsTable(ctx context.Context, resolution ResolutionConfiguration) error {
+func (c *migrator) createOrUpdateFlowsTable(ctx context.Context, resolution ResolutionConfiguration) error {
 	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
 		"allow_suspicious_low_cardinality_types": 1,
 	}))
@@ -450,7 +514,7 @@ func (c *Component) createOrUpdateFlowsTable(ctx context.Context, resolution Res
 		for _, setting := range settings {
 			createQuery.Setting(setting.name, setting.expr())
 		}
-		if err := c.d.ClickHouse.ExecOnCluster(ctx, createQuery); err != nil {
+		if err := c.ch.ExecOnCluster(ctx, createQuery); err != nil {
 			return fmt.Errorf("cannot create %s: %w", tableName, err)
 		}
 		if _, err := c.applySkipIndexes(ctx, tableName, resolution.Interval == 0); err != nil {
@@ -468,13 +532,13 @@ func (c *Component) createOrUpdateFlowsTable(ctx context.Context, resolution Res
 		IsPrimaryKey     uint8  `ch:"is_in_primary_key"`
 		DefaultKind      string `ch:"default_kind"`
 	}
-	if err := c.d.ClickHouse.Select(ctx, &existingColumns, `
+	if err := c.ch.Select(ctx, &existingColumns, `
 SELECT name, type, compression_codec, is_in_sorting_key, is_in_primary_key, default_kind
 FROM system.columns
 WHERE database = $1
 AND table = $2
 ORDER BY position ASC
-`, c.d.ClickHouse.DatabaseName(), tableName); err != nil {
+`, c.ch.DatabaseName(), tableName); err != nil {
 		return fmt.Errorf("cannot query columns table: %w", err)
 	}
 
@@ -512,7 +576,7 @@ outer:
 				if (wantedColumn.ClickHouseAlias != "") != (existingColumn.DefaultKind == "ALIAS") {
 					// either the column was an alias and should be none, or the other way around. Either way, we need to recreate.
 					c.r.Debug().Msg(fmt.Sprintf("column %s alias content has changed, recreating. New ALIAS: %s", existingColumn.Name, wantedColumn.ClickHouseAlias))
-					err := c.d.ClickHouse.ExecOnCluster(ctx,
+					err := c.ch.ExecOnCluster(ctx,
 						sb.AlterTable(sb.Table(tableName)).DropColumn(existingColumn.Name))
 					if err != nil {
 						return fmt.Errorf("cannot drop %s from %s to cleanup aliasing: %w",
@@ -528,7 +592,7 @@ outer:
 				}
 				if resolution.Interval > 0 && !wantedColumn.ClickHouseNotSortingKey && existingColumn.IsSortingKey == 0 {
 					// That's something we can fix, but we need to drop it before recreating it
-					err := c.d.ClickHouse.ExecOnCluster(ctx,
+					err := c.ch.ExecOnCluster(ctx,
 						sb.AlterTable(sb.Table(tableName)).DropColumn(existingColumn.Name))
 					if err != nil {
 						return fmt.Errorf("cannot drop %s from %s to fix ordering: %w",
@@ -562,11 +626,11 @@ outer:
 		if resolution.Interval > 0 {
 			// Drop the view
 			viewName := fmt.Sprintf("%s_consumer", tableName)
-			if err := c.d.ClickHouse.ExecOnCluster(ctx, sb.DropTable(sb.Table(viewName))); err != nil {
+			if err := c.ch.ExecOnCluster(ctx, sb.DropTable(sb.Table(viewName))); err != nil {
 				return fmt.Errorf("cannot drop %s: %w", viewName, err)
 			}
 		}
-		if err := c.d.ClickHouse.ExecOnCluster(ctx, alterQuery); err != nil {
+		if err := c.ch.ExecOnCluster(ctx, alterQuery); err != nil {
 			return fmt.Errorf("cannot update table %s: %w", tableName, err)
 		}
 		modified = true
@@ -585,7 +649,7 @@ outer:
 		for _, setting := range settings {
 			alterSettings.ModifySetting(setting.name, setting.expr())
 		}
-		if err := c.d.ClickHouse.ExecOnCluster(ctx, alterSettings); err != nil {
+		if err := c.ch.ExecOnCluster(ctx, alterSettings); err != nil {
 			return fmt.Errorf("cannot modify settings for table %s: %w", tableName, err)
 		}
 		modified = true
@@ -594,7 +658,7 @@ outer:
 	// Check if we need to update the TTL
 	if !engine.TTL().Matches(ttlExpr) {
 		c.r.Info().Msgf("updating TTL of %s with interval %s", tableName, resolution.Interval)
-		err := c.d.ClickHouse.ExecOnCluster(
+		err := c.ch.ExecOnCluster(
 			clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
 				"materialize_ttl_after_modify": 0,
 			})),
@@ -622,7 +686,7 @@ outer:
 // tableEngine returns the engine of a table, with the clauses ClickHouse writes
 // after it, like the TTL and the settings. A table that does not exist gets an
 // empty engine.
-func (c *Component) tableEngine(ctx context.Context, table string) (sb.Engine, error) {
+func (c *migrator) tableEngine(ctx context.Context, table string) (sb.Engine, error) {
 	engineFull, err := c.tableColumn(ctx, table, "engine_full")
 	if err != nil || engineFull == "" {
 		return sb.Engine{}, err
@@ -636,7 +700,7 @@ func (c *Component) tableEngine(ctx context.Context, table string) (sb.Engine, e
 
 // applySkipIndexes reconciles the skip indexes on tableName with the configured
 // schema indexes. It returns true if any change was made.
-func (c *Component) applySkipIndexes(ctx context.Context, tableName string, isMainTable bool) (bool, error) {
+func (c *migrator) applySkipIndexes(ctx context.Context, tableName string, isMainTable bool) (bool, error) {
 	skipIndexes := c.d.Schema.GetSkipIndexes()
 	toDrop := sb.AlterTable(sb.Table(tableName))
 	toAdd := sb.AlterTable(sb.Table(tableName))
@@ -644,9 +708,9 @@ func (c *Component) applySkipIndexes(ctx context.Context, tableName string, isMa
 	// Collect existing skip indexes.
 	existingIndexes := map[string]string{}
 	if err := func() error {
-		rows, err := c.d.ClickHouse.Query(ctx,
+		rows, err := c.ch.Query(ctx,
 			`SELECT name, type_full FROM system.data_skipping_indices WHERE database = $1 AND table = $2 AND startsWith(name, 'idx_')`,
-			c.d.ClickHouse.DatabaseName(), tableName)
+			c.ch.DatabaseName(), tableName)
 		if err != nil {
 			return fmt.Errorf("cannot list skip indices for %s: %w", tableName, err)
 		}
@@ -709,20 +773,20 @@ func (c *Component) applySkipIndexes(ctx context.Context, tableName string, isMa
 	// Batch drops before adds (drop must precede re-add when type changes).
 	if toDrop.Len() > 0 {
 		c.r.Info().Msgf("removing %d skip index(es) from %s", toDrop.Len(), tableName)
-		if err := c.d.ClickHouse.ExecOnCluster(ctx, toDrop); err != nil {
+		if err := c.ch.ExecOnCluster(ctx, toDrop); err != nil {
 			return false, fmt.Errorf("cannot drop skip indexes on %s: %w", tableName, err)
 		}
 	}
 	if toAdd.Len() > 0 {
 		c.r.Info().Msgf("adding %d skip index(es) to %s", toAdd.Len(), tableName)
-		if err := c.d.ClickHouse.ExecOnCluster(ctx, toAdd); err != nil {
+		if err := c.ch.ExecOnCluster(ctx, toAdd); err != nil {
 			return false, fmt.Errorf("cannot add skip indexes on %s: %w", tableName, err)
 		}
 	}
 	return true, nil
 }
 
-func (c *Component) createFlowsConsumerView(ctx context.Context, resolution ResolutionConfiguration) error {
+func (c *migrator) createFlowsConsumerView(ctx context.Context, resolution ResolutionConfiguration) error {
 	if resolution.Interval == 0 {
 		// The consumer for the main table is created elsewhere.
 		return errSkipStep
@@ -755,10 +819,10 @@ func (c *Component) createFlowsConsumerView(ctx context.Context, resolution Reso
 
 	// Drop and create
 	c.r.Info().Msgf("create %s", viewName)
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, sb.DropTable(sb.Table(viewName))); err != nil {
+	if err := c.ch.ExecOnCluster(ctx, sb.DropTable(sb.Table(viewName))); err != nil {
 		return fmt.Errorf("cannot drop table %s: %w", viewName, err)
 	}
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, sb.CreateMaterializedView(
+	if err := c.ch.ExecOnCluster(ctx, sb.CreateMaterializedView(
 		sb.Table(viewName), sb.Table(c.localTable(tableName)),
 		selectQuery)); err != nil {
 		return fmt.Errorf("cannot create %s: %w", viewName, err)
@@ -769,7 +833,7 @@ func (c *Component) createFlowsConsumerView(ctx context.Context, resolution Reso
 // createDistributedTable creates the distributed version of an existing table.
 // If the table already exists and does not match the definition, it is
 // replaced.
-func (c *Component) createDistributedTable(ctx context.Context, source string) error {
+func (c *migrator) createDistributedTable(ctx context.Context, source string) error {
 	if c.localTable(source) == c.distributedTable(source) {
 		return errSkipStep
 	}
@@ -781,12 +845,12 @@ func (c *Component) createDistributedTable(ctx context.Context, source string) e
 		DefaultKind       string `ch:"default_kind"`
 		DefaultExpression string `ch:"default_expression"`
 	}
-	if err := c.d.ClickHouse.Select(ctx, &existingColumns, `
+	if err := c.ch.Select(ctx, &existingColumns, `
 SELECT name, type, compression_codec, default_kind, default_expression
 FROM system.columns
 WHERE database = $1 AND table = $2
 ORDER BY position ASC
-`, c.d.ClickHouse.DatabaseName(), c.localTable(source)); err != nil {
+`, c.ch.DatabaseName(), c.localTable(source)); err != nil {
 		return fmt.Errorf("cannot query columns table: %w", err)
 	}
 	// The columns are copied from the local table as ClickHouse writes them.
@@ -810,8 +874,8 @@ ORDER BY position ASC
 	createQuery := sb.CreateTable(c.table(c.distributedTable(source))).
 		Columns(columns...).
 		Engine(sb.NewEngine("Distributed",
-			sb.String(c.d.ClickHouse.ClusterName()),
-			sb.String(c.d.ClickHouse.DatabaseName()),
+			sb.String(c.ch.ClusterName()),
+			sb.String(c.ch.DatabaseName()),
 			sb.String(c.localTable(source)),
 			sb.Function("rand")))
 
@@ -828,7 +892,7 @@ ORDER BY position ASC
 	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
 		"allow_suspicious_low_cardinality_types": 1,
 	}))
-	if err := c.d.ClickHouse.ExecOnCluster(ctx, createQuery.OrReplace()); err != nil {
+	if err := c.ch.ExecOnCluster(ctx, createQuery.OrReplace()); err != nil {
 		return fmt.Errorf("cannot create %s: %w", c.distributedTable(source), err)
 	}
 	return nil
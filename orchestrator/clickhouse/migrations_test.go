@@ -391,6 +391,8 @@ WHERE database=currentDatabase() AND table NOT LIKE '.%'`)
 				fmt.Sprintf("flows_%s_raw_consumer", hash),
 				"flows_local",
 				schema.DictionaryICMP,
+				"interface_counters",
+				"interface_counters_local",
 				schema.DictionaryProtocols,
 				schema.DictionaryTCP,
 				schema.DictionaryUDP,
@@ -5,6 +5,8 @@ package clickhouse
 
 import (
 	"time"
+
+	"akvorado/common/schema"
 )
 
 // Configuration describes the configuration for the ClickHouse exporter.
@@ -15,10 +17,22 @@ type Configuration struct {
 	MaximumBatchSize uint `validate:"min=1"`
 	// MaximumWaitTime is the maximum number of seconds to wait before sending the current batch.
 	MaximumWaitTime time.Duration `validate:"min=100ms"`
+	// Aggregation defines how to merge flows before sending them to ClickHouse.
+	Aggregation AggregationConfiguration
 	// minimumBatchSize the mininum number of rows before declaring underloaded and using async insert
 	minimumBatchSize uint
 }
 
+// AggregationConfiguration describes how to merge flows sharing the same
+// columns before sending them to ClickHouse.
+type AggregationConfiguration struct {
+	// Window is the time window to merge flows in. 0 disables aggregation.
+	Window time.Duration `validate:"eq=0|min=1s"`
+	// IgnoredColumns are the columns reset before merging flows, so they do
+	// not prevent aggregation.
+	IgnoredColumns []schema.ColumnKey
+}
+
 const minimumBatchSizeDivider = 10
 
 // DefaultConfiguration represents the default configuration for the ClickHouse exporter.
@@ -27,5 +41,8 @@ func DefaultConfiguration() Configuration {
 		GracePeriod:      time.Minute,
 		MaximumBatchSize: 50_000,
 		MaximumWaitTime:  5 * time.Second,
+		Aggregation: AggregationConfiguration{
+			IgnoredColumns: []schema.ColumnKey{},
+		},
 	}
 }
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"net/netip"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// InterfaceCountersTable is the table receiving interface counters.
+const InterfaceCountersTable = "interface_counters"
+
+// InterfaceCounters are the counters of an interface at a given time, as
+// stored in ClickHouse. Counters are cumulative.
+type InterfaceCounters struct {
+	TimeReceived    uint32
+	ExporterAddress netip.Addr
+	ExporterName    string
+	IfIndex         uint32
+	IfName          string
+	IfDescription   string
+	IfSpeed         uint64 // in Mbps
+	InOctets        uint64
+	OutOctets       uint64
+	InPackets       uint64
+	OutPackets      uint64
+	InErrors        uint64
+	OutErrors       uint64
+	InDiscards      uint64
+	OutDiscards     uint64
+
+	// Tenant selects the database receiving the counters. It is not stored.
+	Tenant string
+}
+
+// countersBatch is a batch of interface counters to be sent to ClickHouse.
+type countersBatch struct {
+	timeReceived    proto.ColDateTime
+	exporterAddress *proto.ColLowCardinality[proto.IPv6]
+	exporterName    *proto.ColLowCardinality[string]
+	ifIndex         proto.ColUInt32
+	ifName          *proto.ColLowCardinality[string]
+	ifDescription   *proto.ColLowCardinality[string]
+	ifSpeed         proto.ColUInt64
+	inOctets        proto.ColUInt64
+	outOctets       proto.ColUInt64
+	inPackets       proto.ColUInt64
+	outPackets      proto.ColUInt64
+	inErrors        proto.ColUInt64
+	outErrors       proto.ColUInt64
+	inDiscards      proto.ColUInt64
+	outDiscards     proto.ColUInt64
+
+	input proto.Input
+}
+
+// newCountersBatch creates a new empty batch of interface counters.
+func newCountersBatch() *countersBatch {
+	b := &countersBatch{
+		exporterAddress: new(proto.ColIPv6).LowCardinality(),
+		exporterName:    new(proto.ColStr).LowCardinality(),
+		ifName:          new(proto.ColStr).LowCardinality(),
+		ifDescription:   new(proto.ColStr).LowCardinality(),
+	}
+	b.input = proto.Input{
+		{Name: "TimeReceived", Data: &b.timeReceived},
+		{Name: "ExporterAddress", Data: b.exporterAddress},
+		{Name: "ExporterName", Data: b.exporterName},
+		{Name: "IfIndex", Data: &b.ifIndex},
+		{Name: "IfName", Data: b.ifName},
+		{Name: "IfDescription", Data: b.ifDescription},
+		{Name: "IfSpeed", Data: &b.ifSpeed},
+		{Name: "InOctets", Data: &b.inOctets},
+		{Name: "OutOctets", Data: &b.outOctets},
+		{Name: "InPackets", Data: &b.inPackets},
+		{Name: "OutPackets", Data: &b.outPackets},
+		{Name: "InErrors", Data: &b.inErrors},
+		{Name: "OutErrors", Data: &b.outErrors},
+		{Name: "InDiscards", Data: &b.inDiscards},
+		{Name: "OutDiscards", Data: &b.outDiscards},
+	}
+	return b
+}
+
+// append adds interface counters to the batch.
+func (b *countersBatch) append(counters *InterfaceCounters) {
+	b.timeReceived.AppendRaw(proto.DateTime(counters.TimeReceived))
+	b.exporterAddress.Append(proto.ToIPv6(counters.ExporterAddress))
+	b.exporterName.Append(counters.ExporterName)
+	b.ifIndex.Append(counters.IfIndex)
+	b.ifName.Append(counters.IfName)
+	b.ifDescription.Append(counters.IfDescription)
+	b.ifSpeed.Append(counters.IfSpeed)
+	b.inOctets.Append(counters.InOctets)
+	b.outOctets.Append(counters.OutOctets)
+	b.inPackets.Append(counters.InPackets)
+	b.outPackets.Append(counters.OutPackets)
+	b.inErrors.Append(counters.InErrors)
+	b.outErrors.Append(counters.OutErrors)
+	b.inDiscards.Append(counters.InDiscards)
+	b.outDiscards.Append(counters.OutDiscards)
+}
+
+// rows returns the number of interface counters in the batch.
+func (b *countersBatch) rows() int {
+	return b.timeReceived.Rows()
+}
+
+// reset empties the batch.
+func (b *countersBatch) reset() {
+	b.input.Reset()
+}
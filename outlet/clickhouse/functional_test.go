@@ -6,6 +6,7 @@ package clickhouse_test
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"testing"
 	"time"
 
@@ -111,7 +112,7 @@ func TestInsert(t *testing.T) {
 		}
 
 		// Check metrics
-		gotMetrics := r.GetMetrics("akvorado_outlet_clickhouse_", "-insert_time", "-wait_time")
+		gotMetrics := r.GetMetrics("akvorado_outlet_clickhouse_", "-insert_time", "-wait_time", "-interface_counters", "-aggregated_flows")
 		var expectedMetrics map[string]string
 		if i < 11 {
 			expectedMetrics = map[string]string{
@@ -183,6 +184,326 @@ func TestInsert(t *testing.T) {
 	}
 }
 
+func TestInsertTenants(t *testing.T) {
+	server, database := clickhousedb.SetupClickHouseDatabase(t)
+	_, tenantDatabase := clickhousedb.SetupClickHouseDatabase(t)
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	bf := sch.NewFlowMessage()
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	ctx = clickhousego.Context(ctx, clickhousego.WithSettings(clickhousego.Settings{
+		"allow_suspicious_low_cardinality_types": 1,
+	}))
+
+	dbConf := clickhousedb.DefaultConfiguration()
+	dbConf.Servers = []string{server}
+	dbConf.Database = database
+	dbConf.Tenants = map[string]string{"beta": tenantDatabase}
+	dbConf.DialTimeout = 100 * time.Millisecond
+	chdb, err := clickhousedb.New(r, dbConf, clickhousedb.Dependencies{
+		Daemon: daemon.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhousedb.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, chdb)
+	ch, err := clickhouse.New(r, clickhouse.DefaultConfiguration(), clickhouse.Dependencies{
+		ClickHouse: chdb,
+		Schema:     sch,
+	})
+	if err != nil {
+		t.Fatalf("clickhouse.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, ch)
+
+	tableName := fmt.Sprintf("flows_%s_raw", sch.ClickHouseHash())
+	for _, db := range []string{database, tenantDatabase} {
+		err = chdb.Exec(ctx, fmt.Sprintf("CREATE OR REPLACE TABLE %s.%s (%s) ENGINE = Memory", db, tableName,
+			sch.ClickHouseCreateTable(
+				schema.ClickHouseSkipGeneratedColumns,
+				schema.ClickHouseSkipAliasedColumns)))
+		if err != nil {
+			t.Fatalf("chdb.Exec() error:\n%+v", err)
+		}
+	}
+
+	// Flows from unknown tenants go to the main database
+	w := ch.NewWorker(1, bf)
+	for i, tenant := range []string{"alpha", "beta", "", "beta"} {
+		bf.TimeReceived = uint32(100 + i)
+		bf.AppendString(schema.ColumnExporterTenant, tenant)
+		bf.Finalize()
+	}
+	w.Flush(ctx)
+
+	type result struct {
+		TimeReceived   time.Time
+		ExporterTenant string
+	}
+	for db, expected := range map[string][]result{
+		database: {
+			{time.Unix(100, 0).UTC(), "alpha"},
+			{time.Unix(102, 0).UTC(), ""},
+		},
+		tenantDatabase: {
+			{time.Unix(101, 0).UTC(), "beta"},
+			{time.Unix(103, 0).UTC(), "beta"},
+		},
+	} {
+		var got []result
+		if err := chdb.Select(ctx, &got, fmt.Sprintf(
+			"SELECT TimeReceived, ExporterTenant FROM %s.%s ORDER BY TimeReceived",
+			db, tableName)); err != nil {
+			t.Fatalf("chdb.Select() error:\n%+v", err)
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Errorf("chdb.Select(%s) (-got, +want):\n%s", db, diff)
+		}
+	}
+}
+
+func TestInsertInterfaceCounters(t *testing.T) {
+	server, database := clickhousedb.SetupClickHouseDatabase(t)
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	dbConf := clickhousedb.DefaultConfiguration()
+	dbConf.Servers = []string{server}
+	dbConf.Database = database
+	dbConf.DialTimeout = 100 * time.Millisecond
+	chdb, err := clickhousedb.New(r, dbConf, clickhousedb.Dependencies{
+		Daemon: daemon.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhousedb.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, chdb)
+	conf := clickhouse.DefaultConfiguration()
+	conf.MaximumBatchSize = 2
+	conf.MaximumWaitTime = time.Minute
+	ch, err := clickhouse.New(r, conf, clickhouse.Dependencies{
+		ClickHouse: chdb,
+		Schema:     sch,
+	})
+	if err != nil {
+		t.Fatalf("clickhouse.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, ch)
+
+	err = chdb.Exec(ctx, fmt.Sprintf(`
+CREATE OR REPLACE TABLE %s (
+ TimeReceived DateTime,
+ ExporterAddress LowCardinality(IPv6),
+ ExporterName LowCardinality(String),
+ IfIndex UInt32,
+ IfName LowCardinality(String),
+ IfDescription LowCardinality(String),
+ IfSpeed UInt64,
+ InOctets UInt64, OutOctets UInt64,
+ InPackets UInt64, OutPackets UInt64,
+ InErrors UInt64, OutErrors UInt64,
+ InDiscards UInt64, OutDiscards UInt64
+) ENGINE = Memory`, clickhouse.InterfaceCountersTable))
+	if err != nil {
+		t.Fatalf("chdb.Exec() error:\n%+v", err)
+	}
+
+	type result struct {
+		IfIndex  uint32
+		IfName   string
+		InOctets uint64
+	}
+	check := func(expected []result) {
+		t.Helper()
+		var got []result
+		if err := chdb.Select(ctx, &got, fmt.Sprintf(
+			"SELECT IfIndex, IfName, InOctets FROM %s ORDER BY IfIndex",
+			clickhouse.InterfaceCountersTable)); err != nil {
+			t.Fatalf("chdb.Select() error:\n%+v", err)
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Fatalf("chdb.Select() (-got, +want):\n%s", diff)
+		}
+	}
+
+	// Counters are sent once we have a full batch or on flush.
+	w := ch.NewWorker(1, sch.NewFlowMessage())
+	expected := []result{}
+	for i := range 3 {
+		w.AppendInterfaceCounters(ctx, &clickhouse.InterfaceCounters{
+			TimeReceived:    uint32(100 + i),
+			ExporterAddress: helpers.AddrTo6(netip.MustParseAddr("192.0.2.1")),
+			IfIndex:         uint32(10 + i),
+			IfName:          fmt.Sprintf("Gi0/0/%d", 10+i),
+			InOctets:        uint64(1000 * i),
+		})
+		expected = append(expected, result{uint32(10 + i), fmt.Sprintf("Gi0/0/%d", 10+i), uint64(1000 * i)})
+	}
+	check(expected[:2])
+	w.Flush(ctx)
+	check(expected)
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_clickhouse_", "interface_counters_total")
+	expectedMetrics := map[string]string{
+		`interface_counters_total`: "3",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestInsertInterfaceCountersTenants(t *testing.T) {
+	server, database := clickhousedb.SetupClickHouseDatabase(t)
+	_, tenantDatabase := clickhousedb.SetupClickHouseDatabase(t)
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	dbConf := clickhousedb.DefaultConfiguration()
+	dbConf.Servers = []string{server}
+	dbConf.Database = database
+	dbConf.Tenants = map[string]string{"beta": tenantDatabase}
+	dbConf.DialTimeout = 100 * time.Millisecond
+	chdb, err := clickhousedb.New(r, dbConf, clickhousedb.Dependencies{
+		Daemon: daemon.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhousedb.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, chdb)
+	ch, err := clickhouse.New(r, clickhouse.DefaultConfiguration(), clickhouse.Dependencies{
+		ClickHouse: chdb,
+		Schema:     sch,
+	})
+	if err != nil {
+		t.Fatalf("clickhouse.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, ch)
+
+	for _, db := range []string{database, tenantDatabase} {
+		err = chdb.Exec(ctx, fmt.Sprintf(`
+CREATE OR REPLACE TABLE %s.%s (
+ TimeReceived DateTime,
+ ExporterAddress LowCardinality(IPv6),
+ ExporterName LowCardinality(String),
+ IfIndex UInt32,
+ IfName LowCardinality(String),
+ IfDescription LowCardinality(String),
+ IfSpeed UInt64,
+ InOctets UInt64, OutOctets UInt64,
+ InPackets UInt64, OutPackets UInt64,
+ InErrors UInt64, OutErrors UInt64,
+ InDiscards UInt64, OutDiscards UInt64
+) ENGINE = Memory`, db, clickhouse.InterfaceCountersTable))
+		if err != nil {
+			t.Fatalf("chdb.Exec() error:\n%+v", err)
+		}
+	}
+
+	// Counters from unknown tenants go to the main database
+	w := ch.NewWorker(1, sch.NewFlowMessage())
+	for i, tenant := range []string{"alpha", "beta", "", "beta"} {
+		w.AppendInterfaceCounters(ctx, &clickhouse.InterfaceCounters{
+			TimeReceived:    uint32(100 + i),
+			ExporterAddress: helpers.AddrTo6(netip.MustParseAddr("192.0.2.1")),
+			IfIndex:         uint32(10 + i),
+			Tenant:          tenant,
+		})
+	}
+	w.Flush(ctx)
+
+	type result struct {
+		IfIndex uint32
+	}
+	for db, expected := range map[string][]result{
+		database:       {{10}, {12}},
+		tenantDatabase: {{11}, {13}},
+	} {
+		var got []result
+		if err := chdb.Select(ctx, &got, fmt.Sprintf(
+			"SELECT IfIndex FROM %s.%s ORDER BY IfIndex",
+			db, clickhouse.InterfaceCountersTable)); err != nil {
+			t.Fatalf("chdb.Select() error:\n%+v", err)
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Errorf("chdb.Select(%s) (-got, +want):\n%s", db, diff)
+		}
+	}
+}
+
+func TestInsertInterfaceCountersWhenIdle(t *testing.T) {
+	server, database := clickhousedb.SetupClickHouseDatabase(t)
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	dbConf := clickhousedb.DefaultConfiguration()
+	dbConf.Servers = []string{server}
+	dbConf.Database = database
+	dbConf.DialTimeout = 100 * time.Millisecond
+	chdb, err := clickhousedb.New(r, dbConf, clickhousedb.Dependencies{
+		Daemon: daemon.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("clickhousedb.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, chdb)
+	config := clickhouse.DefaultConfiguration()
+	config.MaximumWaitTime = 100 * time.Millisecond
+	ch, err := clickhouse.New(r, config, clickhouse.Dependencies{
+		ClickHouse: chdb,
+		Schema:     sch,
+	})
+	if err != nil {
+		t.Fatalf("clickhouse.New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, ch)
+
+	err = chdb.Exec(ctx, fmt.Sprintf(`
+CREATE OR REPLACE TABLE %s (
+ TimeReceived DateTime,
+ ExporterAddress LowCardinality(IPv6),
+ ExporterName LowCardinality(String),
+ IfIndex UInt32,
+ IfName LowCardinality(String),
+ IfDescription LowCardinality(String),
+ IfSpeed UInt64,
+ InOctets UInt64, OutOctets UInt64,
+ InPackets UInt64, OutPackets UInt64,
+ InErrors UInt64, OutErrors UInt64,
+ InDiscards UInt64, OutDiscards UInt64
+) ENGINE = Memory`, clickhouse.InterfaceCountersTable))
+	if err != nil {
+		t.Fatalf("chdb.Exec() error:\n%+v", err)
+	}
+
+	// Without Flush(), the counters are sent after the maximum wait time
+	w := ch.NewWorker(1, sch.NewFlowMessage())
+	w.AppendInterfaceCounters(ctx, &clickhouse.InterfaceCounters{
+		TimeReceived:    100,
+		ExporterAddress: helpers.AddrTo6(netip.MustParseAddr("192.0.2.1")),
+		IfIndex:         10,
+	})
+	time.Sleep(time.Second)
+
+	type result struct {
+		IfIndex uint32
+	}
+	var got []result
+	if err := chdb.Select(ctx, &got, fmt.Sprintf("SELECT IfIndex FROM %s",
+		clickhouse.InterfaceCountersTable)); err != nil {
+		t.Fatalf("chdb.Select() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got, []result{{10}}); diff != "" {
+		t.Errorf("chdb.Select() (-got, +want):\n%s", diff)
+	}
+}
+
 func TestMultipleServers(t *testing.T) {
 	servers := []string{
 		helpers.CheckExternalService(t, "ClickHouse", []string{"clickhouse:9000", "127.0.0.1:9000"}),
@@ -7,6 +7,8 @@ import "akvorado/common/reporter"
 
 type metrics struct {
 	flows       reporter.Summary
+	counters    reporter.Counter
+	aggregated  reporter.Counter
 	waitTime    reporter.Histogram
 	insertTime  reporter.Histogram
 	overloaded  reporter.Counter
@@ -23,6 +25,18 @@ func (c *realComponent) initMetrics() {
 			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
 		},
 	)
+	c.metrics.counters = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "interface_counters_total",
+			Help: "Number of interface counters sent to ClickHouse.",
+		},
+	)
+	c.metrics.aggregated = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "aggregated_flows_total",
+			Help: "Number of flows merged with a previous flow of the batch.",
+		},
+	)
 	c.metrics.waitTime = c.r.Histogram(
 		reporter.HistogramOpts{
 			Name: "wait_time_seconds",
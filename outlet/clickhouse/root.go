@@ -7,6 +7,9 @@
 package clickhouse
 
 import (
+	"fmt"
+	"slices"
+
 	"akvorado/common/clickhousedb"
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
@@ -35,6 +38,17 @@ type Dependencies struct {
 // New creates a new clickhouse component.
 func New(r *reporter.Reporter, configuration Configuration, dependencies Dependencies) (Component, error) {
 	configuration.minimumBatchSize = configuration.MaximumBatchSize / minimumBatchSizeDivider
+	for _, key := range configuration.Aggregation.IgnoredColumns {
+		if slices.Contains([]schema.ColumnKey{schema.ColumnTimeReceived, schema.ColumnBytes, schema.ColumnPackets}, key) {
+			return nil, fmt.Errorf("column %q cannot be ignored for aggregation", key)
+		}
+	}
+	// Flows are only merged inside a batch: a larger window would not be
+	// covered.
+	if configuration.Aggregation.Window > configuration.MaximumWaitTime {
+		return nil, fmt.Errorf("aggregation window (%s) cannot be larger than maximum wait time (%s)",
+			configuration.Aggregation.Window, configuration.MaximumWaitTime)
+	}
 	c := realComponent{
 		r:      r,
 		d:      &dependencies,
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"akvorado/common/helpers"
+	"akvorado/common/reporter"
 	"akvorado/common/schema"
 	"akvorado/outlet/clickhouse"
 )
@@ -52,3 +54,32 @@ func TestMock(t *testing.T) {
 		messagesMutex.Unlock()
 	}
 }
+
+func TestNewAggregation(t *testing.T) {
+	cases := []struct {
+		Description string
+		Window      time.Duration
+		WaitTime    time.Duration
+		Error       bool
+	}{
+		{"disabled", 0, time.Second, false},
+		{"window smaller than wait time", 5 * time.Second, 10 * time.Second, false},
+		{"window equal to wait time", 5 * time.Second, 5 * time.Second, false},
+		{"window larger than wait time", 10 * time.Second, 5 * time.Second, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			configuration := clickhouse.DefaultConfiguration()
+			configuration.Aggregation.Window = tc.Window
+			configuration.MaximumWaitTime = tc.WaitTime
+			_, err := clickhouse.New(reporter.NewMock(t), configuration, clickhouse.Dependencies{
+				Schema: schema.NewMock(t),
+			})
+			if err != nil && !tc.Error {
+				t.Fatalf("New() error:\n%+v", err)
+			} else if err == nil && tc.Error {
+				t.Fatal("New() did not error")
+			}
+		})
+	}
+}
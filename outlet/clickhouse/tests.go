@@ -14,7 +14,8 @@ import (
 
 // mockComponent is a mock version of the ClickHouse exporter.
 type mockComponent struct {
-	callback func(*schema.FlowMessage)
+	callback         func(*schema.FlowMessage)
+	countersCallback func(*InterfaceCounters)
 }
 
 // NewMock creates a new mock exporter that calls the provided callback function with each received flow message.
@@ -24,6 +25,15 @@ func NewMock(_ *testing.T, callback func(*schema.FlowMessage)) Component {
 	}
 }
 
+// NewMockWithCounters creates a new mock exporter that also calls the provided
+// callback function with each received set of interface counters.
+func NewMockWithCounters(_ *testing.T, callback func(*schema.FlowMessage), countersCallback func(*InterfaceCounters)) Component {
+	return &mockComponent{
+		callback:         callback,
+		countersCallback: countersCallback,
+	}
+}
+
 // NewWorker creates a new mock worker.
 func (c *mockComponent) NewWorker(_ int, bf *schema.FlowMessage) Worker {
 	return &mockWorker{
@@ -44,6 +54,15 @@ func (w *mockWorker) FinalizeAndSend(ctx context.Context) WorkerStatus {
 	return WorkerStatusIdle
 }
 
+// AppendInterfaceCounters will record the interface counters for testing
+// purpose.
+func (w *mockWorker) AppendInterfaceCounters(_ context.Context, counters *InterfaceCounters) {
+	if w.c.countersCallback != nil {
+		clone := *counters
+		w.c.countersCallback(&clone)
+	}
+}
+
 // Send will record the sent flows for testing purpose.
 func (w *mockWorker) Flush(_ context.Context) {
 	clone := *w.bf
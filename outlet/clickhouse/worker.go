@@ -8,19 +8,24 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
 	"github.com/cenkalti/backoff/v7"
 
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
+	"akvorado/common/sqlbuilder"
 )
 
 // Worker represents a worker sending to ClickHouse. It is synchronous (no
-// goroutines) and most functions are bound to a context.
+// goroutines) and most functions are bound to a context. The only exception is
+// a timer flushing interface counters when no more data is received.
 type Worker interface {
 	FinalizeAndSend(context.Context) WorkerStatus
+	AppendInterfaceCounters(context.Context, *InterfaceCounters)
 	Flush(context.Context)
 }
 
@@ -45,22 +50,40 @@ type realWorker struct {
 	last   time.Time
 	logger reporter.Logger
 
+	pending []tenantInput // flows of tenants which could not be sent
+
+	// lock protects the counters and the connection against the timer
+	// flushing the counters
+	lock          sync.Mutex
+	counters      map[string]*countersBatch
+	countersLast  time.Time
+	countersTimer *time.Timer
+
 	conn          *ch.Client
 	servers       []string
 	options       ch.Options
 	asyncSettings []ch.Setting
+	tenants       bool // whether some tenants have a dedicated database
+}
+
+// tenantInput is a batch of flows for a tenant.
+type tenantInput struct {
+	tenant string
+	input  proto.Input
 }
 
 // NewWorker creates a new worker to push data to ClickHouse.
 func (c *realComponent) NewWorker(i int, bf *schema.FlowMessage) Worker {
 	opts, servers := c.d.ClickHouse.ChGoOptions()
 	w := realWorker{
-		c:      c,
-		bf:     bf,
-		logger: c.r.With().Int("worker", i).Logger(),
+		c:        c,
+		bf:       bf,
+		counters: map[string]*countersBatch{},
+		logger:   c.r.With().Int("worker", i).Logger(),
 
 		servers: servers,
 		options: opts,
+		tenants: len(c.d.ClickHouse.Tenants()) > 0,
 		asyncSettings: []ch.Setting{
 			{
 				Key:       "async_insert",
@@ -78,6 +101,9 @@ func (c *realComponent) NewWorker(i int, bf *schema.FlowMessage) Worker {
 			},
 		},
 	}
+	if c.config.Aggregation.Window > 0 {
+		bf.EnableAggregation(c.config.Aggregation.Window, c.config.Aggregation.IgnoredColumns)
+	}
 	return &w
 }
 
@@ -87,9 +113,13 @@ func (c *realComponent) NewWorker(i int, bf *schema.FlowMessage) Worker {
 // tips on the insert strategy. Notably, we switch to async insert when the
 // batch size is too small.
 func (w *realWorker) FinalizeAndSend(ctx context.Context) WorkerStatus {
+	previousBatchSize := w.bf.FlowCount()
 	w.bf.Finalize()
 	now := time.Now()
 	batchSize := w.bf.FlowCount()
+	if batchSize == previousBatchSize {
+		w.c.metrics.aggregated.Inc()
+	}
 	waitTime := now.Sub(w.last)
 	if batchSize >= int(w.c.config.MaximumBatchSize) || waitTime >= w.c.config.MaximumWaitTime {
 		// Record wait time since last send
@@ -112,23 +142,202 @@ func (w *realWorker) FinalizeAndSend(ctx context.Context) WorkerStatus {
 	return WorkerStatusIdle
 }
 
+// AppendInterfaceCounters queues interface counters. They are sent to
+// ClickHouse once we have a full batch or exceeded the maximum wait time, as
+// well as each time the flows are sent.
+func (w *realWorker) AppendInterfaceCounters(ctx context.Context, counters *InterfaceCounters) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	tenant := ""
+	if w.tenants {
+		tenant = counters.Tenant
+	}
+	batch, ok := w.counters[tenant]
+	if !ok {
+		batch = newCountersBatch()
+		w.counters[tenant] = batch
+	}
+	batch.append(counters)
+	if w.countersLast.IsZero() {
+		w.countersLast = time.Now()
+	}
+	if uint(batch.rows()) >= w.c.config.MaximumBatchSize || time.Since(w.countersLast) >= w.c.config.MaximumWaitTime {
+		w.flushCounters(ctx)
+		return
+	}
+	w.armCountersTimer()
+}
+
+// armCountersTimer ensures the pending interface counters are sent after the
+// maximum wait time, even when no more data is received. The lock should be
+// held.
+func (w *realWorker) armCountersTimer() {
+	if w.countersTimer != nil {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(w.c.config.MaximumWaitTime, func() {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		if w.countersTimer != timer {
+			// Counters were flushed in the meantime
+			return
+		}
+		w.countersTimer = nil
+		ctx, cancel := context.WithTimeout(context.Background(), w.c.config.MaximumWaitTime)
+		defer cancel()
+		w.flushCounters(ctx)
+		if w.countersRows() > 0 {
+			w.armCountersTimer()
+		}
+	})
+	w.countersTimer = timer
+}
+
+// countersRows returns the number of pending interface counters. The lock
+// should be held.
+func (w *realWorker) countersRows() int {
+	rows := 0
+	for _, batch := range w.counters {
+		rows += batch.rows()
+	}
+	return rows
+}
+
 // Flush sends remaining data to ClickHouse without an additional condition. It
 // should be called before shutting down to flush remaining data. Otherwise,
 // FinalizeAndSend() should be used instead.
 func (w *realWorker) Flush(ctx context.Context) {
-	var useAsync bool
-	if w.bf.FlowCount() == 0 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.flushFlows(ctx)
+	w.flushCounters(ctx)
+}
+
+// flushFlows sends the current batch of flows to ClickHouse.
+func (w *realWorker) flushFlows(ctx context.Context) {
+	if w.bf.FlowCount() == 0 && len(w.pending) == 0 {
 		return
 	}
 	// Async mode if have not a big batch size
 	var settings []ch.Setting
 	if uint(w.bf.FlowCount()) <= w.c.config.minimumBatchSize {
-		useAsync = true
 		settings = w.asyncSettings
 	}
 
-	// We try to send as long as possible. The only exit condition is an
-	// expiration of the context.
+	// Send the flows of the tenants which failed previously. Their rows are
+	// not in the current batch anymore.
+	pending := w.pending
+	w.pending = nil
+	for _, ti := range pending {
+		if !w.sendFlows(ctx, ti, settings) {
+			w.pending = append(w.pending, ti)
+		}
+	}
+	if w.bf.FlowCount() == 0 {
+		return
+	}
+
+	// Send to ClickHouse in flows_XXXXX_raw, in the database of each tenant.
+	// When the batch is split, each tenant gets a copy of its rows and the
+	// ones failing are kept aside to not send the others twice. Otherwise,
+	// the input is the batch itself and we keep the batch on failure.
+	inputs := map[string]proto.Input{"": w.bf.ClickHouseProtoInput()}
+	if w.tenants {
+		inputs = w.bf.ClickHouseProtoInputsBy(schema.ColumnExporterTenant)
+	}
+	for tenant, input := range inputs {
+		ti := tenantInput{tenant: tenant, input: input}
+		if w.sendFlows(ctx, ti, settings) {
+			continue
+		}
+		if len(inputs) == 1 {
+			// The context expired, keep the batch
+			return
+		}
+		w.pending = append(w.pending, ti)
+	}
+	w.c.metrics.flows.Observe(float64(w.bf.FlowCount()))
+
+	// Clear batch
+	w.bf.Clear()
+}
+
+// sendFlows sends the flows of a tenant to ClickHouse. It returns false if the
+// context expired before they could be sent.
+func (w *realWorker) sendFlows(ctx context.Context, ti tenantInput, settings []ch.Setting) bool {
+	table := fmt.Sprintf("flows_%s_raw", w.c.d.Schema.ClickHouseHash())
+	body := ti.input.Into(table)
+	if tc, ok := w.c.d.ClickHouse.Tenant(ti.tenant); ok && tc.DatabaseName() != w.options.Database {
+		body = fmt.Sprintf("INSERT INTO %s %s VALUES",
+			sqlbuilder.Table(table).In(tc.DatabaseName()), ti.input.Columns())
+	}
+	sent := false
+	w.retry(ctx, func(chCtx context.Context) error {
+		start := time.Now()
+		if err := w.conn.Do(chCtx, ch.Query{
+			Body:     body,
+			Input:    ti.input,
+			Settings: settings,
+		}); err != nil {
+			w.logger.Err(err).
+				Int("flows", ti.input[0].Data.Rows()).
+				Str("tenant", ti.tenant).
+				Bool("async", settings != nil).
+				Msg("cannot send batch to ClickHouse")
+			w.c.metrics.errors.WithLabelValues("send").Inc()
+			return err
+		}
+		pushDuration := time.Since(start)
+		w.c.metrics.insertTime.Observe(pushDuration.Seconds())
+		sent = true
+		return nil
+	})
+	return sent
+}
+
+// flushCounters sends the current batches of interface counters to
+// ClickHouse, in the database of each tenant. These batches are small, so
+// async inserts are always used. The lock should be held.
+func (w *realWorker) flushCounters(ctx context.Context) {
+	for tenant, batch := range w.counters {
+		if batch.rows() == 0 {
+			continue
+		}
+		body := batch.input.Into(InterfaceCountersTable)
+		if tc, ok := w.c.d.ClickHouse.Tenant(tenant); ok && tc.DatabaseName() != w.options.Database {
+			body = fmt.Sprintf("INSERT INTO %s %s VALUES",
+				sqlbuilder.Table(InterfaceCountersTable).In(tc.DatabaseName()), batch.input.Columns())
+		}
+		w.retry(ctx, func(chCtx context.Context) error {
+			if err := w.conn.Do(chCtx, ch.Query{
+				Body:     body,
+				Input:    batch.input,
+				Settings: w.asyncSettings,
+			}); err != nil {
+				w.logger.Err(err).
+					Int("counters", batch.rows()).
+					Str("tenant", tenant).
+					Msg("cannot send interface counters to ClickHouse")
+				w.c.metrics.errors.WithLabelValues("send counters").Inc()
+				return err
+			}
+			w.c.metrics.counters.Add(float64(batch.rows()))
+			batch.reset()
+			return nil
+		})
+	}
+	w.countersLast = time.Now()
+	if w.countersTimer != nil && w.countersRows() == 0 {
+		w.countersTimer.Stop()
+		w.countersTimer = nil
+	}
+}
+
+// retry executes the provided function until it succeeds. The only exit
+// condition is an expiration of the context. The function receives a context
+// living at least for the grace period.
+func (w *realWorker) retry(ctx context.Context, fn func(context.Context) error) {
 	b := backoff.NewExponentialBackOff()
 	b.MaxInterval = 30 * time.Second
 	b.InitialInterval = 20 * time.Millisecond
@@ -166,24 +375,7 @@ func (w *realWorker) Flush(ctx context.Context) {
 			cancel()
 		}()
 
-		// Send to ClickHouse in flows_XXXXX_raw.
-		start := time.Now()
-		if err := w.conn.Do(chCtx, ch.Query{
-			Body:     w.bf.ClickHouseProtoInput().Into(fmt.Sprintf("flows_%s_raw", w.c.d.Schema.ClickHouseHash())),
-			Input:    w.bf.ClickHouseProtoInput(),
-			Settings: settings,
-		}); err != nil {
-			w.logger.Err(err).Int("flows", w.bf.FlowCount()).Bool("async", useAsync).Msg("cannot send batch to ClickHouse")
-			w.c.metrics.errors.WithLabelValues("send").Inc()
-			return nil, err
-		}
-		pushDuration := time.Since(start)
-		w.c.metrics.insertTime.Observe(pushDuration.Seconds())
-		w.c.metrics.flows.Observe(float64(w.bf.FlowCount()))
-
-		// Clear batch
-		w.bf.Clear()
-		return nil, nil
+		return nil, fn(chCtx)
 	}, backoff.WithBackOff(b), backoff.WithMaxElapsedTime(0))
 }
 
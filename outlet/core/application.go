@@ -0,0 +1,216 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"akvorado/common/constants"
+	"akvorado/common/helpers"
+	"akvorado/common/schema"
+	"akvorado/outlet/networks"
+)
+
+// ApplicationClassifierRule assigns an application to one side of a flow. A
+// side matches when all the provided criteria match. An empty criterion
+// matches everything.
+type ApplicationClassifierRule struct {
+	// Name is the application assigned to the matching side
+	Name string `validate:"required"`
+	// Protocols is the list of IP protocols to match
+	Protocols []Protocol
+	// Ports is the list of ports to match
+	Ports []uint16
+	// Prefixes is the list of prefixes to match
+	Prefixes []netip.Prefix
+	// ASNs is the list of AS numbers to match
+	ASNs []uint32
+	// NetNames is the list of network names to match
+	NetNames []string
+	// NetRoles is the list of network roles to match
+	NetRoles []string
+	// NetSites is the list of network sites to match
+	NetSites []string
+	// NetRegions is the list of network regions to match
+	NetRegions []string
+	// NetTenants is the list of network tenants to match
+	NetTenants []string
+}
+
+// applicationSide describes one side (source or destination) of a flow.
+// PeerPort is the port of the other side.
+type applicationSide struct {
+	Proto    uint8
+	Addr     netip.Addr
+	Port     uint16
+	PeerPort uint16
+	AS       uint32
+	Net      networks.NetworkAttributes
+}
+
+// match tells if a side of a flow matches the rule.
+func (rule *ApplicationClassifierRule) match(side applicationSide) bool {
+	matchAny := func(values []string, value string) bool {
+		return len(values) == 0 || slices.Contains(values, value)
+	}
+	if len(rule.Protocols) > 0 && !slices.Contains(rule.Protocols, Protocol(side.Proto)) {
+		return false
+	}
+	if len(rule.Ports) > 0 && !slices.Contains(rule.Ports, side.Port) {
+		return false
+	}
+	// The service is on the side with the lowest port. Otherwise, the port is
+	// likely an ephemeral port colliding with a service port.
+	if len(rule.Ports) > 0 && side.PeerPort != 0 && side.Port > side.PeerPort {
+		return false
+	}
+	if len(rule.ASNs) > 0 && !slices.Contains(rule.ASNs, side.AS) {
+		return false
+	}
+	if len(rule.Prefixes) > 0 && !slices.ContainsFunc(rule.Prefixes, func(prefix netip.Prefix) bool {
+		return helpers.PrefixTo6(prefix).Contains(side.Addr)
+	}) {
+		return false
+	}
+	return matchAny(rule.NetNames, side.Net.Name) &&
+		matchAny(rule.NetRoles, side.Net.Role) &&
+		matchAny(rule.NetSites, side.Net.Site) &&
+		matchAny(rule.NetRegions, side.Net.Region) &&
+		matchAny(rule.NetTenants, side.Net.Tenant)
+}
+
+// classifyApplication returns the name of the first rule matching the
+// provided side of a flow, or an empty string.
+func classifyApplication(rules []ApplicationClassifierRule, side applicationSide) string {
+	for idx := range rules {
+		if rules[idx].match(side) {
+			return rules[idx].Name
+		}
+	}
+	return ""
+}
+
+// writeApplications sets the SrcApplication and DstApplication columns. The
+// flow classifiers run before and their values are kept.
+func (c *Component) writeApplications(flow *schema.FlowMessage, srcNet, dstNet networks.NetworkAttributes) {
+	if c.d.Schema.IsDisabled(schema.ColumnGroupApplication) {
+		return
+	}
+	rules := c.classifiers.Load().application
+	if len(rules) == 0 {
+		return
+	}
+	proto := uint8(flow.GetUint(schema.ColumnProto))
+	srcPort := uint16(flow.GetUint(schema.ColumnSrcPort))
+	dstPort := uint16(flow.GetUint(schema.ColumnDstPort))
+	flow.AppendString(schema.ColumnSrcApplication, classifyApplication(rules, applicationSide{
+		Proto:    proto,
+		Addr:     flow.SrcAddr,
+		Port:     srcPort,
+		PeerPort: dstPort,
+		AS:       flow.SrcAS,
+		Net:      srcNet,
+	}))
+	flow.AppendString(schema.ColumnDstApplication, classifyApplication(rules, applicationSide{
+		Proto:    proto,
+		Addr:     flow.DstAddr,
+		Port:     dstPort,
+		PeerPort: srcPort,
+		AS:       flow.DstAS,
+		Net:      dstNet,
+	}))
+}
+
+// Protocol is an IP protocol. It can be provided as a number or as a name.
+type Protocol uint8
+
+var protocolNames = map[Protocol]string{
+	1:   "icmp",
+	6:   "tcp",
+	17:  "udp",
+	47:  "gre",
+	50:  "esp",
+	51:  "ah",
+	58:  "icmpv6",
+	89:  "ospf",
+	112: "vrrp",
+	132: "sctp",
+}
+
+// UnmarshalText parses a protocol name or number.
+func (p *Protocol) UnmarshalText(text []byte) error {
+	name := strings.ToLower(string(text))
+	for proto, protoName := range protocolNames {
+		if protoName == name {
+			*p = proto
+			return nil
+		}
+	}
+	proto, err := strconv.ParseUint(name, 10, 8)
+	if err != nil {
+		return fmt.Errorf("unknown protocol %q", text)
+	}
+	*p = Protocol(proto)
+	return nil
+}
+
+// MarshalText turns a protocol into its name, or its number when unknown.
+func (p Protocol) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// String turns a protocol into its name, or its number when unknown.
+func (p Protocol) String() string {
+	if name, ok := protocolNames[p]; ok {
+		return name
+	}
+	return strconv.Itoa(int(p))
+}
+
+// defaultApplicationClassifiers are the built-in rules for well-known
+// services. They are used after the rules from the configuration.
+var defaultApplicationClassifiers = []ApplicationClassifierRule{
+	{Name: "dns", Protocols: []Protocol{constants.ProtoTCP, constants.ProtoUDP}, Ports: []uint16{53}},
+	{Name: "dns-over-tls", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{853}},
+	{Name: "http", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{80, 8080}},
+	{Name: "https", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{443, 8443}},
+	{Name: "quic", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{443}},
+	{Name: "ssh", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{22}},
+	{Name: "telnet", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{23}},
+	{Name: "ftp", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{20, 21}},
+	{Name: "smtp", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{25, 465, 587}},
+	{Name: "pop3", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{110, 995}},
+	{Name: "imap", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{143, 993}},
+	{Name: "ntp", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{123}},
+	{Name: "dhcp", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{67, 68, 546, 547}},
+	{Name: "tftp", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{69}},
+	{Name: "kerberos", Protocols: []Protocol{constants.ProtoTCP, constants.ProtoUDP}, Ports: []uint16{88}},
+	{Name: "ldap", Protocols: []Protocol{constants.ProtoTCP, constants.ProtoUDP}, Ports: []uint16{389, 636}},
+	{Name: "smb", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{445}},
+	{Name: "nfs", Protocols: []Protocol{constants.ProtoTCP, constants.ProtoUDP}, Ports: []uint16{2049}},
+	{Name: "snmp", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{161, 162}},
+	{Name: "syslog", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{514}},
+	{Name: "radius", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{1812, 1813}},
+	{Name: "bgp", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{179}},
+	{Name: "netflow", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{2055, 4739}},
+	{Name: "sflow", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{6343}},
+	{Name: "rdp", Protocols: []Protocol{constants.ProtoTCP, constants.ProtoUDP}, Ports: []uint16{3389}},
+	{Name: "sip", Protocols: []Protocol{constants.ProtoTCP, constants.ProtoUDP}, Ports: []uint16{5060, 5061}},
+	{Name: "stun", Protocols: []Protocol{constants.ProtoTCP, constants.ProtoUDP}, Ports: []uint16{3478}},
+	{Name: "mysql", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{3306}},
+	{Name: "postgresql", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{5432}},
+	{Name: "mssql", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{1433}},
+	{Name: "redis", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{6379}},
+	{Name: "kafka", Protocols: []Protocol{constants.ProtoTCP}, Ports: []uint16{9092}},
+	{Name: "ipsec", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{500, 4500}},
+	{Name: "ipsec", Protocols: []Protocol{50, 51}},
+	{Name: "openvpn", Protocols: []Protocol{constants.ProtoTCP, constants.ProtoUDP}, Ports: []uint16{1194}},
+	{Name: "wireguard", Protocols: []Protocol{constants.ProtoUDP}, Ports: []uint16{51820}},
+	{Name: "gre", Protocols: []Protocol{47}},
+	{Name: "icmp", Protocols: []Protocol{1, 58}},
+}
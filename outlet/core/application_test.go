@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"net/netip"
+	"testing"
+
+	"akvorado/common/helpers"
+	"akvorado/outlet/networks"
+)
+
+func TestApplicationClassifierDecode(t *testing.T) {
+	helpers.TestConfigurationDecode(t, helpers.ConfigurationDecodeCases{
+		{
+			Description: "protocols as names and numbers",
+			Initial:     func() any { return ApplicationClassifierRule{} },
+			Configuration: func() any {
+				return helpers.M{
+					"name":      "web",
+					"protocols": []any{"tcp", "UDP", 132, "50"},
+					"ports":     []uint16{80, 443},
+					"prefixes":  []string{"192.0.2.0/24", "2001:db8::/32"},
+					"asns":      []uint32{64500},
+					"net-roles": []string{"servers"},
+				}
+			},
+			Expected: ApplicationClassifierRule{
+				Name:      "web",
+				Protocols: []Protocol{6, 17, 132, 50},
+				Ports:     []uint16{80, 443},
+				Prefixes: []netip.Prefix{
+					netip.MustParsePrefix("192.0.2.0/24"),
+					netip.MustParsePrefix("2001:db8::/32"),
+				},
+				ASNs:     []uint32{64500},
+				NetRoles: []string{"servers"},
+			},
+		}, {
+			Description: "unknown protocol",
+			Initial:     func() any { return ApplicationClassifierRule{} },
+			Configuration: func() any {
+				return helpers.M{
+					"name":      "web",
+					"protocols": []string{"nope"},
+				}
+			},
+			Error: true,
+		}, {
+			Description: "missing name",
+			Initial:     func() any { return ApplicationClassifierRule{} },
+			Configuration: func() any {
+				return helpers.M{
+					"ports": []uint16{80},
+				}
+			},
+			Error: true,
+		},
+	})
+}
+
+func TestClassifyApplication(t *testing.T) {
+	configuration := DefaultConfiguration()
+	configuration.ApplicationClassifiers = []ApplicationClassifierRule{
+		{
+			Name:     "backup",
+			Ports:    []uint16{22},
+			Prefixes: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		},
+		{Name: "cdn", ASNs: []uint32{64500}, NetSites: []string{"par1", "par2"}},
+	}
+	rules := newClassifierRules(configuration).application
+
+	cases := []struct {
+		Description string
+		Side        applicationSide
+		Expected    string
+	}{
+		{
+			Description: "custom rule before default rule",
+			Side: applicationSide{
+				Proto: 6, Port: 22,
+				Addr: netip.MustParseAddr("::ffff:192.0.2.10"),
+			},
+			Expected: "backup",
+		}, {
+			Description: "default rule when prefix does not match",
+			Side: applicationSide{
+				Proto: 6, Port: 22,
+				Addr: netip.MustParseAddr("::ffff:198.51.100.10"),
+			},
+			Expected: "ssh",
+		}, {
+			Description: "ASN and network site",
+			Side: applicationSide{
+				Proto: 6, Port: 34567, AS: 64500,
+				Net: networks.NetworkAttributes{Site: "par2"},
+			},
+			Expected: "cdn",
+		}, {
+			Description: "ASN without matching network site",
+			Side: applicationSide{
+				Proto: 6, Port: 34567, AS: 64500,
+				Net: networks.NetworkAttributes{Site: "ams1"},
+			},
+			Expected: "",
+		}, {
+			Description: "protocol mismatch",
+			Side:        applicationSide{Proto: 17, Port: 22},
+			Expected:    "",
+		}, {
+			Description: "client port colliding with a service port",
+			Side:        applicationSide{Proto: 6, Port: 8080, PeerPort: 443},
+			Expected:    "",
+		}, {
+			Description: "service port of the pair",
+			Side:        applicationSide{Proto: 6, Port: 8080, PeerPort: 51234},
+			Expected:    "http",
+		}, {
+			Description: "client port colliding with a service port, non-port rule",
+			Side: applicationSide{
+				Proto: 6, Port: 51820, PeerPort: 443, AS: 64500,
+				Net: networks.NetworkAttributes{Site: "par1"},
+			},
+			Expected: "cdn",
+		}, {
+			Description: "protocol only",
+			Side:        applicationSide{Proto: 50},
+			Expected:    "ipsec",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			if got := classifyApplication(rules, tc.Side); got != tc.Expected {
+				t.Fatalf("classifyApplication() == %q, expected %q", got, tc.Expected)
+			}
+		})
+	}
+
+	configuration.DefaultApplicationClassifiers = false
+	rules = newClassifierRules(configuration).application
+	if got := classifyApplication(rules, applicationSide{Proto: 6, Port: 22}); got != "" {
+		t.Fatalf("classifyApplication() == %q without default rules, expected nothing", got)
+	}
+}
@@ -5,11 +5,13 @@ package core
 
 import (
 	"fmt"
+	"net/netip"
 	"reflect"
 	"regexp"
 	"strings"
 	"sync"
 
+	"akvorado/common/helpers"
 	"akvorado/common/schema"
 
 	"github.com/expr-lang/expr"
@@ -17,15 +19,18 @@ import (
 	"github.com/expr-lang/expr/vm"
 )
 
-// Global cache for regular expressions. No boundary.
+// Global cache for regular expressions and subnets. No boundary.
 var (
-	regexCacheLock sync.RWMutex
-	regexCache     = make(map[string]*regexp.Regexp)
+	regexCacheLock  sync.RWMutex
+	regexCache      = make(map[string]*regexp.Regexp)
+	subnetCacheLock sync.RWMutex
+	subnetCache     = make(map[string]netip.Prefix)
 )
 
 // ExporterClassifierRule defines a classification rule for a exporter.
 type ExporterClassifierRule struct {
 	program *vm.Program
+	tables  []string // lookup tables used with a constant name
 }
 
 // exporterInfo contains the information we want to expose about a exporter.
@@ -48,23 +53,39 @@ type exporterClassification struct {
 type exporterClassifierEnvironment struct {
 	Exporter              exporterInfo
 	CurrentClassification *exporterClassification
+	tables                *lookupTables
 }
 
-// exec executes the exporter classifier with the provided exporter.
-func (scr *ExporterClassifierRule) exec(si exporterInfo, ec *exporterClassification) error {
+// Lookup returns the value associated to a key in a lookup table.
+func (env exporterClassifierEnvironment) Lookup(table, key string) string {
+	return env.tables.lookup(table, key)
+}
+
+// complete tells if the exporter classification does not need more rules.
+func (ec exporterClassification) complete() bool {
+	return ec.Group != "" && ec.Role != "" && ec.Site != "" && ec.Region != "" && ec.Tenant != ""
+}
+
+// exec executes the exporter classifier with the provided exporter. It
+// returns the result of the rule.
+func (scr *ExporterClassifierRule) exec(si exporterInfo, ec *exporterClassification, tables *lookupTables) (bool, error) {
 	env := exporterClassifierEnvironment{
 		Exporter:              si,
 		CurrentClassification: ec,
+		tables:                tables,
 	}
-	if _, err := expr.Run(scr.program, env); err != nil {
-		return fmt.Errorf("unable to execute classifier %q: %w", scr, err)
+	result, err := expr.Run(scr.program, env)
+	if err != nil {
+		return false, fmt.Errorf("unable to execute classifier %q: %w", scr, err)
 	}
-	return nil
+	matched, _ := result.(bool)
+	return matched, nil
 }
 
 // UnmarshalText compiles a classification rule for a exporter.
 func (scr *ExporterClassifierRule) UnmarshalText(text []byte) error {
 	regexValidator := regexValidator{}
+	tableValidator := tableValidator{}
 	withClassificationPatcher := withClassificationPatcher{}
 	options := []expr.Option{
 		expr.Env(exporterClassifierEnvironment{}),
@@ -72,6 +93,7 @@ func (scr *ExporterClassifierRule) UnmarshalText(text []byte) error {
 		expr.AsBool(),
 		expr.Patch(&withClassificationPatcher),
 		expr.Patch(&regexValidator),
+		expr.Patch(&tableValidator),
 		expr.Function(
 			"Format",
 			func(params ...any) (any, error) {
@@ -110,6 +132,7 @@ func (scr *ExporterClassifierRule) UnmarshalText(text []byte) error {
 		return fmt.Errorf("invalid regular expression %q", regexValidator.invalidRegexes[0])
 	}
 	scr.program = program
+	scr.tables = tableValidator.tables
 	return nil
 }
 
@@ -126,6 +149,7 @@ func (scr ExporterClassifierRule) MarshalText() ([]byte, error) {
 // InterfaceClassifierRule defines a classification rule for an interface.
 type InterfaceClassifierRule struct {
 	program *vm.Program
+	tables  []string // lookup tables used with a constant name
 }
 
 // interfaceInfo contains the information we want to expose about an interface.
@@ -135,6 +159,8 @@ type interfaceInfo struct {
 	Description string
 	Speed       uint32
 	VLAN        uint16
+	Neighbor    string
+	OperStatus  string
 }
 
 // interfaceClassification contains the information about an interface classification
@@ -152,24 +178,41 @@ type interfaceClassifierEnvironment struct {
 	Exporter              exporterInfo
 	Interface             interfaceInfo
 	CurrentClassification *interfaceClassification
+	tables                *lookupTables
 }
 
-// exec executes the exporter classifier with the provided interface.
-func (scr *InterfaceClassifierRule) exec(si exporterInfo, ii interfaceInfo, ic *interfaceClassification) error {
+// Lookup returns the value associated to a key in a lookup table.
+func (env interfaceClassifierEnvironment) Lookup(table, key string) string {
+	return env.tables.lookup(table, key)
+}
+
+// complete tells if the interface classification does not need more rules.
+func (ic interfaceClassification) complete() bool {
+	return ic.Connectivity != "" && ic.Provider != "" &&
+		ic.Boundary != schema.InterfaceBoundaryUndefined
+}
+
+// exec executes the exporter classifier with the provided interface. It
+// returns the result of the rule.
+func (scr *InterfaceClassifierRule) exec(si exporterInfo, ii interfaceInfo, ic *interfaceClassification, tables *lookupTables) (bool, error) {
 	env := interfaceClassifierEnvironment{
 		Exporter:              si,
 		Interface:             ii,
 		CurrentClassification: ic,
+		tables:                tables,
 	}
-	if _, err := expr.Run(scr.program, env); err != nil {
-		return fmt.Errorf("unable to execute classifier %q: %w", scr, err)
+	result, err := expr.Run(scr.program, env)
+	if err != nil {
+		return false, fmt.Errorf("unable to execute classifier %q: %w", scr, err)
 	}
-	return nil
+	matched, _ := result.(bool)
+	return matched, nil
 }
 
 // UnmarshalText compiles a classification rule for an interface.
 func (scr *InterfaceClassifierRule) UnmarshalText(text []byte) error {
 	regexValidator := regexValidator{}
+	tableValidator := tableValidator{}
 	withClassificationPatcher := withClassificationPatcher{}
 	options := []expr.Option{
 		expr.Env(interfaceClassifierEnvironment{}),
@@ -177,6 +220,7 @@ func (scr *InterfaceClassifierRule) UnmarshalText(text []byte) error {
 		expr.AsBool(),
 		expr.Patch(&withClassificationPatcher),
 		expr.Patch(&regexValidator),
+		expr.Patch(&tableValidator),
 		expr.Function(
 			"Format",
 			func(params ...any) (any, error) {
@@ -250,6 +294,7 @@ func (scr *InterfaceClassifierRule) UnmarshalText(text []byte) error {
 		return fmt.Errorf("invalid regular expression %q", regexValidator.invalidRegexes[0])
 	}
 	scr.program = program
+	scr.tables = tableValidator.tables
 	return nil
 }
 
@@ -263,6 +308,208 @@ func (scr InterfaceClassifierRule) MarshalText() ([]byte, error) {
 	return []byte(scr.String()), nil
 }
 
+// FlowClassifierRule defines a classification rule for a flow.
+type FlowClassifierRule struct {
+	program *vm.Program
+	columns []schema.ColumnKey // columns set with a constant name
+	tables  []string           // lookup tables used with a constant name
+}
+
+// flowInfo contains the information we want to expose about a flow.
+type flowInfo struct {
+	ExporterAddress  string
+	ExporterName     string
+	InIf             uint32
+	OutIf            uint32
+	SrcAddr          string
+	DstAddr          string
+	NextHop          string
+	SrcAS            uint32
+	DstAS            uint32
+	SrcNetMask       uint8
+	DstNetMask       uint8
+	SrcVlan          uint16
+	DstVlan          uint16
+	SamplingRate     uint64
+	Bytes            uint64
+	Packets          uint64
+	EType            uint16
+	Proto            uint8
+	SrcPort          uint16
+	DstPort          uint16
+	TCPFlags         uint16
+	ForwardingStatus uint8
+}
+
+// flowClassification contains the information about a flow classification.
+// Zero values are left untouched.
+type flowClassification struct {
+	Reject       bool
+	Columns      map[schema.ColumnKey]string
+	SamplingRate uint64
+	SrcAS        uint32
+	DstAS        uint32
+}
+
+// flowClassifierEnvironment defines the environment used by the flow classifier
+type flowClassifierEnvironment struct {
+	Flow                  flowInfo
+	CurrentClassification *flowClassification
+	tables                *lookupTables
+}
+
+// Lookup returns the value associated to a key in a lookup table.
+func (env flowClassifierEnvironment) Lookup(table, key string) string {
+	return env.tables.lookup(table, key)
+}
+
+// exec executes the flow classifier with the provided flow.
+func (scr *FlowClassifierRule) exec(fi flowInfo, fc *flowClassification, tables *lookupTables) error {
+	env := flowClassifierEnvironment{
+		Flow:                  fi,
+		CurrentClassification: fc,
+		tables:                tables,
+	}
+	if _, err := expr.Run(scr.program, env); err != nil {
+		return fmt.Errorf("unable to execute classifier %q: %w", scr, err)
+	}
+	return nil
+}
+
+// UnmarshalText compiles a classification rule for a flow.
+func (scr *FlowClassifierRule) UnmarshalText(text []byte) error {
+	regexValidator := regexValidator{}
+	subnetValidator := subnetValidator{}
+	columnValidator := columnValidator{}
+	tableValidator := tableValidator{}
+	withClassificationPatcher := withClassificationPatcher{}
+	options := []expr.Option{
+		expr.Env(flowClassifierEnvironment{}),
+		expr.WithContext("Context"),
+		expr.AsBool(),
+		expr.Patch(&withClassificationPatcher),
+		expr.Patch(&regexValidator),
+		expr.Patch(&tableValidator),
+		expr.Patch(&subnetValidator),
+		expr.Patch(&columnValidator),
+		expr.Function(
+			"Format",
+			func(params ...any) (any, error) {
+				return fmt.Sprintf(params[0].(string), params[1:]...), nil
+			},
+			new(func(string, ...any) string),
+		),
+		expr.Function(
+			"InSubnet",
+			func(params ...any) (any, error) {
+				return inSubnet(params[0].(string), params[1].(string))
+			},
+			new(func(string, string) (bool, error)),
+		),
+		expr.Function(
+			"Reject",
+			func(params ...any) (any, error) {
+				fc := params[0].(*flowClassification)
+				fc.Reject = true
+				return false, nil
+			},
+			new(func(*flowClassification) bool),
+		),
+		expr.Function(
+			"SetColumn",
+			func(params ...any) (any, error) {
+				fc := params[0].(*flowClassification)
+				var key schema.ColumnKey
+				if err := key.UnmarshalText([]byte(params[1].(string))); err != nil {
+					return false, fmt.Errorf("unknown column %q", params[1].(string))
+				}
+				value := params[2].(string)
+				if _, ok := fc.Columns[key]; ok || value == "" {
+					return true, nil
+				}
+				if fc.Columns == nil {
+					fc.Columns = map[schema.ColumnKey]string{}
+				}
+				fc.Columns[key] = value
+				return true, nil
+			},
+			new(func(*flowClassification, string, string) (bool, error)),
+		),
+		expr.Function(
+			"SetSamplingRate",
+			func(params ...any) (any, error) {
+				fc := params[0].(*flowClassification)
+				value, err := toUint64(params[1])
+				if err != nil {
+					return false, err
+				}
+				if fc.SamplingRate == 0 {
+					fc.SamplingRate = uint64(value)
+				}
+				return true, nil
+			},
+			new(func(*flowClassification, int) (bool, error)),
+		),
+		expr.Function(
+			"SetSrcAS",
+			func(params ...any) (any, error) {
+				fc := params[0].(*flowClassification)
+				value, err := toUint64(params[1])
+				if err != nil {
+					return false, err
+				}
+				if fc.SrcAS == 0 {
+					fc.SrcAS = uint32(value)
+				}
+				return true, nil
+			},
+			new(func(*flowClassification, int) (bool, error)),
+		),
+		expr.Function(
+			"SetDstAS",
+			func(params ...any) (any, error) {
+				fc := params[0].(*flowClassification)
+				value, err := toUint64(params[1])
+				if err != nil {
+					return false, err
+				}
+				if fc.DstAS == 0 {
+					fc.DstAS = uint32(value)
+				}
+				return true, nil
+			},
+			new(func(*flowClassification, int) (bool, error)),
+		),
+	}
+	program, err := expr.Compile(string(text), options...)
+	if err != nil {
+		return fmt.Errorf("cannot compile flow classifier rule %q: %w", string(text), err)
+	}
+	if len(regexValidator.invalidRegexes) > 0 {
+		return fmt.Errorf("invalid regular expression %q", regexValidator.invalidRegexes[0])
+	}
+	if len(subnetValidator.invalidSubnets) > 0 {
+		return fmt.Errorf("invalid subnet %q", subnetValidator.invalidSubnets[0])
+	}
+	if len(columnValidator.invalidColumns) > 0 {
+		return fmt.Errorf("unknown column %q", columnValidator.invalidColumns[0])
+	}
+	scr.program = program
+	scr.tables = tableValidator.tables
+	scr.columns = columnValidator.columns
+	return nil
+}
+
+// String turns a flow classifier rule into a string
+func (scr FlowClassifierRule) String() string {
+	return scr.program.Source().String()
+}
+
+// MarshalText turns a flow classifier rule into a string
+func (scr FlowClassifierRule) MarshalText() ([]byte, error) {
+	return []byte(scr.String()), nil
+}
+
 var normalizeRegex = regexp.MustCompile("[^a-z0-9.+-]+")
 
 // Normalize a string by putting it lowercase and only keeping safe characters
@@ -308,6 +555,43 @@ func classifyStringWithRegex(input, regex, template string, output *string) (boo
 	return true, nil
 }
 
+// inSubnet tells if an IP address is part of a subnet.
+func inSubnet(ip, subnet string) (bool, error) {
+	subnetCacheLock.RLock()
+	prefix, ok := subnetCache[subnet]
+	subnetCacheLock.RUnlock()
+	if !ok {
+		var err error
+		prefix, err = helpers.SubnetMapParseKey(subnet)
+		if err != nil {
+			return false, fmt.Errorf("cannot parse subnet %q: %w", subnet, err)
+		}
+		subnetCacheLock.Lock()
+		subnetCache[subnet] = prefix
+		subnetCacheLock.Unlock()
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false, nil
+	}
+	if addr.Is4() {
+		addr = netip.AddrFrom16(addr.As16())
+	}
+	return prefix.Contains(addr), nil
+}
+
+// toUint64 converts an integer from an expression to an unsigned integer.
+func toUint64(value any) (uint64, error) {
+	v := reflect.ValueOf(value)
+	switch {
+	case v.CanUint():
+		return v.Uint(), nil
+	case v.CanInt() && v.Int() >= 0:
+		return uint64(v.Int()), nil
+	}
+	return 0, fmt.Errorf("invalid value %v", value)
+}
+
 // addExporterClassifyStringFunction adds to the list of compile options two
 // functions for classifying an aspect of an exporter.
 func addExporterClassifyStringFunction(options []expr.Option, name string, fn func(*exporterClassification) *string) []expr.Option {
@@ -382,6 +666,80 @@ func (r *regexValidator) Visit(node *ast.Node) {
 	}
 }
 
+// subnetValidator is a patch to validate subnets at compile-time
+type subnetValidator struct {
+	invalidSubnets []string
+}
+
+func (s *subnetValidator) Visit(node *ast.Node) {
+	n, ok := (*node).(*ast.CallNode)
+	if !ok {
+		return
+	}
+	identifier, ok := n.Callee.(*ast.IdentifierNode)
+	if !ok || identifier.Value != "InSubnet" || len(n.Arguments) != 2 {
+		return
+	}
+	str, ok := n.Arguments[1].(*ast.StringNode)
+	if !ok {
+		return
+	}
+	if _, err := helpers.SubnetMapParseKey(str.Value); err != nil {
+		s.invalidSubnets = append(s.invalidSubnets, str.Value)
+	}
+}
+
+// columnValidator is a patch to validate column names at compile-time. It
+// also collects them to check their type once the schema is known.
+type columnValidator struct {
+	invalidColumns []string
+	columns        []schema.ColumnKey
+}
+
+func (c *columnValidator) Visit(node *ast.Node) {
+	n, ok := (*node).(*ast.CallNode)
+	if !ok {
+		return
+	}
+	identifier, ok := n.Callee.(*ast.IdentifierNode)
+	if !ok || identifier.Value != "SetColumn" || len(n.Arguments) < 2 {
+		return
+	}
+	// The current classification may already have been added.
+	str, ok := n.Arguments[len(n.Arguments)-2].(*ast.StringNode)
+	if !ok {
+		return
+	}
+	var key schema.ColumnKey
+	if err := key.UnmarshalText([]byte(str.Value)); err != nil {
+		c.invalidColumns = append(c.invalidColumns, str.Value)
+		return
+	}
+	c.columns = append(c.columns, key)
+}
+
+// tableValidator is a patch to collect the lookup tables used with a constant
+// name. They are checked once the configured tables are known.
+type tableValidator struct {
+	tables []string
+}
+
+func (t *tableValidator) Visit(node *ast.Node) {
+	n, ok := (*node).(*ast.CallNode)
+	if !ok {
+		return
+	}
+	identifier, ok := n.Callee.(*ast.IdentifierNode)
+	if !ok || identifier.Value != "Lookup" || len(n.Arguments) != 2 {
+		return
+	}
+	str, ok := n.Arguments[0].(*ast.StringNode)
+	if !ok {
+		return
+	}
+	t.tables = append(t.tables, str.Value)
+}
+
 // withClassificationPatcher is a patch to add the current classification as the
 // first argument when the function called expects one.
 type withClassificationPatcher struct{}
@@ -393,7 +751,8 @@ func (a *withClassificationPatcher) Visit(node *ast.Node) {
 		if fn == nil || fn.Kind() != reflect.Func || fn.NumIn() < 1 {
 			return
 		}
-		if fn.In(0).String() == "*core.exporterClassification" || fn.In(0).String() == "*core.interfaceClassification" {
+		switch fn.In(0).String() {
+		case "*core.exporterClassification", "*core.interfaceClassification", "*core.flowClassification":
 			ast.Patch(node, &ast.CallNode{
 				Callee: call.Callee,
 				Arguments: append([]ast.Node{
@@ -125,7 +125,7 @@ Classify("europe")`,
 				return
 			}
 			var classification exporterClassification
-			err = scr.exec(tc.ExporterInfo, &classification)
+			_, err = scr.exec(tc.ExporterInfo, &classification, nil)
 			if !tc.ExpectedErr && err != nil {
 				t.Fatalf("exec(%q) error:\n%+v", tc.Program, err)
 			}
@@ -257,7 +257,7 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")
 				return
 			}
 			var gotClassification interfaceClassification
-			err = scr.exec(tc.ExporterInfo, tc.InterfaceInfo, &gotClassification)
+			_, err = scr.exec(tc.ExporterInfo, tc.InterfaceInfo, &gotClassification, nil)
 			if !tc.ExpectedErr && err != nil {
 				t.Fatalf("exec(%q) error:\n%+v", tc.Program, err)
 			}
@@ -271,6 +271,98 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")
 	}
 }
 
+func TestFlowClassifier(t *testing.T) {
+	cases := []struct {
+		Description            string
+		Program                string
+		FlowInfo               flowInfo
+		ExpectedClassification flowClassification
+		ExpectedErr            bool
+	}{
+		{
+			Description: "trivial classifier",
+			Program:     "false",
+		}, {
+			Description:            "reject",
+			Program:                `Flow.DstPort == 22 && Reject()`,
+			FlowInfo:               flowInfo{DstPort: 22},
+			ExpectedClassification: flowClassification{Reject: true},
+		}, {
+			Description: "no reject",
+			Program:     `Flow.DstPort == 22 && Reject()`,
+			FlowInfo:    flowInfo{DstPort: 23},
+		}, {
+			Description: "set column",
+			Program:     `InSubnet(Flow.SrcAddr, "192.0.2.0/24") && SetColumn("SrcNetName", "scrubbing")`,
+			FlowInfo:    flowInfo{SrcAddr: "192.0.2.10"},
+			ExpectedClassification: flowClassification{
+				Columns: map[schema.ColumnKey]string{schema.ColumnSrcNetName: "scrubbing"},
+			},
+		}, {
+			Description: "set column with IPv6",
+			Program:     `InSubnet(Flow.SrcAddr, "2001:db8::/32") && SetColumn("SrcNetName", "scrubbing")`,
+			FlowInfo:    flowInfo{SrcAddr: "2001:db8::1"},
+			ExpectedClassification: flowClassification{
+				Columns: map[schema.ColumnKey]string{schema.ColumnSrcNetName: "scrubbing"},
+			},
+		}, {
+			Description: "set column, first value wins",
+			Program:     `SetColumn("SrcNetName", "first") && SetColumn("SrcNetName", "second")`,
+			ExpectedClassification: flowClassification{
+				Columns: map[schema.ColumnKey]string{schema.ColumnSrcNetName: "first"},
+			},
+		}, {
+			Description: "rewrites",
+			Program:     `SetSamplingRate(100) && SetSrcAS(Flow.DstAS) && SetDstAS(65000)`,
+			FlowInfo:    flowInfo{DstAS: 64512},
+			ExpectedClassification: flowClassification{
+				SamplingRate: 100,
+				SrcAS:        64512,
+				DstAS:        65000,
+			},
+		}, {
+			Description: "set column with dynamic unknown name",
+			Program:     `SetColumn(Format("Src%s", "Unknown"), "scrubbing")`,
+			ExpectedErr: true,
+		}, {
+			Description: "unknown column",
+			Program:     `SetColumn("SrcUnknown", "scrubbing")`,
+			ExpectedErr: true,
+		}, {
+			Description: "invalid subnet",
+			Program:     `InSubnet(Flow.SrcAddr, "192.0.2.0/33")`,
+			ExpectedErr: true,
+		}, {
+			Description: "inexistant field",
+			Program:     `Flow.SrcCountry == "FR"`,
+			ExpectedErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			var scr FlowClassifierRule
+			err := scr.UnmarshalText([]byte(tc.Program))
+			if !tc.ExpectedErr && err != nil {
+				t.Fatalf("UnmarshalText(%q) error:\n%+v", tc.Program, err)
+			}
+			if tc.ExpectedErr && err != nil {
+				return
+			}
+			var classification flowClassification
+			err = scr.exec(tc.FlowInfo, &classification, nil)
+			if !tc.ExpectedErr && err != nil {
+				t.Fatalf("exec(%q) error:\n%+v", tc.Program, err)
+			}
+			if tc.ExpectedErr && err == nil {
+				t.Fatalf("exec(%q) no error", tc.Program)
+			}
+			if diff := helpers.Diff(classification, tc.ExpectedClassification); diff != "" {
+				t.Fatalf("exec(%q) (-got, +want):\n%s", tc.Program, diff)
+			}
+		})
+	}
+}
+
 func TestRegexValidation(t *testing.T) {
 	cases := []struct {
 		Classifier string
@@ -313,7 +405,7 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")
 	var err error
 	var gotClassification interfaceClassification
 	for b.Loop() {
-		err = scr.exec(ei, ii, &gotClassification)
+		_, err = scr.exec(ei, ii, &gotClassification, nil)
 	}
 	if err != nil {
 		b.Fatalf("exec() error:\n%+v", err)
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"akvorado/common/helpers"
+	"akvorado/common/remotedatasource"
 
 	"github.com/go-viper/mapstructure/v2"
 )
@@ -20,8 +21,21 @@ type Configuration struct {
 	ExporterClassifiers []ExporterClassifierRule
 	// InterfaceClassifiers defines rules for interface classification
 	InterfaceClassifiers []InterfaceClassifierRule
+	// FlowClassifiers defines rules for flow classification
+	FlowClassifiers []FlowClassifierRule
+	// ApplicationClassifiers defines rules to assign an application to each
+	// side of a flow
+	ApplicationClassifiers []ApplicationClassifierRule `validate:"dive"`
+	// DefaultApplicationClassifiers appends the built-in rules for well-known
+	// services to the application classifiers
+	DefaultApplicationClassifiers bool
+	// LookupTables defines remote tables classifiers can query with Lookup()
+	LookupTables map[string]remotedatasource.Source `validate:"dive"`
 	// ClassifierCacheDuration defines the default TTL for classifier cache
 	ClassifierCacheDuration time.Duration `validate:"min=1s"`
+	// ClassifierReloadInterval defines how often to fetch the configuration
+	// again to reload the classifier rules. 0 disables reloading.
+	ClassifierReloadInterval time.Duration `validate:"eq=0|min=1s"`
 	// DefaultSamplingRate defines the default sampling rate to use when the information is missing
 	DefaultSamplingRate *helpers.SubnetMap[uint]
 	// OverrideSamplingRate defines a sampling rate to use instead of the received on
@@ -32,16 +46,39 @@ type Configuration struct {
 	NetProviders []NetProvider `validate:"dive"`
 	// StartupDelay defines how long to wait at start before processing flows.
 	StartupDelay time.Duration `validate:"eq=0|min=1s"`
+	// Deduplication defines how to handle flows seen by several exporters
+	Deduplication DeduplicationConfiguration
+}
+
+// DeduplicationConfiguration describes how to deduplicate flows exported by
+// several exporters on the same path.
+type DeduplicationConfiguration struct {
+	// ExporterGroups lists the exporter groups where flows are deduplicated.
+	// Deduplication is disabled when empty.
+	ExporterGroups []string
+	// Window is how long a flow seen at an upstream exporter is remembered
+	Window time.Duration `validate:"min=1s"`
+	// Action tells what to do with duplicate flows: drop them or mark them
+	// using the Duplicate column.
+	Action string `validate:"oneof=drop mark"`
 }
 
 // DefaultConfiguration represents the default configuration for the core component.
 func DefaultConfiguration() Configuration {
 	return Configuration{
-		ExporterClassifiers:     []ExporterClassifierRule{},
-		InterfaceClassifiers:    []InterfaceClassifierRule{},
-		ClassifierCacheDuration: 5 * time.Minute,
-		ASNProviders:            []ASNProvider{ASNProviderFlow, ASNProviderRouting, ASNProviderNetworks},
-		NetProviders:            []NetProvider{NetProviderFlow, NetProviderRouting},
+		ExporterClassifiers:           []ExporterClassifierRule{},
+		InterfaceClassifiers:          []InterfaceClassifierRule{},
+		FlowClassifiers:               []FlowClassifierRule{},
+		ApplicationClassifiers:        []ApplicationClassifierRule{},
+		DefaultApplicationClassifiers: true,
+		ClassifierCacheDuration:       5 * time.Minute,
+		ASNProviders:                  []ASNProvider{ASNProviderFlow, ASNProviderRouting, ASNProviderNetworks},
+		NetProviders:                  []NetProvider{NetProviderFlow, NetProviderRouting},
+		Deduplication: DeduplicationConfiguration{
+			ExporterGroups: []string{},
+			Window:         time.Minute,
+			Action:         "drop",
+		},
 	}
 }
 
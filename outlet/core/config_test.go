@@ -5,8 +5,10 @@ package core
 
 import (
 	"testing"
+	"time"
 
 	"akvorado/common/helpers"
+	"akvorado/common/remotedatasource"
 )
 
 func TestDefaultConfiguration(t *testing.T) {
@@ -122,6 +124,34 @@ func TestConfigurationUnmarshallerHook(t *testing.T) {
 				NetProviders: []NetProvider{NetProviderFlow, NetProviderRouting},
 			},
 			SkipValidation: true,
+		}, {
+			Description: "lookup-tables",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return helpers.M{
+					"lookup-tables": helpers.M{
+						"circuits": helpers.M{
+							"url":       "https://example.com/circuits.csv",
+							"parser":    "csv-comma",
+							"interval":  "10m",
+							"transform": ".[] | {key: .f1, value: .f2}",
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				LookupTables: map[string]remotedatasource.Source{
+					"circuits": {
+						URL:       "https://example.com/circuits.csv",
+						Method:    "GET",
+						Timeout:   time.Minute,
+						Interval:  10 * time.Minute,
+						Parser:    remotedatasource.ParserCSVComma,
+						Transform: remotedatasource.MustParseTransformQuery(".[] | {key: .f1, value: .f2}"),
+					},
+				},
+			},
+			SkipValidation: true,
 		},
 	})
 }
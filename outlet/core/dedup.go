@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+
+	"akvorado/common/schema"
+)
+
+// deduplicationKey identifies a flow inside an exporter group.
+type deduplicationKey struct {
+	group   string
+	srcAddr netip.Addr
+	dstAddr netip.Addr
+	proto   uint8
+	srcPort uint16
+	dstPort uint16
+}
+
+// deduplicationMaxFlows is the maximum number of flows remembered. Past this
+// limit, new flows are not deduplicated until some others expire.
+const deduplicationMaxFlows = 1_000_000
+
+// deduplicationEntry records the exporter owning a flow.
+type deduplicationEntry struct {
+	exporter netip.Addr
+	lastSeen int64 // Unix timestamp in nanoseconds, from the flow
+	upstream bool  // the flow was received on an external interface
+}
+
+// deduplicate checks if the flow was already seen by another exporter of the
+// same group. Flows entering the network through an external interface are
+// recorded as seen by an upstream exporter. Flows from another exporter
+// received on a non-external interface within the window are duplicates. The
+// window uses the time the flows were received by the inlet, so a lag in
+// Kafka does not matter. It returns true if the flow should be dropped.
+//
+// Exporters use independent timeouts and a downstream exporter may export a
+// flow before the upstream one. In this case, the downstream exporter is
+// recorded and the flow from the upstream exporter is the duplicate. The
+// upstream exporter then takes over for the next flows.
+func (c *Component) deduplicate(flow *schema.FlowMessage, exporterIP netip.Addr, exporterStr string,
+	group string, inIfBoundary schema.InterfaceBoundary,
+) bool {
+	if !slices.Contains(c.config.Deduplication.ExporterGroups, group) {
+		return false
+	}
+	t := time.Unix(int64(flow.TimeReceived), 0)
+	key := deduplicationKey{
+		group:   group,
+		srcAddr: flow.SrcAddr,
+		dstAddr: flow.DstAddr,
+		proto:   uint8(flow.GetUint(schema.ColumnProto)),
+		srcPort: uint16(flow.GetUint(schema.ColumnSrcPort)),
+		dstPort: uint16(flow.GetUint(schema.ColumnDstPort)),
+	}
+	if _, ok := c.deduplication.Load(key); !ok && c.deduplication.Size() >= deduplicationMaxFlows {
+		return false
+	}
+	upstream := inIfBoundary == schema.InterfaceBoundaryExternal
+	windowStart := t.Add(-c.config.Deduplication.Window).UnixNano()
+	duplicate := false
+	c.deduplication.Compute(key, func(entry deduplicationEntry, loaded bool) (deduplicationEntry, xsync.ComputeOp) {
+		fresh := loaded && entry.lastSeen >= windowStart
+		switch {
+		case !fresh, entry.exporter == exporterIP:
+			// The exporter owns the flow
+			return deduplicationEntry{
+				exporter: exporterIP,
+				lastSeen: max(entry.lastSeen, t.UnixNano()),
+				upstream: upstream || (fresh && entry.upstream),
+			}, xsync.UpdateOp
+		case upstream && !entry.upstream:
+			// The downstream exporter was first, the upstream exporter
+			// takes over.
+			duplicate = true
+			return deduplicationEntry{
+				exporter: exporterIP,
+				lastSeen: t.UnixNano(),
+				upstream: true,
+			}, xsync.UpdateOp
+		case upstream:
+			// Another upstream exporter
+			return deduplicationEntry{
+				exporter: exporterIP,
+				lastSeen: t.UnixNano(),
+				upstream: true,
+			}, xsync.UpdateOp
+		case entry.upstream:
+			duplicate = true
+		}
+		// Traffic not entering through an external interface is not
+		// deduplicated.
+		return entry, xsync.CancelOp
+	})
+	for latest := c.deduplicationLatest.Load(); latest < t.UnixNano(); latest = c.deduplicationLatest.Load() {
+		if c.deduplicationLatest.CompareAndSwap(latest, t.UnixNano()) {
+			break
+		}
+	}
+	if !duplicate {
+		return false
+	}
+	c.metrics.flowsDuplicated.WithLabelValues(exporterStr, c.config.Deduplication.Action).Inc()
+	if c.config.Deduplication.Action == "mark" {
+		flow.AppendUint(schema.ColumnDuplicate, 1)
+		return false
+	}
+	return true
+}
+
+// expireDeduplication forgets the flows seen before the provided time. The time
+// is expected to be relative to the most recent flow recorded, not to the
+// current time.
+func (c *Component) expireDeduplication(before time.Time) {
+	c.deduplication.Range(func(key deduplicationKey, entry deduplicationEntry) bool {
+		if entry.lastSeen < before.UnixNano() {
+			c.deduplication.Delete(key)
+		}
+		return true
+	})
+}
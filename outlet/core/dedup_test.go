@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+func TestDeduplication(t *testing.T) {
+	edge := netip.MustParseAddr("::ffff:192.0.2.1")
+	core := netip.MustParseAddr("::ffff:192.0.2.2")
+	t0 := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+
+	for _, action := range []string{"drop", "mark"} {
+		t.Run(action, func(t *testing.T) {
+			r := reporter.NewMock(t)
+			sch := schema.NewMock(t).EnableAllColumns()
+			configuration := DefaultConfiguration()
+			configuration.Deduplication.ExporterGroups = []string{"paris"}
+			configuration.Deduplication.Action = action
+			c, err := New(r, configuration, Dependencies{
+				Daemon: daemon.NewMock(t),
+				HTTP:   httpserver.NewMock(t, r),
+				Schema: sch,
+			})
+			if err != nil {
+				t.Fatalf("New() error:\n%+v", err)
+			}
+
+			bf := sch.NewFlowMessage()
+			deduplicate := func(now time.Time, exporter netip.Addr, group string,
+				boundary schema.InterfaceBoundary, srcPort uint64,
+			) (bool, uint64) {
+				defer bf.Undo()
+				bf.TimeReceived = uint32(now.Unix())
+				bf.SrcAddr = netip.MustParseAddr("::ffff:198.51.100.1")
+				bf.DstAddr = netip.MustParseAddr("::ffff:203.0.113.1")
+				bf.AppendUint(schema.ColumnProto, 6)
+				bf.AppendUint(schema.ColumnSrcPort, srcPort)
+				bf.AppendUint(schema.ColumnDstPort, 443)
+				drop := c.deduplicate(bf, exporter, exporter.Unmap().String(), group, boundary)
+				return drop, bf.GetUint(schema.ColumnDuplicate)
+			}
+
+			cases := []struct {
+				Description string
+				Time        time.Time
+				Exporter    netip.Addr
+				Group       string
+				Boundary    schema.InterfaceBoundary
+				SrcPort     uint64
+				Duplicate   bool
+			}{
+				// The core router exports the flow first: the flow from the
+				// edge router is the duplicate and the edge router takes over.
+				{"core before edge", t0, core, "paris", schema.InterfaceBoundaryInternal, 33000, false},
+				{"edge after core", t0, edge, "paris", schema.InterfaceBoundaryExternal, 33000, true},
+				{"edge again", t0.Add(time.Second), edge, "paris", schema.InterfaceBoundaryInternal, 33000, false},
+				{"core after edge", t0.Add(time.Second), core, "paris", schema.InterfaceBoundaryInternal, 33000, true},
+				{"core, another port", t0.Add(time.Second), core, "paris", schema.InterfaceBoundaryInternal, 33001, false},
+				{"core, another group", t0.Add(time.Second), core, "lyon", schema.InterfaceBoundaryInternal, 33000, false},
+				{"core, undefined boundary", t0.Add(time.Second), core, "paris", schema.InterfaceBoundaryUndefined, 33000, true},
+				{"core after window", t0.Add(2 * time.Minute), core, "paris", schema.InterfaceBoundaryInternal, 33000, false},
+				{"another core router", t0.Add(2 * time.Minute), netip.MustParseAddr("::ffff:192.0.2.3"), "paris", schema.InterfaceBoundaryInternal, 33000, false},
+				{"edge", t0.Add(3 * time.Minute), edge, "paris", schema.InterfaceBoundaryExternal, 33002, false},
+				{"core after edge", t0.Add(3 * time.Minute), core, "paris", schema.InterfaceBoundaryInternal, 33002, true},
+			}
+			for _, tc := range cases {
+				drop, mark := deduplicate(tc.Time, tc.Exporter, tc.Group, tc.Boundary, tc.SrcPort)
+				switch {
+				case tc.Duplicate && action == "drop" && (!drop || mark != 0),
+					tc.Duplicate && action == "mark" && (drop || mark != 1),
+					!tc.Duplicate && (drop || mark != 0):
+					t.Errorf("deduplicate(%s) == %v, %d", tc.Description, drop, mark)
+				}
+			}
+
+			gotMetrics := r.GetMetrics("akvorado_outlet_core_", "flows_duplicated_")
+			expectedMetrics := map[string]string{
+				`flows_duplicated_total{action="` + action + `",exporter="192.0.2.1"}`: "1",
+				`flows_duplicated_total{action="` + action + `",exporter="192.0.2.2"}`: "3",
+			}
+			if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+				t.Fatalf("Metrics (-got, +want):\n%s", diff)
+			}
+
+			c.expireDeduplication(t0.Add(time.Second))
+			if got := c.deduplication.Size(); got != 3 {
+				t.Errorf("expireDeduplication() kept %d entries, expected 3", got)
+			}
+			c.expireDeduplication(t0.Add(2 * time.Second))
+			if got := c.deduplication.Size(); got != 2 {
+				t.Errorf("expireDeduplication() kept %d entries, expected 2", got)
+			}
+			c.expireDeduplication(t0.Add(4 * time.Minute))
+			if got := c.deduplication.Size(); got != 0 {
+				t.Errorf("expireDeduplication() kept %d entries, expected 0", got)
+			}
+		})
+	}
+}
+
+func TestDeduplicationMarkWithoutColumn(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration()
+	configuration.Deduplication.ExporterGroups = []string{"paris"}
+	configuration.Deduplication.Action = "mark"
+	_, err := New(r, configuration, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Schema: schema.NewMock(t),
+	})
+	if err == nil {
+		t.Fatal("New() did not error")
+	}
+}
@@ -0,0 +1,301 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/schema"
+)
+
+// dryRunRequest is the request to test classifier rules. When rules are
+// omitted, the current ones are used. When samples are omitted, the content
+// of the metadata cache is used.
+type dryRunRequest struct {
+	ExporterClassifiers  []ExporterClassifierRule  `json:"exporter-classifiers"`
+	InterfaceClassifiers []InterfaceClassifierRule `json:"interface-classifiers"`
+	Samples              []dryRunSample            `json:"samples"`
+}
+
+// dryRunSample is an exporter and, optionally, one of its interfaces to
+// classify.
+type dryRunSample struct {
+	Exporter  dryRunExporter   `json:"exporter"`
+	Interface *dryRunInterface `json:"interface,omitempty"`
+}
+
+// dryRunExporter is the information about an exporter. The classification
+// fields are the ones set by a metadata provider, if any.
+type dryRunExporter struct {
+	IP     string `json:"ip"`
+	Name   string `json:"name"`
+	Region string `json:"region,omitempty"`
+	Role   string `json:"role,omitempty"`
+	Site   string `json:"site,omitempty"`
+	Group  string `json:"group,omitempty"`
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// dryRunInterface is the information about an interface. The classification
+// fields are the ones set by a metadata provider, if any.
+type dryRunInterface struct {
+	Index        uint32                   `json:"index"`
+	Name         string                   `json:"name"`
+	Description  string                   `json:"description"`
+	Speed        uint32                   `json:"speed"`
+	VLAN         uint16                   `json:"vlan"`
+	Neighbor     string                   `json:"neighbor,omitempty"`
+	OperStatus   string                   `json:"oper-status,omitempty"`
+	Provider     string                   `json:"provider,omitempty"`
+	Connectivity string                   `json:"connectivity,omitempty"`
+	Boundary     schema.InterfaceBoundary `json:"boundary,omitempty"`
+}
+
+// dryRunStep is the result of the execution of one rule.
+type dryRunStep struct {
+	Index          int    `json:"index"`
+	Rule           string `json:"rule"`
+	Matched        bool   `json:"matched"`
+	Error          string `json:"error,omitempty"`
+	Classification any    `json:"classification"`
+}
+
+// dryRunExporterClassification is the representation of an exporter
+// classification.
+type dryRunExporterClassification struct {
+	Group  string `json:"group"`
+	Role   string `json:"role"`
+	Site   string `json:"site"`
+	Region string `json:"region"`
+	Tenant string `json:"tenant"`
+	Reject bool   `json:"reject"`
+}
+
+// dryRunInterfaceClassification is the representation of an interface
+// classification.
+type dryRunInterfaceClassification struct {
+	Connectivity string                   `json:"connectivity"`
+	Provider     string                   `json:"provider"`
+	Boundary     schema.InterfaceBoundary `json:"boundary"`
+	Name         string                   `json:"name"`
+	Description  string                   `json:"description"`
+	Reject       bool                     `json:"reject"`
+}
+
+// dryRunResult is the result of the classification of a sample. When the
+// classification is provided by the metadata provider, rules are not
+// evaluated, like in the enricher.
+type dryRunResult struct {
+	dryRunSample
+	ExporterRules           []dryRunStep                   `json:"exporter-rules"`
+	ExporterClassification  dryRunExporterClassification   `json:"exporter-classification"`
+	ExporterMatch           *int                           `json:"exporter-match"`
+	ExporterProvided        bool                           `json:"exporter-provided,omitempty"`
+	InterfaceRules          []dryRunStep                   `json:"interface-rules,omitempty"`
+	InterfaceClassification *dryRunInterfaceClassification `json:"interface-classification,omitempty"`
+	InterfaceMatch          *int                           `json:"interface-match,omitempty"`
+	InterfaceProvided       bool                           `json:"interface-provided,omitempty"`
+}
+
+// dryRun returns the representation of an exporter classification.
+func (ec exporterClassification) dryRun() dryRunExporterClassification {
+	return dryRunExporterClassification{
+		Group:  ec.Group,
+		Role:   ec.Role,
+		Site:   ec.Site,
+		Region: ec.Region,
+		Tenant: ec.Tenant,
+		Reject: ec.Reject,
+	}
+}
+
+// dryRun returns the representation of an interface classification.
+func (ic interfaceClassification) dryRun() dryRunInterfaceClassification {
+	return dryRunInterfaceClassification{
+		Connectivity: ic.Connectivity,
+		Provider:     ic.Provider,
+		Boundary:     ic.Boundary,
+		Name:         ic.Name,
+		Description:  ic.Description,
+		Reject:       ic.Reject,
+	}
+}
+
+// dryRunExporterRules classifies an exporter like the enricher does, but
+// records the classification after each rule. The match is the index of the
+// rule completing the classification, if any.
+func dryRunExporterRules(rules []ExporterClassifierRule, ei exporterInfo, tables *lookupTables) ([]dryRunStep, exporterClassification, *int) {
+	steps := []dryRunStep{}
+	classification := exporterClassification{}
+	var match *int
+	for idx, rule := range rules {
+		matched, err := rule.exec(ei, &classification, tables)
+		step := dryRunStep{
+			Index:          idx,
+			Rule:           rule.String(),
+			Matched:        matched,
+			Classification: classification.dryRun(),
+		}
+		if err != nil {
+			step.Error = err.Error()
+			steps = append(steps, step)
+			break
+		}
+		steps = append(steps, step)
+		if classification.complete() {
+			match = &idx
+			break
+		}
+	}
+	return steps, classification, match
+}
+
+// dryRunInterfaceRules classifies an interface like the enricher does, but
+// records the classification after each rule.
+func dryRunInterfaceRules(rules []InterfaceClassifierRule, ei exporterInfo, ii interfaceInfo, tables *lookupTables) ([]dryRunStep, interfaceClassification, *int) {
+	steps := []dryRunStep{}
+	classification := interfaceClassification{}
+	var match *int
+	for idx, rule := range rules {
+		matched, err := rule.exec(ei, ii, &classification, tables)
+		step := dryRunStep{
+			Index:          idx,
+			Rule:           rule.String(),
+			Matched:        matched,
+			Classification: classification.dryRun(),
+		}
+		if err != nil {
+			step.Error = err.Error()
+			steps = append(steps, step)
+			break
+		}
+		steps = append(steps, step)
+		if classification.complete() {
+			match = &idx
+			break
+		}
+	}
+	if classification.Name == "" {
+		classification.Name = ii.Name
+	}
+	if classification.Description == "" {
+		classification.Description = ii.Description
+	}
+	return steps, classification, match
+}
+
+// cachedSamples builds the samples from the content of the metadata cache.
+func (c *Component) cachedSamples() []dryRunSample {
+	samples := []dryRunSample{}
+	for query, answer := range c.d.Metadata.CachedAnswers() {
+		if !answer.Found {
+			continue
+		}
+		samples = append(samples, dryRunSample{
+			Exporter: dryRunExporter{
+				IP:     query.ExporterIP.Unmap().String(),
+				Name:   answer.Exporter.Name,
+				Region: answer.Exporter.Region,
+				Role:   answer.Exporter.Role,
+				Site:   answer.Exporter.Site,
+				Group:  answer.Exporter.Group,
+				Tenant: answer.Exporter.Tenant,
+			},
+			Interface: &dryRunInterface{
+				Index:        uint32(query.IfIndex),
+				Name:         answer.Interface.Name,
+				Description:  answer.Interface.Description,
+				Speed:        uint32(answer.Interface.Speed),
+				Neighbor:     answer.Interface.Neighbor,
+				OperStatus:   answer.Interface.OperStatus,
+				Provider:     answer.Interface.Provider,
+				Connectivity: answer.Interface.Connectivity,
+				Boundary:     answer.Interface.Boundary,
+			},
+		})
+	}
+	slices.SortFunc(samples, func(a, b dryRunSample) int {
+		return cmp.Or(
+			cmp.Compare(a.Exporter.IP, b.Exporter.IP),
+			cmp.Compare(a.Interface.Index, b.Interface.Index))
+	})
+	return samples
+}
+
+// ClassifiersDryRunHTTPHandler executes the provided classifier rules, or
+// the current ones, on the provided samples, or on the content of the
+// metadata cache, and returns the classification after each rule. Nothing is
+// changed in the running configuration.
+func (c *Component) ClassifiersDryRunHTTPHandler(w http.ResponseWriter, req *http.Request) {
+	var request dryRunRequest
+	if err := httpserver.BindJSON(req, &request); err != nil {
+		httpserver.WriteJSON(w, http.StatusBadRequest, helpers.M{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	current := c.classifiers.Load()
+	if request.ExporterClassifiers == nil {
+		request.ExporterClassifiers = current.exporter
+	}
+	if request.InterfaceClassifiers == nil {
+		request.InterfaceClassifiers = current.iface
+	}
+	if request.Samples == nil {
+		request.Samples = c.cachedSamples()
+	}
+
+	results := make([]dryRunResult, 0, len(request.Samples))
+	for _, sample := range request.Samples {
+		ei := exporterInfo{IP: sample.Exporter.IP, Name: sample.Exporter.Name}
+		result := dryRunResult{dryRunSample: sample}
+		ec := exporterClassification{
+			Region: sample.Exporter.Region,
+			Role:   sample.Exporter.Role,
+			Site:   sample.Exporter.Site,
+			Group:  sample.Exporter.Group,
+			Tenant: sample.Exporter.Tenant,
+		}
+		if (ec != exporterClassification{}) {
+			// Provided by the metadata component, rules are skipped
+			result.ExporterRules = []dryRunStep{}
+			result.ExporterProvided = true
+		} else {
+			result.ExporterRules, ec, result.ExporterMatch = dryRunExporterRules(request.ExporterClassifiers, ei, c.lookupTables)
+		}
+		result.ExporterClassification = ec.dryRun()
+		if sample.Interface != nil {
+			ii := interfaceInfo{
+				Index:       sample.Interface.Index,
+				Name:        sample.Interface.Name,
+				Description: sample.Interface.Description,
+				Speed:       sample.Interface.Speed,
+				VLAN:        sample.Interface.VLAN,
+				Neighbor:    sample.Interface.Neighbor,
+				OperStatus:  sample.Interface.OperStatus,
+			}
+			ic := interfaceClassification{
+				Provider:     sample.Interface.Provider,
+				Connectivity: sample.Interface.Connectivity,
+				Boundary:     sample.Interface.Boundary,
+			}
+			if (ic != interfaceClassification{}) {
+				// Provided by the metadata component, rules are skipped
+				ic.Name = ii.Name
+				ic.Description = ii.Description
+				result.InterfaceProvided = true
+			} else {
+				result.InterfaceRules, ic, result.InterfaceMatch = dryRunInterfaceRules(request.InterfaceClassifiers, ei, ii, c.lookupTables)
+			}
+			dic := ic.dryRun()
+			result.InterfaceClassification = &dic
+		}
+		results = append(results, result)
+	}
+	httpserver.WriteJSON(w, http.StatusOK, helpers.M{
+		"results": results,
+	})
+}
@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/clickhouse"
+	"akvorado/outlet/flow"
+	"akvorado/outlet/kafkainput"
+	"akvorado/outlet/metadata"
+	"akvorado/outlet/routing"
+)
+
+func TestClassifiersDryRunHTTP(t *testing.T) {
+	r := reporter.NewMock(t)
+	daemonComponent := daemon.NewMock(t)
+	metadataComponent := metadata.NewMock(t, r, metadata.DefaultConfiguration(),
+		metadata.Dependencies{Daemon: daemonComponent})
+	httpComponent := httpserver.NewMock(t, r)
+	configuration := DefaultConfiguration()
+	var rule ExporterClassifierRule
+	if err := rule.UnmarshalText([]byte(`ClassifyRegion("europe")`)); err != nil {
+		t.Fatalf("UnmarshalText() error:\n%+v", err)
+	}
+	configuration.ExporterClassifiers = []ExporterClassifierRule{rule}
+	flowComponent, err := flow.New(r, flow.DefaultConfiguration(), flow.Dependencies{Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("flow.New() error:\n%+v", err)
+	}
+	kafkaInputComponent, _ := kafkainput.NewMock(t, kafkainput.DefaultConfiguration())
+	c, err := New(r, configuration, Dependencies{
+		Daemon:     daemonComponent,
+		Flow:       flowComponent,
+		Metadata:   metadataComponent,
+		KafkaInput: kafkaInputComponent,
+		ClickHouse: clickhouse.NewMock(t, func(*schema.FlowMessage) {}),
+		HTTP:       httpComponent,
+		Routing:    routing.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	// Populate the metadata cache
+	metadataComponent.Lookup(time.Now(), netip.MustParseAddr("::ffff:192.0.2.142"), 10)
+
+	helpers.TestHTTPEndpoints(t, httpComponent.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "provided rules and samples",
+			URL:         "/api/v0/outlet/classifiers/test",
+			JSONInput: helpers.M{
+				"exporter-classifiers": []string{
+					`Exporter.Name startsWith "th2-" && ClassifyRegion("europe")`,
+					`ClassifyGroup("edge") && ClassifyRole("peering") && ClassifySite("th2") && ClassifyTenant("acme")`,
+					`ClassifyRegion("asia")`,
+				},
+				"interface-classifiers": []string{
+					`Interface.Description startsWith "Internal:" && ClassifyInternal()`,
+					`Interface.Description startsWith "Transit:" && ClassifyConnectivity("transit") && ClassifyProvider("cogent") && ClassifyExternal()`,
+				},
+				"samples": []helpers.M{
+					{
+						"exporter": helpers.M{"ip": "192.0.2.1", "name": "th2-edge1"},
+						"interface": helpers.M{
+							"index":       10,
+							"name":        "Gi0/0/10",
+							"description": "Transit: Cogent",
+							"speed":       10000,
+						},
+					}, {
+						"exporter": helpers.M{"ip": "192.0.2.2", "name": "par-edge1"},
+					}, {
+						"exporter": helpers.M{"ip": "192.0.2.3", "name": "th2-edge2", "region": "emea"},
+						"interface": helpers.M{
+							"index":        11,
+							"name":         "Gi0/0/11",
+							"description":  "Transit: Cogent",
+							"speed":        10000,
+							"connectivity": "pni",
+						},
+					},
+				},
+			},
+			JSONOutput: helpers.M{
+				"results": []helpers.M{
+					{
+						"exporter": helpers.M{"ip": "192.0.2.1", "name": "th2-edge1"},
+						"interface": helpers.M{
+							"index":       10,
+							"name":        "Gi0/0/10",
+							"description": "Transit: Cogent",
+							"speed":       10000,
+							"vlan":        0,
+						},
+						"exporter-rules": []helpers.M{
+							{
+								"index":   0,
+								"rule":    `Exporter.Name startsWith "th2-" && ClassifyRegion("europe")`,
+								"matched": true,
+								"classification": helpers.M{
+									"group": "", "role": "", "site": "", "region": "europe", "tenant": "",
+									"reject": false,
+								},
+							}, {
+								"index":   1,
+								"rule":    `ClassifyGroup("edge") && ClassifyRole("peering") && ClassifySite("th2") && ClassifyTenant("acme")`,
+								"matched": true,
+								"classification": helpers.M{
+									"group": "edge", "role": "peering", "site": "th2", "region": "europe", "tenant": "acme",
+									"reject": false,
+								},
+							},
+						},
+						"exporter-classification": helpers.M{
+							"group": "edge", "role": "peering", "site": "th2", "region": "europe", "tenant": "acme",
+							"reject": false,
+						},
+						"exporter-match": 1,
+						"interface-rules": []helpers.M{
+							{
+								"index":   0,
+								"rule":    `Interface.Description startsWith "Internal:" && ClassifyInternal()`,
+								"matched": false,
+								"classification": helpers.M{
+									"connectivity": "", "provider": "", "boundary": "undefined",
+									"name": "", "description": "", "reject": false,
+								},
+							}, {
+								"index":   1,
+								"rule":    `Interface.Description startsWith "Transit:" && ClassifyConnectivity("transit") && ClassifyProvider("cogent") && ClassifyExternal()`,
+								"matched": true,
+								"classification": helpers.M{
+									"connectivity": "transit", "provider": "cogent", "boundary": "external",
+									"name": "", "description": "", "reject": false,
+								},
+							},
+						},
+						"interface-classification": helpers.M{
+							"connectivity": "transit", "provider": "cogent", "boundary": "external",
+							"name": "Gi0/0/10", "description": "Transit: Cogent", "reject": false,
+						},
+						"interface-match": 1,
+					}, {
+						"exporter": helpers.M{"ip": "192.0.2.2", "name": "par-edge1"},
+						"exporter-rules": []helpers.M{
+							{
+								"index":   0,
+								"rule":    `Exporter.Name startsWith "th2-" && ClassifyRegion("europe")`,
+								"matched": false,
+								"classification": helpers.M{
+									"group": "", "role": "", "site": "", "region": "", "tenant": "",
+									"reject": false,
+								},
+							}, {
+								"index":   1,
+								"rule":    `ClassifyGroup("edge") && ClassifyRole("peering") && ClassifySite("th2") && ClassifyTenant("acme")`,
+								"matched": true,
+								"classification": helpers.M{
+									"group": "edge", "role": "peering", "site": "th2", "region": "", "tenant": "acme",
+									"reject": false,
+								},
+							}, {
+								"index":   2,
+								"rule":    `ClassifyRegion("asia")`,
+								"matched": true,
+								"classification": helpers.M{
+									"group": "edge", "role": "peering", "site": "th2", "region": "asia", "tenant": "acme",
+									"reject": false,
+								},
+							},
+						},
+						"exporter-classification": helpers.M{
+							"group": "edge", "role": "peering", "site": "th2", "region": "asia", "tenant": "acme",
+							"reject": false,
+						},
+						"exporter-match": 2,
+					}, {
+						"exporter": helpers.M{"ip": "192.0.2.3", "name": "th2-edge2", "region": "emea"},
+						"interface": helpers.M{
+							"index":        11,
+							"name":         "Gi0/0/11",
+							"description":  "Transit: Cogent",
+							"speed":        10000,
+							"vlan":         0,
+							"connectivity": "pni",
+						},
+						"exporter-rules": []helpers.M{},
+						"exporter-classification": helpers.M{
+							"group": "", "role": "", "site": "", "region": "emea", "tenant": "",
+							"reject": false,
+						},
+						"exporter-match":    nil,
+						"exporter-provided": true,
+						"interface-classification": helpers.M{
+							"connectivity": "pni", "provider": "", "boundary": "undefined",
+							"name": "Gi0/0/11", "description": "Transit: Cogent", "reject": false,
+						},
+						"interface-provided": true,
+					},
+				},
+			},
+		}, {
+			Description: "current rules and metadata cache",
+			URL:         "/api/v0/outlet/classifiers/test",
+			JSONInput:   helpers.M{"interface-classifiers": []string{}},
+			JSONOutput: helpers.M{
+				"results": []helpers.M{
+					{
+						"exporter": helpers.M{"ip": "192.0.2.142", "name": "192_0_2_142"},
+						"interface": helpers.M{
+							"index":       10,
+							"name":        "Gi0/0/10",
+							"description": "Interface 10",
+							"speed":       1000,
+							"vlan":        0,
+						},
+						"exporter-rules": []helpers.M{
+							{
+								"index":   0,
+								"rule":    `ClassifyRegion("europe")`,
+								"matched": true,
+								"classification": helpers.M{
+									"group": "", "role": "", "site": "", "region": "europe", "tenant": "",
+									"reject": false,
+								},
+							},
+						},
+						"exporter-classification": helpers.M{
+							"group": "", "role": "", "site": "", "region": "europe", "tenant": "",
+							"reject": false,
+						},
+						"exporter-match": nil,
+						"interface-classification": helpers.M{
+							"connectivity": "", "provider": "", "boundary": "undefined",
+							"name": "Gi0/0/10", "description": "Interface 10", "reject": false,
+						},
+					},
+				},
+			},
+		}, {
+			Description: "invalid rule",
+			URL:         "/api/v0/outlet/classifiers/test",
+			JSONInput: helpers.M{
+				"exporter-classifiers": []string{`ClassifyUnknown("europe")`},
+			},
+			StatusCode: 400,
+			JSONOutput: helpers.M{"message": `Cannot compile exporter classifier rule "ClassifyUnknown(\"europe\")": unknown name ClassifyUnknown (1:1)
+ | ClassifyUnknown("europe")
+ | ^`},
+		},
+	})
+}
@@ -26,8 +26,11 @@ func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 	var (
 		flowExporterName                                                       string
 		flowInIfName, flowInIfDescription, flowOutIfName, flowOutIfDescription string
+		flowInIfNeighbor, flowOutIfNeighbor                                    string
+		flowInIfOperStatus, flowOutIfOperStatus                                string
 		flowInIfSpeed, flowOutIfSpeed, flowInIfIndex, flowOutIfIndex           uint32
 		flowInIfVlan, flowOutIfVlan                                            uint16
+		exporterSamplingRate                                                   uint
 	)
 	var skip bool
 
@@ -48,10 +51,13 @@ func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 			expClassification.Tenant = answer.Exporter.Tenant
 			expClassification.Site = answer.Exporter.Site
 			expClassification.Group = answer.Exporter.Group
+			exporterSamplingRate = answer.Exporter.SamplingRate
 			flowInIfIndex = flow.InIf
 			flowInIfName = answer.Interface.Name
 			flowInIfDescription = answer.Interface.Description
 			flowInIfSpeed = uint32(answer.Interface.Speed)
+			flowInIfNeighbor = answer.Interface.Neighbor
+			flowInIfOperStatus = answer.Interface.OperStatus
 			inIfClassification.Provider = answer.Interface.Provider
 			inIfClassification.Connectivity = answer.Interface.Connectivity
 			inIfClassification.Boundary = answer.Interface.Boundary
@@ -68,10 +74,13 @@ func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 			expClassification.Tenant = answer.Exporter.Tenant
 			expClassification.Site = answer.Exporter.Site
 			expClassification.Group = answer.Exporter.Group
+			exporterSamplingRate = answer.Exporter.SamplingRate
 			flowOutIfIndex = flow.OutIf
 			flowOutIfName = answer.Interface.Name
 			flowOutIfDescription = answer.Interface.Description
 			flowOutIfSpeed = uint32(answer.Interface.Speed)
+			flowOutIfNeighbor = answer.Interface.Neighbor
+			flowOutIfOperStatus = answer.Interface.OperStatus
 			outIfClassification.Provider = answer.Interface.Provider
 			outIfClassification.Connectivity = answer.Interface.Connectivity
 			outIfClassification.Boundary = answer.Interface.Boundary
@@ -88,9 +97,15 @@ func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 		skip = true
 	}
 
+	if flow.SamplingRate > 0 {
+		c.observeSamplingRate(t, exporterIP, exporterStr, flow.InIf, flow.SamplingRate)
+	}
 	if samplingRate, ok := c.config.OverrideSamplingRate.Lookup(exporterIP); ok && samplingRate > 0 {
 		flow.SamplingRate = uint64(samplingRate)
 	}
+	if flow.SamplingRate == 0 && exporterSamplingRate > 0 {
+		flow.SamplingRate = uint64(exporterSamplingRate)
+	}
 	if flow.SamplingRate == 0 {
 		if samplingRate, ok := c.config.DefaultSamplingRate.Lookup(exporterIP); ok && samplingRate > 0 {
 			flow.SamplingRate = uint64(samplingRate)
@@ -105,30 +120,39 @@ func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 	}
 
 	// Classification
-	if !c.classifyExporter(t, exporterStr, flowExporterName, flow, expClassification) ||
-		!c.classifyInterface(t, flow,
-			exporterInfo{IP: exporterStr, Name: flowExporterName},
-			interfaceInfo{
-				Index:       flowOutIfIndex,
-				Name:        flowOutIfName,
-				Description: flowOutIfDescription,
-				Speed:       flowOutIfSpeed,
-				VLAN:        flowOutIfVlan,
-			}, outIfClassification,
-			false) ||
-		!c.classifyInterface(t, flow,
-			exporterInfo{IP: exporterStr, Name: flowExporterName},
-			interfaceInfo{
-				Index:       flowInIfIndex,
-				Name:        flowInIfName,
-				Description: flowInIfDescription,
-				Speed:       flowInIfSpeed,
-				VLAN:        flowInIfVlan,
-			}, inIfClassification,
-			true) {
+	var ok bool
+	if expClassification, ok = c.classifyExporter(t, exporterStr, flowExporterName, flow, expClassification); !ok {
 		// Flow is rejected
 		return true
 	}
+	if outIfClassification, ok = c.classifyInterface(t, flow,
+		exporterInfo{IP: exporterStr, Name: flowExporterName},
+		interfaceInfo{
+			Index:       flowOutIfIndex,
+			Name:        flowOutIfName,
+			Description: flowOutIfDescription,
+			Speed:       flowOutIfSpeed,
+			VLAN:        flowOutIfVlan,
+			Neighbor:    flowOutIfNeighbor,
+			OperStatus:  flowOutIfOperStatus,
+		}, outIfClassification,
+		false); !ok {
+		return true
+	}
+	if inIfClassification, ok = c.classifyInterface(t, flow,
+		exporterInfo{IP: exporterStr, Name: flowExporterName},
+		interfaceInfo{
+			Index:       flowInIfIndex,
+			Name:        flowInIfName,
+			Description: flowInIfDescription,
+			Speed:       flowInIfSpeed,
+			VLAN:        flowInIfVlan,
+			Neighbor:    flowInIfNeighbor,
+			OperStatus:  flowInIfOperStatus,
+		}, inIfClassification,
+		true); !ok {
+		return true
+	}
 
 	ctx := c.t.Context(context.Background())
 	sourceRouting := c.d.Routing.Lookup(ctx, flow.SrcAddr, netip.Addr{}, flow.ExporterAddress)
@@ -152,6 +176,19 @@ func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 	// set asns according to user config
 	flow.SrcAS = c.getASNumber(flow.SrcAS, sourceRouting.ASN, srcNet.ASN, flow.SrcNetMask)
 	flow.DstAS = c.getASNumber(flow.DstAS, destRouting.ASN, dstNet.ASN, flow.DstNetMask)
+
+	// Flow classification happens once the AS numbers are known and before
+	// the remaining columns are set, so they can be overridden. Exporter and
+	// interface columns are already set and flow classifiers cannot use them.
+	if !c.classifyFlow(flow, exporterStr, flowExporterName) {
+		return true
+	}
+
+	// Deduplication happens once the flow is known to be kept.
+	if c.deduplicate(flow, exporterIP, exporterStr, expClassification.Group, inIfClassification.Boundary) {
+		return true
+	}
+
 	flow.AppendArrayUInt32(schema.ColumnSrcCommunities, sourceRouting.Communities)
 	flow.AppendArrayUInt32(schema.ColumnDstCommunities, destRouting.Communities)
 	flow.AppendArrayUInt32(schema.ColumnDstASPath, destRouting.ASPath)
@@ -180,10 +217,15 @@ func (w *worker) enrichFlow(exporterIP netip.Addr, exporterStr string) bool {
 		flow.AppendString(schema.ColumnSrcGeoCity, srcNet.City)
 		flow.AppendString(schema.ColumnDstGeoCity, dstNet.City)
 	}
+	c.writeApplications(flow, srcNet, dstNet)
 
 	flow.AppendString(schema.ColumnExporterName, flowExporterName)
 	flow.AppendUint(schema.ColumnInIfSpeed, uint64(flowInIfSpeed))
 	flow.AppendUint(schema.ColumnOutIfSpeed, uint64(flowOutIfSpeed))
+	flow.AppendString(schema.ColumnInIfNeighbor, flowInIfNeighbor)
+	flow.AppendString(schema.ColumnOutIfNeighbor, flowOutIfNeighbor)
+	flow.AppendString(schema.ColumnInIfOperStatus, flowInIfOperStatus)
+	flow.AppendString(schema.ColumnOutIfOperStatus, flowOutIfOperStatus)
 
 	return skip
 }
@@ -276,21 +318,31 @@ func (c *Component) writeExporter(flow *schema.FlowMessage, classification expor
 	return true
 }
 
-func (c *Component) classifyExporter(t time.Time, ip, name string, flow *schema.FlowMessage, classification exporterClassification) bool {
+// classifyExporter executes the exporter classifiers. It returns the final
+// classification and false if the flow is rejected.
+func (c *Component) classifyExporter(t time.Time, ip, name string, flow *schema.FlowMessage, classification exporterClassification) (exporterClassification, bool) {
+	classification = c.execExporterClassifiers(t, ip, name, classification)
+	return classification, c.writeExporter(flow, classification)
+}
+
+// execExporterClassifiers executes the exporter classifiers, unless the
+// metadata component already provided a classification.
+func (c *Component) execExporterClassifiers(t time.Time, ip, name string, classification exporterClassification) exporterClassification {
 	// we already have the info provided by the metadata component
 	if (classification != exporterClassification{}) {
-		return c.writeExporter(flow, classification)
+		return classification
 	}
-	if len(c.config.ExporterClassifiers) == 0 {
-		return true
+	rules := c.classifiers.Load()
+	if len(rules.exporter) == 0 {
+		return classification
 	}
 	si := exporterInfo{IP: ip, Name: name}
-	if classification, ok := c.classifierExporterCache.Get(t, si); ok {
-		return c.writeExporter(flow, classification)
+	if classification, ok := rules.exporterCache.Get(t, si); ok {
+		return classification
 	}
 
-	for idx, rule := range c.config.ExporterClassifiers {
-		if err := rule.exec(si, &classification); err != nil {
+	for idx, rule := range rules.exporter {
+		if _, err := rule.exec(si, &classification, c.lookupTables); err != nil {
 			c.classifierErrLogger.Err(err).
 				Str("type", "exporter").
 				Int("index", idx).
@@ -299,13 +351,82 @@ func (c *Component) classifyExporter(t time.Time, ip, name string, flow *schema.
 			c.metrics.classifierErrors.WithLabelValues("exporter", strconv.Itoa(idx)).Inc()
 			break
 		}
-		if classification.Group == "" || classification.Role == "" || classification.Site == "" || classification.Region == "" || classification.Tenant == "" {
-			continue
+		if classification.complete() {
+			break
+		}
+	}
+	rules.exporterCache.Put(t, si, classification)
+	return classification
+}
+
+func (c *Component) writeFlow(flow *schema.FlowMessage, classification flowClassification) bool {
+	if classification.Reject {
+		return false
+	}
+	for key, value := range classification.Columns {
+		if column, ok := c.d.Schema.LookupColumnByKey(key); ok && isWritableStringColumn(column) {
+			flow.AppendString(key, value)
+		}
+	}
+	if classification.SamplingRate > 0 {
+		flow.SamplingRate = classification.SamplingRate
+	}
+	if classification.SrcAS > 0 {
+		flow.SrcAS = classification.SrcAS
+	}
+	if classification.DstAS > 0 {
+		flow.DstAS = classification.DstAS
+	}
+	return true
+}
+
+// classifyFlow executes the flow classifiers. Unlike the other classifiers,
+// there is no cache as the result depends on each flow.
+func (c *Component) classifyFlow(flow *schema.FlowMessage, exporterIP, exporterName string) bool {
+	rules := c.classifiers.Load().flow
+	if len(rules) == 0 {
+		return true
+	}
+	fi := flowInfo{
+		ExporterAddress:  exporterIP,
+		ExporterName:     exporterName,
+		InIf:             flow.InIf,
+		OutIf:            flow.OutIf,
+		SrcAddr:          flow.SrcAddr.Unmap().String(),
+		DstAddr:          flow.DstAddr.Unmap().String(),
+		NextHop:          flow.NextHop.Unmap().String(),
+		SrcAS:            flow.SrcAS,
+		DstAS:            flow.DstAS,
+		SrcNetMask:       flow.SrcNetMask,
+		DstNetMask:       flow.DstNetMask,
+		SrcVlan:          flow.SrcVlan,
+		DstVlan:          flow.DstVlan,
+		SamplingRate:     flow.SamplingRate,
+		Bytes:            flow.GetUint(schema.ColumnBytes),
+		Packets:          flow.GetUint(schema.ColumnPackets),
+		EType:            uint16(flow.GetUint(schema.ColumnEType)),
+		Proto:            uint8(flow.GetUint(schema.ColumnProto)),
+		SrcPort:          uint16(flow.GetUint(schema.ColumnSrcPort)),
+		DstPort:          uint16(flow.GetUint(schema.ColumnDstPort)),
+		TCPFlags:         uint16(flow.GetUint(schema.ColumnTCPFlags)),
+		ForwardingStatus: uint8(flow.GetUint(schema.ColumnForwardingStatus)),
+	}
+	classification := flowClassification{}
+	for idx, rule := range rules {
+		if err := rule.exec(fi, &classification, c.lookupTables); err != nil {
+			c.classifierErrLogger.Err(err).
+				Str("type", "flow").
+				Int("index", idx).
+				Str("exporter", exporterName).
+				Msg("error executing classifier")
+			c.metrics.classifierErrors.WithLabelValues("flow", strconv.Itoa(idx)).Inc()
+			break
+		}
+		if classification.Reject {
+			break
 		}
-		break
 	}
-	c.classifierExporterCache.Put(t, si, classification)
-	return c.writeExporter(flow, classification)
+	return c.writeFlow(flow, classification)
 }
 
 func (c *Component) writeInterface(flow *schema.FlowMessage, classification interfaceClassification, directionIn bool) bool {
@@ -328,6 +449,8 @@ func (c *Component) writeInterface(flow *schema.FlowMessage, classification inte
 	return true
 }
 
+// classifyInterface executes the interface classifiers. It returns the final
+// classification and false if the flow is rejected.
 func (c *Component) classifyInterface(
 	t time.Time,
 	fl *schema.FlowMessage,
@@ -335,30 +458,30 @@ func (c *Component) classifyInterface(
 	ii interfaceInfo,
 	classification interfaceClassification,
 	directionIn bool,
-) bool {
+) (interfaceClassification, bool) {
 	// we already have the info provided by the metadata component
 	if (classification != interfaceClassification{}) {
 		classification.Name = ii.Name
 		classification.Description = ii.Description
-		return c.writeInterface(fl, classification, directionIn)
+		return classification, c.writeInterface(fl, classification, directionIn)
 	}
-	if len(c.config.InterfaceClassifiers) == 0 {
+	rules := c.classifiers.Load()
+	if len(rules.iface) == 0 {
 		classification.Name = ii.Name
 		classification.Description = ii.Description
 		c.writeInterface(fl, classification, directionIn)
-		return true
+		return classification, true
 	}
 	key := exporterAndInterfaceInfo{
 		Exporter:  ei,
 		Interface: ii,
 	}
-	if classification, ok := c.classifierInterfaceCache.Get(t, key); ok {
-		return c.writeInterface(fl, classification, directionIn)
+	if classification, ok := rules.interfaceCache.Get(t, key); ok {
+		return classification, c.writeInterface(fl, classification, directionIn)
 	}
 
-	for idx, rule := range c.config.InterfaceClassifiers {
-		err := rule.exec(ei, ii, &classification)
-		if err != nil {
+	for idx, rule := range rules.iface {
+		if _, err := rule.exec(ei, ii, &classification, c.lookupTables); err != nil {
 			c.classifierErrLogger.Err(err).
 				Str("type", "interface").
 				Int("index", idx).
@@ -368,13 +491,9 @@ func (c *Component) classifyInterface(
 			c.metrics.classifierErrors.WithLabelValues("interface", strconv.Itoa(idx)).Inc()
 			break
 		}
-		if classification.Connectivity == "" || classification.Provider == "" {
-			continue
-		}
-		if classification.Boundary == schema.InterfaceBoundaryUndefined {
-			continue
+		if classification.complete() {
+			break
 		}
-		break
 	}
 	if classification.Name == "" {
 		classification.Name = ii.Name
@@ -382,8 +501,8 @@ func (c *Component) classifyInterface(
 	if classification.Description == "" {
 		classification.Description = ii.Description
 	}
-	c.classifierInterfaceCache.Put(t, key, classification)
-	return c.writeInterface(fl, classification, directionIn)
+	rules.interfaceCache.Put(t, key, classification)
+	return classification, c.writeInterface(fl, classification, directionIn)
 }
 
 func isPrivateAS(as uint32) bool {
@@ -257,6 +257,69 @@ func TestEnrich(t *testing.T) {
 			},
 			OutputFlow: nil,
 		},
+		{
+			Name: "flow rule with reject",
+			Configuration: helpers.M{
+				"flowclassifiers": []string{
+					`Flow.DstPort == 873 && InSubnet(Flow.DstAddr, "192.0.2.0/24") && Reject()`,
+				},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+					DstAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnDstPort: uint16(873),
+					},
+				}
+			},
+			OutputFlow: nil,
+		},
+		{
+			Name: "flow rule with columns and rewrites",
+			Configuration: helpers.M{
+				"flowclassifiers": []string{
+					`Flow.Proto == 17 && Flow.SrcPort == 53 && SetColumn("SrcNetRole", "dns") && SetSamplingRate(100)`,
+					`Flow.SrcAS == 0 && SetSrcAS(64512) && SetColumn("SrcNetRole", "unknown") && SetColumn("DstNetRole", Format("to-%d", Flow.OutIf))`,
+				},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnProto:   uint32(17),
+						schema.ColumnSrcPort: uint16(53),
+					},
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    100,
+				InIf:            100,
+				OutIf:           200,
+				SrcAS:           64512,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnProto:            uint32(17),
+					schema.ColumnSrcPort:          uint16(53),
+					schema.ColumnSrcNetRole:       "dns",
+					schema.ColumnDstNetRole:       "to-200",
+					schema.ColumnSrcApplication:   "dns",
+					schema.ColumnExporterName:     "192_0_2_142",
+					schema.ColumnInIfName:         "Gi0/0/100",
+					schema.ColumnOutIfName:        "Gi0/0/200",
+					schema.ColumnInIfDescription:  "Interface 100",
+					schema.ColumnOutIfDescription: "Interface 200",
+					schema.ColumnInIfSpeed:        uint32(1000),
+					schema.ColumnOutIfSpeed:       uint32(1000),
+				},
+			},
+		},
 		{
 			Name: "interface rule with index",
 			Configuration: helpers.M{
@@ -291,6 +354,73 @@ func TestEnrich(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "interface rule with neighbor",
+			Configuration: helpers.M{
+				"interfaceclassifiers": []string{
+					`Interface.Neighbor != "" && ClassifyInternal() && ClassifyConnectivity("core")`,
+				},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            1020,
+					OutIf:           200,
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				InIf:            1020,
+				OutIf:           200,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnExporterName:     "192_0_2_142",
+					schema.ColumnInIfName:         "Gi0/0/1020",
+					schema.ColumnOutIfName:        "Gi0/0/200",
+					schema.ColumnInIfDescription:  "Interface 1020",
+					schema.ColumnOutIfDescription: "Interface 200",
+					schema.ColumnInIfSpeed:        uint32(1000),
+					schema.ColumnOutIfSpeed:       uint32(1000),
+					schema.ColumnInIfConnectivity: "core",
+					schema.ColumnInIfBoundary:     uint8(schema.InterfaceBoundaryInternal),
+					schema.ColumnInIfNeighbor:     "core1:Et1/1",
+				},
+			},
+		},
+		{
+			Name: "interface rule with operational status",
+			Configuration: helpers.M{
+				"interfaceclassifiers": []string{
+					`Interface.OperStatus == "down" && ClassifyConnectivity("down")`,
+				},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           1030,
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				InIf:            100,
+				OutIf:           1030,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnExporterName:      "192_0_2_142",
+					schema.ColumnInIfName:          "Gi0/0/100",
+					schema.ColumnOutIfName:         "Gi0/0/1030",
+					schema.ColumnInIfDescription:   "Interface 100",
+					schema.ColumnOutIfDescription:  "Interface 1030",
+					schema.ColumnInIfSpeed:         uint32(1000),
+					schema.ColumnOutIfSpeed:        uint32(1000),
+					schema.ColumnOutIfConnectivity: "down",
+					schema.ColumnOutIfOperStatus:   "down",
+				},
+			},
+		},
 		{
 			Name: "interface rule with rename",
 			Configuration: helpers.M{
@@ -671,6 +801,107 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 				},
 			},
 		},
+		{
+			Name: "application classification",
+			Configuration: helpers.M{
+				"applicationclassifiers": []helpers.M{
+					{
+						"name":      "intranet",
+						"protocols": []string{"tcp"},
+						"ports":     []uint16{443},
+						"prefixes":  []string{"203.0.113.0/24"},
+					},
+					{
+						"name":     "customers",
+						"netroles": []string{"customer"},
+					},
+				},
+			},
+			Networks: &networks.Configuration{
+				Networks: helpers.MustNewSubnetMap(map[string]networks.NetworkAttributes{
+					"::ffff:198.51.100.0/120": {Role: "customer"},
+				}),
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+					SrcAddr:         netip.MustParseAddr("::ffff:198.51.100.10"),
+					DstAddr:         netip.MustParseAddr("::ffff:203.0.113.5"),
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnProto:   uint32(6),
+						schema.ColumnSrcPort: uint16(51234),
+						schema.ColumnDstPort: uint16(443),
+					},
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				InIf:            100,
+				OutIf:           200,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				SrcAddr:         netip.MustParseAddr("::ffff:198.51.100.10"),
+				DstAddr:         netip.MustParseAddr("::ffff:203.0.113.5"),
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnProto:            uint32(6),
+					schema.ColumnSrcPort:          uint16(51234),
+					schema.ColumnDstPort:          uint16(443),
+					schema.ColumnExporterName:     "192_0_2_142",
+					schema.ColumnInIfName:         "Gi0/0/100",
+					schema.ColumnOutIfName:        "Gi0/0/200",
+					schema.ColumnInIfDescription:  "Interface 100",
+					schema.ColumnOutIfDescription: "Interface 200",
+					schema.ColumnInIfSpeed:        uint32(1000),
+					schema.ColumnOutIfSpeed:       uint32(1000),
+					schema.ColumnSrcNetRole:       "customer",
+					schema.ColumnSrcApplication:   "customers",
+					schema.ColumnDstApplication:   "intranet",
+				},
+			},
+		},
+		{
+			Name: "application set by flow classifier",
+			Configuration: helpers.M{
+				"flowclassifiers": []string{
+					`Flow.DstPort == 8080 && SetColumn("DstApplication", "proxy")`,
+				},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnProto:   uint32(6),
+						schema.ColumnSrcPort: uint16(22),
+						schema.ColumnDstPort: uint16(8080),
+					},
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				InIf:            100,
+				OutIf:           200,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				OtherColumns: map[schema.ColumnKey]any{
+					schema.ColumnProto:            uint32(6),
+					schema.ColumnSrcPort:          uint16(22),
+					schema.ColumnDstPort:          uint16(8080),
+					schema.ColumnExporterName:     "192_0_2_142",
+					schema.ColumnInIfName:         "Gi0/0/100",
+					schema.ColumnOutIfName:        "Gi0/0/200",
+					schema.ColumnInIfDescription:  "Interface 100",
+					schema.ColumnOutIfDescription: "Interface 200",
+					schema.ColumnInIfSpeed:        uint32(1000),
+					schema.ColumnOutIfSpeed:       uint32(1000),
+					schema.ColumnSrcApplication:   "ssh",
+					schema.ColumnDstApplication:   "proxy",
+				},
+			},
+		},
 		{
 			Name:          "flow with missing interfaces",
 			Configuration: helpers.M{},
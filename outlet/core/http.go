@@ -5,6 +5,7 @@ package core
 
 import (
 	"net/http"
+	"net/netip"
 	"strconv"
 	"sync/atomic"
 	"time"
@@ -65,3 +66,42 @@ func (c *Component) FlowsHTTPHandler(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 }
+
+// TemplatesHTTPHandler lists the templates and sampling rates known by the
+// decoders for each exporter.
+func (c *Component) TemplatesHTTPHandler(w http.ResponseWriter, _ *http.Request) {
+	httpserver.WriteJSON(w, http.StatusOK, helpers.M{
+		"exporters": c.d.Flow.Templates(),
+	})
+}
+
+// ExportersHTTPHandler lists the exporters subject to rate limiting with the
+// number of flows dropped by the rate limiter.
+func (c *Component) ExportersHTTPHandler(w http.ResponseWriter, _ *http.Request) {
+	httpserver.WriteJSON(w, http.StatusOK, helpers.M{
+		"exporters": c.rateLimiter.exporters(),
+	})
+}
+
+// FlushTemplatesHTTPHandler makes the decoders forget the templates and
+// sampling rates of an exporter. Flows from this exporter are dropped until it
+// sends them again.
+func (c *Component) FlushTemplatesHTTPHandler(w http.ResponseWriter, req *http.Request) {
+	exporter, err := netip.ParseAddr(req.PathValue("exporter"))
+	if err != nil {
+		httpserver.WriteJSON(w, http.StatusBadRequest, helpers.M{
+			"message": "Invalid exporter address",
+		})
+		return
+	}
+	if !c.d.Flow.FlushTemplates(exporter) {
+		httpserver.WriteJSON(w, http.StatusNotFound, helpers.M{
+			"message": "Unknown exporter",
+		})
+		return
+	}
+	c.r.Info().Str("exporter", exporter.Unmap().String()).Msg("templates flushed")
+	httpserver.WriteJSON(w, http.StatusOK, helpers.M{
+		"message": "Templates flushed",
+	})
+}
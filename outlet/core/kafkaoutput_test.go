@@ -46,6 +46,8 @@ func (w *finalizingWorker) FinalizeAndSend(context.Context) clickhouse.WorkerSta
 	return clickhouse.WorkerStatusIdle
 }
 
+func (w *finalizingWorker) AppendInterfaceCounters(context.Context, *clickhouse.InterfaceCounters) {}
+
 func (w *finalizingWorker) Flush(context.Context) { w.bf.Clear() }
 
 // TestCoreKafkaOutput wires an enabled Kafka output into the worker and checks the
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync/atomic"
+
+	"akvorado/common/remotedatasource"
+)
+
+// lookupTables are the tables classifiers can query with Lookup(). The set of
+// tables is fixed, the content of each table is replaced on each refresh.
+type lookupTables struct {
+	tables map[string]*atomic.Pointer[map[string]string]
+}
+
+// lookupEntry is an entry of a lookup table, as fetched from a remote source.
+type lookupEntry struct {
+	Key   string `validate:"required"`
+	Value string
+}
+
+// newLookupTables creates an empty lookup table for each source.
+func newLookupTables(sources map[string]remotedatasource.Source) *lookupTables {
+	lt := &lookupTables{
+		tables: make(map[string]*atomic.Pointer[map[string]string], len(sources)),
+	}
+	for name := range sources {
+		lt.tables[name] = &atomic.Pointer[map[string]string]{}
+	}
+	return lt
+}
+
+// lookup returns the value associated to a key in a table. It returns an
+// empty string if the table or the key does not exist or if the table was not
+// fetched yet.
+func (lt *lookupTables) lookup(table, key string) string {
+	if lt == nil {
+		return ""
+	}
+	entries, ok := lt.tables[table]
+	if !ok {
+		return ""
+	}
+	if content := entries.Load(); content != nil {
+		return (*content)[key]
+	}
+	return ""
+}
+
+// update replaces the content of a table. It returns false if the content did
+// not change.
+func (lt *lookupTables) update(table string, content map[string]string) bool {
+	entries := lt.tables[table]
+	if current := entries.Load(); current != nil && maps.Equal(*current, content) {
+		return false
+	}
+	entries.Store(&content)
+	return true
+}
+
+// checkClassifierTables checks the lookup tables used by the classifiers are
+// configured.
+func (c *Component) checkClassifierTables(rules *classifierRules) error {
+	check := func(rule fmt.Stringer, tables []string) error {
+		for _, table := range tables {
+			if _, ok := c.config.LookupTables[table]; !ok {
+				return fmt.Errorf("classifier %q uses unknown lookup table %q", rule, table)
+			}
+		}
+		return nil
+	}
+	for _, rule := range rules.exporter {
+		if err := check(rule, rule.tables); err != nil {
+			return err
+		}
+	}
+	for _, rule := range rules.iface {
+		if err := check(rule, rule.tables); err != nil {
+			return err
+		}
+	}
+	for _, rule := range rules.flow {
+		if err := check(rule, rule.tables); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateLookupTable updates a lookup table from a remote source. It returns the
+// number of entries retrieved. When the content changes, the classifier caches
+// are replaced by empty ones. As for a reload of the rules, a classification
+// computed with the previous content can only be stored in an unreachable
+// cache.
+func (c *Component) UpdateLookupTable(ctx context.Context, name string, source remotedatasource.Source) (int, error) {
+	results, err := c.lookupTablesFetcher.Fetch(ctx, name, source)
+	if err != nil {
+		return 0, err
+	}
+	content := make(map[string]string, len(results))
+	for _, result := range results {
+		content[result.Key] = result.Value
+	}
+	if c.lookupTables.update(name, content) {
+		for {
+			rules := c.classifiers.Load()
+			if c.classifiers.CompareAndSwap(rules, rules.withEmptyCaches()) {
+				break
+			}
+		}
+	}
+	return len(results), nil
+}
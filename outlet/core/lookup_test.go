@@ -0,0 +1,270 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/remotedatasource"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+func TestLookupTables(t *testing.T) {
+	lt := newLookupTables(map[string]remotedatasource.Source{"circuits": {}})
+	if got := lt.lookup("circuits", "CID-1"); got != "" {
+		t.Errorf("lookup() before update == %q, expected empty", got)
+	}
+	if !lt.update("circuits", map[string]string{"CID-1": "cogent"}) {
+		t.Error("update() == false, expected true")
+	}
+	if lt.update("circuits", map[string]string{"CID-1": "cogent"}) {
+		t.Error("update() with same content == true, expected false")
+	}
+	if got := lt.lookup("circuits", "CID-1"); got != "cogent" {
+		t.Errorf("lookup() == %q, expected %q", got, "cogent")
+	}
+	if got := lt.lookup("circuits", "CID-2"); got != "" {
+		t.Errorf("lookup() for unknown key == %q, expected empty", got)
+	}
+	if got := lt.lookup("unknown", "CID-1"); got != "" {
+		t.Errorf("lookup() for unknown table == %q, expected empty", got)
+	}
+	var nilTables *lookupTables
+	if got := nilTables.lookup("circuits", "CID-1"); got != "" {
+		t.Errorf("lookup() on nil tables == %q, expected empty", got)
+	}
+}
+
+func TestClassifierLookup(t *testing.T) {
+	lt := newLookupTables(map[string]remotedatasource.Source{"circuits": {}, "exporters": {}})
+	lt.update("circuits", map[string]string{"CID-1": "cogent"})
+	lt.update("exporters", map[string]string{"edge1": "paris"})
+
+	t.Run("exporter", func(t *testing.T) {
+		var rule ExporterClassifierRule
+		if err := rule.UnmarshalText([]byte(`ClassifySite(Lookup("exporters", Exporter.Name))`)); err != nil {
+			t.Fatalf("UnmarshalText() error:\n%+v", err)
+		}
+		if diff := helpers.Diff(rule.tables, []string{"exporters"}); diff != "" {
+			t.Errorf("UnmarshalText() tables (-got, +want):\n%s", diff)
+		}
+		var classification exporterClassification
+		if _, err := rule.exec(exporterInfo{Name: "edge1"}, &classification, lt); err != nil {
+			t.Fatalf("exec() error:\n%+v", err)
+		}
+		if classification.Site != "paris" {
+			t.Errorf("exec() site == %q, expected %q", classification.Site, "paris")
+		}
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		var rule InterfaceClassifierRule
+		if err := rule.UnmarshalText([]byte(`
+let provider = Lookup("circuits", trimPrefix(Interface.Description, "Transit: "));
+provider != "" && ClassifyProvider(provider) && ClassifyConnectivity("transit")`)); err != nil {
+			t.Fatalf("UnmarshalText() error:\n%+v", err)
+		}
+		if diff := helpers.Diff(rule.tables, []string{"circuits"}); diff != "" {
+			t.Errorf("UnmarshalText() tables (-got, +want):\n%s", diff)
+		}
+		var classification interfaceClassification
+		matched, err := rule.exec(exporterInfo{Name: "edge1"},
+			interfaceInfo{Description: "Transit: CID-1"}, &classification, lt)
+		if err != nil {
+			t.Fatalf("exec() error:\n%+v", err)
+		}
+		if !matched || classification.Provider != "cogent" || classification.Connectivity != "transit" {
+			t.Errorf("exec() == %v, %+v", matched, classification)
+		}
+		classification = interfaceClassification{}
+		matched, err = rule.exec(exporterInfo{Name: "edge1"},
+			interfaceInfo{Description: "Transit: CID-2"}, &classification, lt)
+		if err != nil {
+			t.Fatalf("exec() error:\n%+v", err)
+		}
+		if matched || classification.Provider != "" {
+			t.Errorf("exec() == %v, %+v", matched, classification)
+		}
+	})
+
+	t.Run("flow", func(t *testing.T) {
+		var rule FlowClassifierRule
+		if err := rule.UnmarshalText([]byte(`SetColumn("SrcNetName", Lookup("exporters", Flow.ExporterName))`)); err != nil {
+			t.Fatalf("UnmarshalText() error:\n%+v", err)
+		}
+		var classification flowClassification
+		if err := rule.exec(flowInfo{ExporterName: "edge1"}, &classification, lt); err != nil {
+			t.Fatalf("exec() error:\n%+v", err)
+		}
+		if got := classification.Columns[schema.ColumnSrcNetName]; got != "paris" {
+			t.Errorf("exec() SrcNetName == %q, expected %q", got, "paris")
+		}
+	})
+}
+
+func TestLookupTableUnknown(t *testing.T) {
+	r := reporter.NewMock(t)
+	var rule InterfaceClassifierRule
+	if err := rule.UnmarshalText([]byte(`ClassifyProvider(Lookup("circuits", Interface.Description))`)); err != nil {
+		t.Fatalf("UnmarshalText() error:\n%+v", err)
+	}
+	configuration := DefaultConfiguration()
+	configuration.InterfaceClassifiers = []InterfaceClassifierRule{rule}
+	_, err := New(r, configuration, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Schema: schema.NewMock(t),
+	})
+	if err == nil {
+		t.Fatal("New() did not error")
+	}
+}
+
+func TestUpdateLookupTable(t *testing.T) {
+	r := reporter.NewMock(t)
+	content := "CID-1,cogent\nCID-2,lumen\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		fmt.Fprint(w, content)
+	}))
+	t.Cleanup(server.Close)
+
+	var rule InterfaceClassifierRule
+	if err := rule.UnmarshalText([]byte(`ClassifyProvider(Lookup("circuits", Interface.Description))`)); err != nil {
+		t.Fatalf("UnmarshalText() error:\n%+v", err)
+	}
+	source := remotedatasource.Source{
+		URL:       server.URL,
+		Method:    "GET",
+		Timeout:   time.Second,
+		Interval:  time.Minute,
+		Parser:    remotedatasource.ParserCSVComma,
+		Transform: remotedatasource.MustParseTransformQuery(`.[] | {key: .f1, value: .f2}`),
+	}
+	configuration := DefaultConfiguration()
+	configuration.InterfaceClassifiers = []InterfaceClassifierRule{rule}
+	configuration.LookupTables = map[string]remotedatasource.Source{"circuits": source}
+	c, err := New(r, configuration, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Schema: schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	count, err := c.UpdateLookupTable(context.Background(), "circuits", source)
+	if err != nil {
+		t.Fatalf("UpdateLookupTable() error:\n%+v", err)
+	}
+	if count != 2 {
+		t.Errorf("UpdateLookupTable() == %d, expected 2", count)
+	}
+	if got := c.lookupTables.lookup("circuits", "CID-2"); got != "lumen" {
+		t.Errorf("lookup() == %q, expected %q", got, "lumen")
+	}
+
+	// The cache is only flushed when the content changes.
+	now := time.Now()
+	key := exporterAndInterfaceInfo{Interface: interfaceInfo{Description: "CID-2"}}
+	c.classifiers.Load().interfaceCache.Put(now, key, interfaceClassification{Provider: "lumen"})
+	if _, err := c.UpdateLookupTable(context.Background(), "circuits", source); err != nil {
+		t.Fatalf("UpdateLookupTable() error:\n%+v", err)
+	}
+	if c.classifiers.Load().interfaceCache.Size() != 1 {
+		t.Error("UpdateLookupTable() flushed the cache while content is unchanged")
+	}
+	content = "CID-1,cogent\nCID-2,level3\n"
+	if _, err := c.UpdateLookupTable(context.Background(), "circuits", source); err != nil {
+		t.Fatalf("UpdateLookupTable() error:\n%+v", err)
+	}
+	if c.classifiers.Load().interfaceCache.Size() != 0 {
+		t.Error("UpdateLookupTable() did not flush the cache")
+	}
+	if got := c.lookupTables.lookup("circuits", "CID-2"); got != "level3" {
+		t.Errorf("lookup() == %q, expected %q", got, "level3")
+	}
+}
+
+func TestUpdateLookupTableConcurrently(t *testing.T) {
+	r := reporter.NewMock(t)
+	content := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		fmt.Fprint(w, content)
+	}))
+	t.Cleanup(server.Close)
+
+	var rule ExporterClassifierRule
+	if err := rule.UnmarshalText([]byte(`ClassifyGroup(Lookup("groups", Exporter.Name))`)); err != nil {
+		t.Fatalf("UnmarshalText() error:\n%+v", err)
+	}
+	source := remotedatasource.Source{
+		URL:       server.URL,
+		Method:    "GET",
+		Timeout:   time.Second,
+		Interval:  time.Minute,
+		Parser:    remotedatasource.ParserCSVComma,
+		Transform: remotedatasource.MustParseTransformQuery(`.[] | {key: .f1, value: .f2}`),
+	}
+	configuration := DefaultConfiguration()
+	configuration.ExporterClassifiers = []ExporterClassifierRule{rule}
+	configuration.LookupTables = map[string]remotedatasource.Source{"groups": source}
+	c, err := New(r, configuration, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Schema: schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	// Classify the same exporter from several workers while the table is
+	// updated. Once updated, a classification made with the previous content
+	// should never be returned again.
+	now := time.Now()
+	classify := func() string {
+		return c.execExporterClassifiers(now, "192.0.2.1", "exporter1", exporterClassification{}).Group
+	}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for range 8 {
+		wg.Go(func() {
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					classify()
+					runtime.Gosched()
+				}
+			}
+		})
+	}
+	for i := range 20 {
+		group := fmt.Sprintf("group%d", i)
+		content = fmt.Sprintf("exporter1,%s\n", group)
+		if _, err := c.UpdateLookupTable(context.Background(), "groups", source); err != nil {
+			t.Fatalf("UpdateLookupTable() error:\n%+v", err)
+		}
+		for range 100 {
+			if got := classify(); got != group {
+				t.Fatalf("execExporterClassifiers() group: got %q, expected %q", got, group)
+			}
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -16,11 +16,18 @@ type metrics struct {
 	flowsForwarded   *reporter.CounterVec
 	flowsErrors      *reporter.CounterVec
 	flowsRateLimited *reporter.CounterVec
+	flowsDuplicated  *reporter.CounterVec
 	flowsHTTPClients reporter.GaugeFunc
 
+	interfaceCountersReceived *reporter.CounterVec
+
 	classifierExporterCacheSize  reporter.CounterFunc
 	classifierInterfaceCacheSize reporter.CounterFunc
 	classifierErrors             *reporter.CounterVec
+	classifierReloads            *reporter.CounterVec
+
+	samplingRateChanges    *reporter.CounterVec
+	samplingRateMismatches *reporter.CounterVec
 }
 
 func (c *Component) initMetrics() {
@@ -51,6 +58,13 @@ func (c *Component) initMetrics() {
 		},
 		[]string{"exporter"},
 	)
+	c.metrics.interfaceCountersReceived = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "received_interface_counters_total",
+			Help: "Number of incoming interface counters.",
+		},
+		[]string{"exporter"},
+	)
 	c.metrics.flowsErrors = c.r.CounterVec(
 		reporter.CounterOpts{
 			Name: "flows_errors_total",
@@ -65,6 +79,13 @@ func (c *Component) initMetrics() {
 		},
 		[]string{"exporter"},
 	)
+	c.metrics.flowsDuplicated = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "flows_duplicated_total",
+			Help: "Number of flows already seen by an upstream exporter.",
+		},
+		[]string{"exporter", "action"},
+	)
 	c.metrics.flowsHTTPClients = c.r.GaugeFunc(
 		reporter.GaugeOpts{
 			Name: "flows_http_clients",
@@ -81,7 +102,7 @@ func (c *Component) initMetrics() {
 			Help: "Number of items in the exporter classifier cache.",
 		},
 		func() float64 {
-			return float64(c.classifierExporterCache.Size())
+			return float64(c.classifiers.Load().exporterCache.Size())
 		},
 	)
 	c.metrics.classifierInterfaceCacheSize = c.r.CounterFunc(
@@ -90,7 +111,7 @@ func (c *Component) initMetrics() {
 			Help: "Number of items in the interface classifier cache.",
 		},
 		func() float64 {
-			return float64(c.classifierInterfaceCache.Size())
+			return float64(c.classifiers.Load().interfaceCache.Size())
 		},
 	)
 	c.metrics.classifierErrors = c.r.CounterVec(
@@ -99,4 +120,22 @@ func (c *Component) initMetrics() {
 			Help: "Number of errors when evaluating a classifer.",
 		},
 		[]string{"type", "index"})
+	c.metrics.samplingRateChanges = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "sampling_rate_changes_total",
+			Help: "Number of changes of the sampling rate observed for an exporter and an interface.",
+		},
+		[]string{"exporter"})
+	c.metrics.samplingRateMismatches = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "sampling_rate_override_mismatches_total",
+			Help: "Number of new sampling rates observed differing from the configured override.",
+		},
+		[]string{"exporter"})
+	c.metrics.classifierReloads = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "classifier_reloads_total",
+			Help: "Number of attempts to reload the classifier rules.",
+		},
+		[]string{"result"})
 }
@@ -5,6 +5,7 @@ package core
 
 import (
 	"net/netip"
+	"slices"
 
 	"github.com/puzpuzpuz/xsync/v4"
 )
@@ -19,6 +20,15 @@ type perExporterRateLimiter struct {
 	total         uint64  // received during the current second
 	factor        float64 // sampling rate correction computed from the last second
 	currentSecond uint64  // second the two counters above apply to
+	rateLimited   uint64  // dropped since the exporter was first seen
+}
+
+// rateLimitedExporter is the rate limiting state of an exporter exposed
+// through the API.
+type rateLimitedExporter struct {
+	Exporter           string  `json:"exporter"`
+	RateLimited        uint64  `json:"rate-limited"`
+	SamplingRateFactor float64 `json:"sampling-rate-factor"`
 }
 
 // newRateLimiter returns a new per-exporter rate limiter.
@@ -56,6 +66,7 @@ func (rl rateLimiter) allowOneMessage(exporter netip.Addr, rateLimit, timeReceiv
 		value.total++
 		if value.total > rateLimit {
 			value.dropped++
+			value.rateLimited++
 			verdict = false
 		}
 		return value, xsync.UpdateOp
@@ -63,3 +74,27 @@ func (rl rateLimiter) allowOneMessage(exporter netip.Addr, rateLimit, timeReceiv
 	value, _ := rl.Compute(exporter, update)
 	return verdict, value.factor
 }
+
+// exporters returns the rate limiting state of each exporter, sorted by
+// address.
+func (rl rateLimiter) exporters() []rateLimitedExporter {
+	addrs := []netip.Addr{}
+	rl.Range(func(exporter netip.Addr, _ perExporterRateLimiter) bool {
+		addrs = append(addrs, exporter)
+		return true
+	})
+	slices.SortFunc(addrs, netip.Addr.Compare)
+	result := make([]rateLimitedExporter, 0, len(addrs))
+	for _, exporter := range addrs {
+		value, ok := rl.Load(exporter)
+		if !ok {
+			continue
+		}
+		result = append(result, rateLimitedExporter{
+			Exporter:           exporter.Unmap().String(),
+			RateLimited:        value.rateLimited,
+			SamplingRateFactor: value.factor,
+		})
+	}
+	return result
+}
@@ -104,6 +104,15 @@ func TestRateLimiterPerExporter(t *testing.T) {
 	if diff := helpers.Diff(allowed, 100); diff != "" {
 		t.Fatalf("allow(exporter2) (-got, +want):\n%s", diff)
 	}
+
+	// Only exporter1 got flows dropped
+	expected := []rateLimitedExporter{
+		{Exporter: "192.0.2.1", RateLimited: 50, SamplingRateFactor: 1},
+		{Exporter: "192.0.2.2", RateLimited: 0, SamplingRateFactor: 1},
+	}
+	if diff := helpers.Diff(rl.exporters(), expected); diff != "" {
+		t.Fatalf("exporters() (-got, +want):\n%s", diff)
+	}
 }
 
 func TestRateLimiterBelowLimit(t *testing.T) {
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"akvorado/common/helpers/cache"
+	"akvorado/common/schema"
+)
+
+// classifierRules is the set of classifier rules currently in use. It is
+// replaced as a whole when the rules are reloaded. The caches of the
+// classifications are replaced with the rules: a classification computed with
+// the previous rules can only be stored in an unreachable cache.
+type classifierRules struct {
+	exporter []ExporterClassifierRule
+	iface    []InterfaceClassifierRule
+	flow     []FlowClassifierRule
+	// application includes the default rules, if enabled
+	application []ApplicationClassifierRule
+
+	exporterCache  *cache.Cache[exporterInfo, exporterClassification]
+	interfaceCache *cache.Cache[exporterAndInterfaceInfo, interfaceClassification]
+}
+
+// newClassifierRules extracts the classifier rules from a configuration.
+func newClassifierRules(configuration Configuration) *classifierRules {
+	application := configuration.ApplicationClassifiers
+	if configuration.DefaultApplicationClassifiers {
+		application = slices.Concat(application, defaultApplicationClassifiers)
+	}
+	return &classifierRules{
+		exporter:    configuration.ExporterClassifiers,
+		iface:       configuration.InterfaceClassifiers,
+		flow:        configuration.FlowClassifiers,
+		application: application,
+
+		exporterCache:  cache.New[exporterInfo, exporterClassification](),
+		interfaceCache: cache.New[exporterAndInterfaceInfo, interfaceClassification](),
+	}
+}
+
+// withEmptyCaches returns a copy of the rules with empty caches.
+func (cr *classifierRules) withEmptyCaches() *classifierRules {
+	rules := *cr
+	rules.exporterCache = cache.New[exporterInfo, exporterClassification]()
+	rules.interfaceCache = cache.New[exporterAndInterfaceInfo, interfaceClassification]()
+	return &rules
+}
+
+// equal tells if two sets of classifier rules have the same sources.
+func (cr *classifierRules) equal(other *classifierRules) bool {
+	return slices.EqualFunc(cr.exporter, other.exporter, func(a, b ExporterClassifierRule) bool {
+		return a.String() == b.String()
+	}) && slices.EqualFunc(cr.iface, other.iface, func(a, b InterfaceClassifierRule) bool {
+		return a.String() == b.String()
+	}) && slices.EqualFunc(cr.flow, other.flow, func(a, b FlowClassifierRule) bool {
+		return a.String() == b.String()
+	}) && reflect.DeepEqual(cr.application, other.application)
+}
+
+// isWritableStringColumn tells if a column can be set by a flow classifier.
+func isWritableStringColumn(column *schema.Column) bool {
+	return (column.ClickHouseType == "LowCardinality(String)" ||
+		strings.HasPrefix(column.ClickHouseType, "FixedString(")) &&
+		column.ClickHouseAlias == "" &&
+		column.ClickHouseGenerateFrom == "" &&
+		!column.Disabled &&
+		!isExporterOrInterfaceColumn(column)
+}
+
+// isExporterOrInterfaceColumn tells if a column is set by the exporter and
+// interface classifiers. These columns are set before the flow classifiers
+// are executed and cannot be overridden.
+func isExporterOrInterfaceColumn(column *schema.Column) bool {
+	return strings.HasPrefix(column.Name, "Exporter") ||
+		strings.HasPrefix(column.Name, "InIf") ||
+		strings.HasPrefix(column.Name, "OutIf")
+}
+
+// checkFlowClassifiers checks the columns set by the flow classifiers can be
+// set.
+func (c *Component) checkFlowClassifiers(rules []FlowClassifierRule) error {
+	for _, rule := range rules {
+		for _, key := range rule.columns {
+			column, ok := c.d.Schema.LookupColumnByKey(key)
+			if !ok || column.Disabled {
+				return fmt.Errorf("flow classifier %q sets disabled column %q", rule, key)
+			}
+			if isExporterOrInterfaceColumn(column) {
+				return fmt.Errorf("flow classifier %q sets exporter or interface column %q", rule, key)
+			}
+			if !isWritableStringColumn(column) {
+				return fmt.Errorf("flow classifier %q sets non-string column %q", rule, key)
+			}
+		}
+	}
+	return nil
+}
+
+// reloadClassifiers fetches the configuration again and replaces the
+// classifier rules if they changed. On error, the current rules are kept.
+func (c *Component) reloadClassifiers() error {
+	configuration, err := c.d.ConfigurationFetcher(c.t.Context(nil))
+	if err != nil {
+		c.metrics.classifierReloads.WithLabelValues("error").Inc()
+		return fmt.Errorf("cannot fetch configuration: %w", err)
+	}
+	if err := c.checkFlowClassifiers(configuration.FlowClassifiers); err != nil {
+		c.metrics.classifierReloads.WithLabelValues("error").Inc()
+		return err
+	}
+	rules := newClassifierRules(configuration)
+	if err := c.checkClassifierTables(rules); err != nil {
+		c.metrics.classifierReloads.WithLabelValues("error").Inc()
+		return err
+	}
+	if rules.equal(c.classifiers.Load()) {
+		c.metrics.classifierReloads.WithLabelValues("unchanged").Inc()
+		return nil
+	}
+	c.classifiers.Store(rules)
+	c.metrics.classifierReloads.WithLabelValues("success").Inc()
+	c.r.Info().
+		Int("exporter", len(rules.exporter)).
+		Int("interface", len(rules.iface)).
+		Int("flow", len(rules.flow)).
+		Int("application", len(rules.application)).
+		Msg("classifier rules reloaded")
+	return nil
+}
+
+// watchClassifiers periodically reloads the classifier rules.
+func (c *Component) watchClassifiers() {
+	c.t.Go(func() error {
+		ticker := time.NewTicker(c.config.ClassifierReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.t.Dying():
+				return nil
+			case <-ticker.C:
+				if err := c.reloadClassifiers(); err != nil {
+					c.r.Err(err).Msg("cannot reload classifier rules, keep the current ones")
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+func TestReloadClassifiers(t *testing.T) {
+	r := reporter.NewMock(t)
+	mustRule := func(rule string) ExporterClassifierRule {
+		var result ExporterClassifierRule
+		if err := result.UnmarshalText([]byte(rule)); err != nil {
+			t.Fatalf("UnmarshalText(%q) error:\n%+v", rule, err)
+		}
+		return result
+	}
+	mustFlowRule := func(rule string) FlowClassifierRule {
+		var result FlowClassifierRule
+		if err := result.UnmarshalText([]byte(rule)); err != nil {
+			t.Fatalf("UnmarshalText(%q) error:\n%+v", rule, err)
+		}
+		return result
+	}
+
+	configuration := DefaultConfiguration()
+	configuration.ExporterClassifiers = []ExporterClassifierRule{mustRule(`ClassifyGroup("old")`)}
+	var next Configuration
+	var nextErr error
+	c, err := New(r, configuration, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Schema: schema.NewMock(t),
+		ConfigurationFetcher: func(context.Context) (Configuration, error) {
+			return next, nextErr
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	now := time.Now()
+	classifyGroup := func() string {
+		t.Helper()
+		rules := c.classifiers.Load()
+		var classification exporterClassification
+		if _, err := rules.exporter[0].exec(exporterInfo{IP: "192.0.2.1"}, &classification, nil); err != nil {
+			t.Fatalf("exec() error:\n%+v", err)
+		}
+		return classification.Group
+	}
+	c.classifiers.Load().exporterCache.Put(now, exporterInfo{IP: "192.0.2.1"}, exporterClassification{Group: "old"})
+
+	// Same rules
+	next = DefaultConfiguration()
+	next.ExporterClassifiers = []ExporterClassifierRule{mustRule(`ClassifyGroup("old")`)}
+	if err := c.reloadClassifiers(); err != nil {
+		t.Fatalf("reloadClassifiers() error:\n%+v", err)
+	}
+	if c.classifiers.Load().exporterCache.Size() != 1 {
+		t.Error("reloadClassifiers() flushed the cache while rules are unchanged")
+	}
+
+	// New rules
+	next.ExporterClassifiers = []ExporterClassifierRule{mustRule(`ClassifyGroup("new")`)}
+	if err := c.reloadClassifiers(); err != nil {
+		t.Fatalf("reloadClassifiers() error:\n%+v", err)
+	}
+	if got := classifyGroup(); got != "new" {
+		t.Errorf("reloadClassifiers() group: got %q, expected %q", got, "new")
+	}
+	if c.classifiers.Load().exporterCache.Size() != 0 {
+		t.Error("reloadClassifiers() did not flush the cache")
+	}
+
+	// Invalid rules
+	next.ExporterClassifiers = []ExporterClassifierRule{mustRule(`ClassifyGroup("invalid")`)}
+	next.FlowClassifiers = []FlowClassifierRule{mustFlowRule(`SetColumn("SrcPort", "invalid")`)}
+	if err := c.reloadClassifiers(); err == nil {
+		t.Error("reloadClassifiers() did not error")
+	}
+	if got := classifyGroup(); got != "new" {
+		t.Errorf("reloadClassifiers() group: got %q, expected %q", got, "new")
+	}
+
+	// Unable to fetch
+	nextErr = errors.New("unreachable")
+	if err := c.reloadClassifiers(); err == nil {
+		t.Error("reloadClassifiers() did not error")
+	}
+	if got := classifyGroup(); got != "new" {
+		t.Errorf("reloadClassifiers() group: got %q, expected %q", got, "new")
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_core_", "classifier_reloads_")
+	expectedMetrics := map[string]string{
+		`classifier_reloads_total{result="error"}`:     "2",
+		`classifier_reloads_total{result="success"}`:   "1",
+		`classifier_reloads_total{result="unchanged"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestReloadClassifiersConcurrently(t *testing.T) {
+	r := reporter.NewMock(t)
+	mustRule := func(rule string) ExporterClassifierRule {
+		var result ExporterClassifierRule
+		if err := result.UnmarshalText([]byte(rule)); err != nil {
+			t.Fatalf("UnmarshalText(%q) error:\n%+v", rule, err)
+		}
+		return result
+	}
+	configuration := DefaultConfiguration()
+	configuration.ExporterClassifiers = []ExporterClassifierRule{mustRule(`ClassifyGroup("old")`)}
+	next := DefaultConfiguration()
+	c, err := New(r, configuration, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Schema: schema.NewMock(t),
+		ConfigurationFetcher: func(context.Context) (Configuration, error) {
+			return next, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	// Classify the same exporter from several workers while the rules are
+	// reloaded. Once reloaded, the previous classification should never be
+	// returned again, even if a worker stores it after the reload.
+	now := time.Now()
+	classify := func() string {
+		return c.execExporterClassifiers(now, "192.0.2.1", "exporter1", exporterClassification{}).Group
+	}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for range 8 {
+		wg.Go(func() {
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					classify()
+				}
+			}
+		})
+	}
+	for i := range 20 {
+		group := fmt.Sprintf("group%d", i)
+		next.ExporterClassifiers = []ExporterClassifierRule{mustRule(fmt.Sprintf(`ClassifyGroup(%q)`, group))}
+		if err := c.reloadClassifiers(); err != nil {
+			t.Fatalf("reloadClassifiers() error:\n%+v", err)
+		}
+		for range 100 {
+			if got := classify(); got != group {
+				t.Fatalf("execExporterClassifiers() group: got %q, expected %q", got, group)
+			}
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestCheckFlowClassifiers(t *testing.T) {
+	r := reporter.NewMock(t)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Schema: schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	cases := []struct {
+		Rule  string
+		Error bool
+	}{
+		{`SetColumn("SrcNetName", "scrubbing")`, false},
+		{`SetColumn("SrcPort", "invalid")`, true},
+		{`SetColumn("ExporterRole", "edge")`, true},
+		{`SetColumn("InIfProvider", "cogent")`, true},
+		{`SetColumn("OutIfConnectivity", "transit")`, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Rule, func(t *testing.T) {
+			var rule FlowClassifierRule
+			if err := rule.UnmarshalText([]byte(tc.Rule)); err != nil {
+				t.Fatalf("UnmarshalText(%q) error:\n%+v", tc.Rule, err)
+			}
+			err := c.checkFlowClassifiers([]FlowClassifierRule{rule})
+			if err != nil && !tc.Error {
+				t.Fatalf("checkFlowClassifiers() error:\n%+v", err)
+			} else if err == nil && tc.Error {
+				t.Fatal("checkFlowClassifiers() did not error")
+			}
+		})
+	}
+}
@@ -5,13 +5,17 @@
 package core
 
 import (
+	"context"
+	"fmt"
+	"sync/atomic"
 	"time"
 
+	"github.com/puzpuzpuz/xsync/v4"
 	"gopkg.in/tomb.v2"
 
 	"akvorado/common/daemon"
-	"akvorado/common/helpers/cache"
 	"akvorado/common/httpserver"
+	"akvorado/common/remotedatasource"
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
 	"akvorado/outlet/clickhouse"
@@ -36,9 +40,16 @@ type Component struct {
 	httpFlowChannel    chan []byte
 	httpFlowFlushDelay time.Duration
 
-	classifierExporterCache  *cache.Cache[exporterInfo, exporterClassification]
-	classifierInterfaceCache *cache.Cache[exporterAndInterfaceInfo, interfaceClassification]
-	classifierErrLogger      reporter.Logger
+	classifierErrLogger reporter.Logger
+	classifiers         atomic.Pointer[classifierRules]
+	lookupTables        *lookupTables
+	lookupTablesFetcher *remotedatasource.Component[lookupEntry]
+
+	samplingRates      *xsync.Map[samplingRateKey, *samplingRateState]
+	samplingRateLogger reporter.Logger
+
+	deduplication       *xsync.Map[deduplicationKey, deduplicationEntry]
+	deduplicationLatest atomic.Int64 // most recent flow recorded, Unix timestamp in nanoseconds
 
 	rateLimiter rateLimiter
 }
@@ -55,6 +66,11 @@ type Dependencies struct {
 	ClickHouse  clickhouse.Component
 	HTTP        *httpserver.Component
 	Schema      *schema.Component
+
+	// ConfigurationFetcher fetches the configuration again to reload the
+	// classifier rules. When nil, they are never reloaded. The provided
+	// context is canceled when the component is stopped.
+	ConfigurationFetcher func(context.Context) (Configuration, error)
 }
 
 // New creates a new core component.
@@ -68,12 +84,36 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		httpFlowChannel:    make(chan []byte, 10),
 		httpFlowFlushDelay: time.Second,
 
-		classifierExporterCache:  cache.New[exporterInfo, exporterClassification](),
-		classifierInterfaceCache: cache.New[exporterAndInterfaceInfo, interfaceClassification](),
-		classifierErrLogger:      r.Sample(reporter.BurstSampler(10*time.Second, 3)),
+		classifierErrLogger: r.Sample(reporter.BurstSampler(10*time.Second, 3)),
+
+		samplingRates:      xsync.NewMap[samplingRateKey, *samplingRateState](),
+		samplingRateLogger: r.Sample(reporter.BurstSampler(time.Minute, 10)),
+
+		deduplication: xsync.NewMap[deduplicationKey, deduplicationEntry](),
 
 		rateLimiter: newRateLimiter(),
 	}
+	if err := c.checkFlowClassifiers(c.config.FlowClassifiers); err != nil {
+		return nil, err
+	}
+	if len(c.config.Deduplication.ExporterGroups) > 0 && c.config.Deduplication.Action == "mark" {
+		if column, _ := c.d.Schema.LookupColumnByKey(schema.ColumnDuplicate); column.Disabled {
+			return nil, fmt.Errorf("deduplication action %q requires the %s column to be enabled",
+				c.config.Deduplication.Action, schema.ColumnDuplicate)
+		}
+	}
+	rules := newClassifierRules(c.config)
+	if err := c.checkClassifierTables(rules); err != nil {
+		return nil, err
+	}
+	c.classifiers.Store(rules)
+	c.lookupTables = newLookupTables(c.config.LookupTables)
+	var err error
+	c.lookupTablesFetcher, err = remotedatasource.New[lookupEntry](
+		r, c.UpdateLookupTable, "lookup_table", c.config.LookupTables)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize remote data source fetcher component: %w", err)
+	}
 	c.d.Daemon.Track(&c.t, "outlet/core")
 	c.initMetrics()
 	return &c, nil
@@ -91,13 +131,45 @@ func (c *Component) Start() error {
 				return nil
 			case <-time.After(c.config.ClassifierCacheDuration):
 				before := time.Now().Add(-c.config.ClassifierCacheDuration)
-				c.classifierExporterCache.DeleteLastAccessedBefore(before)
-				c.classifierInterfaceCache.DeleteLastAccessedBefore(before)
+				rules := c.classifiers.Load()
+				rules.exporterCache.DeleteLastAccessedBefore(before)
+				rules.interfaceCache.DeleteLastAccessedBefore(before)
+				c.expireSamplingRates(time.Now().Add(-samplingRateExpiration))
 			}
 		}
 	})
 
+	// Deduplication expiration
+	if len(c.config.Deduplication.ExporterGroups) > 0 {
+		c.t.Go(func() error {
+			ticker := time.NewTicker(c.config.Deduplication.Window)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-c.t.Dying():
+					return nil
+				case <-ticker.C:
+					latest := time.Unix(0, c.deduplicationLatest.Load())
+					c.expireDeduplication(latest.Add(-c.config.Deduplication.Window))
+				}
+			}
+		})
+	}
+
+	if err := c.lookupTablesFetcher.Start(); err != nil {
+		return fmt.Errorf("unable to start lookup tables fetcher component: %w", err)
+	}
+
+	if c.d.ConfigurationFetcher != nil && c.config.ClassifierReloadInterval > 0 {
+		c.watchClassifiers()
+	}
+
 	c.d.HTTP.APIRouter.GET("/api/v0/outlet/flows", c.FlowsHTTPHandler)
+	c.d.HTTP.APIRouter.GET("/api/v0/outlet/templates", c.TemplatesHTTPHandler)
+	c.d.HTTP.APIRouter.GET("/api/v0/outlet/exporters", c.ExportersHTTPHandler)
+	c.d.HTTP.APIRouter.GET("/api/v0/outlet/sampling-rates", c.SamplingRatesHTTPHandler)
+	c.d.HTTP.APIRouter.DELETE("/api/v0/outlet/templates/{exporter}", c.FlushTemplatesHTTPHandler)
+	c.d.HTTP.APIRouter.POST("/api/v0/outlet/classifiers/test", c.ClassifiersDryRunHTTPHandler)
 
 	// Processing flows can be delayed to let the other components collect their
 	// data first.
@@ -126,6 +198,7 @@ func (c *Component) Stop() error {
 	c.r.Info().Msg("stopping core component")
 	c.t.Kill(nil)
 	err := c.t.Wait()
+	c.lookupTablesFetcher.Stop()
 	c.d.KafkaInput.StopWorkers()
 	return err
 }
@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/netip"
 	"strings"
@@ -543,3 +544,214 @@ func TestStartupDelay(t *testing.T) {
 		})
 	})
 }
+
+func TestInterfaceCounters(t *testing.T) {
+	r := reporter.NewMock(t)
+	daemonComponent := daemon.NewMock(t)
+	metadataComponent := metadata.NewMock(t, r, metadata.DefaultConfiguration(),
+		metadata.Dependencies{Daemon: daemonComponent})
+	flowComponent, err := flow.New(r, flow.DefaultConfiguration(), flow.Dependencies{Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("flow.New() error:\n%+v", err)
+	}
+	kafkaInputComponent, _ := kafkainput.NewMock(t, kafkainput.DefaultConfiguration())
+	var got []*clickhouse.InterfaceCounters
+	clickhouseComponent := clickhouse.NewMockWithCounters(t,
+		func(*schema.FlowMessage) {},
+		func(counters *clickhouse.InterfaceCounters) {
+			got = append(got, counters)
+		})
+	// The tenant is used to select the database.
+	configuration := DefaultConfiguration()
+	var rule ExporterClassifierRule
+	if err := rule.UnmarshalText([]byte(`ClassifyTenant("alpha")`)); err != nil {
+		t.Fatalf("UnmarshalText() error:\n%+v", err)
+	}
+	configuration.ExporterClassifiers = []ExporterClassifierRule{rule}
+	c, err := New(r, configuration, Dependencies{
+		Daemon:     daemonComponent,
+		Flow:       flowComponent,
+		Metadata:   metadataComponent,
+		KafkaInput: kafkaInputComponent,
+		ClickHouse: clickhouseComponent,
+		HTTP:       httpserver.NewMock(t, r),
+		Routing:    routing.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	// Use a dedicated worker to process the counters synchronously.
+	receiveFunc, _ := c.newWorker(0, make(chan kafkainput.ScaleRequest, 100))
+	rawFlow := &pb.RawFlow{
+		TimeReceived:  1000,
+		Payload:       helpers.ReadPcapL4(t, "../flow/decoder/sflow/testdata/data-counters.pcap"),
+		SourceAddress: net.ParseIP("192.0.2.142").To16(),
+		Decoder:       pb.RawFlow_DECODER_SFLOW,
+	}
+	data, err := rawFlow.MarshalVT()
+	if err != nil {
+		t.Fatalf("MarshalVT() error:\n%+v", err)
+	}
+	if err := receiveFunc(context.Background(), data); err != nil {
+		t.Fatalf("receiveFunc() error:\n%+v", err)
+	}
+
+	// The metadata mock knows all interfaces and reports a 1000 Mbps speed,
+	// but the speed from the counters takes precedence.
+	expected := []*clickhouse.InterfaceCounters{
+		{
+			TimeReceived:    1000,
+			ExporterAddress: netip.MustParseAddr("::ffff:172.16.0.3"),
+			ExporterName:    "172_16_0_3",
+			IfIndex:         27,
+			IfName:          "Gi0/0/27",
+			IfDescription:   "Interface 27",
+			IfSpeed:         10000,
+			InOctets:        123456789012,
+			OutOctets:       98765432109,
+			InPackets:       1311,
+			OutPackets:      2322,
+			InErrors:        103,
+			OutErrors:       105,
+			InDiscards:      102,
+			OutDiscards:     104,
+			Tenant:          "alpha",
+		}, {
+			TimeReceived:    1000,
+			ExporterAddress: netip.MustParseAddr("::ffff:172.16.0.3"),
+			ExporterName:    "172_16_0_3",
+			IfIndex:         28,
+			IfName:          "Gi0/0/28",
+			IfDescription:   "Interface 28",
+			IfSpeed:         1000,
+			InOctets:        5000,
+			OutOctets:       6000,
+			InPackets:       1011,
+			OutPackets:      2022,
+			InErrors:        3,
+			OutErrors:       5,
+			InDiscards:      2,
+			OutDiscards:     4,
+			Tenant:          "alpha",
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Interface counters (-got, +want):\n%s", diff)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_core_", "received_interface_counters_total")
+	expectedMetrics := map[string]string{
+		`received_interface_counters_total{exporter="172.16.0.3"}`: "2",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestTemplatesHTTP(t *testing.T) {
+	r := reporter.NewMock(t)
+	daemonComponent := daemon.NewMock(t)
+	metadataComponent := metadata.NewMock(t, r, metadata.DefaultConfiguration(),
+		metadata.Dependencies{Daemon: daemonComponent})
+	flowComponent, err := flow.New(r, flow.DefaultConfiguration(), flow.Dependencies{Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("flow.New() error:\n%+v", err)
+	}
+	kafkaInputComponent, _ := kafkainput.NewMock(t, kafkainput.DefaultConfiguration())
+	httpComponent := httpserver.NewMock(t, r)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		Daemon:     daemonComponent,
+		Flow:       flowComponent,
+		Metadata:   metadataComponent,
+		KafkaInput: kafkaInputComponent,
+		ClickHouse: clickhouse.NewMock(t, func(*schema.FlowMessage) {}),
+		HTTP:       httpComponent,
+		Routing:    routing.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	// Use a dedicated worker to process the templates synchronously.
+	receiveFunc, _ := c.newWorker(0, make(chan kafkainput.ScaleRequest, 100))
+	rawFlow := &pb.RawFlow{
+		TimeReceived:  1000,
+		Payload:       helpers.ReadPcapL4(t, "../flow/decoder/netflow/testdata/options-template.pcap"),
+		SourceAddress: net.ParseIP("192.0.2.142").To16(),
+		Decoder:       pb.RawFlow_DECODER_NETFLOW,
+	}
+	data, err := rawFlow.MarshalVT()
+	if err != nil {
+		t.Fatalf("MarshalVT() error:\n%+v", err)
+	}
+	if err := receiveFunc(context.Background(), data); err != nil {
+		t.Fatalf("receiveFunc() error:\n%+v", err)
+	}
+	if got := flowComponent.Templates(); len(got) != 1 || got[0].Exporter != netip.MustParseAddr("192.0.2.142") {
+		t.Fatalf("Templates():\n%+v", got)
+	}
+
+	helpers.TestHTTPEndpoints(t, httpComponent.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "invalid exporter",
+			Method:      "DELETE",
+			URL:         "/api/v0/outlet/templates/not-an-ip",
+			StatusCode:  400,
+			JSONOutput:  helpers.M{"message": "Invalid exporter address"},
+		}, {
+			Description: "unknown exporter",
+			Method:      "DELETE",
+			URL:         "/api/v0/outlet/templates/192.0.2.143",
+			StatusCode:  404,
+			JSONOutput:  helpers.M{"message": "Unknown exporter"},
+		}, {
+			Description: "flush exporter",
+			Method:      "DELETE",
+			URL:         "/api/v0/outlet/templates/192.0.2.142",
+			JSONOutput:  helpers.M{"message": "Templates flushed"},
+		}, {
+			Description: "list after flush",
+			URL:         "/api/v0/outlet/templates",
+			JSONOutput:  helpers.M{"exporters": []any{}},
+		},
+	})
+}
+
+func TestFlowClassifierColumnValidation(t *testing.T) {
+	cases := []struct {
+		Rule  string
+		Error bool
+	}{
+		{`SetColumn("SrcNetName", "scrubbing")`, false},
+		{`SetColumn("SrcCountry", "FR")`, false},
+		{`SetColumn("SrcPort", "scrubbing")`, true},
+		{`SetColumn("InIfBoundary", "external")`, true},
+		{`SetColumn("SrcVlan", "scrubbing")`, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Rule, func(t *testing.T) {
+			r := reporter.NewMock(t)
+			var rule FlowClassifierRule
+			if err := rule.UnmarshalText([]byte(tc.Rule)); err != nil {
+				t.Fatalf("UnmarshalText() error:\n%+v", err)
+			}
+			configuration := DefaultConfiguration()
+			configuration.FlowClassifiers = []FlowClassifierRule{rule}
+			_, err := New(r, configuration, Dependencies{
+				Daemon: daemon.NewMock(t),
+				HTTP:   httpserver.NewMock(t, r),
+				Schema: schema.NewMock(t),
+			})
+			if err == nil && tc.Error {
+				t.Fatal("New() did not error")
+			}
+			if err != nil && !tc.Error {
+				t.Fatalf("New() error:\n%+v", err)
+			}
+		})
+	}
+}
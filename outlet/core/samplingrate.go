@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"cmp"
+	"net/http"
+	"net/netip"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+)
+
+// samplingRateExpiration is how long to keep track of a sampling rate not
+// observed anymore.
+const samplingRateExpiration = time.Hour
+
+// samplingRateKey identifies the source of a sampling rate.
+type samplingRateKey struct {
+	exporter netip.Addr
+	ifIndex  uint32
+}
+
+// samplingRateState is the sampling rate observed for an exporter and an
+// input interface. Fields are updated without lock as most flows do not change
+// anything.
+type samplingRateState struct {
+	samplingRate atomic.Uint64
+	changes      atomic.Uint64
+	lastChange   atomic.Int64 // Unix timestamp
+	lastSeen     atomic.Int64 // Unix timestamp
+}
+
+// samplingRateResponse is the representation of an observed sampling rate in
+// the API.
+type samplingRateResponse struct {
+	Exporter     string    `json:"exporter"`
+	Interface    uint32    `json:"interface"`
+	SamplingRate uint64    `json:"sampling-rate"`
+	Override     uint64    `json:"override,omitempty"`
+	Mismatch     bool      `json:"mismatch"`
+	Changes      uint64    `json:"changes"`
+	LastChange   time.Time `json:"last-change"`
+	LastSeen     time.Time `json:"last-seen"`
+}
+
+// observeSamplingRate records the sampling rate received from an exporter for
+// an interface. It reports when the sampling rate changes or when it differs
+// from the configured override.
+func (c *Component) observeSamplingRate(t time.Time, exporterIP netip.Addr, exporterStr string, ifIndex uint32, samplingRate uint64) {
+	now := t.Unix()
+	key := samplingRateKey{exporter: exporterIP, ifIndex: ifIndex}
+	state, loaded := c.samplingRates.Load(key)
+	if !loaded {
+		state, loaded = c.samplingRates.LoadOrCompute(key, func() (*samplingRateState, bool) {
+			state := &samplingRateState{}
+			state.samplingRate.Store(samplingRate)
+			state.lastChange.Store(now)
+			state.lastSeen.Store(now)
+			return state, false
+		})
+	}
+	if state.lastSeen.Load() != now {
+		state.lastSeen.Store(now)
+	}
+	if loaded {
+		previous := state.samplingRate.Load()
+		if previous == samplingRate || !state.samplingRate.CompareAndSwap(previous, samplingRate) {
+			return
+		}
+		state.changes.Add(1)
+		state.lastChange.Store(now)
+		c.metrics.samplingRateChanges.WithLabelValues(exporterStr).Inc()
+		c.samplingRateLogger.Warn().
+			Str("exporter", exporterStr).
+			Uint32("interface", ifIndex).
+			Uint64("previous", previous).
+			Uint64("current", samplingRate).
+			Msg("sampling rate changed")
+	}
+	if override, ok := c.config.OverrideSamplingRate.Lookup(exporterIP); ok && override > 0 && uint64(override) != samplingRate {
+		c.metrics.samplingRateMismatches.WithLabelValues(exporterStr).Inc()
+		c.samplingRateLogger.Warn().
+			Str("exporter", exporterStr).
+			Uint32("interface", ifIndex).
+			Uint64("observed", samplingRate).
+			Uint("override", override).
+			Msg("observed sampling rate differs from override")
+	}
+}
+
+// expireSamplingRates forgets the sampling rates not observed since the
+// provided time.
+func (c *Component) expireSamplingRates(before time.Time) {
+	c.samplingRates.Range(func(key samplingRateKey, state *samplingRateState) bool {
+		if state.lastSeen.Load() < before.Unix() {
+			c.samplingRates.Delete(key)
+		}
+		return true
+	})
+}
+
+// SamplingRatesHTTPHandler lists the sampling rates observed for each exporter
+// and input interface.
+func (c *Component) SamplingRatesHTTPHandler(w http.ResponseWriter, _ *http.Request) {
+	samplingRates := []samplingRateResponse{}
+	c.samplingRates.Range(func(key samplingRateKey, state *samplingRateState) bool {
+		samplingRate := state.samplingRate.Load()
+		response := samplingRateResponse{
+			Exporter:     key.exporter.Unmap().String(),
+			Interface:    key.ifIndex,
+			SamplingRate: samplingRate,
+			Changes:      state.changes.Load(),
+			LastChange:   time.Unix(state.lastChange.Load(), 0).UTC(),
+			LastSeen:     time.Unix(state.lastSeen.Load(), 0).UTC(),
+		}
+		if override, ok := c.config.OverrideSamplingRate.Lookup(key.exporter); ok && override > 0 {
+			response.Override = uint64(override)
+			response.Mismatch = response.Override != samplingRate
+		}
+		samplingRates = append(samplingRates, response)
+		return true
+	})
+	slices.SortFunc(samplingRates, func(a, b samplingRateResponse) int {
+		return cmp.Or(
+			cmp.Compare(a.Exporter, b.Exporter),
+			cmp.Compare(a.Interface, b.Interface))
+	})
+	httpserver.WriteJSON(w, http.StatusOK, helpers.M{
+		"sampling-rates": samplingRates,
+	})
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/clickhouse"
+	"akvorado/outlet/flow"
+	"akvorado/outlet/kafkainput"
+	"akvorado/outlet/metadata"
+	"akvorado/outlet/routing"
+)
+
+func TestSamplingRates(t *testing.T) {
+	r := reporter.NewMock(t)
+	daemonComponent := daemon.NewMock(t)
+	httpComponent := httpserver.NewMock(t, r)
+	flowComponent, err := flow.New(r, flow.DefaultConfiguration(), flow.Dependencies{Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("flow.New() error:\n%+v", err)
+	}
+	kafkaInputComponent, _ := kafkainput.NewMock(t, kafkainput.DefaultConfiguration())
+	configuration := DefaultConfiguration()
+	configuration.OverrideSamplingRate = helpers.MustNewSubnetMap(map[string]uint{
+		"192.0.2.2/32": 1000,
+	})
+	c, err := New(r, configuration, Dependencies{
+		Daemon: daemonComponent,
+		Flow:   flowComponent,
+		Metadata: metadata.NewMock(t, r, metadata.DefaultConfiguration(),
+			metadata.Dependencies{Daemon: daemonComponent}),
+		KafkaInput: kafkaInputComponent,
+		ClickHouse: clickhouse.NewMock(t, func(*schema.FlowMessage) {}),
+		HTTP:       httpComponent,
+		Routing:    routing.NewMock(t, r),
+		Schema:     schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	exporter1 := netip.MustParseAddr("::ffff:192.0.2.1")
+	exporter2 := netip.MustParseAddr("::ffff:192.0.2.2")
+	t0 := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	c.observeSamplingRate(t0, exporter1, "192.0.2.1", 10, 1000)
+	c.observeSamplingRate(t0, exporter1, "192.0.2.1", 11, 1000)
+	c.observeSamplingRate(t0.Add(time.Minute), exporter1, "192.0.2.1", 10, 1000)
+	c.observeSamplingRate(t0.Add(2*time.Minute), exporter1, "192.0.2.1", 10, 2000)
+	c.observeSamplingRate(t0.Add(3*time.Minute), exporter1, "192.0.2.1", 10, 2000)
+	c.observeSamplingRate(t0, exporter2, "192.0.2.2", 10, 1000)
+	c.observeSamplingRate(t0, exporter2, "192.0.2.2", 20, 500)
+	c.observeSamplingRate(t0.Add(time.Minute), exporter2, "192.0.2.2", 20, 500)
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_core_", "sampling_rate_")
+	expectedMetrics := map[string]string{
+		`sampling_rate_changes_total{exporter="192.0.2.1"}`:             "1",
+		`sampling_rate_override_mismatches_total{exporter="192.0.2.2"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	helpers.TestHTTPEndpoints(t, httpComponent.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/outlet/sampling-rates",
+			JSONOutput: helpers.M{
+				"sampling-rates": []helpers.M{
+					{
+						"exporter":      "192.0.2.1",
+						"interface":     10,
+						"sampling-rate": 2000,
+						"mismatch":      false,
+						"changes":       1,
+						"last-change":   "2026-10-17T10:02:00Z",
+						"last-seen":     "2026-10-17T10:03:00Z",
+					}, {
+						"exporter":      "192.0.2.1",
+						"interface":     11,
+						"sampling-rate": 1000,
+						"mismatch":      false,
+						"changes":       0,
+						"last-change":   "2026-10-17T10:00:00Z",
+						"last-seen":     "2026-10-17T10:00:00Z",
+					}, {
+						"exporter":      "192.0.2.2",
+						"interface":     10,
+						"sampling-rate": 1000,
+						"override":      1000,
+						"mismatch":      false,
+						"changes":       0,
+						"last-change":   "2026-10-17T10:00:00Z",
+						"last-seen":     "2026-10-17T10:00:00Z",
+					}, {
+						"exporter":      "192.0.2.2",
+						"interface":     20,
+						"sampling-rate": 500,
+						"override":      1000,
+						"mismatch":      true,
+						"changes":       0,
+						"last-change":   "2026-10-17T10:00:00Z",
+						"last-seen":     "2026-10-17T10:01:00Z",
+					},
+				},
+			},
+		},
+	})
+
+	// Expire old entries
+	c.expireSamplingRates(t0.Add(2 * time.Minute))
+	if got := c.samplingRates.Size(); got != 1 {
+		t.Errorf("expireSamplingRates() kept %d entries, expected 1", got)
+	}
+}
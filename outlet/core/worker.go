@@ -14,7 +14,10 @@ import (
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
 	"akvorado/outlet/clickhouse"
+	"akvorado/outlet/flow"
+	"akvorado/outlet/flow/decoder"
 	"akvorado/outlet/kafkainput"
+	"akvorado/outlet/metadata/provider"
 )
 
 // worker represents a worker processing incoming flows.
@@ -132,8 +135,28 @@ func (w *worker) processIncomingFlow(ctx context.Context, data []byte) error {
 		}
 	}
 
+	options := flow.DecodeOptions{
+		// Interface counters are enriched with metadata and forwarded to
+		// ClickHouse
+		Counters: func(ic *decoder.InterfaceCounters) {
+			w.processInterfaceCounters(ctx, ic)
+		},
+		// Exporter metadata found in the flows are forwarded to the metadata
+		// providers
+		Metadata: func(em *decoder.ExporterMetadata) {
+			w.c.d.Metadata.Update(provider.Update{
+				ExporterIP:    em.ExporterAddress,
+				IfIndex:       uint(em.IfIndex),
+				IfName:        em.IfName,
+				IfDescription: em.IfDescription,
+				SamplerID:     em.SamplerID,
+				SamplingRate:  uint(em.SamplingRate),
+			})
+		},
+	}
+
 	// Flow decoding
-	err := w.c.d.Flow.Decode(&w.rawFlow, w.bf, finalize)
+	err := w.c.d.Flow.DecodeWithOptions(&w.rawFlow, w.bf, finalize, options)
 	if err != nil {
 		// w.bf.ExporterAddress may not be known yet, so increase raw_flows_errors_total.
 		w.c.metrics.rawFlowsErrors.WithLabelValues("cannot decode payload").Inc()
@@ -142,3 +165,49 @@ func (w *worker) processIncomingFlow(ctx context.Context, data []byte) error {
 
 	return nil
 }
+
+// processInterfaceCounters enriches interface counters with metadata and
+// forwards them to ClickHouse.
+func (w *worker) processInterfaceCounters(ctx context.Context, ic *decoder.InterfaceCounters) {
+	exporter := ic.ExporterAddress.Unmap().String()
+	w.c.metrics.interfaceCountersReceived.WithLabelValues(exporter).Inc()
+
+	row := clickhouse.InterfaceCounters{
+		TimeReceived:    ic.TimeReceived,
+		ExporterAddress: ic.ExporterAddress,
+		IfIndex:         ic.IfIndex,
+		IfSpeed:         ic.IfSpeed / 1_000_000,
+		InOctets:        ic.InOctets,
+		OutOctets:       ic.OutOctets,
+		InPackets:       ic.InPackets,
+		OutPackets:      ic.OutPackets,
+		InErrors:        ic.InErrors,
+		OutErrors:       ic.OutErrors,
+		InDiscards:      ic.InDiscards,
+		OutDiscards:     ic.OutDiscards,
+	}
+	t := time.Now()
+	answer := w.c.d.Metadata.Lookup(t, ic.ExporterAddress, uint(ic.IfIndex))
+	if answer.Found {
+		row.ExporterName = answer.Exporter.Name
+		row.IfName = answer.Interface.Name
+		row.IfDescription = answer.Interface.Description
+		if row.IfSpeed == 0 {
+			row.IfSpeed = uint64(answer.Interface.Speed)
+		}
+		// The tenant selects the database, like for flows.
+		classification := w.c.execExporterClassifiers(t, exporter, answer.Exporter.Name,
+			exporterClassification{
+				Region: answer.Exporter.Region,
+				Role:   answer.Exporter.Role,
+				Tenant: answer.Exporter.Tenant,
+				Site:   answer.Exporter.Site,
+				Group:  answer.Exporter.Group,
+			})
+		if classification.Reject {
+			return
+		}
+		row.Tenant = classification.Tenant
+	}
+	w.cw.AppendInterfaceCounters(ctx, &row)
+}
@@ -3,14 +3,46 @@
 
 package flow
 
+import (
+	"time"
+
+	"akvorado/common/kafka"
+)
+
 // Configuration describes the configuration for the flow component.
 type Configuration struct {
 	// StatePersistFile defines a file to store decoder state (templates, sampling
 	// rates) to survive restarts.
 	StatePersistFile string `validate:"isdefault|filepath"`
+	// SharedState defines how to share decoder state with the other outlets.
+	SharedState SharedStateConfiguration
+}
+
+// SharedStateConfiguration describes how to share decoder state (templates,
+// sampling rates) with other outlets through a compacted Kafka topic.
+type SharedStateConfiguration struct {
+	// Enabled turns on state sharing.
+	Enabled bool
+	// Expiration is the delay after which templates and sampling rates no
+	// longer received by any outlet are removed.
+	Expiration time.Duration `validate:"min=1m"`
+	// SyncTimeout is how long to wait on start for the existing state to be
+	// read.
+	SyncTimeout time.Duration `validate:"min=1s"`
+
+	kafka.Configuration `mapstructure:",squash" yaml:"-,inline"`
 }
 
 // DefaultConfiguration returns the default configuration for the flow component.
 func DefaultConfiguration() Configuration {
-	return Configuration{}
+	cfg := kafka.DefaultConfiguration()
+	cfg.Topic = "flows-decoder-state"
+	return Configuration{
+		SharedState: SharedStateConfiguration{
+			Enabled:       false,
+			Expiration:    time.Hour,
+			SyncTimeout:   30 * time.Second,
+			Configuration: cfg,
+		},
+	}
 }
@@ -13,12 +13,29 @@ import (
 	"akvorado/common/pb"
 	"akvorado/common/schema"
 	"akvorado/outlet/flow/decoder"
+	"akvorado/outlet/flow/decoder/goflow2"
 	"akvorado/outlet/flow/decoder/netflow"
 	"akvorado/outlet/flow/decoder/sflow"
 )
 
+// DecodeOptions are the optional functions to handle what is decoded besides
+// flows.
+type DecodeOptions struct {
+	// Counters is called with interface counters, unless nil.
+	Counters decoder.CountersFunc
+	// Metadata is called with exporter metadata, unless nil.
+	Metadata decoder.MetadataFunc
+}
+
 // Decode decodes a raw flow from protobuf into flow messages.
 func (c *Component) Decode(rawFlow *pb.RawFlow, bf *schema.FlowMessage, finalize decoder.FinalizeFlowFunc) error {
+	return c.DecodeWithOptions(rawFlow, bf, finalize, DecodeOptions{})
+}
+
+// DecodeWithOptions decodes a raw flow from protobuf into flow messages.
+// Interface counters and exporter metadata are handed to the functions from
+// the provided options.
+func (c *Component) DecodeWithOptions(rawFlow *pb.RawFlow, bf *schema.FlowMessage, finalize decoder.FinalizeFlowFunc, decodeOptions DecodeOptions) error {
 	// Get decoder directly by type
 	dec, ok := c.decoders[rawFlow.Decoder]
 	if !ok {
@@ -42,6 +59,22 @@ func (c *Component) Decode(rawFlow *pb.RawFlow, bf *schema.FlowMessage, finalize
 		TimestampSource:       rawFlow.TimestampSource,
 		DecapsulationProtocol: rawFlow.DecapsulationProtocol,
 	}
+	if counters := decodeOptions.Counters; counters != nil {
+		options.Counters = func(ic *decoder.InterfaceCounters) {
+			if rawFlow.UseSourceAddress {
+				ic.ExporterAddress = sourceIP
+			}
+			counters(ic)
+		}
+	}
+	if metadata := decodeOptions.Metadata; metadata != nil {
+		options.Metadata = func(em *decoder.ExporterMetadata) {
+			if rawFlow.UseSourceAddress {
+				em.ExporterAddress = sourceIP
+			}
+			metadata(em)
+		}
+	}
 
 	if err := c.decodeWithMetrics(dec, decoderInput, options, bf, func() {
 		if rawFlow.UseSourceAddress {
@@ -81,4 +114,5 @@ func (c *Component) decodeWithMetrics(dec decoder.Decoder, input decoder.RawFlow
 var availableDecoders = map[pb.RawFlow_Decoder]decoder.NewDecoderFunc{
 	pb.RawFlow_DECODER_NETFLOW: netflow.New,
 	pb.RawFlow_DECODER_SFLOW:   sflow.New,
+	pb.RawFlow_DECODER_GOFLOW2: goflow2.New,
 }
@@ -0,0 +1,304 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package goflow2
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"akvorado/common/constants"
+	"akvorado/common/pb"
+	"akvorado/common/schema"
+	"akvorado/outlet/flow/decoder"
+)
+
+// Field numbers of the FlowMessage message from goflow2 (pb/flow.proto). We
+// decode the message ourselves to only extract the fields we need and to not
+// depend on the generated code.
+const (
+	fieldType             protowire.Number = 1
+	fieldSamplingRate     protowire.Number = 3
+	fieldSrcAddr          protowire.Number = 6
+	fieldDstAddr          protowire.Number = 7
+	fieldBytes            protowire.Number = 9
+	fieldPackets          protowire.Number = 10
+	fieldSamplerAddress   protowire.Number = 11
+	fieldNextHop          protowire.Number = 12
+	fieldSrcAS            protowire.Number = 14
+	fieldDstAS            protowire.Number = 15
+	fieldSrcNet           protowire.Number = 16
+	fieldDstNet           protowire.Number = 17
+	fieldInIf             protowire.Number = 18
+	fieldOutIf            protowire.Number = 19
+	fieldProto            protowire.Number = 20
+	fieldSrcPort          protowire.Number = 21
+	fieldDstPort          protowire.Number = 22
+	fieldIPTos            protowire.Number = 23
+	fieldForwardingStatus protowire.Number = 24
+	fieldIPTTL            protowire.Number = 25
+	fieldTCPFlags         protowire.Number = 26
+	fieldSrcMAC           protowire.Number = 27
+	fieldDstMAC           protowire.Number = 28
+	fieldVlanID           protowire.Number = 29
+	fieldEType            protowire.Number = 30
+	fieldICMPType         protowire.Number = 31
+	fieldICMPCode         protowire.Number = 32
+	fieldSrcVlan          protowire.Number = 33
+	fieldDstVlan          protowire.Number = 34
+	fieldFragmentID       protowire.Number = 35
+	fieldFragmentOffset   protowire.Number = 36
+	fieldIPv6FlowLabel    protowire.Number = 37
+	fieldMPLSLabel        protowire.Number = 81
+	fieldBGPNextHop       protowire.Number = 100
+	fieldBGPCommunities   protowire.Number = 101
+	fieldASPath           protowire.Number = 102
+	fieldTimeReceivedNs   protowire.Number = 110
+	fieldTimeFlowStartNs  protowire.Number = 111
+)
+
+// flowTypes are the names of the flow types from goflow2.
+var flowTypes = []string{"unknown", "sflow5", "netflow5", "netflow9", "ipfix"}
+
+// flowMessage is the subset of the goflow2 FlowMessage we use.
+type flowMessage struct {
+	Type                               uint64
+	SamplingRate                       uint64
+	SamplerAddress, SrcAddr, DstAddr   []byte
+	NextHop, BGPNextHop                []byte
+	Bytes, Packets                     uint64
+	SrcAS, DstAS                       uint64
+	SrcNet, DstNet                     uint64
+	InIf, OutIf                        uint64
+	EType, Proto, SrcPort, DstPort     uint64
+	IPTos, IPTTL, TCPFlags, ICMPType   uint64
+	ICMPCode, IPv6FlowLabel            uint64
+	FragmentID, FragmentOffset         uint64
+	ForwardingStatus                   uint64
+	SrcMAC, DstMAC                     uint64
+	VlanID, SrcVlan, DstVlan           uint64
+	TimeReceivedNs, TimeFlowStartNs    uint64
+	MPLSLabels, BGPCommunities, ASPath []uint32
+}
+
+// typeName returns the name of the flow type.
+func (msg *flowMessage) typeName() string {
+	if msg.Type < uint64(len(flowTypes)) {
+		return flowTypes[msg.Type]
+	}
+	return flowTypes[0]
+}
+
+// unmarshal decodes a FlowMessage. Unknown fields are ignored.
+func (msg *flowMessage) unmarshal(b []byte) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+			}
+			b = b[n:]
+			msg.setVarint(num, v)
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+			}
+			b = b[n:]
+			if err := msg.setBytes(num, v); err != nil {
+				return fmt.Errorf("field %d: %w", num, err)
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+			}
+			b = b[n:]
+		}
+	}
+	return nil
+}
+
+// setVarint sets a scalar field. Repeated fields may also be encoded as
+// unpacked varints.
+func (msg *flowMessage) setVarint(num protowire.Number, v uint64) {
+	switch num {
+	case fieldType:
+		msg.Type = v
+	case fieldSamplingRate:
+		msg.SamplingRate = v
+	case fieldBytes:
+		msg.Bytes = v
+	case fieldPackets:
+		msg.Packets = v
+	case fieldSrcAS:
+		msg.SrcAS = v
+	case fieldDstAS:
+		msg.DstAS = v
+	case fieldSrcNet:
+		msg.SrcNet = v
+	case fieldDstNet:
+		msg.DstNet = v
+	case fieldInIf:
+		msg.InIf = v
+	case fieldOutIf:
+		msg.OutIf = v
+	case fieldProto:
+		msg.Proto = v
+	case fieldSrcPort:
+		msg.SrcPort = v
+	case fieldDstPort:
+		msg.DstPort = v
+	case fieldIPTos:
+		msg.IPTos = v
+	case fieldForwardingStatus:
+		msg.ForwardingStatus = v
+	case fieldIPTTL:
+		msg.IPTTL = v
+	case fieldTCPFlags:
+		msg.TCPFlags = v
+	case fieldSrcMAC:
+		msg.SrcMAC = v
+	case fieldDstMAC:
+		msg.DstMAC = v
+	case fieldVlanID:
+		msg.VlanID = v
+	case fieldEType:
+		msg.EType = v
+	case fieldICMPType:
+		msg.ICMPType = v
+	case fieldICMPCode:
+		msg.ICMPCode = v
+	case fieldSrcVlan:
+		msg.SrcVlan = v
+	case fieldDstVlan:
+		msg.DstVlan = v
+	case fieldFragmentID:
+		msg.FragmentID = v
+	case fieldFragmentOffset:
+		msg.FragmentOffset = v
+	case fieldIPv6FlowLabel:
+		msg.IPv6FlowLabel = v
+	case fieldTimeReceivedNs:
+		msg.TimeReceivedNs = v
+	case fieldTimeFlowStartNs:
+		msg.TimeFlowStartNs = v
+	case fieldMPLSLabel:
+		msg.MPLSLabels = append(msg.MPLSLabels, uint32(v))
+	case fieldBGPCommunities:
+		msg.BGPCommunities = append(msg.BGPCommunities, uint32(v))
+	case fieldASPath:
+		msg.ASPath = append(msg.ASPath, uint32(v))
+	}
+}
+
+// setBytes sets a bytes field or a packed repeated field.
+func (msg *flowMessage) setBytes(num protowire.Number, v []byte) error {
+	switch num {
+	case fieldSamplerAddress:
+		msg.SamplerAddress = v
+	case fieldSrcAddr:
+		msg.SrcAddr = v
+	case fieldDstAddr:
+		msg.DstAddr = v
+	case fieldNextHop:
+		msg.NextHop = v
+	case fieldBGPNextHop:
+		msg.BGPNextHop = v
+	case fieldMPLSLabel, fieldBGPCommunities, fieldASPath:
+		for len(v) > 0 {
+			value, n := protowire.ConsumeVarint(v)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			v = v[n:]
+			msg.setVarint(num, value)
+		}
+	}
+	return nil
+}
+
+// decode fills the flow message from the goflow2 message.
+func (nd *Decoder) decode(in decoder.RawFlow, msg *flowMessage, options decoder.Options, bf *schema.FlowMessage) {
+	bf.TimeReceived = uint32(in.TimeReceived.UTC().Unix())
+	switch options.TimestampSource {
+	case pb.RawFlow_TS_NETFLOW_PACKET:
+		if msg.TimeReceivedNs > 0 {
+			bf.TimeReceived = uint32(time.Duration(msg.TimeReceivedNs) / time.Second)
+		}
+	case pb.RawFlow_TS_NETFLOW_FIRST_SWITCHED:
+		if msg.TimeFlowStartNs > 0 {
+			bf.TimeReceived = uint32(time.Duration(msg.TimeFlowStartNs) / time.Second)
+		}
+	}
+
+	bf.ExporterAddress = in.Source
+	if len(msg.SamplerAddress) > 0 {
+		bf.ExporterAddress = decoder.DecodeIP(msg.SamplerAddress)
+	}
+	bf.SamplingRate = msg.SamplingRate
+	bf.InIf = uint32(msg.InIf)
+	bf.OutIf = uint32(msg.OutIf)
+	bf.SrcAddr = decoder.DecodeIP(msg.SrcAddr)
+	bf.DstAddr = decoder.DecodeIP(msg.DstAddr)
+	bf.NextHop = decoder.DecodeIP(msg.BGPNextHop)
+	if !bf.NextHop.IsValid() {
+		bf.NextHop = decoder.DecodeIP(msg.NextHop)
+	}
+	bf.SrcAS = uint32(msg.SrcAS)
+	bf.DstAS = uint32(msg.DstAS)
+	bf.SrcNetMask = uint8(msg.SrcNet)
+	bf.DstNetMask = uint8(msg.DstNet)
+	if len(msg.ASPath) > 0 {
+		bf.AppendArrayUInt32(schema.ColumnDstASPath, msg.ASPath)
+	}
+	if len(msg.BGPCommunities) > 0 {
+		bf.AppendArrayUInt32(schema.ColumnDstCommunities, msg.BGPCommunities)
+	}
+	if len(msg.MPLSLabels) > 0 {
+		bf.AppendArrayUInt32(schema.ColumnMPLSLabels, msg.MPLSLabels)
+	}
+
+	bf.AppendUint(schema.ColumnBytes, msg.Bytes)
+	bf.AppendUint(schema.ColumnPackets, msg.Packets)
+	bf.AppendUint(schema.ColumnEType, msg.EType)
+	bf.AppendUint(schema.ColumnProto, msg.Proto)
+	bf.AppendUint(schema.ColumnSrcPort, msg.SrcPort)
+	bf.AppendUint(schema.ColumnDstPort, msg.DstPort)
+	bf.AppendUint(schema.ColumnForwardingStatus, msg.ForwardingStatus)
+
+	if !nd.d.Schema.IsDisabled(schema.ColumnGroupL2) {
+		bf.SrcVlan = uint16(msg.SrcVlan)
+		bf.DstVlan = uint16(msg.DstVlan)
+		if bf.SrcVlan == 0 {
+			bf.SrcVlan = uint16(msg.VlanID)
+		}
+		bf.AppendUint(schema.ColumnSrcMAC, msg.SrcMAC)
+		bf.AppendUint(schema.ColumnDstMAC, msg.DstMAC)
+	}
+
+	if !nd.d.Schema.IsDisabled(schema.ColumnGroupL3L4) {
+		bf.AppendUint(schema.ColumnIPTTL, msg.IPTTL)
+		bf.AppendUint(schema.ColumnIPTos, msg.IPTos)
+		bf.AppendUint(schema.ColumnTCPFlags, msg.TCPFlags)
+		bf.AppendUint(schema.ColumnIPFragmentID, msg.FragmentID)
+		bf.AppendUint(schema.ColumnIPFragmentOffset, msg.FragmentOffset)
+		bf.AppendUint(schema.ColumnIPv6FlowLabel, msg.IPv6FlowLabel)
+		switch msg.Proto {
+		case constants.ProtoICMPv4:
+			bf.AppendUint(schema.ColumnICMPv4Type, msg.ICMPType)
+			bf.AppendUint(schema.ColumnICMPv4Code, msg.ICMPCode)
+		case constants.ProtoICMPv6:
+			bf.AppendUint(schema.ColumnICMPv6Type, msg.ICMPType)
+			bf.AppendUint(schema.ColumnICMPv6Code, msg.ICMPCode)
+		}
+	}
+}
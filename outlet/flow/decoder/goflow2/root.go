@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package goflow2 handles flows already decoded by goflow2 and encoded with its
+// protobuf format.
+package goflow2
+
+import (
+	"fmt"
+	"time"
+
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/flow/decoder"
+)
+
+// Decoder contains the state for the goflow2 decoder.
+type Decoder struct {
+	r         *reporter.Reporter
+	d         decoder.Dependencies
+	errLogger reporter.Logger
+
+	metrics struct {
+		errors *reporter.CounterVec
+		flows  *reporter.CounterVec
+	}
+}
+
+// New instantiates a new goflow2 decoder.
+func New(r *reporter.Reporter, dependencies decoder.Dependencies) decoder.Decoder {
+	nd := &Decoder{
+		r:         r,
+		d:         dependencies,
+		errLogger: r.Sample(reporter.BurstSampler(30*time.Second, 3)),
+	}
+
+	nd.metrics.errors = nd.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Number of goflow2 flows processed with errors.",
+		},
+		[]string{"exporter", "error"},
+	)
+	nd.metrics.flows = nd.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "flows_total",
+			Help: "Number of goflow2 flows processed.",
+		},
+		[]string{"exporter", "type"},
+	)
+
+	return nd
+}
+
+// Decode decodes a goflow2 protobuf message. The payload should contain
+// exactly one message, without length prefix.
+func (nd *Decoder) Decode(in decoder.RawFlow, options decoder.Options, bf *schema.FlowMessage, finalize decoder.FinalizeFlowFunc) (int, error) {
+	key := in.Source.String()
+	var msg flowMessage
+	if err := msg.unmarshal(in.Payload); err != nil {
+		nd.metrics.errors.WithLabelValues(key, "protobuf decoding error").Inc()
+		nd.errLogger.Err(err).Str("exporter", key).Msg("error while decoding goflow2 message")
+		return 0, fmt.Errorf("error while decoding goflow2 message: %w", err)
+	}
+	nd.metrics.flows.WithLabelValues(key, msg.typeName()).Inc()
+	nd.decode(in, &msg, options, bf)
+	finalize()
+	return 1, nil
+}
+
+// Name returns the name of the decoder.
+func (nd *Decoder) Name() string {
+	return "goflow2"
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package goflow2
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"akvorado/common/constants"
+	"akvorado/common/helpers"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/flow/decoder"
+)
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func TestDecode(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t).EnableAllColumns()
+	d := New(r, decoder.Dependencies{Schema: sch})
+	bf := sch.NewFlowMessage()
+	got := []*schema.FlowMessage{}
+	finalize := func() {
+		clone := *bf
+		got = append(got, &clone)
+		bf.Clear()
+	}
+
+	// Build a goflow2 message. AS path is packed, communities are not.
+	var packed []byte
+	for _, asn := range []uint64{65000, 65001, 65002} {
+		packed = protowire.AppendVarint(packed, asn)
+	}
+	msg := appendVarint(nil, fieldType, 4)
+	msg = appendVarint(msg, fieldTimeReceivedNs, uint64(time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC).UnixNano()))
+	msg = appendVarint(msg, fieldTimeFlowStartNs, uint64(time.Date(2026, 10, 1, 9, 59, 0, 0, time.UTC).UnixNano()))
+	msg = appendVarint(msg, fieldSamplingRate, 1000)
+	msg = appendBytes(msg, fieldSamplerAddress, []byte{192, 0, 2, 1})
+	msg = appendVarint(msg, fieldBytes, 1500)
+	msg = appendVarint(msg, fieldPackets, 1)
+	msg = appendBytes(msg, fieldSrcAddr, netip.MustParseAddr("2001:db8::1").AsSlice())
+	msg = appendBytes(msg, fieldDstAddr, netip.MustParseAddr("2001:db8::2").AsSlice())
+	msg = appendBytes(msg, fieldNextHop, netip.MustParseAddr("2001:db8::3").AsSlice())
+	msg = appendVarint(msg, fieldEType, constants.ETypeIPv6)
+	msg = appendVarint(msg, fieldProto, constants.ProtoICMPv6)
+	msg = appendVarint(msg, fieldICMPType, 128)
+	msg = appendVarint(msg, fieldInIf, 10)
+	msg = appendVarint(msg, fieldOutIf, 20)
+	msg = appendVarint(msg, fieldSrcAS, 65000)
+	msg = appendVarint(msg, fieldDstAS, 65002)
+	msg = appendVarint(msg, fieldSrcNet, 48)
+	msg = appendVarint(msg, fieldDstNet, 56)
+	msg = appendVarint(msg, fieldVlanID, 100)
+	msg = appendVarint(msg, fieldSrcMAC, 0x0242ac110002)
+	msg = appendVarint(msg, fieldIPTTL, 64)
+	msg = appendBytes(msg, fieldASPath, packed)
+	msg = appendVarint(msg, fieldBGPCommunities, 4259840100)
+	msg = appendVarint(msg, fieldBGPCommunities, 4259840200)
+	msg = appendVarint(msg, 1000, 1) // unknown field
+
+	expected := []*schema.FlowMessage{
+		{
+			SamplingRate:    1000,
+			ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.1"),
+			InIf:            10,
+			OutIf:           20,
+			SrcVlan:         100,
+			SrcAddr:         netip.MustParseAddr("2001:db8::1"),
+			DstAddr:         netip.MustParseAddr("2001:db8::2"),
+			NextHop:         netip.MustParseAddr("2001:db8::3"),
+			SrcAS:           65000,
+			DstAS:           65002,
+			SrcNetMask:      48,
+			DstNetMask:      56,
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnBytes:          uint64(1500),
+				schema.ColumnPackets:        uint64(1),
+				schema.ColumnEType:          uint32(constants.ETypeIPv6),
+				schema.ColumnProto:          uint32(constants.ProtoICMPv6),
+				schema.ColumnICMPv6Type:     uint8(128),
+				schema.ColumnSrcMAC:         uint64(0x0242ac110002),
+				schema.ColumnIPTTL:          uint8(64),
+				schema.ColumnDstASPath:      []uint32{65000, 65001, 65002},
+				schema.ColumnDstCommunities: []uint32{4259840100, 4259840200},
+			},
+		},
+	}
+
+	cases := []struct {
+		Source       pb.RawFlow_TimestampSource
+		TimeReceived time.Time
+	}{
+		{pb.RawFlow_TS_INPUT, time.Date(2026, 10, 1, 10, 0, 5, 0, time.UTC)},
+		{pb.RawFlow_TS_NETFLOW_PACKET, time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)},
+		{pb.RawFlow_TS_NETFLOW_FIRST_SWITCHED, time.Date(2026, 10, 1, 9, 59, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Source.String(), func(t *testing.T) {
+			got = got[:0]
+			n, err := d.Decode(decoder.RawFlow{
+				TimeReceived: time.Date(2026, 10, 1, 10, 0, 5, 0, time.UTC),
+				Payload:      msg,
+				Source:       netip.MustParseAddr("::ffff:203.0.113.1"),
+			}, decoder.Options{TimestampSource: tc.Source}, bf, finalize)
+			if err != nil {
+				t.Fatalf("Decode() error:\n%+v", err)
+			}
+			if n != 1 {
+				t.Errorf("Decode() returned %d flows, expected 1", n)
+			}
+			expected[0].TimeReceived = uint32(tc.TimeReceived.Unix())
+			if diff := helpers.Diff(got, expected); diff != "" {
+				t.Fatalf("Decode() (-got, +want):\n%s", diff)
+			}
+		})
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_flow_decoder_goflow2_")
+	expectedMetrics := map[string]string{
+		`flows_total{exporter="::ffff:203.0.113.1",type="ipfix"}`: "3",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	d := New(r, decoder.Dependencies{Schema: sch})
+	bf := sch.NewFlowMessage()
+
+	msg := appendVarint(nil, fieldType, 1)
+	msg = appendBytes(msg, fieldSrcAddr, []byte{192, 0, 2, 1})
+	_, err := d.Decode(decoder.RawFlow{
+		TimeReceived: time.Now(),
+		Payload:      msg[:len(msg)-2],
+		Source:       netip.MustParseAddr("::ffff:203.0.113.1"),
+	}, decoder.Options{}, bf, func() {
+		t.Fatal("finalize() should not be called")
+	})
+	if err == nil {
+		t.Fatal("Decode() did not error on truncated message")
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_flow_decoder_goflow2_")
+	expectedMetrics := map[string]string{
+		`errors_total{error="protobuf decoding error",exporter="::ffff:203.0.113.1"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
@@ -7,7 +7,9 @@ package netflow
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"net/netip"
+	"strings"
 
 	"akvorado/common/constants"
 	"akvorado/common/pb"
@@ -35,6 +37,18 @@ const (
 
 const juniperPEN = 2636
 
+// Cisco NSEL (NetFlow Security Event Logging) fields, only exported with
+// NetFlow v9 by ASA and FTD firewalls.
+const (
+	nselFieldIngressACLID = 33000 // NF_F_INGRESS_ACL_ID
+	nselFieldEgressACLID  = 33001 // NF_F_EGRESS_ACL_ID
+	nselFieldFwExtEvent   = 33002 // NF_F_FW_EXT_EVENT
+)
+
+// nfv9ScopeInterface is the NetFlow v9 scope field type for an interface
+// (RFC 3954, section 6.1).
+const nfv9ScopeInterface = 2
+
 func (nd *Decoder) decodeNFv5(packet *netflowlegacy.PacketNetFlowV5, ts, sysUptime uint64, options decoder.Options, bf *schema.FlowMessage, finalize decoder.FinalizeFlowFunc) {
 	for _, record := range packet.Records {
 		bf.SamplingRate = uint64(packet.SamplingInterval)
@@ -77,7 +91,18 @@ func (nd *Decoder) decodeNFv9IPFIX(version uint16, obsDomainID uint32, flowSets
 					samplingRate                uint32
 					samplerID                   uint64
 					packetInterval, packetSpace uint32
+					ifIndex                     uint32
+					ifName, ifDescription       string
 				)
+				for _, field := range record.ScopesValues {
+					v, ok := field.Value.([]byte)
+					if !ok || field.PenProvided {
+						continue
+					}
+					if (version == 9 && field.Type == nfv9ScopeInterface) || field.Type == netflow.IPFIX_FIELD_ingressInterface {
+						ifIndex = uint32(decodeUNumber(v))
+					}
+				}
 				for _, field := range record.OptionsValues {
 					v, ok := field.Value.([]byte)
 					if !ok || field.PenProvided {
@@ -92,6 +117,12 @@ func (nd *Decoder) decodeNFv9IPFIX(version uint16, obsDomainID uint32, flowSets
 						packetInterval = uint32(decodeUNumber(v))
 					case netflow.IPFIX_FIELD_samplingPacketSpace:
 						packetSpace = uint32(decodeUNumber(v))
+					case netflow.IPFIX_FIELD_ingressInterface:
+						ifIndex = uint32(decodeUNumber(v))
+					case netflow.IPFIX_FIELD_interfaceName:
+						ifName = decodeString(v)
+					case netflow.IPFIX_FIELD_interfaceDescription:
+						ifDescription = decodeString(v)
 					}
 				}
 				if packetInterval > 0 {
@@ -100,6 +131,15 @@ func (nd *Decoder) decodeNFv9IPFIX(version uint16, obsDomainID uint32, flowSets
 				if samplingRate > 0 {
 					tao.SetSamplingRate(version, obsDomainID, samplerID, samplingRate)
 				}
+				if options.Metadata != nil && (samplingRate > 0 || ifName != "" || ifDescription != "") {
+					options.Metadata(&decoder.ExporterMetadata{
+						IfIndex:       ifIndex,
+						IfName:        ifName,
+						IfDescription: ifDescription,
+						SamplerID:     samplerID,
+						SamplingRate:  samplingRate,
+					})
+				}
 			}
 		case netflow.DataFlowSet:
 			for _, record := range tFlowSet.Records {
@@ -171,9 +211,9 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templat
 
 			switch field.Type {
 			// Statistics
-			case netflow.IPFIX_FIELD_octetDeltaCount, netflow.IPFIX_FIELD_postOctetDeltaCount, netflow.IPFIX_FIELD_initiatorOctets, netflow.IPFIX_FIELD_responderOctets:
+			case netflow.IPFIX_FIELD_octetDeltaCount, netflow.IPFIX_FIELD_postOctetDeltaCount, netflow.IPFIX_FIELD_initiatorOctets:
 				bf.AppendUint(schema.ColumnBytes, decodeUNumber(v))
-			case netflow.IPFIX_FIELD_packetDeltaCount, netflow.IPFIX_FIELD_postPacketDeltaCount:
+			case netflow.IPFIX_FIELD_packetDeltaCount, netflow.IPFIX_FIELD_postPacketDeltaCount, netflow.IPFIX_FIELD_initiatorPackets:
 				n := decodeUNumber(v)
 				if dir == directionReverse && n == 0 {
 					// We are in the reverse direction, but the flow is empty.
@@ -181,6 +221,30 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templat
 					return
 				}
 				bf.AppendUint(schema.ColumnPackets, n)
+			case netflow.IPFIX_FIELD_responderOctets, netflow.IPFIX_FIELD_responderPackets:
+				// Bidirectional flows from firewalls (NSEL): responder counters
+				// are for the reverse direction, like with RFC 5103. In the
+				// reverse pass, the initiator counters are skipped instead.
+				initiator := uint16(netflow.IPFIX_FIELD_initiatorOctets)
+				column := schema.ColumnBytes
+				if field.Type == netflow.IPFIX_FIELD_responderPackets {
+					initiator = netflow.IPFIX_FIELD_initiatorPackets
+					column = schema.ColumnPackets
+				}
+				if dir == directionForward {
+					if reversePresent == nil {
+						reversePresent = bitset.New(uint(initiator))
+					}
+					reversePresent.Set(uint(initiator))
+					continue
+				}
+				n := decodeUNumber(v)
+				if n == 0 {
+					// We are in the reverse direction, but the flow is empty.
+					bf.Undo()
+					return
+				}
+				bf.AppendUint(column, n)
 			case netflow.IPFIX_FIELD_samplingInterval, netflow.IPFIX_FIELD_samplerRandomInterval:
 				bf.SamplingRate = decodeUNumber(v)
 			case netflow.IPFIX_FIELD_samplerId, netflow.IPFIX_FIELD_selectorId:
@@ -245,8 +309,8 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templat
 					bf.OutIf = uint32(decodeUNumber(v))
 				}
 
-			// RFC7133 (aka IPFIX 315)
-			case netflow.IPFIX_FIELD_dataLinkFrameSection:
+			// RFC7133 (aka IPFIX 315) and NetFlow-Lite (IPFIX 104)
+			case netflow.IPFIX_FIELD_dataLinkFrameSection, netflow.IPFIX_FIELD_layer2packetSectionData:
 				if l3Length := decoder.ParseEthernet(nd.d.Schema, bf, options.DecapsulationProtocol, v); l3Length > 0 {
 					bf.AppendUint(schema.ColumnBytes, l3Length)
 					bf.AppendUint(schema.ColumnPackets, 1)
@@ -306,6 +370,22 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templat
 					}
 				}
 
+				if !nd.d.Schema.IsDisabled(schema.ColumnGroupFirewall) {
+					// Firewall events
+					switch field.Type {
+					case netflow.IPFIX_FIELD_firewallEvent:
+						if event := decodeUNumber(v); event <= uint64(schema.FirewallEventUpdated) {
+							bf.AppendUint(schema.ColumnFirewallEvent, event)
+						}
+					case nselFieldFwExtEvent:
+						bf.AppendUint(schema.ColumnFirewallExtendedEvent, decodeUNumber(v))
+					case nselFieldIngressACLID:
+						bf.AppendString(schema.ColumnIngressACLID, decodeACLID(v))
+					case nselFieldEgressACLID:
+						bf.AppendString(schema.ColumnEgressACLID, decodeACLID(v))
+					}
+				}
+
 				if !nd.d.Schema.IsDisabled(schema.ColumnGroupL2) {
 					// L2
 					switch field.Type {
@@ -407,6 +487,23 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, tao *templat
 	}
 }
 
+// decodeACLID decodes an NSEL ACL identifier. It is made of the hashes of the
+// ACL, of the ACE and of the extended ACE. They are rendered as on the
+// firewall, separated by dashes. An empty string is returned when the
+// identifier is all zeros.
+func decodeACLID(b []byte) string {
+	if bytes.Count(b, []byte{0}) == len(b) {
+		return ""
+	}
+	if len(b) != 12 {
+		return fmt.Sprintf("%x", b)
+	}
+	return fmt.Sprintf("0x%08x-0x%08x-0x%08x",
+		binary.BigEndian.Uint32(b[0:4]),
+		binary.BigEndian.Uint32(b[4:8]),
+		binary.BigEndian.Uint32(b[8:12]))
+}
+
 func decodeUNumber(b []byte) uint64 {
 	l := len(b)
 	switch l {
@@ -435,6 +532,12 @@ var (
 	allZeroIPv6Bytes = netip.MustParseAddr("::").AsSlice()
 )
 
+// decodeString decodes a string field. Some exporters pad them with NUL
+// characters.
+func decodeString(b []byte) string {
+	return strings.TrimRight(string(b), "\x00")
+}
+
 func isAllZeroIP(b []byte) bool {
 	return len(b) == 4 && bytes.Equal(b, allZeroIPv4Bytes) ||
 		len(b) == 16 && bytes.Equal(b, allZeroIPv6Bytes)
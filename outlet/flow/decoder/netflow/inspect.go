@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netflow
+
+import (
+	"cmp"
+	"net/netip"
+	"slices"
+
+	"github.com/netsampler/goflow2/v3/decoders/netflow"
+
+	"akvorado/outlet/flow/decoder"
+)
+
+var _ decoder.TemplatesDecoder = &Decoder{}
+
+// Templates returns the templates and sampling rates known for each exporter,
+// sorted by exporter address.
+func (nd *Decoder) Templates() []decoder.ExporterTemplates {
+	nd.collection.lock.Lock()
+	taos := make([]*templatesAndOptions, 0, len(nd.collection.Collection))
+	for _, tao := range nd.collection.Collection {
+		taos = append(taos, tao)
+	}
+	nd.collection.lock.Unlock()
+
+	result := make([]decoder.ExporterTemplates, 0, len(taos))
+	for _, tao := range taos {
+		exporter, err := netip.ParseAddr(tao.Key)
+		if err != nil {
+			continue
+		}
+		result = append(result, tao.inspect(exporter.Unmap()))
+	}
+	slices.SortFunc(result, func(a, b decoder.ExporterTemplates) int {
+		return a.Exporter.Compare(b.Exporter)
+	})
+	return result
+}
+
+// FlushTemplates forgets the templates and sampling rates of the provided
+// exporter. Flows using these templates are dropped until the exporter sends
+// them again.
+func (nd *Decoder) FlushTemplates(exporter netip.Addr) bool {
+	tao := nd.collection.Delete(netip.AddrFrom16(exporter.As16()).String())
+	if tao == nil {
+		return false
+	}
+	tao.unpublish()
+	return true
+}
+
+// inspect returns the templates and sampling rates for an exporter.
+func (t *templatesAndOptions) inspect(exporter netip.Addr) decoder.ExporterTemplates {
+	result := decoder.ExporterTemplates{
+		Exporter:      exporter,
+		Templates:     []decoder.Template{},
+		SamplingRates: []decoder.SamplingRate{},
+	}
+
+	t.templateLock.RLock()
+	for key, template := range t.Templates {
+		tmpl := decoder.Template{
+			Version:             key.version,
+			ObservationDomainID: key.obsDomainID,
+			TemplateID:          key.templateID,
+			LastUpdated:         t.LastUpdates[key],
+		}
+		switch template := template.(type) {
+		case netflow.TemplateRecord:
+			tmpl.Type = "data"
+			tmpl.Fields = convertFields(template.Fields)
+		case netflow.IPFIXOptionsTemplateRecord:
+			tmpl.Type = "options"
+			tmpl.ScopeFields = convertFields(template.Scopes)
+			tmpl.Fields = convertFields(template.Options)
+		case netflow.NFv9OptionsTemplateRecord:
+			tmpl.Type = "options"
+			tmpl.ScopeFields = convertFields(template.Scopes)
+			tmpl.Fields = convertFields(template.Options)
+		default:
+			continue
+		}
+		result.Templates = append(result.Templates, tmpl)
+	}
+	t.templateLock.RUnlock()
+	slices.SortFunc(result.Templates, func(a, b decoder.Template) int {
+		return cmp.Or(
+			cmp.Compare(a.Version, b.Version),
+			cmp.Compare(a.ObservationDomainID, b.ObservationDomainID),
+			cmp.Compare(a.TemplateID, b.TemplateID))
+	})
+
+	t.samplingRateLock.RLock()
+	for key, rate := range t.SamplingRates {
+		result.SamplingRates = append(result.SamplingRates, decoder.SamplingRate{
+			Version:             key.version,
+			ObservationDomainID: key.obsDomainID,
+			SamplerID:           key.samplerID,
+			Rate:                rate,
+		})
+	}
+	t.samplingRateLock.RUnlock()
+	slices.SortFunc(result.SamplingRates, func(a, b decoder.SamplingRate) int {
+		return cmp.Or(
+			cmp.Compare(a.Version, b.Version),
+			cmp.Compare(a.ObservationDomainID, b.ObservationDomainID),
+			cmp.Compare(a.SamplerID, b.SamplerID))
+	})
+
+	return result
+}
+
+// convertFields converts template fields from goflow2.
+func convertFields(fields []netflow.Field) []decoder.TemplateField {
+	result := make([]decoder.TemplateField, 0, len(fields))
+	for _, field := range fields {
+		f := decoder.TemplateField{
+			Type:   field.Type,
+			Length: field.Length,
+		}
+		if field.PenProvided {
+			f.PEN = field.Pen
+		}
+		result = append(result, f)
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netflow
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/netsampler/goflow2/v3/decoders/netflowlegacy"
+)
+
+// Header and record lengths for NetFlow v1 and v7, once the version has been
+// consumed. NetFlow v7 records are NetFlow v5 records with an additional
+// router shortcut field.
+const (
+	nfv1HeaderLength = 14
+	nfv1RecordLength = 48
+	nfv7HeaderLength = 22
+	nfv7RecordLength = 52
+)
+
+// decodeLegacyMessage decodes a NetFlow v1 or v7 packet (version already
+// consumed) into a NetFlow v5 packet. This way, both versions share the same
+// field mapping as NetFlow v5. Fields not present in NetFlow v1 (AS numbers
+// and netmasks) are left empty. Neither version carries a sampling rate.
+func decodeLegacyMessage(version uint16, payload []byte, packet *netflowlegacy.PacketNetFlowV5) error {
+	var headerLength, recordLength int
+	switch version {
+	case 1:
+		headerLength, recordLength = nfv1HeaderLength, nfv1RecordLength
+	case 7:
+		headerLength, recordLength = nfv7HeaderLength, nfv7RecordLength
+	default:
+		return fmt.Errorf("unsupported legacy NetFlow version %d", version)
+	}
+	if len(payload) < headerLength {
+		return fmt.Errorf("header too small (%d bytes)", len(payload))
+	}
+	packet.Version = version
+	packet.Count = binary.BigEndian.Uint16(payload[0:2])
+	packet.SysUptime = binary.BigEndian.Uint32(payload[2:6])
+	packet.UnixSecs = binary.BigEndian.Uint32(payload[6:10])
+	packet.UnixNSecs = binary.BigEndian.Uint32(payload[10:14])
+	if version == 7 {
+		packet.FlowSequence = binary.BigEndian.Uint32(payload[14:18])
+	}
+	payload = payload[headerLength:]
+	if len(payload) < int(packet.Count)*recordLength {
+		return fmt.Errorf("packet too small for %d records (%d bytes)", packet.Count, len(payload))
+	}
+
+	packet.Records = make([]netflowlegacy.RecordsNetFlowV5, packet.Count)
+	for i := range packet.Records {
+		data := payload[i*recordLength : (i+1)*recordLength]
+		record := &packet.Records[i]
+		record.SrcAddr = netflowlegacy.IPAddress(binary.BigEndian.Uint32(data[0:4]))
+		record.DstAddr = netflowlegacy.IPAddress(binary.BigEndian.Uint32(data[4:8]))
+		record.NextHop = netflowlegacy.IPAddress(binary.BigEndian.Uint32(data[8:12]))
+		record.Input = binary.BigEndian.Uint16(data[12:14])
+		record.Output = binary.BigEndian.Uint16(data[14:16])
+		record.DPkts = binary.BigEndian.Uint32(data[16:20])
+		record.DOctets = binary.BigEndian.Uint32(data[20:24])
+		record.First = binary.BigEndian.Uint32(data[24:28])
+		record.Last = binary.BigEndian.Uint32(data[28:32])
+		record.SrcPort = binary.BigEndian.Uint16(data[32:34])
+		record.DstPort = binary.BigEndian.Uint16(data[34:36])
+		switch version {
+		case 1:
+			record.Proto = data[38]
+			record.Tos = data[39]
+			record.TCPFlags = data[40]
+		case 7:
+			record.TCPFlags = data[37]
+			record.Proto = data[38]
+			record.Tos = data[39]
+			record.SrcAS = binary.BigEndian.Uint16(data[40:42])
+			record.DstAS = binary.BigEndian.Uint16(data[42:44])
+			record.SrcMask = data[44]
+			record.DstMask = data[45]
+		}
+	}
+	return nil
+}
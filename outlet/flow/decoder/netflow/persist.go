@@ -39,67 +39,74 @@ func (srk *samplingRateKey) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// typedTemplate is a template with its type, to be able to decode it.
+type typedTemplate struct {
+	Type     string
+	Template any
+}
+
+// rawTypedTemplate is a template with its type, not decoded yet.
+type rawTypedTemplate struct {
+	Type     string
+	Template json.RawMessage
+}
+
+// newTypedTemplate attaches its type to a template.
+func newTypedTemplate(template any) (typedTemplate, error) {
+	switch template := template.(type) {
+	case netflow.TemplateRecord:
+		return typedTemplate{Type: "data", Template: template}, nil
+	case netflow.IPFIXOptionsTemplateRecord:
+		return typedTemplate{Type: "ipfix-option", Template: template}, nil
+	case netflow.NFv9OptionsTemplateRecord:
+		return typedTemplate{Type: "nfv9-option", Template: template}, nil
+	default:
+		return typedTemplate{}, fmt.Errorf("unknown template type %q", reflect.TypeOf(template).String())
+	}
+}
+
+// decode decodes a template according to its type.
+func (rt rawTypedTemplate) decode() (any, error) {
+	switch rt.Type {
+	case "data":
+		var tmpl netflow.TemplateRecord
+		err := json.Unmarshal(rt.Template, &tmpl)
+		return tmpl, err
+	case "ipfix-option":
+		var tmpl netflow.IPFIXOptionsTemplateRecord
+		err := json.Unmarshal(rt.Template, &tmpl)
+		return tmpl, err
+	case "nfv9-option":
+		var tmpl netflow.NFv9OptionsTemplateRecord
+		err := json.Unmarshal(rt.Template, &tmpl)
+		return tmpl, err
+	default:
+		return nil, fmt.Errorf("unknown type %q", rt.Type)
+	}
+}
+
 // MarshalJSON encodes a set of NetFlow templates.
 func (t *templates) MarshalJSON() ([]byte, error) {
-	type typedTemplate struct {
-		Type     string
-		Template any
-	}
 	data := make(map[templateKey]typedTemplate, len(*t))
 	for k, v := range *t {
-		switch v := v.(type) {
-		case netflow.TemplateRecord:
-			data[k] = typedTemplate{
-				Type:     "data",
-				Template: v,
-			}
-		case netflow.IPFIXOptionsTemplateRecord:
-			data[k] = typedTemplate{
-				Type:     "ipfix-option",
-				Template: v,
-			}
-		case netflow.NFv9OptionsTemplateRecord:
-			data[k] = typedTemplate{
-				Type:     "nfv9-option",
-				Template: v,
-			}
-		default:
-			return nil, fmt.Errorf("unknown template type %q", reflect.TypeOf(v).String())
+		tt, err := newTypedTemplate(v)
+		if err != nil {
+			return nil, err
 		}
+		data[k] = tt
 	}
 	return json.Marshal(&data)
 }
 
 // UnmarshalJSON decodes a set of NetFlow templates.
 func (t *templates) UnmarshalJSON(data []byte) error {
-	type typedTemplate struct {
-		Type     string
-		Template json.RawMessage
-	}
-	var templatesWithTypes map[templateKey]typedTemplate
+	var templatesWithTypes map[templateKey]rawTypedTemplate
 	if err := json.Unmarshal(data, &templatesWithTypes); err != nil {
 		return err
 	}
 	targetTemplates := make(templates, len(templatesWithTypes))
 	for k, v := range templatesWithTypes {
-		var targetTemplate any
-		var err error
-		switch v.Type {
-		case "data":
-			var tmpl netflow.TemplateRecord
-			err = json.Unmarshal(v.Template, &tmpl)
-			targetTemplate = tmpl
-		case "ipfix-option":
-			var tmpl netflow.IPFIXOptionsTemplateRecord
-			err = json.Unmarshal(v.Template, &tmpl)
-			targetTemplate = tmpl
-		case "nfv9-option":
-			var tmpl netflow.NFv9OptionsTemplateRecord
-			err = json.Unmarshal(v.Template, &tmpl)
-			targetTemplate = tmpl
-		default:
-			return fmt.Errorf("unknown type %q", v.Type)
-		}
+		targetTemplate, err := v.decode()
 		if err != nil {
 			return err
 		}
@@ -1,7 +1,7 @@
 // SPDX-FileCopyrightText: 2022 Free Mobile
 // SPDX-License-Identifier: AGPL-3.0-only
 
-// Package netflow handles NetFlow v9 and IPFIX decoding.
+// Package netflow handles NetFlow v1, v5, v7, v9 and IPFIX decoding.
 package netflow
 
 import (
@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/netsampler/goflow2/v3/decoders/netflow"
@@ -28,6 +29,9 @@ type Decoder struct {
 
 	// Templates and sampling systems
 	collection templateAndOptionCollection
+	// Publish changes to templates and sampling rates, when not nil
+	publish        decoder.StatePublishFunc
+	publishRefresh time.Duration
 
 	metrics struct {
 		errors    *reporter.CounterVec
@@ -113,20 +117,36 @@ func (nd *Decoder) Decode(in decoder.RawFlow, options decoder.Options, bf *schem
 		bf.ExporterAddress = in.Source
 		finalize()
 	}
+	if options.Metadata != nil {
+		metadata := options.Metadata
+		options.Metadata = func(em *decoder.ExporterMetadata) {
+			em.ExporterAddress = in.Source
+			metadata(em)
+		}
+	}
 
 	switch version {
-	case 5:
+	case 1, 5, 7:
 		if options.DecapsulationProtocol != pb.RawFlow_DECAP_NONE {
 			nd.metrics.errors.WithLabelValues(key, "non-encapsulated packet").Inc()
 			return 0, nil
 		}
-		var packetNFv5 netflowlegacy.PacketNetFlowV5
-		if err := netflowlegacy.DecodeMessage(buf, &packetNFv5); err != nil {
-			nd.metrics.errors.WithLabelValues(key, "NetFlow v5 decoding error").Inc()
-			nd.errLogger.Err(err).Str("exporter", key).Msg("error while decoding NetFlow v5")
-			return 0, fmt.Errorf("NetFlow v5 decoding error: %w", err)
+		// NetFlow v1 and v7 are decoded as NetFlow v5 packets.
+		var (
+			packetNFv5 netflowlegacy.PacketNetFlowV5
+			err        error
+		)
+		versionStr = strconv.Itoa(int(version))
+		if version == 5 {
+			err = netflowlegacy.DecodeMessage(buf, &packetNFv5)
+		} else {
+			err = decodeLegacyMessage(version, buf.Bytes(), &packetNFv5)
+		}
+		if err != nil {
+			nd.metrics.errors.WithLabelValues(key, fmt.Sprintf("NetFlow v%s decoding error", versionStr)).Inc()
+			nd.errLogger.Err(err).Str("exporter", key).Msgf("error while decoding NetFlow v%s", versionStr)
+			return 0, fmt.Errorf("NetFlow v%s decoding error: %w", versionStr, err)
 		}
-		versionStr = "5"
 		nd.metrics.sets.WithLabelValues(key, versionStr, "PDU").Inc()
 		nd.metrics.records.WithLabelValues(key, versionStr, "PDU").
 			Add(float64(len(packetNFv5.Records)))
@@ -4,11 +4,15 @@
 package netflow
 
 import (
+	"encoding/binary"
 	"fmt"
 	"net/netip"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"akvorado/common/constants"
 	"akvorado/common/helpers"
@@ -551,6 +555,89 @@ func TestDecodeDataLink(t *testing.T) {
 	}
 }
 
+func TestDecodeNetFlowLite(t *testing.T) {
+	_, nfdecoder, bf, got, finalize := setup(t, true)
+	options := decoder.Options{TimestampSource: pb.RawFlow_TS_INPUT}
+
+	// Ethernet frame with an IPv4/TCP packet
+	frame := []byte{
+		// Ethernet: destination, source, EtherType
+		0x00, 0x11, 0x22, 0x33, 0x44, 0x55,
+		0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb,
+		0x08, 0x00,
+		// IPv4: version/IHL, ToS, total length, ID, flags/offset, TTL,
+		// protocol, checksum, source, destination
+		0x45, 0x00, 0x05, 0xdc,
+		0x12, 0x34, 0x40, 0x00,
+		0x3f, 0x06, 0x00, 0x00,
+		192, 0, 2, 1,
+		198, 51, 100, 2,
+		// TCP: ports, sequence, acknowledgment, offset/flags, window,
+		// checksum, urgent pointer
+		0x01, 0xbb, 0xc7, 0x38,
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x00,
+		0x50, 0x12, 0xff, 0xff,
+		0x00, 0x00, 0x00, 0x00,
+	}
+	templateSet := []byte{
+		0x00, 0x02, 0x00, 0x14, // set ID 2, length
+		0x01, 0x00, 0x00, 0x03, // template 256, 3 fields
+		0x00, 0x0a, 0x00, 0x04, // ingressInterface
+		0x00, 0x0e, 0x00, 0x04, // egressInterface
+		0x00, 0x68, 0xff, 0xff, // layer2packetSectionData, variable length
+	}
+	dataSet := []byte{0x01, 0x00, 0x00, 0x00}         // set ID 256, length set below
+	dataSet = append(dataSet, 0x00, 0x00, 0x00, 0x05) // ingressInterface
+	dataSet = append(dataSet, 0x00, 0x00, 0x00, 0x09) // egressInterface
+	dataSet = append(dataSet, byte(len(frame)))
+	dataSet = append(dataSet, frame...)
+	binary.BigEndian.PutUint16(dataSet[2:], uint16(len(dataSet)))
+	data := []byte{
+		0x00, 0x0a, 0x00, 0x00, // version 10, length set below
+		0x6a, 0x00, 0x00, 0x00, // export time
+		0x00, 0x00, 0x00, 0x01, // sequence number
+		0x00, 0x00, 0x00, 0x00, // observation domain
+	}
+	data = append(data, templateSet...)
+	data = append(data, dataSet...)
+	binary.BigEndian.PutUint16(data[2:], uint16(len(data)))
+
+	_, err := nfdecoder.Decode(
+		decoder.RawFlow{Payload: data, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+		options, bf, finalize)
+	if err != nil {
+		t.Fatalf("Decode() error:\n%+v", err)
+	}
+
+	expectedFlows := []*schema.FlowMessage{
+		{
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.1"),
+			DstAddr:         netip.MustParseAddr("::ffff:198.51.100.2"),
+			InIf:            5,
+			OutIf:           9,
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnBytes:        uint64(1500),
+				schema.ColumnPackets:      uint64(1),
+				schema.ColumnEType:        uint32(constants.ETypeIPv4),
+				schema.ColumnProto:        uint32(constants.ProtoTCP),
+				schema.ColumnSrcPort:      uint16(443),
+				schema.ColumnDstPort:      uint16(51000),
+				schema.ColumnSrcMAC:       uint64(0x66778899aabb),
+				schema.ColumnDstMAC:       uint64(0x001122334455),
+				schema.ColumnIPFragmentID: uint32(0x1234),
+				schema.ColumnIPTTL:        uint8(63),
+				schema.ColumnTCPFlags:     uint16(0x12),
+			},
+		},
+	}
+
+	if diff := helpers.Diff(got, &expectedFlows); diff != "" {
+		t.Fatalf("Decode() (-got, +want):\n%s", diff)
+	}
+}
+
 func TestDecodeWithoutTemplate(t *testing.T) {
 	_, nfdecoder, bf, got, finalize := setup(t, true)
 	options := decoder.Options{TimestampSource: pb.RawFlow_TS_INPUT}
@@ -685,6 +772,243 @@ func TestDecodeNFv5(t *testing.T) {
 	}
 }
 
+// The NetFlow v1 capture is synthetic. It is generated by
+// testdata/legacy-gen.go.
+func TestDecodeNFv1(t *testing.T) {
+	r, nfdecoder, bf, got, finalize := setup(t, false)
+	options := decoder.Options{TimestampSource: pb.RawFlow_TS_NETFLOW_FIRST_SWITCHED}
+
+	data := helpers.ReadPcapL4(t, filepath.Join("testdata", "nfv1.pcap"))
+	_, err := nfdecoder.Decode(
+		decoder.RawFlow{Payload: data, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+		options, bf, finalize)
+	if err != nil {
+		t.Fatalf("Decode() error:\n%+v", err)
+	}
+
+	expectedFlows := []*schema.FlowMessage{
+		{
+			TimeReceived:    1791179400,
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			SrcAddr:         netip.MustParseAddr("::ffff:198.51.100.10"),
+			DstAddr:         netip.MustParseAddr("::ffff:203.0.113.20"),
+			NextHop:         netip.MustParseAddr("::ffff:192.0.2.254"),
+			SamplingRate:    1,
+			InIf:            3,
+			OutIf:           7,
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnBytes:    uint64(8640),
+				schema.ColumnPackets:  uint64(12),
+				schema.ColumnEType:    uint32(constants.ETypeIPv4),
+				schema.ColumnProto:    uint32(constants.ProtoTCP),
+				schema.ColumnSrcPort:  uint16(443),
+				schema.ColumnDstPort:  uint16(52311),
+				schema.ColumnIPTos:    uint8(0x20),
+				schema.ColumnTCPFlags: uint16(0x1b),
+			},
+		}, {
+			TimeReceived:    1791192400,
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			SrcAddr:         netip.MustParseAddr("::ffff:203.0.113.20"),
+			DstAddr:         netip.MustParseAddr("::ffff:198.51.100.10"),
+			NextHop:         netip.MustParseAddr("::ffff:192.0.2.253"),
+			SamplingRate:    1,
+			InIf:            7,
+			OutIf:           3,
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnBytes:   uint64(76),
+				schema.ColumnPackets: uint64(1),
+				schema.ColumnEType:   uint32(constants.ETypeIPv4),
+				schema.ColumnProto:   uint32(constants.ProtoUDP),
+				schema.ColumnSrcPort: uint16(53),
+				schema.ColumnDstPort: uint16(40000),
+			},
+		},
+	}
+	if diff := helpers.Diff(*got, expectedFlows); diff != "" {
+		t.Fatalf("Decode() (-got, +want):\n%s", diff)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_flow_decoder_netflow_")
+	expectedMetrics := map[string]string{
+		`packets_total{exporter="::ffff:127.0.0.1",version="1"}`:            "1",
+		`records_total{exporter="::ffff:127.0.0.1",type="PDU",version="1"}`: "2",
+		`sets_total{exporter="::ffff:127.0.0.1",type="PDU",version="1"}`:    "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Decode() metrics (-got, +want):\n%s", diff)
+	}
+}
+
+// The NetFlow v7 capture is synthetic. It is generated by
+// testdata/legacy-gen.go.
+func TestDecodeNFv7(t *testing.T) {
+	r, nfdecoder, bf, got, finalize := setup(t, false)
+	options := decoder.Options{TimestampSource: pb.RawFlow_TS_NETFLOW_PACKET}
+
+	data := helpers.ReadPcapL4(t, filepath.Join("testdata", "nfv7.pcap"))
+	_, err := nfdecoder.Decode(
+		decoder.RawFlow{Payload: data, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+		options, bf, finalize)
+	if err != nil {
+		t.Fatalf("Decode() error:\n%+v", err)
+	}
+
+	expectedFlows := []*schema.FlowMessage{
+		{
+			TimeReceived:    1791194400,
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			SrcAddr:         netip.MustParseAddr("::ffff:198.51.100.10"),
+			DstAddr:         netip.MustParseAddr("::ffff:203.0.113.20"),
+			NextHop:         netip.MustParseAddr("::ffff:192.0.2.254"),
+			SamplingRate:    1,
+			InIf:            3,
+			OutIf:           7,
+			SrcAS:           64496,
+			DstAS:           64497,
+			SrcNetMask:      24,
+			DstNetMask:      22,
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnBytes:    uint64(8640),
+				schema.ColumnPackets:  uint64(12),
+				schema.ColumnEType:    uint32(constants.ETypeIPv4),
+				schema.ColumnProto:    uint32(constants.ProtoTCP),
+				schema.ColumnSrcPort:  uint16(443),
+				schema.ColumnDstPort:  uint16(52311),
+				schema.ColumnIPTos:    uint8(0x20),
+				schema.ColumnTCPFlags: uint16(0x1b),
+			},
+		},
+	}
+	if diff := helpers.Diff(*got, expectedFlows); diff != "" {
+		t.Fatalf("Decode() (-got, +want):\n%s", diff)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_flow_decoder_netflow_")
+	expectedMetrics := map[string]string{
+		`packets_total{exporter="::ffff:127.0.0.1",version="7"}`:            "1",
+		`records_total{exporter="::ffff:127.0.0.1",type="PDU",version="7"}`: "1",
+		`sets_total{exporter="::ffff:127.0.0.1",type="PDU",version="7"}`:    "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Decode() metrics (-got, +want):\n%s", diff)
+	}
+}
+
+// The synthetic NetFlow v1 and v7 captures are checked against their
+// dissection by testdata/legacy-dissect.py, written independently from
+// testdata/legacy-gen.go.
+func TestDecodeLegacyDissection(t *testing.T) {
+	for _, version := range []int{1, 7} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			_, nfdecoder, bf, got, finalize := setup(t, false)
+			options := decoder.Options{TimestampSource: pb.RawFlow_TS_NETFLOW_PACKET}
+			exporter := netip.MustParseAddr("::ffff:127.0.0.1")
+
+			data := helpers.ReadPcapL4(t, filepath.Join("testdata", fmt.Sprintf("nfv%d.pcap", version)))
+			if _, err := nfdecoder.Decode(decoder.RawFlow{Payload: data, Source: exporter},
+				options, bf, finalize); err != nil {
+				t.Fatalf("Decode() error:\n%+v", err)
+			}
+
+			// Build the expected flows from the dissection
+			dissection, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("nfv%d.txt", version)))
+			if err != nil {
+				t.Fatalf("ReadFile() error:\n%+v", err)
+			}
+			header := map[string]string{}
+			records := []map[string]string{}
+			for _, line := range strings.Split(string(dissection), "\n") {
+				switch {
+				case strings.HasPrefix(line, "    pdu "):
+					records = append(records, map[string]string{})
+				case strings.HasPrefix(line, "        "):
+					name, value, _ := strings.Cut(strings.TrimSpace(line), ": ")
+					records[len(records)-1][name] = value
+				case strings.HasPrefix(line, "    "):
+					name, value, _ := strings.Cut(strings.TrimSpace(line), ": ")
+					header[name] = value
+				}
+			}
+			number := func(value string) uint64 {
+				t.Helper()
+				n, err := strconv.ParseUint(value, 0, 32)
+				if err != nil {
+					t.Fatalf("ParseUint(%q) error:\n%+v", value, err)
+				}
+				return n
+			}
+			address := func(value string) netip.Addr {
+				return netip.AddrFrom16(netip.MustParseAddr(value).As16())
+			}
+			expectedFlows := []*schema.FlowMessage{}
+			for _, record := range records {
+				flow := &schema.FlowMessage{
+					TimeReceived:    uint32(number(header["UnixSecs"])),
+					ExporterAddress: exporter,
+					SrcAddr:         address(record["SrcAddr"]),
+					DstAddr:         address(record["DstAddr"]),
+					NextHop:         address(record["NextHop"]),
+					SamplingRate:    1,
+					InIf:            uint32(number(record["InputInt"])),
+					OutIf:           uint32(number(record["OutputInt"])),
+					OtherColumns: map[schema.ColumnKey]any{
+						schema.ColumnBytes:   number(record["Octets"]),
+						schema.ColumnPackets: number(record["Packets"]),
+						schema.ColumnEType:   uint32(constants.ETypeIPv4),
+						schema.ColumnProto:   uint32(number(record["Protocol"])),
+						schema.ColumnSrcPort: uint16(number(record["SrcPort"])),
+						schema.ColumnDstPort: uint16(number(record["DstPort"])),
+					},
+				}
+				if tos := number(record["ToS"]); tos != 0 {
+					flow.OtherColumns[schema.ColumnIPTos] = uint8(tos)
+				}
+				if flags := number(record["TCPFlags"]); flags != 0 {
+					flow.OtherColumns[schema.ColumnTCPFlags] = uint16(flags)
+				}
+				if version == 7 {
+					flow.SrcAS = uint32(number(record["SrcAS"]))
+					flow.DstAS = uint32(number(record["DstAS"]))
+					flow.SrcNetMask = uint8(number(record["SrcMask"]))
+					flow.DstNetMask = uint8(number(record["DstMask"]))
+				}
+				expectedFlows = append(expectedFlows, flow)
+			}
+			if len(expectedFlows) == 0 {
+				t.Fatal("no record in dissection")
+			}
+			if diff := helpers.Diff(*got, expectedFlows); diff != "" {
+				t.Fatalf("Decode() (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeLegacyTruncated(t *testing.T) {
+	r, nfdecoder, bf, _, finalize := setup(t, false)
+	options := decoder.Options{TimestampSource: pb.RawFlow_TS_INPUT}
+
+	for _, pcap := range []string{"nfv1.pcap", "nfv7.pcap"} {
+		data := helpers.ReadPcapL4(t, filepath.Join("testdata", pcap))
+		_, err := nfdecoder.Decode(
+			decoder.RawFlow{Payload: data[:len(data)-10], Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+			options, bf, finalize)
+		if err == nil {
+			t.Fatalf("Decode(%s) did not error on truncated packet", pcap)
+		}
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_flow_decoder_netflow_", "errors_total")
+	expectedMetrics := map[string]string{
+		`errors_total{error="NetFlow v1 decoding error",exporter="::ffff:127.0.0.1"}`: "1",
+		`errors_total{error="NetFlow v7 decoding error",exporter="::ffff:127.0.0.1"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Decode() metrics (-got, +want):\n%s", diff)
+	}
+}
+
 func TestDecodeTimestampFromNetFlowPacket(t *testing.T) {
 	_, nfdecoder, bf, got, finalize := setup(t, false)
 	options := decoder.Options{TimestampSource: pb.RawFlow_TS_NETFLOW_PACKET}
@@ -793,6 +1117,91 @@ func TestDecodeNAT(t *testing.T) {
 	}
 }
 
+func TestDecodeNSEL(t *testing.T) {
+	_, nfdecoder, bf, got, finalize := setup(t, true)
+	options := decoder.Options{TimestampSource: pb.RawFlow_TS_INPUT}
+
+	// The following PCAP contains a flow teardown, a flow denied and a flow
+	// created event from a Cisco ASA. The teardown event carries both
+	// initiator and responder byte counters and is split into two flows.
+	data := helpers.ReadPcapL4(t, filepath.Join("testdata", "nsel.pcap"))
+	_, err := nfdecoder.Decode(
+		decoder.RawFlow{Payload: data, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+		options, bf, finalize)
+	if err != nil {
+		t.Fatalf("Decode() error:\n%+v", err)
+	}
+
+	aclID := "0x433a1af1-0x8a9cf6e2-0x00000000"
+	expectedFlows := []*schema.FlowMessage{
+		{
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+			DstAddr:         netip.MustParseAddr("::ffff:198.51.100.20"),
+			InIf:            3,
+			OutIf:           4,
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnBytes:                 uint64(1500),
+				schema.ColumnSrcPort:               uint16(51234),
+				schema.ColumnDstPort:               uint16(443),
+				schema.ColumnEType:                 uint32(constants.ETypeIPv4),
+				schema.ColumnProto:                 uint32(constants.ProtoTCP),
+				schema.ColumnFirewallEvent:         uint8(schema.FirewallEventDeleted),
+				schema.ColumnFirewallExtendedEvent: uint16(2030),
+				schema.ColumnIngressACLID:          aclID,
+			},
+		}, {
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			SrcAddr:         netip.MustParseAddr("::ffff:198.51.100.20"),
+			DstAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+			InIf:            4,
+			OutIf:           3,
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnBytes:                 uint64(9000),
+				schema.ColumnSrcPort:               uint16(443),
+				schema.ColumnDstPort:               uint16(51234),
+				schema.ColumnEType:                 uint32(constants.ETypeIPv4),
+				schema.ColumnProto:                 uint32(constants.ProtoTCP),
+				schema.ColumnFirewallEvent:         uint8(schema.FirewallEventDeleted),
+				schema.ColumnFirewallExtendedEvent: uint16(2030),
+				schema.ColumnIngressACLID:          aclID,
+			},
+		}, {
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.11"),
+			DstAddr:         netip.MustParseAddr("::ffff:198.51.100.21"),
+			InIf:            3,
+			OutIf:           4,
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnSrcPort:               uint16(40000),
+				schema.ColumnDstPort:               uint16(22),
+				schema.ColumnEType:                 uint32(constants.ETypeIPv4),
+				schema.ColumnProto:                 uint32(constants.ProtoTCP),
+				schema.ColumnFirewallEvent:         uint8(schema.FirewallEventDenied),
+				schema.ColumnFirewallExtendedEvent: uint16(1001),
+				schema.ColumnIngressACLID:          aclID,
+			},
+		}, {
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.12"),
+			DstAddr:         netip.MustParseAddr("::ffff:198.51.100.22"),
+			InIf:            3,
+			OutIf:           4,
+			OtherColumns: map[schema.ColumnKey]any{
+				schema.ColumnSrcPort:       uint16(53000),
+				schema.ColumnDstPort:       uint16(53),
+				schema.ColumnEType:         uint32(constants.ETypeIPv4),
+				schema.ColumnProto:         uint32(constants.ProtoUDP),
+				schema.ColumnFirewallEvent: uint8(schema.FirewallEventCreated),
+			},
+		},
+	}
+
+	if diff := helpers.Diff(*got, expectedFlows); diff != "" {
+		t.Fatalf("Decode() (-got, +want):\n%s", diff)
+	}
+}
+
 func TestDecodePhysicalInterfaces(t *testing.T) {
 	_, nfdecoder, bf, got, finalize := setup(t, true)
 	options := decoder.Options{TimestampSource: pb.RawFlow_TS_INPUT}
@@ -1026,6 +1435,11 @@ func TestDecodeNonEncap(t *testing.T) {
 			expectedErrorMetrics: map[string]string{
 				`errors_total{error="non-encapsulated packet",exporter="::ffff:127.0.0.1"}`: "1",
 			},
+		}, {
+			pcaps: []string{"nfv1.pcap", "nfv7.pcap"},
+			expectedErrorMetrics: map[string]string{
+				`errors_total{error="non-encapsulated packet",exporter="::ffff:127.0.0.1"}`: "2",
+			},
 		},
 	}
 	for i, tc := range cases {
@@ -1215,3 +1629,114 @@ func TestDecodeEVPN(t *testing.T) {
 		t.Fatalf("Flow #4 diff (-got, +want):\n%s", diff)
 	}
 }
+
+func TestDecodeInterfaceOptions(t *testing.T) {
+	for _, file := range []string{"interfaces-options.pcap", "ipfix-interfaces-options.pcap"} {
+		t.Run(file, func(t *testing.T) {
+			_, nfdecoder, bf, _, finalize := setup(t, true)
+			got := []decoder.ExporterMetadata{}
+			options := decoder.Options{
+				Metadata: func(em *decoder.ExporterMetadata) {
+					got = append(got, *em)
+				},
+			}
+			data := helpers.ReadPcapL4(t, filepath.Join("testdata", file))
+			_, err := nfdecoder.Decode(
+				decoder.RawFlow{Payload: data, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+				options, bf, finalize)
+			if err != nil {
+				t.Fatalf("Decode() error:\n%+v", err)
+			}
+
+			exporter := netip.MustParseAddr("::ffff:127.0.0.1")
+			expected := []decoder.ExporterMetadata{
+				{
+					ExporterAddress: exporter,
+					IfIndex:         586,
+					IfName:          "Gi0/0/0/1",
+					IfDescription:   "Transit: Cogent",
+				}, {
+					ExporterAddress: exporter,
+					IfIndex:         587,
+					IfName:          "Gi0/0/0/2",
+					IfDescription:   "PNI: Netflix",
+				},
+			}
+			if file == "interfaces-options.pcap" {
+				expected = append(expected, decoder.ExporterMetadata{
+					ExporterAddress: exporter,
+					SamplerID:       1,
+					SamplingRate:    1000,
+				})
+			}
+			if diff := helpers.Diff(got, expected); diff != "" {
+				t.Fatalf("Decode() (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	_, nfdecoder, bf, _, finalize := setup(t, true)
+	data := helpers.ReadPcapL4(t, filepath.Join("testdata", "interfaces-options.pcap"))
+	_, err := nfdecoder.Decode(
+		decoder.RawFlow{Payload: data, Source: netip.MustParseAddr("::ffff:127.0.0.1")},
+		decoder.Options{}, bf, finalize)
+	if err != nil {
+		t.Fatalf("Decode() error:\n%+v", err)
+	}
+
+	td := nfdecoder.(decoder.TemplatesDecoder)
+	got := td.Templates()
+	for i := range got {
+		for j := range got[i].Templates {
+			if got[i].Templates[j].LastUpdated.IsZero() {
+				t.Errorf("Templates() last update for template %d is zero", got[i].Templates[j].TemplateID)
+			}
+			got[i].Templates[j].LastUpdated = time.Time{}
+		}
+	}
+	expected := []decoder.ExporterTemplates{
+		{
+			Exporter: netip.MustParseAddr("127.0.0.1"),
+			Templates: []decoder.Template{
+				{
+					Version:     9,
+					TemplateID:  257,
+					Type:        "options",
+					ScopeFields: []decoder.TemplateField{{Type: 2, Length: 4}},
+					Fields: []decoder.TemplateField{
+						{Type: 82, Length: 16},
+						{Type: 83, Length: 32},
+					},
+				}, {
+					Version:     9,
+					TemplateID:  258,
+					Type:        "options",
+					ScopeFields: []decoder.TemplateField{{Type: 1, Length: 4}},
+					Fields: []decoder.TemplateField{
+						{Type: 48, Length: 1},
+						{Type: 34, Length: 4},
+					},
+				},
+			},
+			SamplingRates: []decoder.SamplingRate{
+				{Version: 9, SamplerID: 1, Rate: 1000},
+			},
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Templates() (-got, +want):\n%s", diff)
+	}
+
+	// Flush the templates
+	if !td.FlushTemplates(netip.MustParseAddr("127.0.0.1")) {
+		t.Fatal("FlushTemplates() == false")
+	}
+	if got := td.Templates(); len(got) != 0 {
+		t.Fatalf("Templates() after flush:\n%+v", got)
+	}
+	if td.FlushTemplates(netip.MustParseAddr("127.0.0.1")) {
+		t.Fatal("FlushTemplates() == true on unknown exporter")
+	}
+}
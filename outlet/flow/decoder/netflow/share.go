@@ -0,0 +1,236 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"akvorado/outlet/flow/decoder"
+)
+
+var _ decoder.StateSharingDecoder = &Decoder{}
+
+// Templates and sampling rates are shared using the exporter key, the kind of
+// element and the template or sampling rate key as a state key. For example,
+// "::ffff:192.0.2.1/template/9-0-257". Templates are encoded like in the
+// persisted state. Sampling rates are encoded as JSON numbers.
+const (
+	stateTemplate     = "template"
+	stateSamplingRate = "sampling-rate"
+)
+
+// SetStatePublisher sets the function to call when a template or a sampling
+// rate changes. Templates and sampling rates still received are published again
+// after the refresh interval.
+func (nd *Decoder) SetStatePublisher(publish decoder.StatePublishFunc, refresh time.Duration) {
+	nd.publish = publish
+	nd.publishRefresh = refresh
+}
+
+// needsRefresh tells if an element last published at the provided time should
+// be published again.
+func (nd *Decoder) needsRefresh(published time.Time) bool {
+	return nd.publishRefresh > 0 && time.Since(published) >= nd.publishRefresh
+}
+
+// markTemplateShared records a template was published. The template lock
+// should be held.
+func (t *templatesAndOptions) markTemplateShared(key templateKey) {
+	if t.templatesShared == nil {
+		t.templatesShared = make(map[templateKey]time.Time)
+	}
+	t.templatesShared[key] = time.Now()
+}
+
+// markSamplingRateShared records a sampling rate was published. The sampling
+// rate lock should be held.
+func (t *templatesAndOptions) markSamplingRateShared(key samplingRateKey) {
+	if t.samplingRatesShared == nil {
+		t.samplingRatesShared = make(map[samplingRateKey]time.Time)
+	}
+	t.samplingRatesShared[key] = time.Now()
+}
+
+// publishTemplate publishes a new template, if a publisher is set.
+func (nd *Decoder) publishTemplate(exporter string, key templateKey, template any) {
+	if nd.publish == nil {
+		return
+	}
+	tt, err := newTypedTemplate(template)
+	if err != nil {
+		return
+	}
+	value, err := json.Marshal(&tt)
+	if err != nil {
+		return
+	}
+	k, _ := key.MarshalText()
+	nd.publish(fmt.Sprintf("%s/%s/%s", exporter, stateTemplate, k), value)
+}
+
+// publishSamplingRate publishes a new sampling rate, if a publisher is set.
+func (nd *Decoder) publishSamplingRate(exporter string, key samplingRateKey, samplingRate uint32) {
+	if nd.publish == nil {
+		return
+	}
+	k, _ := key.MarshalText()
+	nd.publish(fmt.Sprintf("%s/%s/%s", exporter, stateSamplingRate, k),
+		fmt.Appendf(nil, "%d", samplingRate))
+}
+
+// unpublish publishes the removal of all templates and sampling rates of an
+// exporter, if a publisher is set.
+func (t *templatesAndOptions) unpublish() {
+	if t.nd.publish == nil {
+		return
+	}
+	t.templateLock.RLock()
+	templateKeys := slices.Collect(maps.Keys(t.Templates))
+	t.templateLock.RUnlock()
+	t.samplingRateLock.RLock()
+	samplingRateKeys := slices.Collect(maps.Keys(t.SamplingRates))
+	t.samplingRateLock.RUnlock()
+	t.publishRemovals(templateKeys, samplingRateKeys)
+}
+
+// publishRemovals publishes the removal of the provided templates and sampling
+// rates.
+func (t *templatesAndOptions) publishRemovals(templateKeys []templateKey, samplingRateKeys []samplingRateKey) {
+	for _, key := range templateKeys {
+		k, _ := key.MarshalText()
+		t.nd.publish(fmt.Sprintf("%s/%s/%s", t.Key, stateTemplate, k), nil)
+	}
+	for _, key := range samplingRateKeys {
+		k, _ := key.MarshalText()
+		t.nd.publish(fmt.Sprintf("%s/%s/%s", t.Key, stateSamplingRate, k), nil)
+	}
+}
+
+// ExpireState removes the templates and sampling rates not published by any
+// instance since the provided time and publishes their removal. As the
+// instances receiving an exporter publish its templates and sampling rates at
+// each refresh interval, they are only removed when no instance receives them
+// anymore.
+func (nd *Decoder) ExpireState(before time.Time) {
+	nd.collection.lock.Lock()
+	taos := slices.Collect(maps.Values(nd.collection.Collection))
+	nd.collection.lock.Unlock()
+	for _, tao := range taos {
+		tao.expire(before)
+	}
+}
+
+// expire removes the templates and sampling rates not published since the
+// provided time. Elements never published, for example when restored from a
+// previous state, are not removed on the first call.
+func (t *templatesAndOptions) expire(before time.Time) {
+	var templateKeys []templateKey
+	t.templateLock.Lock()
+	for key := range t.Templates {
+		published, ok := t.templatesShared[key]
+		if !ok {
+			t.markTemplateShared(key)
+			continue
+		}
+		if published.Before(before) {
+			delete(t.Templates, key)
+			delete(t.LastUpdates, key)
+			delete(t.templatesShared, key)
+			templateKeys = append(templateKeys, key)
+		}
+	}
+	t.templateLock.Unlock()
+	var samplingRateKeys []samplingRateKey
+	t.samplingRateLock.Lock()
+	for key := range t.SamplingRates {
+		published, ok := t.samplingRatesShared[key]
+		if !ok {
+			t.markSamplingRateShared(key)
+			continue
+		}
+		if published.Before(before) {
+			delete(t.SamplingRates, key)
+			delete(t.samplingRatesShared, key)
+			samplingRateKeys = append(samplingRateKeys, key)
+		}
+	}
+	t.samplingRateLock.Unlock()
+	if t.nd.publish != nil {
+		t.publishRemovals(templateKeys, samplingRateKeys)
+	}
+}
+
+// ApplyState applies a template or a sampling rate published by another
+// instance.
+func (nd *Decoder) ApplyState(key string, value []byte) error {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid state key %q", key)
+	}
+	exporter, kind, elementKey := parts[0], parts[1], parts[2]
+	switch kind {
+	case stateTemplate:
+		var tk templateKey
+		if err := tk.UnmarshalText([]byte(elementKey)); err != nil {
+			return err
+		}
+		var template any
+		if value != nil {
+			var rt rawTypedTemplate
+			if err := json.Unmarshal(value, &rt); err != nil {
+				return fmt.Errorf("cannot decode template %q: %w", key, err)
+			}
+			var err error
+			if template, err = rt.decode(); err != nil {
+				return fmt.Errorf("cannot decode template %q: %w", key, err)
+			}
+		}
+		if template == nil {
+			if tao := nd.collection.Lookup(exporter); tao != nil {
+				tao.templateLock.Lock()
+				delete(tao.Templates, tk)
+				delete(tao.LastUpdates, tk)
+				delete(tao.templatesShared, tk)
+				tao.templateLock.Unlock()
+			}
+			return nil
+		}
+		tao := nd.collection.Get(exporter)
+		tao.templateLock.Lock()
+		tao.setTemplate(tk, template)
+		tao.markTemplateShared(tk)
+		tao.templateLock.Unlock()
+	case stateSamplingRate:
+		var srk samplingRateKey
+		if err := srk.UnmarshalText([]byte(elementKey)); err != nil {
+			return err
+		}
+		if value == nil {
+			if tao := nd.collection.Lookup(exporter); tao != nil {
+				tao.samplingRateLock.Lock()
+				delete(tao.SamplingRates, srk)
+				delete(tao.samplingRatesShared, srk)
+				tao.samplingRateLock.Unlock()
+			}
+			return nil
+		}
+		var samplingRate uint32
+		if err := json.Unmarshal(value, &samplingRate); err != nil {
+			return fmt.Errorf("cannot decode sampling rate %q: %w", key, err)
+		}
+		tao := nd.collection.Get(exporter)
+		tao.samplingRateLock.Lock()
+		tao.SamplingRates[srk] = samplingRate
+		tao.markSamplingRateShared(srk)
+		tao.samplingRateLock.Unlock()
+	default:
+		return fmt.Errorf("unknown state kind %q", kind)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netflow
+
+import (
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/flow/decoder"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestShareState(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	nd1 := New(r, decoder.Dependencies{Schema: sch}).(*Decoder)
+	nd2 := New(r, decoder.Dependencies{Schema: sch}).(*Decoder)
+	published := map[string][]byte{}
+	count := 0
+	nd1.SetStatePublisher(func(key string, value []byte) {
+		published[key] = value
+		count++
+	}, 0)
+
+	// Decode templates and sampling rate with the first decoder
+	exporter := netip.MustParseAddr("::ffff:127.0.0.1")
+	bf := sch.NewFlowMessage()
+	data := helpers.ReadPcapL4(t, filepath.Join("testdata", "interfaces-options.pcap"))
+	for range 2 {
+		if _, err := nd1.Decode(decoder.RawFlow{Payload: data, Source: exporter},
+			decoder.Options{}, bf, func() {}); err != nil {
+			t.Fatalf("Decode() error:\n%+v", err)
+		}
+	}
+	expectedPublished := map[string][]byte{
+		"::ffff:127.0.0.1/template/9-0-257":    []byte(`{"Type":"nfv9-option","Template":{"TemplateId":257,"ScopeLength":4,"OptionLength":8,"Scopes":[{"PenProvided":false,"Type":2,"Length":4,"Pen":0}],"Options":[{"PenProvided":false,"Type":82,"Length":16,"Pen":0},{"PenProvided":false,"Type":83,"Length":32,"Pen":0}]}}`),
+		"::ffff:127.0.0.1/template/9-0-258":    []byte(`{"Type":"nfv9-option","Template":{"TemplateId":258,"ScopeLength":4,"OptionLength":8,"Scopes":[{"PenProvided":false,"Type":1,"Length":4,"Pen":0}],"Options":[{"PenProvided":false,"Type":48,"Length":1,"Pen":0},{"PenProvided":false,"Type":34,"Length":4,"Pen":0}]}}`),
+		"::ffff:127.0.0.1/sampling-rate/9-0-1": []byte("1000"),
+	}
+	if diff := helpers.Diff(published, expectedPublished); diff != "" {
+		t.Fatalf("SetStatePublisher() (-got, +want):\n%s", diff)
+	}
+	// Unchanged templates are not published again
+	if count != 3 {
+		t.Fatalf("SetStatePublisher() called %d times, expected 3", count)
+	}
+
+	// Apply them to the second decoder
+	for key, value := range published {
+		if err := nd2.ApplyState(key, value); err != nil {
+			t.Fatalf("ApplyState(%q) error:\n%+v", key, err)
+		}
+	}
+	if diff := helpers.Diff(nd2.collection.Collection, nd1.collection.Collection,
+		cmpopts.IgnoreUnexported(templatesAndOptions{}),
+		cmpopts.EquateApproxTime(time.Minute)); diff != "" {
+		t.Fatalf("ApplyState() (-got, +want):\n%s", diff)
+	}
+
+	// Flushing publishes removals
+	nd1.FlushTemplates(exporter)
+	for key, value := range published {
+		if value != nil {
+			t.Fatalf("FlushTemplates() did not remove %q", key)
+		}
+		if err := nd2.ApplyState(key, value); err != nil {
+			t.Fatalf("ApplyState(%q) error:\n%+v", key, err)
+		}
+	}
+	if got := nd2.Templates(); len(got) != 1 || len(got[0].Templates) != 0 || len(got[0].SamplingRates) != 0 {
+		t.Fatalf("Templates() after removal:\n%+v", got)
+	}
+
+	// Invalid keys
+	for _, key := range []string{"nothing", "::ffff:127.0.0.1/unknown/9-0-1", "::ffff:127.0.0.1/template/9-0"} {
+		if err := nd2.ApplyState(key, []byte("1")); err == nil {
+			t.Errorf("ApplyState(%q) did not error", key)
+		}
+	}
+}
+
+func TestShareStateExpiration(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	nd := New(r, decoder.Dependencies{Schema: sch}).(*Decoder)
+	published := map[string][]byte{}
+	count := 0
+	nd.SetStatePublisher(func(key string, value []byte) {
+		published[key] = value
+		count++
+	}, 20*time.Millisecond)
+	exporter := netip.MustParseAddr("::ffff:127.0.0.1")
+	bf := sch.NewFlowMessage()
+	data := helpers.ReadPcapL4(t, filepath.Join("testdata", "interfaces-options.pcap"))
+	decode := func() {
+		t.Helper()
+		if _, err := nd.Decode(decoder.RawFlow{Payload: data, Source: exporter},
+			decoder.Options{}, bf, func() {}); err != nil {
+			t.Fatalf("Decode() error:\n%+v", err)
+		}
+	}
+
+	// Unchanged elements are published again after the refresh interval
+	decode()
+	decode()
+	if count != 3 {
+		t.Fatalf("SetStatePublisher() called %d times, expected 3", count)
+	}
+	time.Sleep(30 * time.Millisecond)
+	decode()
+	if count != 6 {
+		t.Fatalf("SetStatePublisher() called %d times after refresh, expected 6", count)
+	}
+
+	// Recently published elements are not expired
+	nd.ExpireState(time.Now().Add(-time.Minute))
+	if count != 6 {
+		t.Fatalf("ExpireState() published %d changes, expected 0", count-6)
+	}
+
+	// Elements received from another instance are not expired either
+	time.Sleep(10 * time.Millisecond)
+	before := time.Now()
+	key := "::ffff:127.0.0.1/sampling-rate/9-0-1"
+	if err := nd.ApplyState(key, []byte("1000")); err != nil {
+		t.Fatalf("ApplyState(%q) error:\n%+v", key, err)
+	}
+	nd.ExpireState(before)
+	expectedPublished := map[string][]byte{
+		"::ffff:127.0.0.1/template/9-0-257":    nil,
+		"::ffff:127.0.0.1/template/9-0-258":    nil,
+		"::ffff:127.0.0.1/sampling-rate/9-0-1": []byte("1000"),
+	}
+	if diff := helpers.Diff(published, expectedPublished); diff != "" {
+		t.Fatalf("ExpireState() (-got, +want):\n%s", diff)
+	}
+	got := nd.Templates()
+	if len(got) != 1 || len(got[0].Templates) != 0 || len(got[0].SamplingRates) != 1 {
+		t.Fatalf("Templates() after expiration:\n%+v", got)
+	}
+}
@@ -4,8 +4,10 @@
 package netflow
 
 import (
+	"reflect"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/netsampler/goflow2/v3/decoders/netflow"
 )
@@ -27,7 +29,13 @@ type templatesAndOptions struct {
 
 	Key           string
 	Templates     templates
+	LastUpdates   map[templateKey]time.Time
 	SamplingRates map[samplingRateKey]uint32
+
+	// When sharing state, last time each element was published by any
+	// instance. They are protected by the lock of the element.
+	templatesShared     map[templateKey]time.Time
+	samplingRatesShared map[samplingRateKey]time.Time
 }
 
 // templates is a mapping to one of netflow.TemplateRecord,
@@ -65,12 +73,34 @@ func (c *templateAndOptionCollection) Get(key string) *templatesAndOptions {
 		nd:            c.nd,
 		Key:           key,
 		Templates:     make(map[templateKey]any),
+		LastUpdates:   make(map[templateKey]time.Time),
 		SamplingRates: make(map[samplingRateKey]uint32),
 	}
 	c.Collection[key] = t
 	return t
 }
 
+// Lookup returns templates and options for the provided key, or nil if there
+// are none.
+func (c *templateAndOptionCollection) Lookup(key string) *templatesAndOptions {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Collection[key]
+}
+
+// Delete removes templates and options for the provided key. It returns the
+// removed entry or nil if there was none.
+func (c *templateAndOptionCollection) Delete(key string) *templatesAndOptions {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	t, ok := c.Collection[key]
+	if !ok {
+		return nil
+	}
+	delete(c.Collection, key)
+	return t
+}
+
 // GetTemplate returns the requested template.
 func (t *templatesAndOptions) GetTemplate(_ netflow.FlowContext, version uint16, obsDomainID uint32, templateID uint16) (any, error) {
 	t.templateLock.RLock()
@@ -102,12 +132,32 @@ func (t *templatesAndOptions) AddTemplate(_ netflow.FlowContext, version uint16,
 		typeStr,
 	).Inc()
 
+	key := templateKey{version: version, obsDomainID: obsDomainID, templateID: templateID}
 	t.templateLock.Lock()
-	defer t.templateLock.Unlock()
-	t.Templates[templateKey{version: version, obsDomainID: obsDomainID, templateID: templateID}] = template
+	previous, ok := t.Templates[key]
+	changed := !ok || !reflect.DeepEqual(previous, template)
+	t.setTemplate(key, template)
+	publish := t.nd.publish != nil && (changed || t.nd.needsRefresh(t.templatesShared[key]))
+	if publish {
+		t.markTemplateShared(key)
+	}
+	t.templateLock.Unlock()
+	if publish {
+		t.nd.publishTemplate(t.Key, key, template)
+	}
 	return netflow.TemplateAdded, nil
 }
 
+// setTemplate stores a template. The template lock should be held.
+func (t *templatesAndOptions) setTemplate(key templateKey, template any) {
+	t.Templates[key] = template
+	if t.LastUpdates == nil {
+		// State saved by an older version
+		t.LastUpdates = make(map[templateKey]time.Time)
+	}
+	t.LastUpdates[key] = time.Now()
+}
+
 // GetSamplingRate returns the requested sampling rate.
 func (t *templatesAndOptions) GetSamplingRate(version uint16, obsDomainID uint32, samplerID uint64) uint32 {
 	t.samplingRateLock.RLock()
@@ -122,11 +172,21 @@ func (t *templatesAndOptions) GetSamplingRate(version uint16, obsDomainID uint32
 
 // SetSamplingRate sets the sampling rate.
 func (t *templatesAndOptions) SetSamplingRate(version uint16, obsDomainID uint32, samplerID uint64, samplingRate uint32) {
-	t.samplingRateLock.Lock()
-	defer t.samplingRateLock.Unlock()
-	t.SamplingRates[samplingRateKey{
+	key := samplingRateKey{
 		version:     version,
 		obsDomainID: obsDomainID,
 		samplerID:   samplerID,
-	}] = samplingRate
+	}
+	t.samplingRateLock.Lock()
+	previous, ok := t.SamplingRates[key]
+	t.SamplingRates[key] = samplingRate
+	publish := t.nd.publish != nil &&
+		(!ok || previous != samplingRate || t.nd.needsRefresh(t.samplingRatesShared[key]))
+	if publish {
+		t.markSamplingRateShared(key)
+	}
+	t.samplingRateLock.Unlock()
+	if publish {
+		t.nd.publishSamplingRate(t.Key, key, samplingRate)
+	}
 }
@@ -0,0 +1,131 @@
+# SPDX-FileCopyrightText: 2026 Free Mobile
+# SPDX-License-Identifier: AGPL-3.0-only
+
+"""Dissect NetFlow v1 and v7 captures into text files.
+
+nfv1.pcap and nfv7.pcap are synthetic. To not only check the decoder against
+the program generating them, this dissector, written independently from the
+field tables of the Cisco NetFlow export datagram format documentation, produces
+nfv1.txt and nfv7.txt. The decoder is tested against these files. Run it from
+this directory with "python3 legacy-dissect.py".
+"""
+
+import ipaddress
+import struct
+import sys
+
+# Field tables, in order, as (name, size in bytes, format). Fields whose name
+# starts with "-" are padding or reserved.
+HEADERS = {
+    1: [
+        ("Version", 2, "u"),
+        ("Count", 2, "u"),
+        ("SysUptime", 4, "u"),
+        ("UnixSecs", 4, "u"),
+        ("UnixNsecs", 4, "u"),
+    ],
+    7: [
+        ("Version", 2, "u"),
+        ("Count", 2, "u"),
+        ("SysUptime", 4, "u"),
+        ("UnixSecs", 4, "u"),
+        ("UnixNsecs", 4, "u"),
+        ("FlowSequence", 4, "u"),
+        ("-reserved", 4, "u"),
+    ],
+}
+RECORDS = {
+    1: [
+        ("SrcAddr", 4, "ip"),
+        ("DstAddr", 4, "ip"),
+        ("NextHop", 4, "ip"),
+        ("InputInt", 2, "u"),
+        ("OutputInt", 2, "u"),
+        ("Packets", 4, "u"),
+        ("Octets", 4, "u"),
+        ("First", 4, "u"),
+        ("Last", 4, "u"),
+        ("SrcPort", 2, "u"),
+        ("DstPort", 2, "u"),
+        ("-pad1", 2, "u"),
+        ("Protocol", 1, "u"),
+        ("ToS", 1, "x"),
+        ("TCPFlags", 1, "x"),
+        ("-pad2", 3, "u"),
+        ("-reserved", 4, "u"),
+    ],
+    7: [
+        ("SrcAddr", 4, "ip"),
+        ("DstAddr", 4, "ip"),
+        ("NextHop", 4, "ip"),
+        ("InputInt", 2, "u"),
+        ("OutputInt", 2, "u"),
+        ("Packets", 4, "u"),
+        ("Octets", 4, "u"),
+        ("First", 4, "u"),
+        ("Last", 4, "u"),
+        ("SrcPort", 2, "u"),
+        ("DstPort", 2, "u"),
+        ("Flags", 1, "x"),
+        ("TCPFlags", 1, "x"),
+        ("Protocol", 1, "u"),
+        ("ToS", 1, "x"),
+        ("SrcAS", 2, "u"),
+        ("DstAS", 2, "u"),
+        ("SrcMask", 1, "u"),
+        ("DstMask", 1, "u"),
+        ("Flags2", 2, "x"),
+        ("RouterShortcut", 4, "ip"),
+    ],
+}
+
+
+def udp_payload(path):
+    """Return the UDP payload of the first packet of an Ethernet pcap file."""
+    with open(path, "rb") as f:
+        data = f.read()
+    magic = struct.unpack("<I", data[:4])[0]
+    endian = "<" if magic == 0xA1B2C3D4 else ">"
+    caplen = struct.unpack(endian + "I", data[24 + 8 : 24 + 12])[0]
+    frame = data[24 + 16 : 24 + 16 + caplen]
+    assert struct.unpack(">H", frame[12:14])[0] == 0x0800, "not IPv4"
+    ip = frame[14:]
+    ihl = (ip[0] & 0x0F) * 4
+    assert ip[9] == 17, "not UDP"
+    return ip[ihl + 8 :]
+
+
+def dissect(payload, fields, offset, indent, out):
+    """Dissect fields starting at offset and return the values and the new offset."""
+    values = {}
+    for name, size, kind in fields:
+        raw = payload[offset : offset + size]
+        assert len(raw) == size, "truncated packet"
+        if kind == "ip":
+            value = str(ipaddress.IPv4Address(raw))
+        else:
+            value = int.from_bytes(raw, "big")
+        if not name.startswith("-"):
+            text = f"0x{value:02x}" if kind == "x" else str(value)
+            out.append(f"{indent}{name}: {text}")
+        values[name] = value
+        offset += size
+    return values, offset
+
+
+def main(directory):
+    for version in (1, 7):
+        payload = udp_payload(f"{directory}/nfv{version}.pcap")
+        out = [f"Cisco NetFlow v{version}"]
+        header, offset = dissect(payload, HEADERS[version], 0, "    ", out)
+        assert header["Version"] == version, "unexpected version"
+        for i in range(header["Count"]):
+            out.append(f"    pdu {i + 1}/{header['Count']}")
+            _, offset = dissect(payload, RECORDS[version], offset, "        ", out)
+        assert offset == len(payload), "trailing bytes"
+        with open(f"{directory}/nfv{version}.txt", "w") as f:
+            f.write("\n".join(out) + "\n")
+
+
+if __name__ == "__main__":
+    main(sys.argv[1] if len(sys.argv) > 1 else ".")
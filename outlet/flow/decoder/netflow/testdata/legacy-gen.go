@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+//go:build ignore
+
+// This program generates nfv1.pcap and nfv7.pcap. These captures are
+// synthetic: no NetFlow v1 or v7 exporter was available to capture real
+// packets. The layout of the packets follows the format documented by Cisco.
+// Run it from this directory with "go run legacy-gen.go .", then update the
+// dissections with "python3 legacy-dissect.py".
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// rec describes a flow record. Fields not present in a version are ignored.
+type rec struct {
+	src, dst, nh      string
+	in, out           uint16
+	pkts, octets      uint32
+	first, last       uint32
+	sport, dport      uint16
+	proto, tos, flags uint8
+	srcAS, dstAS      uint16
+	srcMask, dstMask  uint8
+}
+
+func ip4(s string) []byte { return net.ParseIP(s).To4() }
+
+// v1 builds a NetFlow v1 packet.
+func v1(uptime, secs uint32, recs []rec) []byte {
+	b := make([]byte, 16+48*len(recs))
+	binary.BigEndian.PutUint16(b[0:], 1)
+	binary.BigEndian.PutUint16(b[2:], uint16(len(recs)))
+	binary.BigEndian.PutUint32(b[4:], uptime)
+	binary.BigEndian.PutUint32(b[8:], secs)
+	binary.BigEndian.PutUint32(b[12:], 123456)
+	for i, r := range recs {
+		o := b[16+48*i:]
+		copy(o[0:], ip4(r.src))
+		copy(o[4:], ip4(r.dst))
+		copy(o[8:], ip4(r.nh))
+		binary.BigEndian.PutUint16(o[12:], r.in)
+		binary.BigEndian.PutUint16(o[14:], r.out)
+		binary.BigEndian.PutUint32(o[16:], r.pkts)
+		binary.BigEndian.PutUint32(o[20:], r.octets)
+		binary.BigEndian.PutUint32(o[24:], r.first)
+		binary.BigEndian.PutUint32(o[28:], r.last)
+		binary.BigEndian.PutUint16(o[32:], r.sport)
+		binary.BigEndian.PutUint16(o[34:], r.dport)
+		o[38] = r.proto
+		o[39] = r.tos
+		o[40] = r.flags
+	}
+	return b
+}
+
+// v7 builds a NetFlow v7 packet.
+func v7(uptime, secs uint32, recs []rec) []byte {
+	b := make([]byte, 24+52*len(recs))
+	binary.BigEndian.PutUint16(b[0:], 7)
+	binary.BigEndian.PutUint16(b[2:], uint16(len(recs)))
+	binary.BigEndian.PutUint32(b[4:], uptime)
+	binary.BigEndian.PutUint32(b[8:], secs)
+	binary.BigEndian.PutUint32(b[12:], 654321)
+	binary.BigEndian.PutUint32(b[16:], 4242)
+	for i, r := range recs {
+		o := b[24+52*i:]
+		copy(o[0:], ip4(r.src))
+		copy(o[4:], ip4(r.dst))
+		copy(o[8:], ip4(r.nh))
+		binary.BigEndian.PutUint16(o[12:], r.in)
+		binary.BigEndian.PutUint16(o[14:], r.out)
+		binary.BigEndian.PutUint32(o[16:], r.pkts)
+		binary.BigEndian.PutUint32(o[20:], r.octets)
+		binary.BigEndian.PutUint32(o[24:], r.first)
+		binary.BigEndian.PutUint32(o[28:], r.last)
+		binary.BigEndian.PutUint16(o[32:], r.sport)
+		binary.BigEndian.PutUint16(o[34:], r.dport)
+		o[37] = r.flags
+		o[38] = r.proto
+		o[39] = r.tos
+		binary.BigEndian.PutUint16(o[40:], r.srcAS)
+		binary.BigEndian.PutUint16(o[42:], r.dstAS)
+		o[44] = r.srcMask
+		o[45] = r.dstMask
+		copy(o[48:], ip4("10.0.0.1"))
+	}
+	return b
+}
+
+// write writes a pcap file with a single UDP packet carrying the payload.
+func write(path string, ts time.Time, payload []byte) {
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}, EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{Version: 4, TTL: 64, SrcIP: ip4("10.0.0.1"), DstIP: ip4("10.0.0.2"), Protocol: layers.IPProtocolUDP}
+	udp := &layers.UDP{SrcPort: 51234, DstPort: 2055}
+	udp.SetNetworkLayerForChecksum(ip)
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, eth, ip, udp, gopacket.Payload(payload)); err != nil {
+		panic(err)
+	}
+	data := buf.Bytes()
+	f, err := os.Create(path)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	w := pcapgo.NewWriter(f)
+	if err := w.WriteFileHeader(65535, layers.LinkTypeEthernet); err != nil {
+		panic(err)
+	}
+	if err := w.WritePacket(gopacket.CaptureInfo{Timestamp: ts, CaptureLength: len(data), Length: len(data)}, data); err != nil {
+		panic(err)
+	}
+}
+
+func main() {
+	secs := uint32(1791194400) // 2026-10-05 10:00:00 UTC
+	uptime := uint32(86400000)
+	ts := time.Unix(int64(secs), 0)
+	write(os.Args[1]+"/nfv1.pcap", ts, v1(uptime, secs, []rec{
+		{src: "198.51.100.10", dst: "203.0.113.20", nh: "192.0.2.254", in: 3, out: 7,
+			pkts: 12, octets: 8640, first: uptime - 15000, last: uptime - 1000,
+			sport: 443, dport: 52311, proto: 6, tos: 0x20, flags: 0x1b},
+		{src: "203.0.113.20", dst: "198.51.100.10", nh: "192.0.2.253", in: 7, out: 3,
+			pkts: 1, octets: 76, first: uptime - 2000, last: uptime - 2000,
+			sport: 53, dport: 40000, proto: 17},
+	}))
+	write(os.Args[1]+"/nfv7.pcap", ts, v7(uptime, secs, []rec{
+		{src: "198.51.100.10", dst: "203.0.113.20", nh: "192.0.2.254", in: 3, out: 7,
+			pkts: 12, octets: 8640, first: uptime - 15000, last: uptime - 1000,
+			sport: 443, dport: 52311, proto: 6, tos: 0x20, flags: 0x1b,
+			srcAS: 64496, dstAS: 64497, srcMask: 24, dstMask: 22},
+	}))
+}
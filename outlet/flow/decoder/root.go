@@ -27,12 +27,50 @@ type Decoder interface {
 	Name() string
 }
 
+// TemplatesDecoder is the interface implemented by decoders keeping templates
+// for each exporter, like NetFlow v9 and IPFIX.
+type TemplatesDecoder interface {
+	// Templates returns the templates and sampling rates known for each
+	// exporter.
+	Templates() []ExporterTemplates
+	// FlushTemplates forgets the templates and sampling rates of the provided
+	// exporter. It returns false if the exporter was unknown.
+	FlushTemplates(exporter netip.Addr) bool
+}
+
+// StateSharingDecoder is the interface implemented by decoders whose state can
+// be shared with other instances, like NetFlow v9 and IPFIX templates.
+type StateSharingDecoder interface {
+	// SetStatePublisher sets the function to call each time the state is
+	// changed locally. Elements still in use are published again after the
+	// refresh interval, even if they did not change, unless it is 0. It should
+	// be called before decoding anything.
+	SetStatePublisher(publish StatePublishFunc, refresh time.Duration)
+	// ApplyState applies a state change published by another instance. It
+	// does not trigger a publication.
+	ApplyState(key string, value []byte) error
+	// ExpireState removes the elements not published by any instance since
+	// the provided time and publishes their removal.
+	ExpireState(before time.Time)
+}
+
+// StatePublishFunc is the signature of a function publishing a state change.
+// The key identifies an element of the state and the value is its encoding. A
+// nil value means the element was removed.
+type StatePublishFunc func(key string, value []byte)
+
 // Options specifies option to influence the behaviour of the decoder
 type Options struct {
 	// TimestampSource is a selector for how to set the TimeReceived.
 	TimestampSource pb.RawFlow_TimestampSource
 	// DecapsulationProtocol is the protocol the decapsulate
 	DecapsulationProtocol pb.RawFlow_DecapsulationProtocol
+	// Counters is called for each set of interface counters found in the
+	// payload. When nil, interface counters are ignored.
+	Counters CountersFunc
+	// Metadata is called for each exporter metadata record found in the
+	// payload, like NetFlow/IPFIX options data. When nil, they are ignored.
+	Metadata MetadataFunc
 }
 
 // Dependencies are the dependencies for the decoder
@@ -50,6 +88,77 @@ type RawFlow struct {
 // NewDecoderFunc is the signature of a function to instantiate a decoder.
 type NewDecoderFunc func(*reporter.Reporter, Dependencies) Decoder
 
+// InterfaceCounters are the generic counters of an interface, as exported by
+// sFlow counter samples. Counters are cumulative.
+type InterfaceCounters struct {
+	TimeReceived    uint32
+	ExporterAddress netip.Addr
+	IfIndex         uint32
+	IfSpeed         uint64 // in bits per second
+	InOctets        uint64
+	OutOctets       uint64
+	InPackets       uint64
+	OutPackets      uint64
+	InErrors        uint64
+	OutErrors       uint64
+	InDiscards      uint64
+	OutDiscards     uint64
+}
+
+// CountersFunc is the signature of a function to handle interface counters.
+// The provided structure should not be kept after returning.
+type CountersFunc func(*InterfaceCounters)
+
+// ExporterMetadata is metadata about an exporter embedded in the flows, like
+// NetFlow/IPFIX options data. A record describes either an interface (when
+// IfIndex is not 0) or a sampler (when SamplingRate is not 0), or both.
+type ExporterMetadata struct {
+	ExporterAddress netip.Addr
+	IfIndex         uint32
+	IfName          string
+	IfDescription   string
+	SamplerID       uint64
+	SamplingRate    uint32
+}
+
+// MetadataFunc is the signature of a function to handle exporter metadata.
+// The provided structure should not be kept after returning.
+type MetadataFunc func(*ExporterMetadata)
+
 // FinalizeFlowFunc is the signature of a function to finalize a flow. The
 // caller has a reference to the flow message he provided.
 type FinalizeFlowFunc func()
+
+// ExporterTemplates are the templates and sampling rates known for an
+// exporter.
+type ExporterTemplates struct {
+	Exporter      netip.Addr     `json:"exporter"`
+	Templates     []Template     `json:"templates"`
+	SamplingRates []SamplingRate `json:"sampling-rates"`
+}
+
+// Template describes a template received from an exporter.
+type Template struct {
+	Version             uint16          `json:"version"`
+	ObservationDomainID uint32          `json:"observation-domain-id"`
+	TemplateID          uint16          `json:"template-id"`
+	Type                string          `json:"type"`
+	ScopeFields         []TemplateField `json:"scope-fields,omitempty"`
+	Fields              []TemplateField `json:"fields"`
+	LastUpdated         time.Time       `json:"last-updated"`
+}
+
+// TemplateField is a field of a template.
+type TemplateField struct {
+	Type   uint16 `json:"type"`
+	Length uint16 `json:"length"`
+	PEN    uint32 `json:"pen,omitempty"`
+}
+
+// SamplingRate is a sampling rate received from an exporter.
+type SamplingRate struct {
+	Version             uint16 `json:"version"`
+	ObservationDomainID uint32 `json:"observation-domain-id"`
+	SamplerID           uint64 `json:"sampler-id"`
+	Rate                uint32 `json:"rate"`
+}
@@ -91,6 +91,11 @@ func (nd *Decoder) decode(exporter string, packet sflow.Packet, options decoder.
 				forwardingStatus = cmp.Or(sflowDiscardReasonToForwardingStatus[flowSample.OutputIfValue], 128)
 			case interfaceFormatMultiple:
 			}
+		case sflow.CounterSample:
+			if options.Counters != nil {
+				decodeCounterSample(packet.AgentIP, flowSample, options.Counters)
+			}
+			continue
 		}
 
 		if bf.InIf == interfaceLocal {
@@ -231,6 +236,34 @@ func (nd *Decoder) decode(exporter string, packet sflow.Packet, options decoder.
 	return nil
 }
 
+// decodeCounterSample extracts the generic interface counters from a counter
+// sample. Other counter records are ignored.
+func decodeCounterSample(agentIP []byte, sample sflow.CounterSample, counters decoder.CountersFunc) {
+	for _, record := range sample.Records {
+		ifCounters, ok := record.Data.(sflow.IfCounters)
+		if !ok {
+			continue
+		}
+		counters(&decoder.InterfaceCounters{
+			ExporterAddress: decoder.DecodeIP(agentIP),
+			IfIndex:         ifCounters.IfIndex,
+			IfSpeed:         ifCounters.IfSpeed,
+			InOctets:        ifCounters.IfInOctets,
+			OutOctets:       ifCounters.IfOutOctets,
+			InPackets: uint64(ifCounters.IfInUcastPkts) +
+				uint64(ifCounters.IfInMulticastPkts) +
+				uint64(ifCounters.IfInBroadcastPkts),
+			OutPackets: uint64(ifCounters.IfOutUcastPkts) +
+				uint64(ifCounters.IfOutMulticastPkts) +
+				uint64(ifCounters.IfOutBroadcastPkts),
+			InErrors:    uint64(ifCounters.IfInErrors),
+			OutErrors:   uint64(ifCounters.IfOutErrors),
+			InDiscards:  uint64(ifCounters.IfInDiscards),
+			OutDiscards: uint64(ifCounters.IfOutDiscards),
+		})
+	}
+}
+
 func (nd *Decoder) parseSampledHeader(bf *schema.FlowMessage, decap pb.RawFlow_DecapsulationProtocol, header *sflow.SampledHeader) uint64 {
 	data := header.HeaderData
 	switch header.Protocol {
@@ -109,6 +109,14 @@ func (nd *Decoder) Decode(in decoder.RawFlow, options decoder.Options, bf *schem
 		}
 	}
 
+	if options.Counters != nil {
+		counters := options.Counters
+		options.Counters = func(ic *decoder.InterfaceCounters) {
+			ic.TimeReceived = uint32(ts)
+			counters(ic)
+		}
+	}
+
 	return len(samples), nd.decode(key, packet, options, bf, func() {
 		bf.TimeReceived = uint32(ts)
 		finalize()
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"akvorado/common/constants"
 	"akvorado/common/helpers"
@@ -634,6 +635,61 @@ func TestDecode(t *testing.T) {
 		}
 	})
 
+	t.Run("counter samples", func(t *testing.T) {
+		got = got[:0]
+		gotCounters := []decoder.InterfaceCounters{}
+		data := helpers.ReadPcapL4(t, filepath.Join("testdata", "data-counters.pcap"))
+		_, err := sdecoder.Decode(
+			decoder.RawFlow{
+				Payload:      data,
+				Source:       netip.MustParseAddr("::ffff:127.0.0.1"),
+				TimeReceived: time.Unix(1791194400, 0),
+			},
+			decoder.Options{
+				Counters: func(ic *decoder.InterfaceCounters) {
+					gotCounters = append(gotCounters, *ic)
+				},
+			}, bf, finalize)
+		if err != nil {
+			t.Fatalf("Decode() error:\n%+v", err)
+		}
+		if diff := helpers.Diff(got, []*schema.FlowMessage{}); diff != "" {
+			t.Fatalf("Decode() (-got, +want):\n%s", diff)
+		}
+		expectedCounters := []decoder.InterfaceCounters{
+			{
+				TimeReceived:    1791194400,
+				ExporterAddress: netip.MustParseAddr("::ffff:172.16.0.3"),
+				IfIndex:         27,
+				IfSpeed:         10_000_000_000,
+				InOctets:        123456789012,
+				OutOctets:       98765432109,
+				InPackets:       1311,
+				OutPackets:      2322,
+				InErrors:        103,
+				OutErrors:       105,
+				InDiscards:      102,
+				OutDiscards:     104,
+			}, {
+				TimeReceived:    1791194400,
+				ExporterAddress: netip.MustParseAddr("::ffff:172.16.0.3"),
+				IfIndex:         28,
+				IfSpeed:         1_000_000_000,
+				InOctets:        5000,
+				OutOctets:       6000,
+				InPackets:       1011,
+				OutPackets:      2022,
+				InErrors:        3,
+				OutErrors:       5,
+				InDiscards:      2,
+				OutDiscards:     4,
+			},
+		}
+		if diff := helpers.Diff(gotCounters, expectedCounters); diff != "" {
+			t.Fatalf("Decode() counters (-got, +want):\n%s", diff)
+		}
+	})
+
 	// All pcaps without "encap" should return nothing.
 	t.Run("non-encap flows", func(t *testing.T) {
 		pcapPattern := filepath.Join("testdata", "*.pcap")
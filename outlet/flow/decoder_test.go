@@ -4,6 +4,7 @@
 package flow
 
 import (
+	"fmt"
 	"net"
 	"net/netip"
 	"path"
@@ -204,6 +205,41 @@ func TestFlowDecode(t *testing.T) {
 		t.Logf("Successfully decoded %d sflow flows", len(got))
 	})
 
+	// Test sflow counters
+	t.Run("sflow counters", func(t *testing.T) {
+		got = got[:0]
+		sflowBase := path.Join(path.Dir(src), "decoder", "sflow", "testdata")
+		rawFlow := &pb.RawFlow{
+			TimeReceived:     1791194400,
+			Payload:          helpers.ReadPcapL4(t, path.Join(sflowBase, "data-counters.pcap")),
+			SourceAddress:    net.ParseIP("127.0.0.1").To16(),
+			UseSourceAddress: true,
+			Decoder:          pb.RawFlow_DECODER_SFLOW,
+			TimestampSource:  pb.RawFlow_TS_INPUT,
+		}
+
+		gotCounters := []string{}
+		err := c.DecodeWithOptions(rawFlow, bf, finalize, DecodeOptions{
+			Counters: func(ic *decoder.InterfaceCounters) {
+				gotCounters = append(gotCounters, fmt.Sprintf("%d %s %d %d",
+					ic.TimeReceived, ic.ExporterAddress, ic.IfIndex, ic.InOctets))
+			},
+		})
+		if err != nil {
+			t.Fatalf("Decode() sflow error:\n%+v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("Decode() sflow counters returned flows")
+		}
+		expectedCounters := []string{
+			"1791194400 ::ffff:127.0.0.1 27 123456789012",
+			"1791194400 ::ffff:127.0.0.1 28 5000",
+		}
+		if diff := helpers.Diff(gotCounters, expectedCounters); diff != "" {
+			t.Fatalf("Decode() counters (-got, +want):\n%s", diff)
+		}
+	})
+
 	// Test error cases
 	t.Run("errors", func(t *testing.T) {
 		// Unknown decoder
@@ -5,10 +5,17 @@
 package flow
 
 import (
+	"sync/atomic"
 	"time"
 
+	"github.com/twmb/franz-go/pkg/kgo"
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/kafka"
 	"akvorado/common/pb"
 	"akvorado/common/reporter"
+	"akvorado/common/schema"
 	"akvorado/outlet/flow/decoder"
 )
 
@@ -16,20 +23,32 @@ import (
 type Component struct {
 	r         *reporter.Reporter
 	d         *Dependencies
+	t         tomb.Tomb
 	config    Configuration
 	errLogger reporter.Logger
 
 	metrics struct {
 		decoderStats  *reporter.CounterVec
 		decoderErrors *reporter.CounterVec
+
+		sharedStatePublished *reporter.CounterVec
+		sharedStateReceived  *reporter.CounterVec
+		sharedStateErrors    *reporter.CounterVec
 	}
 
+	// Shared state
+	kafkaOpts   []kgo.Opt
+	kafkaClient atomic.Pointer[kgo.Client]
+
 	// Available decoders
 	decoders map[pb.RawFlow_Decoder]decoder.Decoder
 }
 
 // Dependencies are the dependencies of the flow component.
-type Dependencies = decoder.Dependencies
+type Dependencies struct {
+	Daemon daemon.Component // only needed when sharing state
+	Schema *schema.Component
+}
 
 // New creates a new flow component.
 func New(r *reporter.Reporter, config Configuration, dependencies Dependencies) (*Component, error) {
@@ -43,7 +62,7 @@ func New(r *reporter.Reporter, config Configuration, dependencies Dependencies)
 
 	// Initialize available decoders
 	for decoderType, decoderFunc := range availableDecoders {
-		c.decoders[decoderType] = decoderFunc(r, dependencies)
+		c.decoders[decoderType] = decoderFunc(r, decoder.Dependencies{Schema: dependencies.Schema})
 	}
 
 	// Metrics
@@ -62,6 +81,16 @@ func New(r *reporter.Reporter, config Configuration, dependencies Dependencies)
 		[]string{"name"},
 	)
 
+	if config.SharedState.Enabled {
+		kafkaOpts, err := kafka.NewConfig(r, config.SharedState.Configuration)
+		if err != nil {
+			return nil, err
+		}
+		c.kafkaOpts = kafkaOpts
+		c.initSharedState()
+		c.d.Daemon.Track(&c.t, "outlet/flow")
+	}
+
 	return &c, nil
 }
 
@@ -74,11 +103,17 @@ func (c *Component) Start() error {
 			c.r.Info().Msg("previous decoders' state loaded")
 		}
 	}
+	if c.config.SharedState.Enabled {
+		return c.startSharedState()
+	}
 	return nil
 }
 
 // Stop stops the flow component
 func (c *Component) Stop() error {
+	if c.config.SharedState.Enabled {
+		c.stopSharedState()
+	}
 	if c.config.StatePersistFile != "" {
 		if err := c.SaveState(c.config.StatePersistFile); err != nil {
 			c.r.Err(err).Msg("cannot save decorders' state")
@@ -0,0 +1,244 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"akvorado/common/reporter"
+	"akvorado/outlet/flow/decoder"
+)
+
+// The decoder state is shared through a compacted Kafka topic. The key of each
+// record is the name of the decoder and the key of the state element,
+// separated by a slash. A record with an empty value removes the element. Each
+// outlet reads the whole topic on start and then follows it. Elements still
+// received are published again regularly, so any outlet can remove the ones
+// nobody has published for a while.
+
+// initSharedState registers the metrics for state sharing and plugs the
+// decoders into the publisher.
+func (c *Component) initSharedState() {
+	c.metrics.sharedStatePublished = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "shared_state_published_total",
+			Help: "Number of decoder state changes published to other outlets.",
+		},
+		[]string{"decoder"},
+	)
+	c.metrics.sharedStateReceived = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "shared_state_received_total",
+			Help: "Number of decoder state changes received from outlets.",
+		},
+		[]string{"decoder"},
+	)
+	c.metrics.sharedStateErrors = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "shared_state_errors_total",
+			Help: "Number of errors while sharing decoder state.",
+		},
+		[]string{"error"},
+	)
+
+	for _, dec := range c.decoders {
+		if sd, ok := dec.(decoder.StateSharingDecoder); ok {
+			name := dec.Name()
+			sd.SetStatePublisher(func(key string, value []byte) {
+				c.publishState(name, key, value)
+			}, c.config.SharedState.Expiration/4)
+		}
+	}
+}
+
+// startSharedState connects to Kafka, creates the topic if needed, and starts
+// following it. It waits for the existing state to be read, up to the
+// configured timeout.
+func (c *Component) startSharedState() error {
+	topic := c.config.SharedState.Topic
+	client, err := kgo.NewClient(append(c.kafkaOpts,
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)...)
+	if err != nil {
+		c.r.Err(err).
+			Str("brokers", strings.Join(c.config.SharedState.Brokers, ",")).
+			Msg("unable to create Kafka client for shared state")
+		return fmt.Errorf("unable to create Kafka client for shared state: %w", err)
+	}
+	c.createSharedStateTopic(client)
+	c.kafkaClient.Store(client)
+	remaining := c.sharedStateEndOffsets(client)
+
+	caughtUp := make(chan struct{})
+	if len(remaining) == 0 {
+		close(caughtUp)
+	}
+	c.t.Go(func() error {
+		ctx := c.t.Context(nil)
+		for {
+			fetches := client.PollFetches(ctx)
+			if fetches.IsClientClosed() || ctx.Err() != nil {
+				return nil
+			}
+			fetches.EachError(func(_ string, _ int32, err error) {
+				c.metrics.sharedStateErrors.WithLabelValues("cannot fetch").Inc()
+				c.errLogger.Err(err).Msg("cannot fetch shared state")
+			})
+			fetches.EachRecord(func(record *kgo.Record) {
+				c.applyState(record)
+				if end, ok := remaining[record.Partition]; ok && record.Offset+1 >= end {
+					delete(remaining, record.Partition)
+					if len(remaining) == 0 {
+						close(caughtUp)
+					}
+				}
+			})
+		}
+	})
+	c.t.Go(func() error {
+		expiration := c.config.SharedState.Expiration
+		ticker := time.NewTicker(expiration / 10)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.t.Dying():
+				return nil
+			case <-ticker.C:
+				before := time.Now().Add(-expiration)
+				for _, dec := range c.decoders {
+					if sd, ok := dec.(decoder.StateSharingDecoder); ok {
+						sd.ExpireState(before)
+					}
+				}
+			}
+		}
+	})
+
+	select {
+	case <-caughtUp:
+		c.r.Info().Msg("shared state loaded")
+	case <-time.After(c.config.SharedState.SyncTimeout):
+		c.r.Warn().Msg("timeout while loading shared state, continuing")
+	}
+	return nil
+}
+
+// sharedStateEndOffsets returns the end offset of each non-empty partition of
+// the shared state topic. On error, nothing is returned and the state is not
+// waited for.
+func (c *Component) sharedStateEndOffsets(client *kgo.Client) map[int32]int64 {
+	topic := c.config.SharedState.Topic
+	ctx, cancel := context.WithTimeout(c.t.Context(nil), 10*time.Second)
+	defer cancel()
+	offsets, err := kadm.NewClient(client).ListEndOffsets(ctx, topic)
+	if err != nil {
+		c.r.Warn().Err(err).Str("topic", topic).Msg("cannot get shared state end offsets")
+		return nil
+	}
+	remaining := map[int32]int64{}
+	offsets.Each(func(o kadm.ListedOffset) {
+		if o.Err != nil {
+			c.r.Warn().Err(o.Err).Str("topic", topic).Int32("partition", o.Partition).
+				Msg("cannot get shared state end offset")
+			return
+		}
+		if o.Offset > 0 {
+			remaining[o.Partition] = o.Offset
+		}
+	})
+	return remaining
+}
+
+// createSharedStateTopic creates the compacted topic for the shared state if it
+// does not exist. Errors are only logged as the topic may be managed by other
+// means.
+func (c *Component) createSharedStateTopic(client *kgo.Client) {
+	topic := c.config.SharedState.Topic
+	ctx, cancel := context.WithTimeout(c.t.Context(nil), 10*time.Second)
+	defer cancel()
+	compact := "compact"
+	resp, err := kadm.NewClient(client).CreateTopic(ctx, 1, -1,
+		map[string]*string{"cleanup.policy": &compact}, topic)
+	if err == nil {
+		err = resp.Err
+	}
+	switch {
+	case err == nil:
+		c.r.Info().Str("topic", topic).Msg("shared state topic created")
+	case errors.Is(err, kerr.TopicAlreadyExists):
+	default:
+		c.r.Warn().Err(err).Str("topic", topic).Msg("cannot create shared state topic")
+	}
+}
+
+// stopSharedState stops following the topic and gives the pending changes a
+// chance to be published.
+func (c *Component) stopSharedState() {
+	c.t.Kill(nil)
+	c.t.Wait()
+	client := c.kafkaClient.Swap(nil)
+	if client == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Flush(ctx); err != nil {
+		c.r.Warn().Err(err).Msg("cannot flush the remaining shared state changes")
+	}
+	client.Close()
+}
+
+// publishState publishes a state change from a decoder. This is best effort:
+// if the producer is not ready or busy, the change is dropped.
+func (c *Component) publishState(name, key string, value []byte) {
+	client := c.kafkaClient.Load()
+	if client == nil {
+		return
+	}
+	record := &kgo.Record{
+		Topic: c.config.SharedState.Topic,
+		Key:   []byte(fmt.Sprintf("%s/%s", name, key)),
+		Value: value,
+	}
+	client.TryProduce(context.Background(), record, func(_ *kgo.Record, err error) {
+		if err != nil {
+			c.metrics.sharedStateErrors.WithLabelValues("cannot publish").Inc()
+			c.errLogger.Err(err).Msg("cannot publish shared state")
+			return
+		}
+		c.metrics.sharedStatePublished.WithLabelValues(name).Inc()
+	})
+}
+
+// applyState applies a state change received from the topic.
+func (c *Component) applyState(record *kgo.Record) {
+	name, key, ok := strings.Cut(string(record.Key), "/")
+	if !ok {
+		c.metrics.sharedStateErrors.WithLabelValues("invalid key").Inc()
+		return
+	}
+	for _, dec := range c.decoders {
+		sd, ok := dec.(decoder.StateSharingDecoder)
+		if !ok || dec.Name() != name {
+			continue
+		}
+		if err := sd.ApplyState(key, record.Value); err != nil {
+			c.metrics.sharedStateErrors.WithLabelValues("cannot apply").Inc()
+			c.errLogger.Err(err).Str("decoder", name).Msg("cannot apply shared state")
+			return
+		}
+		c.metrics.sharedStateReceived.WithLabelValues(name).Inc()
+		return
+	}
+	c.metrics.sharedStateErrors.WithLabelValues("unknown decoder").Inc()
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flow
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/kafka"
+	"akvorado/common/pb"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+func TestSharedState(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.WithLogger(kafka.NewLogger(r)),
+	)
+	if err != nil {
+		t.Fatalf("NewCluster() error: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+
+	configuration := DefaultConfiguration()
+	configuration.SharedState.Enabled = true
+	configuration.SharedState.Topic = fmt.Sprintf("test-topic-%d", rand.Int())
+	configuration.SharedState.Brokers = cluster.ListenAddrs()
+	newComponent := func() *Component {
+		c, err := New(reporter.NewMock(t), configuration, Dependencies{
+			Daemon: daemon.NewMock(t),
+			Schema: sch,
+		})
+		if err != nil {
+			t.Fatalf("New() error:\n%+v", err)
+		}
+		helpers.StartStop(t, c)
+		return c
+	}
+	c1 := newComponent()
+	c2 := newComponent()
+
+	decode := func(c *Component, file string) int {
+		t.Helper()
+		rawFlow := &pb.RawFlow{
+			TimeReceived:  uint64(time.Now().Unix()),
+			Payload:       helpers.ReadPcapL4(t, filepath.Join("decoder", "netflow", "testdata", file)),
+			SourceAddress: net.ParseIP("127.0.0.1").To16(),
+			Decoder:       pb.RawFlow_DECODER_NETFLOW,
+		}
+		bf := sch.NewFlowMessage()
+		count := 0
+		if err := c.Decode(rawFlow, bf, func() {
+			count++
+			bf.Finalize()
+		}); err != nil {
+			t.Fatalf("Decode() error:\n%+v", err)
+		}
+		return count
+	}
+	waitFor := func(description string, condition func() bool) {
+		t.Helper()
+		timeout := time.After(10 * time.Second)
+		for !condition() {
+			select {
+			case <-timeout:
+				t.Fatalf("timeout while waiting for %s", description)
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	// Templates received by the first outlet are usable by the second one
+	if got := decode(c2, "data.pcap"); got != 0 {
+		t.Fatalf("Decode() without templates returned %d flows", got)
+	}
+	decode(c1, "template.pcap")
+	waitFor("templates on second outlet", func() bool {
+		return len(c2.Templates()) == 1 && len(c2.Templates()[0].Templates) > 0
+	})
+	if got := decode(c2, "data.pcap"); got == 0 {
+		t.Fatal("Decode() with shared templates returned no flow")
+	}
+
+	// An outlet started later has the templates once started
+	c3 := newComponent()
+	if got := decode(c3, "data.pcap"); got == 0 {
+		t.Fatal("Decode() on new outlet returned no flow")
+	}
+
+	// Flushing templates on the second outlet flushes them on the first one
+	c2.FlushTemplates(netip.MustParseAddr("127.0.0.1"))
+	waitFor("flushed templates on first outlet", func() bool {
+		got := c1.Templates()
+		return len(got) == 1 && len(got[0].Templates) == 0
+	})
+	if got := decode(c1, "data.pcap"); got != 0 {
+		t.Fatalf("Decode() after flush returned %d flows", got)
+	}
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flow
+
+import (
+	"net/netip"
+	"slices"
+
+	"akvorado/outlet/flow/decoder"
+)
+
+// Templates returns the templates and sampling rates known by the decoders for
+// each exporter.
+func (c *Component) Templates() []decoder.ExporterTemplates {
+	result := []decoder.ExporterTemplates{}
+	for _, dec := range c.decoders {
+		if td, ok := dec.(decoder.TemplatesDecoder); ok {
+			result = append(result, td.Templates()...)
+		}
+	}
+	slices.SortStableFunc(result, func(a, b decoder.ExporterTemplates) int {
+		return a.Exporter.Compare(b.Exporter)
+	})
+	return result
+}
+
+// FlushTemplates makes the decoders forget the templates and sampling rates of
+// the provided exporter. It returns false if no decoder knew the exporter.
+func (c *Component) FlushTemplates(exporter netip.Addr) bool {
+	flushed := false
+	for _, dec := range c.decoders {
+		if td, ok := dec.(decoder.TemplatesDecoder); ok && td.FlushTemplates(exporter) {
+			flushed = true
+		}
+	}
+	return flushed
+}
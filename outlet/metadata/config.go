@@ -9,6 +9,8 @@ import (
 	"akvorado/common/helpers"
 	"akvorado/outlet/metadata/provider"
 	"akvorado/outlet/metadata/provider/gnmi"
+	"akvorado/outlet/metadata/provider/netbox"
+	"akvorado/outlet/metadata/provider/netflow"
 	"akvorado/outlet/metadata/provider/snmp"
 	"akvorado/outlet/metadata/provider/static"
 )
@@ -26,6 +28,10 @@ type Configuration struct {
 
 	// Provider defines the configuration of the providers to use
 	Providers []ProviderConfiguration
+	// Merge queries all the providers and assembles the answer field by
+	// field, the first provider setting a field winning. Otherwise, the first
+	// provider accepting the query answers.
+	Merge bool
 
 	// QueryTimeout defines how long to wait for a provider to answer.
 	QueryTimeout time.Duration `validate:"min=100ms,max=1m"`
@@ -47,6 +53,9 @@ func DefaultConfiguration() Configuration {
 
 // ProviderConfiguration represents the configuration for a metadata provider.
 type ProviderConfiguration struct {
+	// Fields restricts the fields the provider can set when merging
+	// answers. When empty, the provider can set all of them.
+	Fields []MergeField
 	// Config is the actual configuration for the provider.
 	Config provider.Configuration
 }
@@ -57,9 +66,11 @@ func (pc ProviderConfiguration) MarshalYAML() (any, error) {
 }
 
 var providers = map[string](func() provider.Configuration){
-	"snmp":   snmp.DefaultConfiguration,
-	"gnmi":   gnmi.DefaultConfiguration,
-	"static": static.DefaultConfiguration,
+	"snmp":    snmp.DefaultConfiguration,
+	"gnmi":    gnmi.DefaultConfiguration,
+	"static":  static.DefaultConfiguration,
+	"netflow": netflow.DefaultConfiguration,
+	"netbox":  netbox.DefaultConfiguration,
 }
 
 func init() {
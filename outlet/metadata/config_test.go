@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"akvorado/common/helpers"
+	"akvorado/outlet/metadata/provider/netflow"
 )
 
 func TestDefaultConfiguration(t *testing.T) {
@@ -14,3 +15,48 @@ func TestDefaultConfiguration(t *testing.T) {
 		t.Fatalf("validate.Struct() error:\n%+v", err)
 	}
 }
+
+func TestConfigurationUnmarshallerHook(t *testing.T) {
+	helpers.TestConfigurationDecode(t, helpers.ConfigurationDecodeCases{
+		{
+			Description: "merge with fields",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return helpers.M{
+					"merge": true,
+					"providers": []helpers.M{
+						{
+							"type":   "netflow",
+							"fields": []string{"interface-name", "exporter-sampling-rate"},
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Merge: true,
+				Providers: []ProviderConfiguration{
+					{
+						Fields: []MergeField{"interface-name", "exporter-sampling-rate"},
+						Config: &netflow.Configuration{},
+					},
+				},
+			},
+			SkipValidation: true,
+		}, {
+			Description: "unknown field",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return helpers.M{
+					"merge": true,
+					"providers": []helpers.M{
+						{
+							"type":   "netflow",
+							"fields": []string{"interface-color"},
+						},
+					},
+				}
+			},
+			Error: true,
+		},
+	})
+}
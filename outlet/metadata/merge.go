@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metadata
+
+import (
+	"fmt"
+	"slices"
+
+	"akvorado/common/schema"
+	"akvorado/outlet/metadata/provider"
+)
+
+// MergeField is a field of an answer which can be provided by a provider
+// when merging answers.
+type MergeField string
+
+// mergeFields maps the fields of an answer to a pointer to their value.
+var mergeFields = []struct {
+	name  MergeField
+	value func(*provider.Answer) any
+}{
+	{"exporter-name", func(a *provider.Answer) any { return &a.Exporter.Name }},
+	{"exporter-region", func(a *provider.Answer) any { return &a.Exporter.Region }},
+	{"exporter-role", func(a *provider.Answer) any { return &a.Exporter.Role }},
+	{"exporter-tenant", func(a *provider.Answer) any { return &a.Exporter.Tenant }},
+	{"exporter-site", func(a *provider.Answer) any { return &a.Exporter.Site }},
+	{"exporter-group", func(a *provider.Answer) any { return &a.Exporter.Group }},
+	{"exporter-sampling-rate", func(a *provider.Answer) any { return &a.Exporter.SamplingRate }},
+	{"interface-name", func(a *provider.Answer) any { return &a.Interface.Name }},
+	{"interface-description", func(a *provider.Answer) any { return &a.Interface.Description }},
+	{"interface-speed", func(a *provider.Answer) any { return &a.Interface.Speed }},
+	{"interface-provider", func(a *provider.Answer) any { return &a.Interface.Provider }},
+	{"interface-connectivity", func(a *provider.Answer) any { return &a.Interface.Connectivity }},
+	{"interface-boundary", func(a *provider.Answer) any { return &a.Interface.Boundary }},
+	{"interface-neighbor", func(a *provider.Answer) any { return &a.Interface.Neighbor }},
+	{"interface-oper-status", func(a *provider.Answer) any { return &a.Interface.OperStatus }},
+}
+
+// optionalMergeFields are the fields which are not required for an answer to
+// be complete. Most interfaces do not have a neighbor and most providers do
+// not know the operational status.
+var optionalMergeFields = []MergeField{"interface-neighbor", "interface-oper-status"}
+
+// UnmarshalText parses a merge field.
+func (mf *MergeField) UnmarshalText(text []byte) error {
+	for _, field := range mergeFields {
+		if string(field.name) == string(text) {
+			*mf = field.name
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown field %q", text)
+}
+
+// mergeAnswer copies the fields set in src and not set in dst. When fields
+// is not empty, only these fields are copied. It returns true if all the
+// fields of dst are set.
+func mergeAnswer(dst *provider.Answer, src provider.Answer, fields []MergeField) bool {
+	if src.Found {
+		dst.Found = true
+	}
+	complete := true
+	for _, field := range mergeFields {
+		allowed := len(fields) == 0 || slices.Contains(fields, field.name)
+		optional := slices.Contains(optionalMergeFields, field.name)
+		switch d := field.value(dst).(type) {
+		case *string:
+			if *d == "" && allowed {
+				*d = *field.value(&src).(*string)
+			}
+			complete = complete && (*d != "" || optional)
+		case *uint:
+			if *d == 0 && allowed {
+				*d = *field.value(&src).(*uint)
+			}
+			complete = complete && *d != 0
+		case *schema.InterfaceBoundary:
+			if *d == schema.InterfaceBoundaryUndefined && allowed {
+				*d = *field.value(&src).(*schema.InterfaceBoundary)
+			}
+			complete = complete && *d != schema.InterfaceBoundaryUndefined
+		}
+	}
+	return complete
+}
@@ -38,9 +38,11 @@ func (state *exporterState) update(events []event, model Model) {
 	// properties, for example if the index is in the state hierarchy but the
 	// name is in the config hierarchy)
 	// - mapping from keys to speeds (same remark)
+	// - mapping from keys to operational statuses (same remark)
 	i := 0
 	indexes := map[string]uint{}
 	speeds := map[string]uint{}
+	statuses := map[string]string{}
 outer1:
 	for _, event := range events {
 		for _, path := range model.SystemNamePaths {
@@ -67,6 +69,11 @@ outer1:
 				speeds[event.Keys] = speed
 			}
 		}
+		for _, path := range model.IfOperStatusPaths {
+			if event.Path == path {
+				statuses[event.Keys] = convertOperStatus(event.Value)
+			}
+		}
 		events[i] = event
 		i++
 	}
@@ -103,7 +110,7 @@ outer2:
 		}
 	}
 
-	// Third-pass: unnamed interfaces and speed
+	// Third-pass: unnamed interfaces, speed and operational status
 	for keys, index := range indexes {
 		iface := state.Interfaces[index]
 		// Set name
@@ -124,9 +131,14 @@ outer2:
 			delete(state.Interfaces, index)
 			continue
 		}
-		// Set speed
-		for iface.Speed == 0 && keys != "" {
-			iface.Speed = speeds[keys]
+		// Set speed and operational status, using the parent interface if needed
+		for keys != "" && (iface.Speed == 0 || iface.OperStatus == "") {
+			if iface.Speed == 0 {
+				iface.Speed = speeds[keys]
+			}
+			if iface.OperStatus == "" {
+				iface.OperStatus = statuses[keys]
+			}
 			keys = keys[:max(0, strings.LastIndex(keys, ","))]
 		}
 		// Copy back
@@ -224,13 +236,29 @@ retryDetect:
 
 	// Receive updates. There are several possibilities:
 	// - SubscribeOnce: works as expected, but needs polling
-	// - Subscribe, mode stream + on change: no deletes received, some implementations may not send changes
+	// - Subscribe, mode stream + on change: some implementations may not send changes
 	// - Subscribe, mode stream + sampling: we cannot know when stuff get deleted without expiring them ourselves
 	// - SubscribePoll: not widely implemented
 	//
-	// So, we use SubscribeOnce. This is not the most efficient way, but we ensure we get a coherent state.
+	// By default, we use SubscribeOnce. This is not the most efficient way,
+	// but we ensure we get a coherent state. Streaming can be enabled when the
+	// targets are known to behave correctly.
+	var subscriptionOptions []api.GNMIOption
+	listMode := api.SubscriptionListModeONCE()
+	switch p.config.SubscriptionMode {
+	case SubscriptionOnChange:
+		listMode = api.SubscriptionListModeSTREAM()
+		subscriptionOptions = []api.GNMIOption{api.SubscriptionModeON_CHANGE()}
+	case SubscriptionSample:
+		listMode = api.SubscriptionListModeSTREAM()
+		subscriptionOptions = []api.GNMIOption{
+			api.SubscriptionModeSAMPLE(),
+			api.SampleInterval(p.config.SampleInterval),
+		}
+	}
 	subscribeRequestOptions := model.gnmiOptions(
-		api.SubscriptionListModeONCE(),
+		subscriptionOptions,
+		listMode,
 		api.Encoding(encoding),
 	)
 	if setTarget, ok := p.config.SetTarget.Lookup(exporterIP); ok && setTarget {
@@ -240,6 +268,10 @@ retryDetect:
 	if err != nil {
 		panic(fmt.Errorf("NewSubscribeRequest() error: %w", err))
 	}
+	if p.config.SubscriptionMode != SubscriptionOnce {
+		p.collectStream(l, tg, exporterIP, state, model, subscribeReq)
+		return
+	}
 	retryFetchBackoff := backoff.NewExponentialBackOff()
 	retryFetchBackoff.MaxInterval = time.Minute
 	retryFetchBackoff.InitialInterval = time.Second
@@ -323,7 +355,7 @@ retryDetect:
 func (p *Provider) detectModelAndEncoding(ctx context.Context, tg *target.Target) (Model, string, error) {
 	for _, model := range p.config.Models {
 		for _, encoding := range []string{"json_ietf", "json"} {
-			subscribeRequestOptions := model.gnmiOptions(api.SubscriptionListModeONCE(), api.Encoding(encoding))
+			subscribeRequestOptions := model.gnmiOptions(nil, api.SubscriptionListModeONCE(), api.Encoding(encoding))
 			subscribeReq, err := api.NewSubscribeRequest(subscribeRequestOptions...)
 			if err != nil {
 				panic(fmt.Errorf("NewSubscribeRequest() error: %w", err))
@@ -55,6 +55,11 @@ func TestStateUpdate(t *testing.T) {
 		{"/interface/ifindex", "name=ethernet-1/4", "103"},
 		{"/interface/subinterface/ifindex", "name=ethernet-1/4,index=1", "105"},
 		{"/interface/ifindex", "name=lag1", "106"},
+		{"/interface/oper-state", "name=ethernet-1/1", "up"},
+		{"/interface/oper-state", "name=ethernet-1/2", "down"},
+		{"/interface/oper-state", "name=ethernet-1/4", "up"},
+		{"/interface/subinterface/oper-state", "name=ethernet-1/4,index=1", "down"},
+		{"/interface/oper-state", "name=lag1", "UP"},
 		{"/system/name/host-name", "", "srlinux"},
 	}, model)
 	expected = exporterState{
@@ -64,11 +69,13 @@ func TestStateUpdate(t *testing.T) {
 				Name:        "ethernet-1/1",
 				Description: "1st interface",
 				Speed:       100_000,
+				OperStatus:  "up",
 			},
 			101: {
 				Name:        "ethernet-1/2",
 				Description: "2nd interface",
 				Speed:       100_000,
+				OperStatus:  "down",
 			},
 			102: {
 				Name:        "ethernet-1/3",
@@ -79,16 +86,19 @@ func TestStateUpdate(t *testing.T) {
 				Name:        "ethernet-1/4",
 				Description: "",
 				Speed:       25_000,
+				OperStatus:  "up",
 			},
 			105: {
 				Name:        "ethernet-1/4.1",
 				Description: "4th interface",
 				Speed:       25_000,
+				OperStatus:  "down",
 			},
 			106: {
 				Name:        "lag1",
 				Description: "lag interface",
 				Speed:       100,
+				OperStatus:  "up",
 			},
 		},
 	}
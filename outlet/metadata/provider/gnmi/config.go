@@ -21,6 +21,12 @@ type Configuration struct {
 	Timeout time.Duration `validate:"min=100ms"`
 	// MinimalRefreshInterval tells how much time to wait at least between two refreshes
 	MinimalRefreshInterval time.Duration `validate:"min=1s"`
+	// SubscriptionMode tells how to subscribe to the paths: poll them once
+	// per refresh or stream changes from the target.
+	SubscriptionMode SubscriptionMode
+	// SampleInterval is the interval requested for sampled subscriptions. When
+	// 0, the target chooses the interval.
+	SampleInterval time.Duration `validate:"omitempty,min=1s"`
 	// Targets is a mapping from exporter IPs to gNMI target IP.
 	Targets *helpers.SubnetMap[netip.Addr]
 	// SetTarget is a mapping from exporter IPs to whatever set target name in gNMI path prefix
@@ -52,6 +58,7 @@ type Model struct {
 	IfNamePaths        []string      `validate:"required_without=IfNameKeys"`
 	IfDescriptionPaths []string      `validate:"min=1"`
 	IfSpeedPaths       []IfSpeedPath `validate:"min=1,dive"`
+	IfOperStatusPaths  []string
 }
 
 // IfSpeedPath defines a path for oper speed.
@@ -74,11 +81,25 @@ const (
 	SpeedHuman
 )
 
+// SubscriptionMode defines how to subscribe to the paths of a model.
+type SubscriptionMode int
+
+const (
+	// SubscriptionOnce polls the paths with a ONCE subscription
+	SubscriptionOnce SubscriptionMode = iota
+	// SubscriptionOnChange streams changes with an ON_CHANGE subscription
+	SubscriptionOnChange
+	// SubscriptionSample streams values with a SAMPLE subscription
+	SubscriptionSample
+)
+
 // DefaultConfiguration represents the default configuration for the SNMP client.
 func DefaultConfiguration() provider.Configuration {
 	return Configuration{
 		Timeout:                  time.Second,
 		MinimalRefreshInterval:   time.Minute,
+		SubscriptionMode:         SubscriptionOnce,
+		SampleInterval:           time.Minute,
 		Targets:                  helpers.MustNewSubnetMap(map[string]netip.Addr{}),
 		SetTarget:                helpers.MustNewSubnetMap(map[string]bool{}),
 		Ports:                    helpers.MustNewSubnetMap(map[string]uint16{"::/0": 9339}),
@@ -107,6 +128,10 @@ func DefaultModels() []Model {
 				{"/state/port/ethernet/oper-speed", SpeedMbps},
 				{"/state/lag/bandwidth", SpeedBps},
 			},
+			IfOperStatusPaths: []string{
+				"/state/port/oper-state",
+				"/state/lag/oper-state",
+			},
 		}, {
 			Name:            "Nokia SR Linux",
 			SystemNamePaths: []string{"/system/name/host-name"},
@@ -126,6 +151,10 @@ func DefaultModels() []Model {
 				{"/interface/ethernet/port-speed", SpeedHuman},
 				{"/interface/lag/lag-speed", SpeedBps},
 			},
+			IfOperStatusPaths: []string{
+				"/interface/oper-state",
+				"/interface/subinterface/oper-state",
+			},
 		}, {
 			Name:            "OpenConfig",
 			SystemNamePaths: []string{"/system/config/hostname"},
@@ -146,6 +175,10 @@ func DefaultModels() []Model {
 				{"/interfaces/interface/ethernet/state/negotiated-port-speed", SpeedEthernet},
 				{"/interfaces/interface/ethernet/state/port-speed", SpeedEthernet},
 			},
+			IfOperStatusPaths: []string{
+				"/interfaces/interface/state/oper-status",
+				"/interfaces/interface/subinterfaces/subinterface/state/oper-status",
+			},
 		}, {
 			Name:               "IETF",
 			SystemNamePaths:    []string{"/system/hostname"},
@@ -155,6 +188,7 @@ func DefaultModels() []Model {
 			IfSpeedPaths: []IfSpeedPath{
 				{"/interfaces/interface/speed", SpeedBps},
 			},
+			IfOperStatusPaths: []string{"/interfaces/interface/oper-status"},
 		},
 	}
 }
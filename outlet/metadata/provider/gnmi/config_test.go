@@ -218,6 +218,49 @@ func TestDefaults(t *testing.T) {
 					},
 				},
 			},
+		}, {
+			Description: "sample subscription",
+			Initial: func() any {
+				return Configuration{Timeout: time.Second, MinimalRefreshInterval: time.Minute}
+			},
+			Configuration: func() any {
+				return helpers.M{
+					"subscription-mode": "sample",
+					"sample-interval":   "10s",
+					"models": []helpers.M{
+						{
+							"name":                 "custom",
+							"if-index-paths":       "/some/path",
+							"if-description-paths": "/some/other/path",
+							"if-name-paths":        "/something",
+							"if-speed-paths": []helpers.M{
+								{"path": "/path1", "unit": "mbps"},
+							},
+							"if-oper-status-paths": "/status",
+							"system-name-paths":    "/another/path",
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Timeout:                time.Second,
+				MinimalRefreshInterval: time.Minute,
+				SubscriptionMode:       SubscriptionSample,
+				SampleInterval:         10 * time.Second,
+				Models: []Model{
+					{
+						Name:               "custom",
+						IfIndexPaths:       []string{"/some/path"},
+						IfDescriptionPaths: []string{"/some/other/path"},
+						IfNamePaths:        []string{"/something"},
+						IfSpeedPaths: []IfSpeedPath{
+							{"/path1", SpeedMbps},
+						},
+						IfOperStatusPaths: []string{"/status"},
+						SystemNamePaths:   []string{"/another/path"},
+					},
+				},
+			},
 		}, {
 			Description: "defaults only",
 			Initial: func() any {
@@ -23,10 +23,12 @@ type Provider struct {
 	state     map[netip.Addr]*exporterState
 	stateLock sync.Mutex
 	refresh   chan bool
+	notify    func(netip.Addr, uint)
 }
 
 var (
 	_ provider.Provider      = &Provider{}
+	_ provider.Notifier      = &Provider{}
 	_ provider.Configuration = Configuration{}
 )
 
@@ -101,3 +103,9 @@ func (p *Provider) Query(ctx context.Context, q provider.Query) (provider.Answer
 		Interface: iface,
 	}, nil
 }
+
+// Notify registers a function to call when an interface changes while
+// streaming updates.
+func (p *Provider) Notify(notify func(exporterIP netip.Addr, ifIndex uint)) {
+	p.notify = notify
+}
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package gnmi
+
+import (
+	"net/netip"
+	"strings"
+	"time"
+
+	"akvorado/common/reporter"
+	"akvorado/outlet/metadata/provider"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/pkg/api/target"
+)
+
+const (
+	// streamRetryInterval is the time to wait before subscribing again after
+	// an error.
+	streamRetryInterval = 10 * time.Second
+	// streamUpdateDelay is the time to wait for more changes before updating
+	// the state once synchronized.
+	streamUpdateDelay = time.Second
+	// streamSampleExpiry is the number of sample intervals after which a leaf
+	// not refreshed by the target is removed.
+	streamSampleExpiry = 3
+)
+
+// eventKey identifies a leaf received in a subscription.
+type eventKey struct {
+	Path string
+	Keys string
+}
+
+// eventValue is the last value received for a leaf.
+type eventValue struct {
+	Value   string
+	Updated time.Time
+}
+
+// eventStore keeps the last value received for each leaf of a stream.
+type eventStore map[eventKey]eventValue
+
+// apply applies the deletes and the updates of a response to the store.
+func (store eventStore) apply(response *gnmi.SubscribeResponse, now time.Time) {
+	for _, deleted := range subscribeResponseToDeletes(response) {
+		for key := range store {
+			if hasElemPrefix(key.Path, deleted.Path, "/") && hasElemPrefix(key.Keys, deleted.Keys, ",") {
+				delete(store, key)
+			}
+		}
+	}
+	for _, ev := range subscribeResponseToEvents(response) {
+		store[eventKey{ev.Path, ev.Keys}] = eventValue{ev.Value, now}
+	}
+}
+
+// expire removes the leaves not updated since the provided time. It returns
+// true if some leaves were removed.
+func (store eventStore) expire(before time.Time) bool {
+	expired := false
+	for key, value := range store {
+		if value.Updated.Before(before) {
+			delete(store, key)
+			expired = true
+		}
+	}
+	return expired
+}
+
+// events returns the content of the store as a list of events.
+func (store eventStore) events() []event {
+	events := make([]event, 0, len(store))
+	for key, value := range store {
+		events = append(events, event{key.Path, key.Keys, value.Value})
+	}
+	return events
+}
+
+// hasElemPrefix tells if s starts with the provided list of elements
+// separated by sep. An empty prefix matches everything.
+func hasElemPrefix(s, prefix, sep string) bool {
+	return prefix == "" || s == prefix || strings.HasPrefix(s, prefix+sep)
+}
+
+// collectStream subscribes to the model paths in stream mode and updates the
+// state on each change. The target sends the complete state first, followed
+// by a sync response. On error, the subscription is retried and the target
+// sends the complete state again. In sample mode, targets do not send deletes,
+// so leaves not refreshed during a few sample intervals are removed.
+func (p *Provider) collectStream(l reporter.Logger, tg *target.Target, exporterIP netip.Addr, state *exporterState, model Model, subscribeReq *gnmi.SubscribeRequest) {
+	exporterStr := exporterIP.Unmap().String()
+	tg.Config.RetryTimer = streamRetryInterval
+	responses, errs := tg.SubscribeStreamChan(p.ctx, subscribeReq, "akvorado")
+
+	store := eventStore{}
+	synced := false
+	start := time.Now()
+	var updateC <-chan time.Time
+	var expireC <-chan time.Time
+	expiry := time.Duration(streamSampleExpiry) * p.config.SampleInterval
+	if p.config.SubscriptionMode == SubscriptionSample && expiry > 0 {
+		ticker := time.NewTicker(p.config.SampleInterval)
+		defer ticker.Stop()
+		expireC = ticker.C
+	}
+	updateState := func() {
+		events := store.events()
+		p.metrics.paths.WithLabelValues(exporterStr).Set(float64(len(events)))
+		p.stateLock.Lock()
+		previous := state.Interfaces
+		wasReady := state.ready
+		state.update(events, model)
+		state.ready = true
+		current := state.Interfaces
+		p.stateLock.Unlock()
+		l.Debug().Msg("state updated")
+		p.metrics.ready.WithLabelValues(exporterStr).Set(1)
+		p.metrics.updates.WithLabelValues(exporterStr).Inc()
+		if wasReady && p.notify != nil {
+			notifyChanges(exporterIP, previous, current, p.notify)
+		}
+	}
+
+	l.Debug().Msg("streaming")
+	for {
+		var readyChan chan bool
+		if state.ready {
+			readyChan = state.Ready
+		}
+		select {
+		case readyChan <- true:
+		case <-p.ctx.Done():
+			return
+		case err := <-errs:
+			if p.ctx.Err() != nil {
+				return
+			}
+			// We keep the current state until the target sends a complete
+			// state again.
+			l.Err(err).Msg("cannot stream")
+			p.metrics.errors.WithLabelValues(exporterStr, "cannot stream").Inc()
+			store = eventStore{}
+			synced = false
+			updateC = nil
+			start = time.Now()
+		case response := <-responses:
+			store.apply(response, time.Now())
+			if response.GetSyncResponse() {
+				p.metrics.times.WithLabelValues(exporterStr).Observe(time.Since(start).Seconds())
+				synced = true
+				updateC = nil
+				updateState()
+			} else if synced && updateC == nil {
+				updateC = time.After(streamUpdateDelay)
+			}
+		case <-updateC:
+			updateC = nil
+			updateState()
+		case <-expireC:
+			if store.expire(time.Now().Add(-expiry)) && synced {
+				l.Debug().Msg("stale leaves expired")
+				updateState()
+			}
+		}
+	}
+}
+
+// notifyChanges calls notify for each interface which is different between
+// the two provided sets.
+func notifyChanges(exporterIP netip.Addr, previous, current map[uint]provider.Interface, notify func(netip.Addr, uint)) {
+	for index, iface := range current {
+		if old, ok := previous[index]; !ok || old != iface {
+			notify(exporterIP, index)
+		}
+	}
+	for index := range previous {
+		if _, ok := current[index]; !ok {
+			notify(exporterIP, index)
+		}
+	}
+}
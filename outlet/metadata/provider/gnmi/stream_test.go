@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package gnmi
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/outlet/metadata/provider"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestEventStore(t *testing.T) {
+	update := func(elems []*gnmi.PathElem, value string) *gnmi.Update {
+		return &gnmi.Update{
+			Path: &gnmi.Path{Elem: elems},
+			Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: value}},
+		}
+	}
+	iface := func(name string, elems ...string) []*gnmi.PathElem {
+		path := []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": name}}}
+		for _, elem := range elems {
+			path = append(path, &gnmi.PathElem{Name: elem})
+		}
+		return path
+	}
+	subiface := func(name, index string, elems ...string) []*gnmi.PathElem {
+		path := append(iface(name),
+			&gnmi.PathElem{Name: "subinterface", Key: map[string]string{"index": index}})
+		for _, elem := range elems {
+			path = append(path, &gnmi.PathElem{Name: elem})
+		}
+		return path
+	}
+	notification := func(n *gnmi.Notification) *gnmi.SubscribeResponse {
+		return &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: n}}
+	}
+	sortedEvents := func(store eventStore) []event {
+		events := store.events()
+		slices.SortFunc(events, func(a, b event) int {
+			if a.Keys != b.Keys {
+				if a.Keys < b.Keys {
+					return -1
+				}
+				return 1
+			}
+			if a.Path < b.Path {
+				return -1
+			} else if a.Path > b.Path {
+				return 1
+			}
+			return 0
+		})
+		return events
+	}
+
+	now := time.Now()
+	store := eventStore{}
+	store.apply(notification(&gnmi.Notification{
+		Update: []*gnmi.Update{
+			update(iface("ethernet-1/1", "description"), "1st interface"),
+			update(iface("ethernet-1/1", "oper-state"), "up"),
+			update(iface("ethernet-1/2", "description"), "2nd interface"),
+			update(iface("ethernet-1/2", "oper-state"), "up"),
+			update(subiface("ethernet-1/2", "1", "description"), "2nd subinterface"),
+			update(subiface("ethernet-1/2", "2", "description"), "3rd subinterface"),
+		},
+	}), now)
+	store.apply(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}}, now)
+	expected := []event{
+		{"/interface/description", "name=ethernet-1/1", "1st interface"},
+		{"/interface/oper-state", "name=ethernet-1/1", "up"},
+		{"/interface/description", "name=ethernet-1/2", "2nd interface"},
+		{"/interface/oper-state", "name=ethernet-1/2", "up"},
+		{"/interface/subinterface/description", "name=ethernet-1/2,index=1", "2nd subinterface"},
+		{"/interface/subinterface/description", "name=ethernet-1/2,index=2", "3rd subinterface"},
+	}
+	if diff := helpers.Diff(sortedEvents(store), expected); diff != "" {
+		t.Fatalf("apply() (-got, +want):\n%s", diff)
+	}
+
+	// Changes and deletes
+	store.apply(notification(&gnmi.Notification{
+		Prefix: &gnmi.Path{Elem: iface("ethernet-1/2")},
+		Delete: []*gnmi.Path{
+			{Elem: []*gnmi.PathElem{{Name: "subinterface", Key: map[string]string{"index": "1"}}}},
+		},
+		Update: []*gnmi.Update{
+			update([]*gnmi.PathElem{{Name: "oper-state"}}, "down"),
+		},
+	}), now)
+	store.apply(notification(&gnmi.Notification{
+		Delete: []*gnmi.Path{{Elem: iface("ethernet-1/1")}},
+	}), now)
+	expected = []event{
+		{"/interface/description", "name=ethernet-1/2", "2nd interface"},
+		{"/interface/oper-state", "name=ethernet-1/2", "down"},
+		{"/interface/subinterface/description", "name=ethernet-1/2,index=2", "3rd subinterface"},
+	}
+	if diff := helpers.Diff(sortedEvents(store), expected); diff != "" {
+		t.Fatalf("apply() (-got, +want):\n%s", diff)
+	}
+
+	// Expire leaves not refreshed
+	store.apply(notification(&gnmi.Notification{
+		Update: []*gnmi.Update{
+			update(iface("ethernet-1/2", "description"), "2nd interface"),
+		},
+	}), now.Add(time.Minute))
+	if store.expire(now) {
+		t.Fatal("expire() removed leaves")
+	}
+	if !store.expire(now.Add(time.Second)) {
+		t.Fatal("expire() did not remove leaves")
+	}
+	expected = []event{
+		{"/interface/description", "name=ethernet-1/2", "2nd interface"},
+	}
+	if diff := helpers.Diff(sortedEvents(store), expected); diff != "" {
+		t.Fatalf("expire() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestNotifyChanges(t *testing.T) {
+	exporterIP := netip.MustParseAddr("::ffff:192.0.2.1")
+	previous := map[uint]provider.Interface{
+		100: {Name: "ethernet-1/1", Description: "1st interface", Speed: 10_000, OperStatus: "up"},
+		101: {Name: "ethernet-1/2", Description: "2nd interface", Speed: 10_000, OperStatus: "up"},
+		102: {Name: "ethernet-1/3", Description: "3rd interface", Speed: 10_000, OperStatus: "up"},
+	}
+	current := map[uint]provider.Interface{
+		100: {Name: "ethernet-1/1", Description: "1st interface", Speed: 10_000, OperStatus: "up"},
+		101: {Name: "ethernet-1/2", Description: "2nd interface", Speed: 10_000, OperStatus: "down"},
+		103: {Name: "ethernet-1/4", Description: "4th interface", Speed: 10_000, OperStatus: "up"},
+	}
+	got := []uint{}
+	notifyChanges(exporterIP, previous, current, func(ip netip.Addr, ifIndex uint) {
+		if ip != exporterIP {
+			t.Errorf("notifyChanges() exporter %s, expected %s", ip, exporterIP)
+		}
+		got = append(got, ifIndex)
+	})
+	slices.Sort(got)
+	if diff := helpers.Diff(got, []uint{101, 102, 103}); diff != "" {
+		t.Fatalf("notifyChanges() (-got, +want):\n%s", diff)
+	}
+}
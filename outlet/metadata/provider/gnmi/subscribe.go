@@ -14,8 +14,8 @@ import (
 	"github.com/openconfig/gnmi/proto/gnmi"
 )
 
-// event describes an event received in a subscription. No deletion is handled
-// as we don't really care about them.
+// event describes an event received in a subscription. Deletions are extracted
+// separately with subscribeResponseToDeletes.
 type event struct {
 	Path  string // path without keys
 	Keys  string // comma-separated keys
@@ -82,11 +82,20 @@ func subscribeResponseToEvents(response *gnmi.SubscribeResponse) []event {
 			}
 			events = jsonAppendToEvents(events, ev, value)
 		}
-		// No need to proceed delete events, we don't rely on them
-		// for _, u := range n.GetDelete() {
-		// 	ev := gnmiPathToEvent(u, prefixEvent)
-		// 	events = jsonAppendToEvents(events, ev, "")
-		// }
+	}
+	return events
+}
+
+// subscribeResponseToDeletes returns the paths deleted in a response as events
+// without value. Each of them may cover several leaves.
+func subscribeResponseToDeletes(response *gnmi.SubscribeResponse) []event {
+	events := []event{}
+	n := response.GetUpdate()
+	if n != nil {
+		prefixEvent := gnmiPathToEvent(n.GetPrefix(), event{})
+		for _, u := range n.GetDelete() {
+			events = append(events, gnmiPathToEvent(u, prefixEvent))
+		}
 	}
 	return events
 }
@@ -11,11 +11,16 @@ import (
 	"github.com/openconfig/gnmic/pkg/api"
 )
 
-// gnmiOptions returns the list of GNMIOptions to poll for a given model.
-func (m Model) gnmiOptions(options ...api.GNMIOption) []api.GNMIOption {
+// gnmiOptions returns the list of GNMIOptions to subscribe to for a given
+// model. The subscription options are applied to each path.
+func (m Model) gnmiOptions(subscriptionOptions []api.GNMIOption, options ...api.GNMIOption) []api.GNMIOption {
+	appendPath := func(path string) {
+		pathOptions := append([]api.GNMIOption{api.Path(path)}, subscriptionOptions...)
+		options = append(options, api.Subscription(pathOptions...))
+	}
 	appendPaths := func(paths []string) {
 		for _, path := range paths {
-			options = append(options, api.Subscription(api.Path(path)))
+			appendPath(path)
 		}
 	}
 	appendPaths(m.SystemNamePaths)
@@ -23,11 +28,18 @@ func (m Model) gnmiOptions(options ...api.GNMIOption) []api.GNMIOption {
 	appendPaths(m.IfNamePaths)
 	appendPaths(m.IfDescriptionPaths)
 	for _, path := range m.IfSpeedPaths {
-		options = append(options, api.Subscription(api.Path(path.Path)))
+		appendPath(path.Path)
 	}
+	appendPaths(m.IfOperStatusPaths)
 	return options
 }
 
+// convertOperStatus normalizes an operational status (UP, LOWER_LAYER_DOWN,
+// up, lower-layer-down) to lower-case words separated by hyphens.
+func convertOperStatus(strValue string) string {
+	return strings.ReplaceAll(strings.ToLower(strValue), "_", "-")
+}
+
 // convertSpeed converts a speed to an integer value in Mbps.
 func convertSpeed(strValue string, unit IfSpeedPathUnit) (uint, error) {
 	switch unit {
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrStatusCode is returned when NetBox answers with an unexpected status code.
+var ErrStatusCode = errors.New("unexpected status code")
+
+// nestedObject is the brief representation of an object nested in another.
+type nestedObject struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// customFields are the custom fields attached to an object.
+type customFields map[string]any
+
+// netboxIPAddress is an IP address from /api/ipam/ip-addresses/.
+type netboxIPAddress struct {
+	ID int `json:"id"`
+}
+
+// netboxDevice is a device from /api/dcim/devices/.
+type netboxDevice struct {
+	ID           int           `json:"id"`
+	Name         string        `json:"name"`
+	Role         *nestedObject `json:"role"`
+	DeviceRole   *nestedObject `json:"device_role"` // before NetBox 4.0
+	Tenant       *nestedObject `json:"tenant"`
+	Site         *nestedObject `json:"site"`
+	CustomFields customFields  `json:"custom_fields"`
+}
+
+// netboxSite is a site from /api/dcim/sites/.
+type netboxSite struct {
+	Region *nestedObject `json:"region"`
+}
+
+// netboxInterface is an interface from /api/dcim/interfaces/.
+type netboxInterface struct {
+	Name         string       `json:"name"`
+	Description  string       `json:"description"`
+	Speed        *uint        `json:"speed"` // kbps
+	CustomFields customFields `json:"custom_fields"`
+}
+
+// page is a page of results from a list endpoint.
+type page[T any] struct {
+	Next    *string `json:"next"`
+	Results []T     `json:"results"`
+}
+
+// String returns a custom field as a string. Integers are formatted and
+// objects are replaced by their name.
+func (cf customFields) String(name string) string {
+	if name == "" {
+		return ""
+	}
+	switch value := cf[name].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case map[string]any:
+		if name, ok := value["name"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// name returns the name of a nested object, if any.
+func (o *nestedObject) name() string {
+	if o == nil {
+		return ""
+	}
+	return o.Name
+}
+
+// get fetches the provided path and decodes the JSON answer.
+func (p *Provider) get(ctx context.Context, target string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("cannot build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Token %s", p.config.Token))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot query NetBox: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w %d for %s", ErrStatusCode, resp.StatusCode, req.URL.Path)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("cannot decode answer for %s: %w", req.URL.Path, err)
+	}
+	return nil
+}
+
+// list fetches all the objects from a list endpoint, following the pages.
+func list[T any](ctx context.Context, p *Provider, path string, query url.Values) ([]T, error) {
+	query.Set("limit", strconv.FormatUint(uint64(p.config.PageSize), 10))
+	target := p.url(path, query)
+	results := []T{}
+	for target != "" {
+		var current page[T]
+		if err := p.get(ctx, target, &current); err != nil {
+			return nil, err
+		}
+		results = append(results, current.Results...)
+		target = ""
+		if current.Next != nil {
+			target = *current.Next
+		}
+	}
+	return results, nil
+}
+
+// url builds the URL for the provided API path.
+func (p *Provider) url(path string, query url.Values) string {
+	target := strings.TrimSuffix(p.config.URL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return target
+}
+
+// fetchDevice fetches the device whose primary IP is the provided address,
+// with its site and its interfaces. It returns nil if there is no such device.
+func (p *Provider) fetchDevice(ctx context.Context, exporterIP netip.Addr) (*device, error) {
+	addr := exporterIP.Unmap()
+	addresses, err := list[netboxIPAddress](ctx, p, "/api/ipam/ip-addresses/",
+		url.Values{"address": {addr.String()}})
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	filter := "primary_ip4_id"
+	if addr.Is6() {
+		filter = "primary_ip6_id"
+	}
+	query := url.Values{}
+	for _, address := range addresses {
+		query.Add(filter, strconv.Itoa(address.ID))
+	}
+	devices, err := list[netboxDevice](ctx, p, "/api/dcim/devices/", query)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, nil
+	}
+	nd := devices[0]
+
+	var site netboxSite
+	if nd.Site != nil {
+		if err := p.get(ctx, p.url(fmt.Sprintf("/api/dcim/sites/%d/", nd.Site.ID), nil), &site); err != nil {
+			return nil, err
+		}
+	}
+	interfaces, err := list[netboxInterface](ctx, p, "/api/dcim/interfaces/",
+		url.Values{"device_id": {strconv.Itoa(nd.ID)}})
+	if err != nil {
+		return nil, err
+	}
+	return p.newDevice(exporterIP, nd, site, interfaces), nil
+}
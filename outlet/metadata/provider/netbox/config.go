@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netbox
+
+import (
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/outlet/metadata/provider"
+)
+
+// Configuration describes the configuration for the NetBox provider.
+type Configuration struct {
+	// URL is the base URL of the NetBox instance
+	URL string `validate:"required,url"`
+	// Token is the API token used to authenticate to NetBox
+	Token string `validate:"required"`
+	// TLS defines the TLS configuration to connect to NetBox
+	TLS helpers.TLSConfiguration
+	// Timeout tells how much time to wait for each answer from NetBox
+	Timeout time.Duration `validate:"min=100ms"`
+	// CacheDuration tells how long to keep a device and its interfaces
+	// before fetching them again
+	CacheDuration time.Duration `validate:"min=1m"`
+	// PageSize is the number of objects to request for each page
+	PageSize uint `validate:"min=1,max=1000"`
+	// CustomFields defines the custom fields to use
+	CustomFields CustomFields
+}
+
+// CustomFields defines the names of the NetBox custom fields to use. An empty
+// name disables the matching feature.
+type CustomFields struct {
+	// IfIndex is the interface custom field containing the SNMP ifIndex.
+	// Interfaces without it are matched by the name learned from the flows.
+	IfIndex string
+	// Provider is the interface custom field containing the provider
+	Provider string
+	// Connectivity is the interface custom field containing the connectivity
+	Connectivity string
+	// Boundary is the interface custom field containing the boundary
+	Boundary string
+	// Group is the device custom field containing the exporter group
+	Group string
+}
+
+// DefaultConfiguration represents the default configuration for the NetBox
+// provider.
+func DefaultConfiguration() provider.Configuration {
+	return Configuration{
+		Timeout:       2 * time.Second,
+		CacheDuration: 10 * time.Minute,
+		PageSize:      100,
+		CustomFields: CustomFields{
+			IfIndex:      "snmp_ifindex",
+			Provider:     "provider",
+			Connectivity: "connectivity",
+			Boundary:     "boundary",
+		},
+	}
+}
@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package netbox is a metadata provider using the REST API of NetBox to answer
+// to requests. Devices are looked up by their primary IP and interfaces by
+// their SNMP ifIndex, stored in a custom field, or by their name.
+package netbox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/metadata/provider"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Provider represents the NetBox provider.
+type Provider struct {
+	r      *reporter.Reporter
+	config Configuration
+	client *http.Client
+
+	sf          singleflight.Group
+	devicesLock sync.RWMutex
+	devices     map[netip.Addr]*device
+	ifNamesLock sync.RWMutex
+	ifNames     map[netip.Addr]map[uint]string
+
+	errLogger reporter.Logger
+	timeNow   func() time.Time
+
+	metrics struct {
+		fetches *reporter.CounterVec
+	}
+}
+
+// device is a device fetched from NetBox. A nil exporter means the exporter
+// is not known by NetBox.
+type device struct {
+	expires         time.Time
+	exporter        *provider.Exporter
+	interfacesIndex map[uint]provider.Interface
+	interfacesName  map[string]provider.Interface
+}
+
+var (
+	_ provider.Provider      = &Provider{}
+	_ provider.Updater       = &Provider{}
+	_ provider.Configuration = Configuration{}
+)
+
+// New creates a new NetBox provider from configuration.
+func (configuration Configuration) New(_ context.Context, r *reporter.Reporter) (provider.Provider, error) {
+	tlsConfig, err := configuration.TLS.MakeTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build TLS configuration: %w", err)
+	}
+	p := &Provider{
+		r:      r,
+		config: configuration,
+		client: &http.Client{
+			Timeout: configuration.Timeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+		devices:   map[netip.Addr]*device{},
+		ifNames:   map[netip.Addr]map[uint]string{},
+		errLogger: r.Sample(reporter.BurstSampler(time.Minute, 3)),
+		timeNow:   time.Now,
+	}
+	p.metrics.fetches = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "fetches_total",
+			Help: "Number of devices fetched from NetBox.",
+		},
+		[]string{"exporter", "result"})
+	return p, nil
+}
+
+// Query queries NetBox for the exporter and the interface. Unknown exporters
+// and interfaces are skipped to let the next provider answer.
+func (p *Provider) Query(ctx context.Context, query provider.Query) (provider.Answer, error) {
+	d, err := p.lookupDevice(ctx, query.ExporterIP)
+	if err != nil {
+		return provider.Answer{}, err
+	}
+	if d.exporter == nil {
+		return provider.Answer{}, provider.ErrSkipProvider
+	}
+	iface, ok := d.interfacesIndex[query.IfIndex]
+	if !ok {
+		p.ifNamesLock.RLock()
+		name, okName := p.ifNames[query.ExporterIP][query.IfIndex]
+		p.ifNamesLock.RUnlock()
+		if okName {
+			iface, ok = d.interfacesName[name]
+		}
+	}
+	if !ok {
+		return provider.Answer{}, provider.ErrSkipProvider
+	}
+	return provider.Answer{
+		Found:     true,
+		Exporter:  *d.exporter,
+		Interface: iface,
+	}, nil
+}
+
+// Update records the interface names learned from the flows. They are used
+// to match interfaces without the ifIndex custom field.
+func (p *Provider) Update(update provider.Update) bool {
+	if update.IfIndex == 0 || update.IfName == "" {
+		return false
+	}
+	p.ifNamesLock.Lock()
+	defer p.ifNamesLock.Unlock()
+	names, ok := p.ifNames[update.ExporterIP]
+	if !ok {
+		names = map[uint]string{}
+		p.ifNames[update.ExporterIP] = names
+	}
+	if names[update.IfIndex] == update.IfName {
+		return false
+	}
+	names[update.IfIndex] = update.IfName
+	return true
+}
+
+// lookupDevice returns the device from the cache or fetches it from NetBox.
+// Concurrent requests for the same exporter are coalesced.
+func (p *Provider) lookupDevice(ctx context.Context, exporterIP netip.Addr) (*device, error) {
+	p.devicesLock.RLock()
+	d, ok := p.devices[exporterIP]
+	p.devicesLock.RUnlock()
+	if ok && p.timeNow().Before(d.expires) {
+		return d, nil
+	}
+
+	result, err, _ := p.sf.Do(exporterIP.String(), func() (any, error) {
+		exporterStr := exporterIP.Unmap().String()
+		d, err := p.fetchDevice(ctx, exporterIP)
+		if err != nil {
+			p.metrics.fetches.WithLabelValues(exporterStr, "error").Inc()
+			p.errLogger.Err(err).Str("exporter", exporterStr).Msg("cannot fetch device from NetBox")
+			return nil, err
+		}
+		if d == nil {
+			p.metrics.fetches.WithLabelValues(exporterStr, "not-found").Inc()
+			d = &device{}
+		} else {
+			p.metrics.fetches.WithLabelValues(exporterStr, "found").Inc()
+		}
+		d.expires = p.timeNow().Add(p.config.CacheDuration)
+		p.devicesLock.Lock()
+		p.devices[exporterIP] = d
+		p.devicesLock.Unlock()
+		return d, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*device), nil
+}
+
+// newDevice builds a device from the objects returned by NetBox.
+func (p *Provider) newDevice(exporterIP netip.Addr, nd netboxDevice, site netboxSite, interfaces []netboxInterface) *device {
+	cf := p.config.CustomFields
+	exporter := provider.Exporter{
+		Name:   nd.Name,
+		Region: site.Region.name(),
+		Role:   nd.Role.name(),
+		Tenant: nd.Tenant.name(),
+		Site:   nd.Site.name(),
+		Group:  nd.CustomFields.String(cf.Group),
+	}
+	if exporter.Name == "" {
+		exporter.Name = exporterIP.Unmap().String()
+	}
+	if exporter.Role == "" {
+		exporter.Role = nd.DeviceRole.name()
+	}
+
+	d := &device{
+		exporter:        &exporter,
+		interfacesIndex: map[uint]provider.Interface{},
+		interfacesName:  map[string]provider.Interface{},
+	}
+	for _, ni := range interfaces {
+		iface := provider.Interface{
+			Name:         ni.Name,
+			Description:  ni.Description,
+			Provider:     ni.CustomFields.String(cf.Provider),
+			Connectivity: ni.CustomFields.String(cf.Connectivity),
+		}
+		if ni.Speed != nil {
+			iface.Speed = *ni.Speed / 1000
+		}
+		if boundary := ni.CustomFields.String(cf.Boundary); boundary != "" {
+			if err := iface.Boundary.UnmarshalText([]byte(boundary)); err != nil {
+				p.errLogger.Warn().
+					Str("exporter", exporterIP.Unmap().String()).
+					Str("interface", ni.Name).
+					Str("boundary", boundary).
+					Msg("invalid interface boundary in NetBox")
+				iface.Boundary = schema.InterfaceBoundaryUndefined
+			}
+		}
+		d.interfacesName[ni.Name] = iface
+		if ifIndex, err := strconv.ParseUint(ni.CustomFields.String(cf.IfIndex), 10, 32); err == nil {
+			d.interfacesIndex[uint(ifIndex)] = iface
+		}
+	}
+	return d
+}
@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/outlet/metadata/provider"
+)
+
+func newFakeNetBox(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	reply := func(w http.ResponseWriter, payload any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(payload)
+	}
+	mux.HandleFunc("GET /api/ipam/ip-addresses/", func(w http.ResponseWriter, r *http.Request) {
+		results := []helpers.M{}
+		switch r.URL.Query().Get("address") {
+		case "192.0.2.1":
+			results = append(results, helpers.M{"id": 10})
+		case "2001:db8::1":
+			results = append(results, helpers.M{"id": 11})
+		}
+		reply(w, helpers.M{"next": nil, "results": results})
+	})
+	mux.HandleFunc("GET /api/dcim/devices/", func(w http.ResponseWriter, r *http.Request) {
+		results := []helpers.M{}
+		if r.URL.Query().Get("primary_ip4_id") == "10" {
+			results = append(results, helpers.M{
+				"id":            1,
+				"name":          "edge1.par",
+				"role":          helpers.M{"id": 1, "name": "edge"},
+				"tenant":        helpers.M{"id": 1, "name": "ops"},
+				"site":          helpers.M{"id": 3, "name": "par1"},
+				"custom_fields": helpers.M{"group": "paris"},
+			})
+		}
+		reply(w, helpers.M{"next": nil, "results": results})
+	})
+	mux.HandleFunc("GET /api/dcim/sites/3/", func(w http.ResponseWriter, _ *http.Request) {
+		reply(w, helpers.M{"id": 3, "region": helpers.M{"id": 5, "name": "europe"}})
+	})
+	mux.HandleFunc("GET /api/dcim/interfaces/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("device_id") != "1" || r.URL.Query().Get("limit") != "2" {
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("offset") == "" {
+			next := "http://" + r.Host + "/api/dcim/interfaces/?device_id=1&limit=2&offset=2"
+			reply(w, helpers.M{"next": next, "results": []helpers.M{
+				{
+					"name":        "Gi0/0/1",
+					"description": "Transit: Cogent",
+					"speed":       10000000,
+					"custom_fields": helpers.M{
+						"snmp_ifindex": 10,
+						"provider":     "cogent",
+						"connectivity": "transit",
+						"boundary":     "external",
+					},
+				}, {
+					"name":          "Gi0/0/2",
+					"description":   "Core",
+					"speed":         100000000,
+					"custom_fields": helpers.M{"snmp_ifindex": nil, "boundary": "internal"},
+				},
+			}})
+			return
+		}
+		reply(w, helpers.M{"next": nil, "results": []helpers.M{
+			{
+				"name":          "Gi0/0/3",
+				"description":   "PNI: Google",
+				"speed":         nil,
+				"custom_fields": helpers.M{"snmp_ifindex": "13", "provider": "google"},
+			},
+		}})
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Token secret" {
+			http.Error(w, "invalid token", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &requests
+}
+
+func TestNetBoxProvider(t *testing.T) {
+	ts, requests := newFakeNetBox(t)
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(Configuration)
+	configuration.URL = ts.URL + "/"
+	configuration.Token = "secret"
+	configuration.PageSize = 2
+	configuration.CustomFields.Group = "group"
+	p, err := configuration.New(context.Background(), r)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	exporterIP := netip.MustParseAddr("::ffff:192.0.2.1")
+	exporter := provider.Exporter{
+		Name:   "edge1.par",
+		Region: "europe",
+		Role:   "edge",
+		Tenant: "ops",
+		Site:   "par1",
+		Group:  "paris",
+	}
+
+	cases := []struct {
+		Description string
+		Query       provider.Query
+		Update      *provider.Update
+		Expected    provider.Answer
+		Error       error
+	}{
+		{
+			Description: "interface by ifIndex",
+			Query:       provider.Query{ExporterIP: exporterIP, IfIndex: 10},
+			Expected: provider.Answer{
+				Found:    true,
+				Exporter: exporter,
+				Interface: provider.Interface{
+					Name:         "Gi0/0/1",
+					Description:  "Transit: Cogent",
+					Speed:        10000,
+					Provider:     "cogent",
+					Connectivity: "transit",
+					Boundary:     schema.InterfaceBoundaryExternal,
+				},
+			},
+		}, {
+			Description: "interface by ifIndex as a string on second page",
+			Query:       provider.Query{ExporterIP: exporterIP, IfIndex: 13},
+			Expected: provider.Answer{
+				Found:    true,
+				Exporter: exporter,
+				Interface: provider.Interface{
+					Name:        "Gi0/0/3",
+					Description: "PNI: Google",
+					Provider:    "google",
+				},
+			},
+		}, {
+			Description: "interface without ifIndex",
+			Query:       provider.Query{ExporterIP: exporterIP, IfIndex: 12},
+			Error:       provider.ErrSkipProvider,
+		}, {
+			Description: "interface by name learned from flows",
+			Query:       provider.Query{ExporterIP: exporterIP, IfIndex: 12},
+			Update:      &provider.Update{ExporterIP: exporterIP, IfIndex: 12, IfName: "Gi0/0/2"},
+			Expected: provider.Answer{
+				Found:    true,
+				Exporter: exporter,
+				Interface: provider.Interface{
+					Name:        "Gi0/0/2",
+					Description: "Core",
+					Speed:       100000,
+					Boundary:    schema.InterfaceBoundaryInternal,
+				},
+			},
+		}, {
+			Description: "unknown exporter",
+			Query:       provider.Query{ExporterIP: netip.MustParseAddr("::ffff:192.0.2.2"), IfIndex: 10},
+			Error:       provider.ErrSkipProvider,
+		}, {
+			Description: "IP address not used as a primary IP",
+			Query:       provider.Query{ExporterIP: netip.MustParseAddr("2001:db8::1"), IfIndex: 10},
+			Error:       provider.ErrSkipProvider,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			if tc.Update != nil {
+				if !p.(provider.Updater).Update(*tc.Update) {
+					t.Error("Update() == false, expected true")
+				}
+				if p.(provider.Updater).Update(*tc.Update) {
+					t.Error("Update() twice == true, expected false")
+				}
+			}
+			got, err := p.Query(context.Background(), tc.Query)
+			if !errors.Is(err, tc.Error) {
+				t.Fatalf("Query() error:\n%+v", err)
+			}
+			if diff := helpers.Diff(got, tc.Expected); diff != "" {
+				t.Fatalf("Query() (-got, +want):\n%s", diff)
+			}
+		})
+	}
+
+	// Devices are cached: 1 query for the IP address, 1 for the device, 1 for
+	// the site, 2 for the interfaces. 1 query for the unknown exporter, 2
+	// for the IPv6 address.
+	if got := requests.Load(); got != 8 {
+		t.Errorf("NetBox requests == %d, expected 8", got)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_metadata_provider_netbox_")
+	expectedMetrics := map[string]string{
+		`fetches_total{exporter="192.0.2.1",result="found"}`:       "1",
+		`fetches_total{exporter="192.0.2.2",result="not-found"}`:   "1",
+		`fetches_total{exporter="2001:db8::1",result="not-found"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	// Expire the cache
+	p.(*Provider).timeNow = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, err := p.Query(context.Background(), provider.Query{ExporterIP: exporterIP, IfIndex: 10}); err != nil {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+	if got := requests.Load(); got != 13 {
+		t.Errorf("NetBox requests == %d, expected 13", got)
+	}
+}
+
+func TestNetBoxProviderErrors(t *testing.T) {
+	ts, _ := newFakeNetBox(t)
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(Configuration)
+	configuration.URL = ts.URL
+	configuration.Token = "wrong"
+	p, err := configuration.New(context.Background(), r)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	_, err = p.Query(context.Background(), provider.Query{
+		ExporterIP: netip.MustParseAddr("::ffff:192.0.2.1"),
+		IfIndex:    10,
+	})
+	if !errors.Is(err, ErrStatusCode) {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+}
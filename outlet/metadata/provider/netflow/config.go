@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netflow
+
+import (
+	"akvorado/outlet/metadata/provider"
+)
+
+// Configuration describes the configuration for the NetFlow provider. It has
+// no option: the metadata are learned from the flows.
+type Configuration struct{}
+
+// DefaultConfiguration represents the default configuration for the NetFlow
+// provider.
+func DefaultConfiguration() provider.Configuration {
+	return Configuration{}
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package netflow is a metadata provider answering from the metadata embedded
+// in the flows, like the interface names and the sampling rates exported by
+// routers through NetFlow v9 and IPFIX options data. It does not poll anything.
+package netflow
+
+import (
+	"cmp"
+	"context"
+	"net/netip"
+	"sync"
+
+	"akvorado/common/reporter"
+	"akvorado/outlet/metadata/provider"
+)
+
+// Provider represents the NetFlow provider.
+type Provider struct {
+	r *reporter.Reporter
+
+	exportersLock sync.RWMutex
+	exporters     map[netip.Addr]*exporterState
+
+	metrics struct {
+		updates *reporter.CounterVec
+	}
+}
+
+// exporterState is what we know about an exporter. interfaceRates are the
+// sampling rates of the samplers bound to an interface.
+type exporterState struct {
+	interfaces     map[uint]provider.Interface
+	samplers       map[uint64]uint
+	interfaceRates map[uint]uint
+}
+
+var (
+	_ provider.Provider      = &Provider{}
+	_ provider.Updater       = &Provider{}
+	_ provider.Configuration = Configuration{}
+)
+
+// New creates a new NetFlow provider from configuration.
+func (configuration Configuration) New(_ context.Context, r *reporter.Reporter) (provider.Provider, error) {
+	p := &Provider{
+		r:         r,
+		exporters: map[netip.Addr]*exporterState{},
+	}
+	p.metrics.updates = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "updates_total",
+			Help: "Number of updates changing the known metadata.",
+		},
+		[]string{"exporter", "type"})
+	return p, nil
+}
+
+// Update records an interface or a sampler learned from the flows.
+func (p *Provider) Update(update provider.Update) bool {
+	p.exportersLock.Lock()
+	defer p.exportersLock.Unlock()
+	exporter, ok := p.exporters[update.ExporterIP]
+	if !ok {
+		exporter = &exporterState{
+			interfaces:     map[uint]provider.Interface{},
+			samplers:       map[uint64]uint{},
+			interfaceRates: map[uint]uint{},
+		}
+		p.exporters[update.ExporterIP] = exporter
+	}
+
+	changed := false
+	exporterStr := update.ExporterIP.Unmap().String()
+	if update.IfIndex != 0 && (update.IfName != "" || update.IfDescription != "") {
+		// Some exporters send the name and the description in different
+		// records: keep the known value of a missing field.
+		current, ok := exporter.interfaces[update.IfIndex]
+		iface := provider.Interface{
+			Name:        cmp.Or(update.IfName, current.Name),
+			Description: cmp.Or(update.IfDescription, current.Description),
+		}
+		if !ok || current != iface {
+			exporter.interfaces[update.IfIndex] = iface
+			p.metrics.updates.WithLabelValues(exporterStr, "interface").Inc()
+			changed = true
+		}
+	}
+	if update.SamplingRate != 0 {
+		if current, ok := exporter.samplers[update.SamplerID]; !ok || current != update.SamplingRate {
+			exporter.samplers[update.SamplerID] = update.SamplingRate
+			p.metrics.updates.WithLabelValues(exporterStr, "sampler").Inc()
+			changed = true
+		}
+		if update.IfIndex != 0 && exporter.interfaceRates[update.IfIndex] != update.SamplingRate {
+			exporter.interfaceRates[update.IfIndex] = update.SamplingRate
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Query answers from the metadata learned from the flows. Unknown exporters
+// and interfaces are skipped.
+func (p *Provider) Query(_ context.Context, query provider.Query) (provider.Answer, error) {
+	p.exportersLock.RLock()
+	defer p.exportersLock.RUnlock()
+	exporter, ok := p.exporters[query.ExporterIP]
+	if !ok {
+		return provider.Answer{}, provider.ErrSkipProvider
+	}
+	iface, ok := exporter.interfaces[query.IfIndex]
+	if !ok {
+		return provider.Answer{}, provider.ErrSkipProvider
+	}
+	return provider.Answer{
+		Found: true,
+		Exporter: provider.Exporter{
+			Name:         query.ExporterIP.Unmap().String(),
+			SamplingRate: exporter.samplingRate(query.IfIndex),
+		},
+		Interface: iface,
+	}, nil
+}
+
+// samplingRate returns the sampling rate of the sampler bound to the provided
+// interface. Otherwise, it returns the sampling rate of the exporter when all
+// its samplers agree. When they conflict, it returns 0 and the sampling rate is
+// left to the other providers or to the configuration.
+func (e *exporterState) samplingRate(ifIndex uint) uint {
+	if rate, ok := e.interfaceRates[ifIndex]; ok {
+		return rate
+	}
+	var rate uint
+	for _, r := range e.samplers {
+		if rate != 0 && r != rate {
+			return 0
+		}
+		rate = r
+	}
+	return rate
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netflow
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/outlet/metadata/provider"
+)
+
+func TestNetFlowProvider(t *testing.T) {
+	r := reporter.NewMock(t)
+	p, err := DefaultConfiguration().New(context.Background(), r)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	exporter := netip.MustParseAddr("::ffff:192.0.2.1")
+	updater := p.(provider.Updater)
+
+	// Nothing is known yet
+	if _, err := p.Query(context.Background(), provider.Query{ExporterIP: exporter, IfIndex: 10}); err != provider.ErrSkipProvider {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+
+	updates := []struct {
+		update  provider.Update
+		changed bool
+	}{
+		{provider.Update{ExporterIP: exporter, IfIndex: 10, IfName: "Gi0/0/10", IfDescription: "Transit"}, true},
+		{provider.Update{ExporterIP: exporter, IfIndex: 10, IfName: "Gi0/0/10", IfDescription: "Transit"}, false},
+		{provider.Update{ExporterIP: exporter, IfIndex: 11, IfName: "Gi0/0/11", IfDescription: "PNI"}, true},
+		{provider.Update{ExporterIP: exporter, SamplerID: 1, SamplingRate: 1000}, true},
+		{provider.Update{ExporterIP: exporter, SamplerID: 1, SamplingRate: 1000}, false},
+	}
+	for _, u := range updates {
+		if changed := updater.Update(u.update); changed != u.changed {
+			t.Errorf("Update(%+v) == %v but expected %v", u.update, changed, u.changed)
+		}
+	}
+
+	got, err := p.Query(context.Background(), provider.Query{ExporterIP: exporter, IfIndex: 10})
+	if err != nil {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+	expected := provider.Answer{
+		Found: true,
+		Exporter: provider.Exporter{
+			Name:         "192.0.2.1",
+			SamplingRate: 1000,
+		},
+		Interface: provider.Interface{
+			Name:        "Gi0/0/10",
+			Description: "Transit",
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Query() (-got, +want):\n%s", diff)
+	}
+
+	// Unknown interface
+	if _, err := p.Query(context.Background(), provider.Query{ExporterIP: exporter, IfIndex: 12}); err != provider.ErrSkipProvider {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+
+	// Samplers disagree, no sampling rate, unless the sampler is bound to the
+	// interface
+	updater.Update(provider.Update{ExporterIP: exporter, SamplerID: 2, SamplingRate: 100})
+	got, err = p.Query(context.Background(), provider.Query{ExporterIP: exporter, IfIndex: 11})
+	if err != nil {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+	if got.Exporter.SamplingRate != 0 {
+		t.Fatalf("Query() sampling rate == %d, expected 0", got.Exporter.SamplingRate)
+	}
+	if !updater.Update(provider.Update{ExporterIP: exporter, IfIndex: 11, SamplerID: 2, SamplingRate: 100}) {
+		t.Fatal("Update() with a bound sampler did not change anything")
+	}
+	got, err = p.Query(context.Background(), provider.Query{ExporterIP: exporter, IfIndex: 11})
+	if err != nil {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+	if got.Exporter.SamplingRate != 100 {
+		t.Fatalf("Query() sampling rate == %d, expected 100", got.Exporter.SamplingRate)
+	}
+
+	// Description only, the name is kept
+	updater.Update(provider.Update{ExporterIP: exporter, IfIndex: 10, IfDescription: "Transit: Cogent"})
+	updater.Update(provider.Update{ExporterIP: exporter, IfIndex: 12, IfDescription: "Backbone"})
+	for ifIndex, expected := range map[uint]provider.Interface{
+		10: {Name: "Gi0/0/10", Description: "Transit: Cogent"},
+		12: {Description: "Backbone"},
+	} {
+		got, err := p.Query(context.Background(), provider.Query{ExporterIP: exporter, IfIndex: ifIndex})
+		if err != nil {
+			t.Fatalf("Query() error:\n%+v", err)
+		}
+		if diff := helpers.Diff(got.Interface, expected); diff != "" {
+			t.Fatalf("Query(%d) (-got, +want):\n%s", ifIndex, diff)
+		}
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_metadata_provider_netflow_")
+	expectedMetrics := map[string]string{
+		`updates_total{exporter="192.0.2.1",type="interface"}`: "4",
+		`updates_total{exporter="192.0.2.1",type="sampler"}`:   "2",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
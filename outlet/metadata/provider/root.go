@@ -25,6 +25,8 @@ type Interface struct {
 	Provider     string
 	Connectivity string
 	Boundary     schema.InterfaceBoundary
+	Neighbor     string
+	OperStatus   string
 }
 
 // Exporter describes a router that exports netflow
@@ -41,6 +43,9 @@ type Exporter struct {
 	Site string
 	// Group is a functional or organisational identifier for the exporter, used to set ExporterGroup.
 	Group string
+	// SamplingRate is the sampling rate used by the exporter, when known by
+	// the provider. It is used when a flow does not carry its own.
+	SamplingRate uint `mapstructure:"-" yaml:"-"`
 }
 
 // Query is the query sent to a provider.
@@ -65,6 +70,42 @@ type Provider interface {
 	Query(ctx context.Context, query Query) (Answer, error)
 }
 
+// Update is an update about an exporter learned from the flows themselves.
+// It describes an interface (IfName or IfDescription is set), a sampler
+// (SamplingRate is not 0), or both. A sampler with IfIndex set is bound to
+// this interface.
+type Update struct {
+	ExporterIP    netip.Addr
+	IfIndex       uint
+	IfName        string
+	IfDescription string
+	SamplerID     uint64
+	SamplingRate  uint
+}
+
+// Updater is the interface a provider accepting updates from the flows should
+// implement.
+type Updater interface {
+	// Update records the provided update. It returns true if the state of the
+	// provider has changed.
+	Update(update Update) bool
+}
+
+// Notifier is the interface a provider learning about changes by itself should
+// implement.
+type Notifier interface {
+	// Notify registers a function to call when the metadata of an interface
+	// has changed.
+	Notify(func(exporterIP netip.Addr, ifIndex uint))
+}
+
+// Stopper is the interface a provider running goroutines should implement.
+type Stopper interface {
+	// Stop waits for the goroutines to terminate. It is called once the
+	// context provided to New is canceled.
+	Stop()
+}
+
 // Configuration defines an interface to configure a provider.
 type Configuration interface {
 	// New instantiates a new provider from its configuration. The provided
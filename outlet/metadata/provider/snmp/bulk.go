@@ -0,0 +1,251 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package snmp
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+
+	"akvorado/outlet/metadata/provider"
+)
+
+const (
+	// bulkRetryInterval is the delay before polling again an exporter after
+	// a failure.
+	bulkRetryInterval = time.Minute
+	// bulkExpiryIntervals is the number of refresh intervals after which an
+	// exporter not queried anymore is forgotten.
+	bulkExpiryIntervals = 12
+)
+
+// exporterState is the state of an exporter polled in bulk. Its fields are
+// protected by the statesLock of the provider.
+type exporterState struct {
+	// ready is closed once the first poll is done
+	ready       chan bool
+	name        string
+	interfaces  map[uint]provider.Interface
+	err         error
+	lastQueried time.Time
+}
+
+// queryBulk answers a query from the state of the exporter. On first contact,
+// a poller is started in the background to walk the interface tables of the
+// exporter and to refresh them periodically.
+func (p *Provider) queryBulk(ctx context.Context, exporter, agent netip.Addr, port uint16, ifIndex uint) (provider.Answer, error) {
+	p.statesLock.Lock()
+	if err := p.ctx.Err(); err != nil {
+		p.statesLock.Unlock()
+		return provider.Answer{}, err
+	}
+	state, ok := p.states[exporter]
+	if !ok {
+		state = &exporterState{ready: make(chan bool)}
+		p.states[exporter] = state
+		p.pollers.Add(1)
+		go p.startBulkPoller(exporter, agent, port, state)
+	}
+	state.lastQueried = time.Now()
+	p.statesLock.Unlock()
+
+	// Wait for the first poll.
+	select {
+	case <-state.ready:
+	case <-ctx.Done():
+		p.metrics.errors.WithLabelValues(exporter.Unmap().String(), "not ready").Inc()
+		return provider.Answer{}, ctx.Err()
+	}
+
+	p.statesLock.RLock()
+	defer p.statesLock.RUnlock()
+	if state.name == "" {
+		return provider.Answer{}, state.err
+	}
+	iface, ok := state.interfaces[ifIndex]
+	if !ok {
+		return provider.Answer{}, nil
+	}
+	return provider.Answer{
+		Found: true,
+		Exporter: provider.Exporter{
+			Name: state.name,
+		},
+		Interface: iface,
+	}, nil
+}
+
+// startBulkPoller polls all the interfaces of an exporter until the provider
+// is stopped or the exporter is not queried anymore. On error, the previous
+// state is kept. Interfaces which have changed are notified.
+func (p *Provider) startBulkPoller(exporter, agent netip.Addr, port uint16, state *exporterState) {
+	defer p.pollers.Done()
+	first := true
+	for {
+		name, interfaces, err := p.PollAll(p.ctx, exporter, agent, port)
+		changed := []uint{}
+		p.statesLock.Lock()
+		if err == nil {
+			for ifIndex, iface := range interfaces {
+				if previous, ok := state.interfaces[ifIndex]; !ok || previous != iface || name != state.name {
+					changed = append(changed, ifIndex)
+				}
+			}
+			for ifIndex := range state.interfaces {
+				if _, ok := interfaces[ifIndex]; !ok {
+					changed = append(changed, ifIndex)
+				}
+			}
+			state.name = name
+			state.interfaces = interfaces
+			state.err = nil
+		} else if state.name == "" {
+			state.err = err
+		}
+		p.statesLock.Unlock()
+		if first {
+			close(state.ready)
+			first = false
+		}
+		if p.notify != nil {
+			for _, ifIndex := range changed {
+				p.notify(exporter, ifIndex)
+			}
+		}
+
+		interval := p.config.BulkRefreshInterval
+		if err != nil {
+			interval = min(interval, bulkRetryInterval)
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-p.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		p.statesLock.Lock()
+		if time.Since(state.lastQueried) > bulkExpiryIntervals*p.config.BulkRefreshInterval {
+			delete(p.states, exporter)
+			p.statesLock.Unlock()
+			return
+		}
+		p.statesLock.Unlock()
+	}
+}
+
+// PollAll polls the SNMP provider for all the interfaces of an exporter. It
+// walks ifTable and ifXTable with GETBULK requests and returns the system
+// name and the interfaces indexed by ifIndex.
+func (p *Provider) PollAll(ctx context.Context, exporter, agent netip.Addr, port uint16) (string, map[uint]provider.Interface, error) {
+	exporterStr := exporter.Unmap().String()
+	g, usm, communities := p.newSNMPSession(ctx, exporter, agent, port)
+	start := time.Now()
+	if err := g.Connect(); err != nil {
+		p.metrics.errors.WithLabelValues(exporterStr, "connect").Inc()
+		p.errLogger.Err(err).Str("exporter", exporterStr).Msg("unable to connect")
+		return "", nil, err
+	}
+	defer g.Conn.Close()
+
+	// Get sysName. For SNMPv2, use the first community answering.
+	var sysName string
+	if g.Version == gosnmp.Version3 {
+		communities = communities[:1]
+	}
+	for _, community := range communities {
+		g.Community = community
+		result, err := g.Get([]string{"1.3.6.1.2.1.1.5.0"})
+		if errors.Is(err, context.Canceled) {
+			return "", nil, err
+		}
+		if err != nil || len(result.Variables) != 1 {
+			continue
+		}
+		if sysName, _ = pduString(result.Variables[0]); sysName != "" {
+			break
+		}
+	}
+	if sysName == "" {
+		p.metrics.errors.WithLabelValues(exporterStr, "sysname missing").Inc()
+		err := errors.New("unable to get sysName")
+		p.errLogger.Err(err).Str("exporter", exporterStr).Msg("unable to poll exporter")
+		return "", nil, err
+	}
+	if usm != nil {
+		p.storeV3Cache(usm, exporter)
+	}
+
+	// Walk the interface tables.
+	columns := []struct {
+		oid  string
+		what string
+	}{
+		{ifDescrTable, "ifdescr"},
+		{ifNameTable, "ifname"},
+		{"1.3.6.1.2.1.31.1.1.1.18", "ifalias"},
+		{"1.3.6.1.2.1.31.1.1.1.15", "ifspeed"},
+	}
+	values := make([]map[uint]gosnmp.SnmpPDU, len(columns))
+	for idx, column := range columns {
+		entries, err := walkTable(g, column.oid)
+		if err != nil {
+			p.metrics.errors.WithLabelValues(exporterStr, "walk").Inc()
+			p.errLogger.Err(err).
+				Str("exporter", exporterStr).
+				Msgf("unable to walk %s", column.what)
+			return "", nil, err
+		}
+		values[idx] = make(map[uint]gosnmp.SnmpPDU, len(entries))
+		for _, entry := range entries {
+			ifIndex, err := strconv.ParseUint(entry.index, 10, 32)
+			if err != nil {
+				continue
+			}
+			values[idx][uint(ifIndex)] = entry.value
+		}
+	}
+	var neighbors map[uint]string
+	if p.config.Neighbors {
+		ifIndexes := map[string]uint{}
+		for _, names := range []map[uint]gosnmp.SnmpPDU{values[0], values[1]} {
+			for ifIndex, pdu := range names {
+				if name, _ := pduString(pdu); name != "" {
+					ifIndexes[name] = ifIndex
+				}
+			}
+		}
+		neighbors = p.pollNeighbors(g, exporterStr, ifIndexes)
+	}
+
+	interfaces := make(map[uint]provider.Interface, len(values[1]))
+	for ifIndex, pdu := range values[1] {
+		ifName, okName := pduString(pdu)
+		ifDescr, okDescr := pduString(values[0][ifIndex])
+		ifAlias, okAlias := pduString(values[2][ifIndex])
+		ifSpeed, okSpeed := values[3][ifIndex].Value.(uint)
+		if !okSpeed {
+			p.metrics.errors.WithLabelValues(exporterStr, "ifspeed missing").Inc()
+			continue
+		}
+		if !okName {
+			p.metrics.errors.WithLabelValues(exporterStr, "ifname unknown type").Inc()
+			continue
+		}
+		interfaces[ifIndex] = provider.Interface{
+			Name:        ifName,
+			Description: ifDescription(ifName, ifDescr, okDescr, ifAlias, okAlias),
+			Speed:       ifSpeed,
+			Neighbor:    neighbors[ifIndex],
+		}
+	}
+	p.metrics.times.WithLabelValues(exporterStr).Observe(time.Since(start).Seconds())
+	p.metrics.successes.WithLabelValues(exporterStr).Inc()
+	return sysName, interfaces, nil
+}
@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package snmp
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/outlet/metadata/provider"
+)
+
+func TestBulkPoller(t *testing.T) {
+	lo := netip.MustParseAddr("::ffff:127.0.0.1")
+	r := reporter.NewMock(t)
+
+	var alias atomic.Value
+	alias.Store("Transit")
+	port := startSNMPServer(t, map[string]any{
+		"1.3.6.1.2.1.1.5.0":           "exporter62",
+		"1.3.6.1.2.1.2.2.1.2.641":     "Gi0/0/0/0",
+		"1.3.6.1.2.1.2.2.1.2.642":     "Gi0/0/0/1",
+		"1.3.6.1.2.1.2.2.1.2.643":     "Gi0/0/0/2",
+		"1.3.6.1.2.1.31.1.1.1.1.641":  "Gi0/0/0/0",
+		"1.3.6.1.2.1.31.1.1.1.1.642":  "Gi0/0/0/1",
+		"1.3.6.1.2.1.31.1.1.1.1.643":  "Gi0/0/0/2",
+		"1.3.6.1.2.1.31.1.1.1.15.641": uint(10000),
+		"1.3.6.1.2.1.31.1.1.1.15.642": uint(20000),
+		// ifSpeed.643 missing
+		"1.3.6.1.2.1.31.1.1.1.18.641": func() string { return alias.Load().(string) },
+		"1.3.6.1.2.1.31.1.1.1.18.642": "Peering",
+		// CDP neighbor
+		"1.3.6.1.4.1.9.9.23.1.2.1.1.6.642.1": "switch1",
+		"1.3.6.1.4.1.9.9.23.1.2.1.1.7.642.1": "Gi1/0/1",
+	})
+
+	config := DefaultConfiguration().(Configuration)
+	config.PollerTimeout = 100 * time.Millisecond
+	config.Neighbors = true
+	config.BulkPolling = true
+	config.BulkRefreshInterval = 500 * time.Millisecond
+	config.Ports = helpers.MustNewSubnetMap(map[string]uint16{
+		"::/0": uint16(port),
+	})
+	p, err := config.New(t.Context(), r)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	var notifiedLock sync.Mutex
+	notified := []uint{}
+	p.(*Provider).Notify(func(_ netip.Addr, ifIndex uint) {
+		notifiedLock.Lock()
+		notified = append(notified, ifIndex)
+		notifiedLock.Unlock()
+	})
+	checkNotified := func(expected []uint) {
+		t.Helper()
+		notifiedLock.Lock()
+		defer notifiedLock.Unlock()
+		slices.Sort(notified)
+		if diff := helpers.Diff(notified, expected); diff != "" {
+			t.Fatalf("Notify() (-got, +want):\n%s", diff)
+		}
+		notified = []uint{}
+	}
+
+	query := func() []string {
+		got := []string{}
+		for _, ifIndex := range []uint{641, 642, 643, 644} {
+			answer, err := p.Query(t.Context(), provider.Query{ExporterIP: lo, IfIndex: ifIndex})
+			if err != nil {
+				t.Fatalf("Query() error:\n%+v", err)
+			}
+			got = append(got, fmt.Sprintf("%v %s %d %s %s %d %s",
+				answer.Found, answer.Exporter.Name, ifIndex, answer.Interface.Name,
+				answer.Interface.Description, answer.Interface.Speed, answer.Interface.Neighbor))
+		}
+		return got
+	}
+
+	got := query()
+	if diff := helpers.Diff(got, []string{
+		"true exporter62 641 Gi0/0/0/0 Transit 10000 ",
+		"true exporter62 642 Gi0/0/0/1 Peering 20000 switch1:Gi1/0/1",
+		"false  643   0 ",
+		"false  644   0 ",
+	}); diff != "" {
+		t.Fatalf("Query() (-got, +want):\n%s", diff)
+	}
+
+	checkNotified([]uint{641, 642})
+
+	gotMetrics := r.GetMetrics("akvorado_outlet_metadata_provider_snmp_poller_", "error_", "success_")
+	expectedMetrics := map[string]string{
+		`error_requests_total{error="ifspeed missing",exporter="127.0.0.1"}`: "1",
+		`success_requests_total{exporter="127.0.0.1"}`:                       "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	// Wait for a refresh
+	alias.Store("Transit: Cogent")
+	time.Sleep(700 * time.Millisecond)
+	got = query()
+	if diff := helpers.Diff(got[0], "true exporter62 641 Gi0/0/0/0 Transit: Cogent 10000 "); diff != "" {
+		t.Fatalf("Query() after refresh (-got, +want):\n%s", diff)
+	}
+	checkNotified([]uint{641})
+}
+
+func TestBulkPollerExpiry(t *testing.T) {
+	lo := netip.MustParseAddr("::ffff:127.0.0.1")
+	r := reporter.NewMock(t)
+	port := startSNMPServer(t, map[string]any{
+		"1.3.6.1.2.1.1.5.0":           "exporter62",
+		"1.3.6.1.2.1.31.1.1.1.1.641":  "Gi0/0/0/0",
+		"1.3.6.1.2.1.31.1.1.1.15.641": uint(10000),
+	})
+
+	config := DefaultConfiguration().(Configuration)
+	config.PollerTimeout = 100 * time.Millisecond
+	config.BulkPolling = true
+	config.BulkRefreshInterval = 20 * time.Millisecond
+	config.Ports = helpers.MustNewSubnetMap(map[string]uint16{
+		"::/0": uint16(port),
+	})
+	ctx, cancel := context.WithCancel(t.Context())
+	p, err := config.New(ctx, r)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	sp := p.(*Provider)
+
+	answer, err := p.Query(ctx, provider.Query{ExporterIP: lo, IfIndex: 641})
+	if err != nil {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+	if !answer.Found {
+		t.Fatal("Query() did not find the interface")
+	}
+
+	// Without queries, the state is removed after 12 refresh intervals.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		sp.statesLock.RLock()
+		count := len(sp.states)
+		sp.statesLock.RUnlock()
+		if count == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("exporter state not expired")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// Query again and stop: Stop() waits for the poller.
+	if _, err := p.Query(ctx, provider.Query{ExporterIP: lo, IfIndex: 641}); err != nil {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+	cancel()
+	sp.Stop()
+	if _, err := p.Query(t.Context(), provider.Query{ExporterIP: lo, IfIndex: 641}); err == nil {
+		t.Fatal("Query() after Stop() did not error")
+	}
+}
@@ -21,6 +21,14 @@ type Configuration struct {
 	PollerRetries int `validate:"min=0"`
 	// PollerTimeout tell how much time a poller should wait for an answer before retrying
 	PollerTimeout time.Duration `validate:"min=100ms"`
+	// Neighbors tells if LLDP and CDP neighbors should be polled
+	Neighbors bool
+	// BulkPolling tells if all the interfaces of an exporter should be
+	// polled at once on first contact
+	BulkPolling bool
+	// BulkRefreshInterval tells how often the interfaces of an exporter are
+	// polled again when using bulk polling
+	BulkRefreshInterval time.Duration `validate:"required_if=BulkPolling true,omitempty,min=1m"`
 
 	// Credentials is a mapping from exporter IPs to credentials
 	Credentials *helpers.SubnetMap[Credentials] `validate:"omitempty,dive"`
@@ -50,6 +58,8 @@ func DefaultConfiguration() provider.Configuration {
 		PollerRetries: 1,
 		PollerTimeout: time.Second,
 
+		BulkRefreshInterval: 10 * time.Minute,
+
 		Credentials: helpers.MustNewSubnetMap(map[string]Credentials{
 			"::/0": {
 				Communities: []string{"public"},
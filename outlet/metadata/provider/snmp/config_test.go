@@ -406,6 +406,35 @@ func TestConfigurationUnmarshallerHook(t *testing.T) {
 				}
 			},
 			Error: true,
+		}, {
+			Description: "bulk polling",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return helpers.M{
+					"poller-timeout":        "200ms",
+					"bulk-polling":          true,
+					"bulk-refresh-interval": "5m",
+				}
+			},
+			Expected: Configuration{
+				PollerTimeout:       200 * time.Millisecond,
+				BulkPolling:         true,
+				BulkRefreshInterval: 5 * time.Minute,
+				Credentials: helpers.MustNewSubnetMap(map[string]Credentials{
+					"::/0": {Communities: []string{"public"}},
+				}),
+			},
+		}, {
+			Description: "bulk polling with a too short refresh interval",
+			Initial:     func() any { return Configuration{} },
+			Configuration: func() any {
+				return helpers.M{
+					"poller-timeout":        "200ms",
+					"bulk-polling":          true,
+					"bulk-refresh-interval": "10s",
+				}
+			},
+			Error: true,
 		},
 	})
 }
@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2026 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package snmp
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	lldpLocPortTable   = "1.0.8802.1.1.2.1.3.7.1"
+	lldpLocPortID      = lldpLocPortTable + ".3"
+	lldpLocPortDesc    = lldpLocPortTable + ".4"
+	lldpRemTable       = "1.0.8802.1.1.2.1.4.1.1"
+	lldpRemPortIDType  = lldpRemTable + ".6"
+	lldpRemPortID      = lldpRemTable + ".7"
+	lldpRemPortDesc    = lldpRemTable + ".8"
+	lldpRemSysName     = lldpRemTable + ".9"
+	cdpCacheTable      = "1.3.6.1.4.1.9.9.23.1.2.1.1"
+	cdpCacheDeviceID   = cdpCacheTable + ".6"
+	cdpCacheDevicePort = cdpCacheTable + ".7"
+	ifDescrTable       = "1.3.6.1.2.1.2.2.1.2"
+	ifNameTable        = "1.3.6.1.2.1.31.1.1.1.1"
+
+	// lldpPortIDInterfaceName is the lldpRemPortIdSubtype value when the
+	// port ID is the interface name.
+	lldpPortIDInterfaceName = 5
+
+	// neighborsCacheDuration is how long the neighbors of an exporter are
+	// kept when polling interfaces one by one.
+	neighborsCacheDuration = 10 * time.Minute
+)
+
+// neighborsEntry is the cached neighbors of an exporter.
+type neighborsEntry struct {
+	lock      sync.Mutex
+	updated   time.Time
+	neighbors map[uint]string
+}
+
+// cachedNeighbors returns the neighbors of an exporter, walking the LLDP and
+// CDP tables only if they were not walked recently. It is used when polling
+// interfaces one by one.
+func (p *Provider) cachedNeighbors(g *gosnmp.GoSNMP, exporter netip.Addr) map[uint]string {
+	now := time.Now()
+	p.neighborsLock.Lock()
+	entry, ok := p.neighbors[exporter]
+	if !ok {
+		// Forget about the exporters not polled recently.
+		for exporter, entry := range p.neighbors {
+			if entry.lock.TryLock() {
+				if now.Sub(entry.updated) >= 2*neighborsCacheDuration {
+					delete(p.neighbors, exporter)
+				}
+				entry.lock.Unlock()
+			}
+		}
+		entry = &neighborsEntry{}
+		p.neighbors[exporter] = entry
+	}
+	p.neighborsLock.Unlock()
+
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+	if now.Sub(entry.updated) >= neighborsCacheDuration {
+		entry.neighbors = p.pollNeighbors(g, exporter.Unmap().String(), nil)
+		entry.updated = now
+	}
+	return entry.neighbors
+}
+
+// pollNeighbors walks the LLDP and CDP tables to find the neighbor connected
+// to each interface. LLDP is preferred over CDP. Neighbors are returned as
+// "device:port" or just "device" when the port is unknown, indexed by
+// ifIndex. LLDP local ports are mapped to interfaces using the provided
+// mapping from interface names (ifName and ifDescr) to ifIndex. When nil, the
+// mapping is built by walking ifName and ifDescr.
+func (p *Provider) pollNeighbors(g *gosnmp.GoSNMP, exporterStr string, ifIndexes map[string]uint) map[uint]string {
+	walk := func(oid, what string) []tableEntry {
+		entries, err := walkTable(g, oid)
+		if err != nil {
+			p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s walk", what)).Inc()
+			p.errLogger.Err(err).
+				Str("exporter", exporterStr).
+				Msgf("unable to walk %s table", what)
+			return nil
+		}
+		return entries
+	}
+	byIndex := func(entries []tableEntry) map[string]gosnmp.SnmpPDU {
+		result := make(map[string]gosnmp.SnmpPDU, len(entries))
+		for _, entry := range entries {
+			result[entry.index] = entry.value
+		}
+		return result
+	}
+	format := func(device, port string) string {
+		if port == "" {
+			return device
+		}
+		return fmt.Sprintf("%s:%s", device, port)
+	}
+	neighbors := map[uint]string{}
+
+	// LLDP. Entries are indexed by lldpRemTimeMark, lldpRemLocalPortNum and
+	// lldpRemIndex. The local port number is not the ifIndex on many
+	// platforms. It is resolved through lldpLocPortTable, by matching the
+	// local port ID or description with the name of an interface.
+	if sysNames := walk(lldpRemSysName, "lldp"); len(sysNames) > 0 {
+		if ifIndexes == nil {
+			ifIndexes = map[string]uint{}
+			for _, oid := range []string{ifDescrTable, ifNameTable} {
+				for _, entry := range walk(oid, "ifname") {
+					ifIndex, err := strconv.ParseUint(entry.index, 10, 32)
+					if name, _ := pduString(entry.value); err == nil && name != "" {
+						ifIndexes[name] = uint(ifIndex)
+					}
+				}
+			}
+		}
+		localPortIDs := byIndex(walk(lldpLocPortID, "lldp"))
+		localPortDescs := byIndex(walk(lldpLocPortDesc, "lldp"))
+		localIfIndex := func(localPort string) (uint, bool) {
+			for _, pdu := range []gosnmp.SnmpPDU{localPortIDs[localPort], localPortDescs[localPort]} {
+				if name, _ := pduString(pdu); name != "" {
+					if ifIndex, ok := ifIndexes[name]; ok {
+						return ifIndex, true
+					}
+				}
+			}
+			return 0, false
+		}
+		portIDTypes := byIndex(walk(lldpRemPortIDType, "lldp"))
+		portIDs := byIndex(walk(lldpRemPortID, "lldp"))
+		portDescs := byIndex(walk(lldpRemPortDesc, "lldp"))
+		for _, entry := range sysNames {
+			parts := strings.Split(entry.index, ".")
+			if len(parts) != 3 {
+				continue
+			}
+			ifIndex, ok := localIfIndex(parts[1])
+			if !ok {
+				p.metrics.errors.WithLabelValues(exporterStr, "lldp local port unknown").Inc()
+				continue
+			}
+			device, _ := pduString(entry.value)
+			if _, ok := neighbors[ifIndex]; ok || device == "" {
+				continue
+			}
+			portID, _ := pduString(portIDs[entry.index])
+			portDesc, _ := pduString(portDescs[entry.index])
+			portIDType, _ := portIDTypes[entry.index].Value.(int)
+			port := portDesc
+			if portIDType == lldpPortIDInterfaceName || portDesc == "" {
+				port = portID
+			}
+			neighbors[ifIndex] = format(device, port)
+		}
+	}
+
+	// CDP. Entries are indexed by ifIndex and cdpCacheDeviceIndex.
+	if devices := walk(cdpCacheDeviceID, "cdp"); len(devices) > 0 {
+		ports := byIndex(walk(cdpCacheDevicePort, "cdp"))
+		for _, entry := range devices {
+			parts := strings.Split(entry.index, ".")
+			if len(parts) != 2 {
+				continue
+			}
+			ifIndex, err := strconv.ParseUint(parts[0], 10, 32)
+			if err != nil {
+				continue
+			}
+			device, _ := pduString(entry.value)
+			if _, ok := neighbors[uint(ifIndex)]; ok || device == "" {
+				continue
+			}
+			port, _ := pduString(ports[entry.index])
+			neighbors[uint(ifIndex)] = format(device, port)
+		}
+	}
+	return neighbors
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/netip"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/gosnmp/gosnmp"
@@ -198,6 +199,7 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 		// community with partial access can be filled in by another
 		// one.
 		success := false
+		successCommunity := ""
 		for idx, community := range communities {
 			g.Community = community
 			// Fatal error if last community and no success.
@@ -206,10 +208,15 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 			if err != nil {
 				return provider.Answer{}, err
 			}
+			if ok && !success {
+				successCommunity = community
+			}
 			if ok {
 				success = true
 			}
 		}
+		// Neighbors are polled with the first working community.
+		g.Community = successCommunity
 	}
 	p.metrics.times.WithLabelValues(exporterStr).Observe(time.Since(start).Seconds())
 
@@ -246,40 +253,19 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 		return provider.Answer{}, errors.New("sysName empty")
 	}
 
-	var (
-		name, description string
-		speed             uint
-	)
-	ok = true
 	ifDescrVal, okDescr := processStr(1, "ifdescr")
 	ifNameVal, okName := processStr(2, "ifname")
 	ifAliasVal, okAlias := processStr(3, "ifalias")
 	ifSpeedVal, okSpeed := processUint(4, "ifspeed")
 
-	// Many equipments are using ifDescr for the interface name and
-	// ifAlias for the description, which is counter-intuitive. We want
-	// both the name and the description.
-
-	if okName {
-		// If we have ifName, use ifDescr if it is different and ifAlias
-		// is not. Otherwise, keep description empty.
-		name = ifNameVal
-		if okAlias && ifAliasVal != ifNameVal {
-			description = ifAliasVal
-		} else if okDescr && ifDescrVal != ifNameVal {
-			description = ifDescrVal
+	// ifName is mandatory. It would be unexpected to have ifAlias and not
+	// ifName. And if we have only ifDescr, we can't really know what this
+	// is. Speed is mandatory too.
+	if okName && okSpeed {
+		var neighbor string
+		if p.config.Neighbors {
+			neighbor = p.cachedNeighbors(g, exporter)[ifIndex]
 		}
-	} else {
-		// Don't handle the other case yet. It would be unexpected to
-		// have ifAlias and not ifName. And if we have only ifDescr, we
-		// can't really know what this is.
-		ok = false
-	}
-
-	// Speed is mandatory
-	ok = ok && okSpeed
-	speed = ifSpeedVal
-	if ok {
 		p.metrics.successes.WithLabelValues(exporterStr).Inc()
 		return provider.Answer{
 			Found: true,
@@ -287,11 +273,56 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 				Name: sysNameVal,
 			},
 			Interface: provider.Interface{
-				Name:        name,
-				Description: description,
-				Speed:       speed,
+				Name:        ifNameVal,
+				Description: ifDescription(ifNameVal, ifDescrVal, okDescr, ifAliasVal, okAlias),
+				Speed:       ifSpeedVal,
+				Neighbor:    neighbor,
 			},
 		}, nil
 	}
 	return provider.Answer{}, nil
 }
+
+// ifDescription returns the description of an interface from its ifName,
+// ifDescr and ifAlias. Many equipments are using ifDescr for the interface
+// name and ifAlias for the description, which is counter-intuitive. We want
+// both the name and the description: use ifAlias if it is different from
+// ifName, otherwise ifDescr if it is different. Otherwise, keep the
+// description empty.
+func ifDescription(ifName, ifDescr string, okDescr bool, ifAlias string, okAlias bool) string {
+	if okAlias && ifAlias != ifName {
+		return ifAlias
+	} else if okDescr && ifDescr != ifName {
+		return ifDescr
+	}
+	return ""
+}
+
+// tableEntry is an entry of a walked SNMP table. The index is the OID
+// suffix after the walked OID.
+type tableEntry struct {
+	index string
+	value gosnmp.SnmpPDU
+}
+
+// walkTable walks the provided OID with GETBULK requests.
+func walkTable(g *gosnmp.GoSNMP, oid string) ([]tableEntry, error) {
+	entries := []tableEntry{}
+	err := g.BulkWalk(oid, func(pdu gosnmp.SnmpPDU) error {
+		entries = append(entries, tableEntry{
+			index: strings.TrimPrefix(pdu.Name, "."+oid+"."),
+			value: pdu,
+		})
+		return nil
+	})
+	return entries, err
+}
+
+// pduString returns the value of a PDU as a string, if it is an octet
+// string.
+func pduString(pdu gosnmp.SnmpPDU) (string, bool) {
+	if pdu.Type != gosnmp.OctetString {
+		return "", false
+	}
+	return string(pdu.Value.([]byte)), true
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/netip"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -371,3 +372,124 @@ func TestPoller(t *testing.T) {
 		})
 	}
 }
+
+// startSNMPServer starts an SNMP server answering to the "public" community
+// with the provided values. Values can be strings, ints, uints, or functions
+// returning a string. It returns the port of the server.
+func startSNMPServer(t *testing.T, values map[string]any) int {
+	t.Helper()
+	oids := []*GoSNMPServer.PDUValueControlItem{}
+	for oid, value := range values {
+		item := &GoSNMPServer.PDUValueControlItem{
+			OID:   oid,
+			OnGet: func() (any, error) { return value, nil },
+		}
+		switch value := value.(type) {
+		case string:
+			item.Type = gosnmp.OctetString
+		case func() string:
+			item.Type = gosnmp.OctetString
+			item.OnGet = func() (any, error) { return value(), nil }
+		case int:
+			item.Type = gosnmp.Integer
+		case uint:
+			item.Type = gosnmp.Gauge32
+		}
+		oids = append(oids, item)
+	}
+	server := GoSNMPServer.NewSNMPServer(GoSNMPServer.MasterAgent{
+		SubAgents: []*GoSNMPServer.SubAgent{
+			{CommunityIDs: []string{"public"}, OIDs: oids},
+		},
+	})
+	if err := server.ListenUDP("udp", "127.0.0.1:0"); err != nil {
+		t.Fatalf("ListenUDP() err:\n%+v", err)
+	}
+	_, portStr, err := net.SplitHostPort(server.Address().String())
+	if err != nil {
+		panic(err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		panic(err)
+	}
+	go server.ServeForever()
+	t.Cleanup(server.Shutdown)
+	return port
+}
+
+func TestPollerNeighbors(t *testing.T) {
+	lo := netip.MustParseAddr("::ffff:127.0.0.1")
+	r := reporter.NewMock(t)
+
+	var walks atomic.Int32
+	values := map[string]any{
+		"1.3.6.1.2.1.1.5.0": "exporter62",
+		// Local LLDP ports, not numbered like interfaces. The first one
+		// is matched by port ID, the second one by description.
+		"1.0.8802.1.1.2.1.3.7.1.3.1": "Gi0/0/0/641",
+		"1.0.8802.1.1.2.1.3.7.1.4.1": "uplink",
+		"1.0.8802.1.1.2.1.3.7.1.3.3": "\x00\x66\x77\x88\x99\xaa",
+		"1.0.8802.1.1.2.1.3.7.1.4.3": "Gi0/0/0/643",
+		// LLDP neighbor with the interface name as port ID
+		"1.0.8802.1.1.2.1.4.1.1.6.0.1.1": 5,
+		"1.0.8802.1.1.2.1.4.1.1.7.0.1.1": "Et1/1",
+		"1.0.8802.1.1.2.1.4.1.1.8.0.1.1": "to exporter62",
+		"1.0.8802.1.1.2.1.4.1.1.9.0.1.1": func() string {
+			walks.Add(1)
+			return "core1"
+		},
+		// LLDP neighbor with a MAC address as port ID
+		"1.0.8802.1.1.2.1.4.1.1.6.0.3.2": 3,
+		"1.0.8802.1.1.2.1.4.1.1.7.0.3.2": "\x00\x11\x22\x33\x44\x55",
+		"1.0.8802.1.1.2.1.4.1.1.8.0.3.2": "xe-0/0/1",
+		"1.0.8802.1.1.2.1.4.1.1.9.0.3.2": "core2",
+		// LLDP neighbor on an unknown local port
+		"1.0.8802.1.1.2.1.4.1.1.9.0.644.3": "core3",
+		// CDP neighbor
+		"1.3.6.1.4.1.9.9.23.1.2.1.1.6.642.7": "switch1",
+		"1.3.6.1.4.1.9.9.23.1.2.1.1.7.642.7": "GigabitEthernet1/0/1",
+	}
+	for _, ifIndex := range []int{641, 642, 643, 644} {
+		values[fmt.Sprintf("1.3.6.1.2.1.2.2.1.2.%d", ifIndex)] = fmt.Sprintf("Gi0/0/0/%d", ifIndex)
+		values[fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.1.%d", ifIndex)] = fmt.Sprintf("Gi0/0/0/%d", ifIndex)
+		values[fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.15.%d", ifIndex)] = uint(10000)
+	}
+	port := startSNMPServer(t, values)
+
+	config := DefaultConfiguration().(Configuration)
+	config.PollerTimeout = 100 * time.Millisecond
+	config.Neighbors = true
+	config.Ports = helpers.MustNewSubnetMap(map[string]uint16{
+		"::/0": uint16(port),
+	})
+	p, err := config.New(t.Context(), r)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	got := []string{}
+	var firstWalk int32
+	for _, ifIndex := range []uint{641, 642, 643, 644} {
+		answer, err := p.Query(t.Context(), provider.Query{ExporterIP: lo, IfIndex: ifIndex})
+		if err != nil {
+			t.Fatalf("Query() error:\n%+v", err)
+		}
+		got = append(got, fmt.Sprintf("%d %s %q", ifIndex, answer.Interface.Name, answer.Interface.Neighbor))
+		if firstWalk == 0 {
+			firstWalk = walks.Load()
+		}
+	}
+	if diff := helpers.Diff(got, []string{
+		`641 Gi0/0/0/641 "core1:Et1/1"`,
+		`642 Gi0/0/0/642 "switch1:GigabitEthernet1/0/1"`,
+		`643 Gi0/0/0/643 "core2:xe-0/0/1"`,
+		`644 Gi0/0/0/644 ""`,
+	}); diff != "" {
+		t.Fatalf("Poll() (-got, +want):\n%s", diff)
+	}
+	// Neighbors are cached: the LLDP table is only walked on the first query.
+	if got := walks.Load(); got != firstWalk {
+		t.Errorf("LLDP table walked again (%d accesses instead of %d)", got, firstWalk)
+	}
+}
@@ -33,10 +33,19 @@ type Provider struct {
 	r         *reporter.Reporter
 	config    *Configuration
 	errLogger reporter.Logger
+	ctx       context.Context
 
 	v3CacheMu sync.RWMutex
 	v3Cache   map[netip.Addr]cachedV3State
 
+	statesLock sync.RWMutex
+	states     map[netip.Addr]*exporterState
+	pollers    sync.WaitGroup
+	notify     func(netip.Addr, uint)
+
+	neighborsLock sync.Mutex
+	neighbors     map[netip.Addr]*neighborsEntry
+
 	metrics struct {
 		successes     *reporter.CounterVec
 		errors        *reporter.CounterVec
@@ -49,11 +58,13 @@ type Provider struct {
 
 var (
 	_ provider.Provider      = &Provider{}
+	_ provider.Notifier      = &Provider{}
+	_ provider.Stopper       = &Provider{}
 	_ provider.Configuration = Configuration{}
 )
 
 // New creates a new SNMP provider from configuration
-func (configuration Configuration) New(_ context.Context, r *reporter.Reporter) (provider.Provider, error) {
+func (configuration Configuration) New(ctx context.Context, r *reporter.Reporter) (provider.Provider, error) {
 	for exporterIP, agentIP := range configuration.Agents {
 		if exporterIP.Is4() || agentIP.Is4() {
 			delete(configuration.Agents, exporterIP)
@@ -67,7 +78,10 @@ func (configuration Configuration) New(_ context.Context, r *reporter.Reporter)
 		r:         r,
 		config:    &configuration,
 		errLogger: r.Sample(reporter.BurstSampler(10*time.Second, 3)),
+		ctx:       ctx,
 		v3Cache:   map[netip.Addr]cachedV3State{},
+		states:    map[netip.Addr]*exporterState{},
+		neighbors: map[netip.Addr]*neighborsEntry{},
 	}
 
 	p.metrics.successes = r.CounterVec(
@@ -113,5 +127,22 @@ func (p *Provider) Query(ctx context.Context, query provider.Query) (provider.An
 		agentIP = query.ExporterIP
 	}
 	agentPort := p.config.Ports.LookupOrDefault(query.ExporterIP, 161)
+	if p.config.BulkPolling {
+		return p.queryBulk(ctx, query.ExporterIP, agentIP, agentPort, query.IfIndex)
+	}
 	return p.Poll(ctx, query.ExporterIP, agentIP, agentPort, query.IfIndex)
 }
+
+// Notify registers a function to call when an interface changes while
+// polling in bulk.
+func (p *Provider) Notify(notify func(exporterIP netip.Addr, ifIndex uint)) {
+	p.notify = notify
+}
+
+// Stop waits for the bulk pollers to terminate.
+func (p *Provider) Stop() {
+	// Once the lock is acquired, no new poller can be started.
+	p.statesLock.Lock()
+	p.statesLock.Unlock()
+	p.pollers.Wait()
+}
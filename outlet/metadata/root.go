@@ -39,6 +39,7 @@ type Component struct {
 	providers              []provider.Provider
 	initialDeadline        time.Time
 	providerSkipLogger     reporter.Logger
+	providerErrLogger      reporter.Logger
 
 	metrics struct {
 		cacheRefreshRuns         reporter.Counter
@@ -78,6 +79,7 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		providerBreakerLoggers: make(map[netip.Addr]reporter.Logger),
 		providers:              make([]provider.Provider, 0, 1),
 		providerSkipLogger:     r.Sample(reporter.BurstSampler(time.Minute, 3)),
+		providerErrLogger:      r.Sample(reporter.BurstSampler(time.Minute, 3)),
 	}
 	c.d.Daemon.Track(&c.t, "outlet/metadata")
 
@@ -87,6 +89,11 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		if err != nil {
 			return nil, err
 		}
+		if n, ok := selectedProvider.(provider.Notifier); ok {
+			n.Notify(func(exporterIP netip.Addr, ifIndex uint) {
+				go c.refreshCacheEntry(exporterIP, ifIndex)
+			})
+		}
 		c.providers = append(c.providers, selectedProvider)
 	}
 
@@ -175,7 +182,13 @@ func (c *Component) Stop() error {
 	}()
 	c.r.Info().Msg("stopping metadata component")
 	c.t.Kill(nil)
-	return c.t.Wait()
+	err := c.t.Wait()
+	for _, p := range c.providers {
+		if s, ok := p.(provider.Stopper); ok {
+			s.Stop()
+		}
+	}
+	return err
 }
 
 // Lookup for interface information for the provided exporter and ifIndex. If
@@ -202,6 +215,11 @@ func (c *Component) Lookup(t time.Time, exporterIP netip.Addr, ifIndex uint) pro
 	return result.(provider.Answer)
 }
 
+// CachedAnswers returns the answers currently in the cache.
+func (c *Component) CachedAnswers() map[provider.Query]provider.Answer {
+	return c.sc.cache.Items()
+}
+
 // queryProviders queries all providers. It returns the answer for the specific
 // query and cache it.
 func (c *Component) queryProviders(query provider.Query) (provider.Answer, error) {
@@ -229,19 +247,45 @@ func (c *Component) queryProviders(query provider.Query) (provider.Answer, error
 		defer cancel()
 
 		now := time.Now()
-		for _, p := range c.providers {
+		answered := false
+		var errs []error
+		for idx, p := range c.providers {
 			answer, err := p.Query(ctx, query)
 			if err == provider.ErrSkipProvider {
 				// Next provider
 				continue
 			}
+			if err != nil && c.config.Merge {
+				// Keep merging with the remaining providers
+				errs = append(errs, err)
+				continue
+			}
 			if err != nil {
 				return err
 			}
-			c.sc.Put(now, query, answer)
-			result = answer
+			if !c.config.Merge {
+				c.sc.Put(now, query, answer)
+				result = answer
+				return nil
+			}
+			answered = true
+			if mergeAnswer(&result, answer, c.config.Providers[idx].Fields) {
+				break
+			}
+		}
+		if answered {
+			for _, err := range errs {
+				c.metrics.providerErrors.Inc()
+				c.providerErrLogger.Err(err).
+					Str("exporter", query.ExporterIP.Unmap().String()).
+					Msg("provider error, merging the answers of the other providers")
+			}
+			c.sc.Put(now, query, result)
 			return nil
 		}
+		if len(errs) > 0 {
+			return errs[len(errs)-1]
+		}
 		// All providers were skipped. Cache a negative result.
 		c.metrics.providerSkips.WithLabelValues(query.ExporterIP.Unmap().String()).Inc()
 		c.providerSkipLogger.Warn().
@@ -272,6 +316,21 @@ func (c *Component) queryProviders(query provider.Query) (provider.Answer, error
 	return result, nil
 }
 
+// Update forwards metadata learned from the flows to the providers accepting
+// them. When this changes the known metadata for an interface, the matching
+// cache entry is refreshed in the background.
+func (c *Component) Update(update provider.Update) {
+	changed := false
+	for _, p := range c.providers {
+		if u, ok := p.(provider.Updater); ok && u.Update(update) {
+			changed = true
+		}
+	}
+	if changed && update.IfIndex != 0 {
+		go c.refreshCacheEntry(update.ExporterIP, update.IfIndex)
+	}
+}
+
 // refreshCacheEntry refreshes a single cache entry.
 func (c *Component) refreshCacheEntry(exporterIP netip.Addr, ifIndex uint) {
 	query := provider.Query{
@@ -17,6 +17,7 @@ import (
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
 	"akvorado/outlet/metadata/provider"
+	"akvorado/outlet/metadata/provider/netflow"
 	"akvorado/outlet/metadata/provider/static"
 )
 
@@ -354,6 +355,105 @@ func TestMultipleProviders(t *testing.T) {
 	}
 }
 
+func TestMergeProviders(t *testing.T) {
+	r := reporter.NewMock(t)
+	staticConfiguration := static.Configuration{
+		Exporters: helpers.MustNewSubnetMap(map[string]static.ExporterConfiguration{
+			"::ffff:192.0.2.0/120": {
+				Exporter: provider.Exporter{
+					Name:   "static1",
+					Site:   "par1",
+					Tenant: "ops",
+				},
+				IfIndexes: map[uint]provider.Interface{
+					10: {
+						Name:        "static10",
+						Description: "static description",
+						Speed:       10,
+						Provider:    "cogent",
+					},
+				},
+				SkipMissingInterfaces: true,
+			},
+		}),
+	}
+	configuration := DefaultConfiguration()
+	configuration.Merge = true
+	configuration.Providers = []ProviderConfiguration{
+		{Config: skipProviderConfiguration{}},
+		{
+			Config: staticConfiguration,
+			Fields: []MergeField{"exporter-site", "exporter-tenant", "interface-provider"},
+		},
+		{Config: mockProviderConfiguration{}},
+	}
+	c := NewMock(t, r, configuration, Dependencies{Daemon: daemon.NewMock(t)})
+
+	expectMockLookup(t, c, "192.0.2.1", 10, provider.Answer{
+		Found: true,
+		Exporter: provider.Exporter{
+			Name:   "192_0_2_1",
+			Site:   "par1",
+			Tenant: "ops",
+		},
+		Interface: provider.Interface{
+			Name:        "Gi0/0/10",
+			Description: "Interface 10",
+			Speed:       1000,
+			Provider:    "cogent",
+		},
+	})
+	// The static provider skips this one
+	expectMockLookup(t, c, "192.0.2.1", 11, provider.Answer{
+		Found:    true,
+		Exporter: provider.Exporter{Name: "192_0_2_1"},
+		Interface: provider.Interface{
+			Name:        "Gi0/0/11",
+			Description: "Interface 11",
+			Speed:       1000,
+		},
+	})
+	// The mock provider does not find this one
+	expectMockLookup(t, c, "192.0.2.1", 999, provider.Answer{})
+
+	var field MergeField
+	if err := field.UnmarshalText([]byte("interface-speed")); err != nil {
+		t.Errorf("UnmarshalText() error:\n%+v", err)
+	}
+	if err := field.UnmarshalText([]byte("interface-color")); err == nil {
+		t.Error("UnmarshalText() did not error")
+	}
+}
+
+func TestMergeProvidersWithError(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration()
+	configuration.Merge = true
+	configuration.Providers = []ProviderConfiguration{
+		{Config: mockProviderConfiguration{}},
+		{Config: errorProviderConfiguration{}},
+	}
+	c := NewMock(t, r, configuration, Dependencies{Daemon: daemon.NewMock(t)})
+
+	// The error of the second provider does not discard the first answer
+	expectMockLookup(t, c, "192.0.2.1", 10, provider.Answer{
+		Found:    true,
+		Exporter: provider.Exporter{Name: "192_0_2_1"},
+		Interface: provider.Interface{
+			Name:        "Gi0/0/10",
+			Description: "Interface 10",
+			Speed:       1000,
+		},
+	})
+	gotMetrics := r.GetMetrics("akvorado_outlet_metadata_provider_", "errors_total")
+	expectedMetrics := map[string]string{
+		`errors_total`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Errorf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
 func TestNegativeCache(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		r := reporter.NewMock(t)
@@ -398,3 +498,33 @@ func TestNegativeCache(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdateFromFlows(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := reporter.NewMock(t)
+		configuration := DefaultConfiguration()
+		configuration.Providers = []ProviderConfiguration{{Config: netflow.DefaultConfiguration()}}
+		c := NewMock(t, r, configuration, Dependencies{Daemon: daemon.NewMock(t)})
+
+		// Nothing learned yet, negative answer is cached
+		expectMockLookup(t, c, "127.0.0.1", 765, provider.Answer{})
+
+		// Interface learned from the flows replaces the negative answer
+		exporter := helpers.AddrTo6(netip.MustParseAddr("127.0.0.1"))
+		c.Update(provider.Update{
+			ExporterIP:    exporter,
+			IfIndex:       765,
+			IfName:        "Gi0/0/765",
+			IfDescription: "Interface 765",
+		})
+		synctest.Wait()
+		expectMockLookup(t, c, "127.0.0.1", 765, provider.Answer{
+			Found:    true,
+			Exporter: provider.Exporter{Name: "127.0.0.1"},
+			Interface: provider.Interface{
+				Name:        "Gi0/0/765",
+				Description: "Interface 765",
+			},
+		})
+	})
+}
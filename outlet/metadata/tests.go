@@ -26,6 +26,8 @@ type mockProvider struct{}
 //   - ifIndex = 998 → transient error
 //   - ifIndex = 1010 → with metadata for exporter
 //   - ifIndex = 2010 → with metadata for exporter and interface
+//   - ifIndex = 1020 → with a neighbor
+//   - ifIndex = 1030 → operationally down
 func (mp mockProvider) Query(_ context.Context, query provider.Query) (provider.Answer, error) {
 	ifIndex := query.IfIndex
 	if ifIndex == 999 {
@@ -53,6 +55,16 @@ func (mp mockProvider) Query(_ context.Context, query provider.Query) (provider.
 		answer.Exporter.Tenant = "metadata tenant"
 	}
 
+	// iface with a neighbor
+	if ifIndex == 1020 {
+		answer.Interface.Neighbor = "core1:Et1/1"
+	}
+
+	// iface operationally down
+	if ifIndex == 1030 {
+		answer.Interface.OperStatus = "down"
+	}
+
 	// out iface with metadata
 	if ifIndex == 2010 {
 		answer.Interface.Boundary = schema.InterfaceBoundaryExternal